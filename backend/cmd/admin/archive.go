@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"be-simpletracker/internal/core/archive"
+	"be-simpletracker/internal/core/module"
+	"be-simpletracker/internal/database"
+)
+
+func runExport(args []string) error {
+	fs := newFlagSet("export")
+	username := fs.String("username", "", "account username")
+	out := fs.String("out", "", "archive path (default simpletracker-USERNAME-DATE.zip)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireUsername(fs, *username); err != nil {
+		return err
+	}
+	user, err := newService().UserByUsername(*username)
+	if err != nil {
+		return fmt.Errorf("find user %q: %w", *username, err)
+	}
+	modules, err := enabledModules()
+	if err != nil {
+		return err
+	}
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("simpletracker-%s-%s.zip", user.Username, time.Now().UTC().Format("20060102"))
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	manifest, err := archive.Export(context.Background(), database.GetDB(), modules.HasTable, user.ID, user.Username, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	rows := 0
+	for _, e := range manifest.Entities {
+		rows += e.Rows
+	}
+	fmt.Printf("exported %d rows for %q to %s\n", rows, user.Username, path)
+	return nil
+}
+
+func runImport(args []string) error {
+	fs := newFlagSet("import")
+	username := fs.String("username", "", "account to import into")
+	in := fs.String("in", "", "archive written by export or GET /export")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without changing anything")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireUsername(fs, *username); err != nil {
+		return err
+	}
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+	user, err := newService().UserByUsername(*username)
+	if err != nil {
+		return fmt.Errorf("find user %q: %w", *username, err)
+	}
+	modules, err := enabledModules()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	report, err := archive.Import(context.Background(), database.GetDB(), modules.HasTable, user.ID, f, info.Size(), *dryRun)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return printImportReport(report, user.Username)
+}
+
+// enabledModules builds the module registry from DISABLED_MODULES, as the server does.
+func enabledModules() (*module.Registry, error) {
+	modules, err := module.NewRegistry(module.All(database.GetDB()), module.Config{Disabled: cfg.Server.DisabledModules})
+	if err != nil {
+		return nil, fmt.Errorf("DISABLED_MODULES: %w", err)
+	}
+	return modules, nil
+}
+
+func printImportReport(report archive.Report, username string) error {
+	verb := "imported"
+	if report.DryRun {
+		verb = "dry run: would import"
+	}
+	fmt.Printf("%s into %q from an export of %q taken %s (schema %d)\n", verb, username,
+		report.Manifest.Username, report.Manifest.ExportedAt.Format(time.DateTime), report.Manifest.SchemaVersion)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tROWS\tCREATED\tMATCHED EXISTING")
+	for _, e := range report.Entities {
+		if e.Rows == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", e.Name, e.Rows, e.Created, e.Matched)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, warning := range report.Warnings {
+		fmt.Printf("warning: %s\n", warning)
+	}
+	return nil
+}
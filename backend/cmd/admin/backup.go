@@ -0,0 +1,176 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"be-simpletracker/internal/backup"
+	"be-simpletracker/internal/database"
+)
+
+// backupDir is -dir, else BACKUP_DIR, else the dumps directory the image creates.
+func backupDir(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return cmp.Or(cfg.Backup.Dir, "database/dumps")
+}
+
+func runBackup(args []string) error {
+	fs := newFlagSet("backup")
+	dir := fs.String("dir", "", "snapshot directory (default BACKUP_DIR or database/dumps)")
+	prune := fs.Bool("prune", false, "delete snapshots the BACKUP_KEEP_* policy no longer keeps")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	snapshot, err := backup.Create(context.Background(), database.GetDB(), backupDir(*dir))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s (%d bytes)\n", snapshot.Path, snapshot.Size)
+	if !*prune {
+		return nil
+	}
+	removed, err := backup.Prune(backupDir(*dir), backup.PolicyFrom(cfg.Backup))
+	for _, s := range removed {
+		fmt.Printf("pruned %s\n", s.Name)
+	}
+	return err
+}
+
+func runBackups(args []string) error {
+	fs := newFlagSet("backups")
+	dir := fs.String("dir", "", "snapshot directory (default BACKUP_DIR or database/dumps)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	snapshots, err := backup.List(backupDir(*dir))
+	if err != nil {
+		return err
+	}
+	keep := backup.PolicyFrom(cfg.Backup).Keep(snapshots)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTAKEN\tSIZE\tRETENTION")
+	for _, s := range snapshots {
+		retention := "pruned next run"
+		if keep[s.Name] {
+			retention = "kept"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", s.Name, s.TakenAt.Local().Format(time.DateTime), s.Size, retention)
+	}
+	return w.Flush()
+}
+
+func runRestore(args []string) error {
+	fs := newFlagSet("restore")
+	file := fs.String("file", "", "snapshot written by backup")
+	verify := fs.Bool("verify", false, "load the snapshot into a scratch database, check it, and drop it")
+	scratchURL := fs.String("scratch-url", "", "postgres URL of the server to create the scratch database on (default: the configured database's server)")
+	into := fs.String("into", "", "empty postgres database URL, or new SQLite file path, to restore into")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+	if *verify == (*into != "") {
+		return errors.New("pass exactly one of -verify or -into")
+	}
+
+	ctx := context.Background()
+	var report backup.Report
+	var err error
+	if *verify {
+		server := *scratchURL
+		if server == "" && filepath.Ext(*file) == ".pgdump" {
+			if server, err = cfg.PostgresDSN(); err != nil {
+				return err
+			}
+		}
+		report, err = backup.Verify(ctx, *file, server)
+	} else {
+		if err := refuseLiveDatabase(*into); err != nil {
+			return err
+		}
+		report, err = backup.Restore(ctx, *file, *into)
+	}
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if err := printRestoreReport(report); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return errors.New("snapshot failed verification")
+	}
+	return nil
+}
+
+// refuseLiveDatabase stops restore -into from writing over the database the server uses.
+func refuseLiveDatabase(target string) error {
+	if strings.ToLower(cfg.Database.Driver) == "sqlite" {
+		live, err := filepath.Abs(cfg.Database.Path)
+		if err != nil {
+			return err
+		}
+		if abs, err := filepath.Abs(target); err == nil && abs == live {
+			return errors.New("-into is the live database; restore somewhere else and swap it in while the server is stopped")
+		}
+		return nil
+	}
+	if live, err := cfg.PostgresDSN(); err == nil && live == target {
+		return errors.New("-into is the live database; restore into an empty database instead")
+	}
+	return nil
+}
+
+func printRestoreReport(report backup.Report) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, c := range report.Checks {
+		status := "ok"
+		if !c.OK {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", status, c.Name, c.Detail)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(report.Rows) == 0 {
+		return nil
+	}
+	tables := make([]string, 0, len(report.Rows))
+	for table := range report.Rows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tROWS")
+	for _, table := range tables {
+		fmt.Fprintf(w, "%s\t%d\n", table, report.Rows[table])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if report.Target != "" && report.OK() {
+		fmt.Printf("\nrestored into %s\n", report.Target)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"be-simpletracker/internal/core/auth"
+)
+
+func newJWTSecret() (string, error) {
+	b := make([]byte, 48)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// rotatedSecrets puts a new secret in front of the ring and keeps at most keep old ones for
+// verification, newest first.
+func rotatedSecrets(ring *auth.Keyring, secret string, keep int) (current string, previous []string) {
+	for _, key := range ring.Keys() {
+		if len(previous) >= keep {
+			break
+		}
+		previous = append(previous, string(key.Secret))
+	}
+	return secret, previous
+}
+
+// updateEnvFile replaces (or appends) KEY=value lines in a dotenv file, leaving every other
+// line untouched.
+func updateEnvFile(path string, values map[string]string, order []string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	written := map[string]bool{}
+	for i, line := range lines {
+		key, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if value, tracked := values[key]; tracked && !written[key] {
+			lines[i] = key + "=" + value
+			written[key] = true
+		}
+	}
+	for _, key := range order {
+		if !written[key] {
+			lines = append(lines, key+"="+values[key])
+		}
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), info.Mode().Perm())
+}
+
+func runRotateJWTKey(args []string) error {
+	fs := newFlagSet("rotate-jwt-key")
+	keep := fs.Int("keep", 2, "number of old keys still accepted for verification")
+	envFile := fs.String("env-file", "", "dotenv file to update in place; prints the new values when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keep < 1 {
+		return fmt.Errorf("-keep must be at least 1 so existing sessions stay valid")
+	}
+	ring, err := auth.LoadKeyring(cfg.Auth)
+	if err != nil {
+		return err
+	}
+	secret, err := newJWTSecret()
+	if err != nil {
+		return err
+	}
+	current, previous := rotatedSecrets(ring, secret, *keep)
+	values := map[string]string{
+		"JWT_SECRET":           current,
+		"JWT_PREVIOUS_SECRETS": strings.Join(previous, ","),
+	}
+	order := []string{"JWT_SECRET", "JWT_PREVIOUS_SECRETS"}
+
+	if *envFile == "" {
+		for _, key := range order {
+			fmt.Printf("%s=%s\n", key, values[key])
+		}
+	} else {
+		if err := updateEnvFile(*envFile, values, order); err != nil {
+			return err
+		}
+		fmt.Printf("updated %s\n", *envFile)
+	}
+	fmt.Fprintf(os.Stderr, "new signing key %s; restart every server instance to apply it\n", auth.KeyID([]byte(current)))
+	return nil
+}
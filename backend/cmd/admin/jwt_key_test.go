@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/core/auth"
+)
+
+func TestRotatedSecretsKeepsNewestOldKeys(t *testing.T) {
+	ring, err := auth.LoadKeyring(config.Auth{
+		JWTSecret:          "current-secret",
+		JWTPreviousSecrets: []string{"older-secret", "oldest-secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, previous := rotatedSecrets(ring, "new-secret", 2)
+	if current != "new-secret" {
+		t.Fatalf("current: %q", current)
+	}
+	if want := []string{"current-secret", "older-secret"}; !reflect.DeepEqual(previous, want) {
+		t.Fatalf("previous: got %v want %v", previous, want)
+	}
+}
+
+func TestUpdateEnvFileReplacesOnlyKeyLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("# secrets\nJWT_SECRET=old\nLISTEN_ADDR=0.0.0.0:8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]string{"JWT_SECRET": "new", "JWT_PREVIOUS_SECRETS": "old"}
+	if err := updateEnvFile(path, values, []string{"JWT_SECRET", "JWT_PREVIOUS_SECRETS"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# secrets\nJWT_SECRET=new\nLISTEN_ADDR=0.0.0.0:8080\nJWT_PREVIOUS_SECRETS=old\n"
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("mode changed to %v", info.Mode().Perm())
+	}
+}
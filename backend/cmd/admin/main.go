@@ -0,0 +1,349 @@
+// Standalone command: operates the instance without psql.
+// Usage: go run ./cmd/admin <command> [flags]   (from backend/)
+// Uses the same .env, CONFIG_FILE and DATABASE_DRIVER settings as the API server.
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/core/auth"
+	"be-simpletracker/internal/core/auth/services"
+	"be-simpletracker/internal/database"
+)
+
+const usage = `usage: admin <command> [flags]
+
+commands:
+  users                                   list accounts
+  create-user -username NAME [-email E]   create an account (prints a generated password
+              [-password-stdin]           unless one is read from stdin)
+  reset-password -username NAME           set a new password and sign out every session
+              [-password-stdin]
+  disable -username NAME                  block sign-in and end all sessions
+  enable -username NAME                   allow sign-in again
+  sessions -username NAME                 list signed-in devices
+  revoke-sessions -username NAME [-id N]  sign out one device, or all of them
+  rotate-jwt-key [-keep N] [-env-file F]  generate a new JWT_SECRET, keeping the old one
+                                          for verification
+  check-config                            run the server's startup config checks
+  export -username NAME [-out F]          write the account's data to a zip archive
+  import -username NAME -in F             add an archive's data to the account; matching
+         [-dry-run] [-json]               foods, exercises and days are merged
+  backup [-dir D] [-prune]                snapshot the whole database (pg_dump or a SQLite
+                                          copy) with a .sha256 checksum file
+  backups [-dir D]                        list snapshots and what retention keeps
+  restore -file F -verify                 load a snapshot into a scratch database, run
+          [-scratch-url URL] [-json]      integrity checks, and drop it again
+  restore -file F -into TARGET [-json]    restore into an empty database, then check it
+`
+
+type command struct {
+	run   func(args []string) error
+	needs needs
+}
+
+type needs int
+
+const (
+	needsConfig needs = iota
+	needsDB
+)
+
+// cfg is the configuration main loads before running a command.
+var cfg *config.Config
+
+var commands = map[string]command{
+	"users":           {runUsers, needsDB},
+	"create-user":     {runCreateUser, needsDB},
+	"reset-password":  {runResetPassword, needsDB},
+	"disable":         {runDisable, needsDB},
+	"enable":          {runEnable, needsDB},
+	"sessions":        {runSessions, needsDB},
+	"revoke-sessions": {runRevokeSessions, needsDB},
+	"rotate-jwt-key":  {runRotateJWTKey, needsConfig},
+	"check-config":    {runCheckConfig, needsConfig},
+	"export":          {runExport, needsDB},
+	"import":          {runImport, needsDB},
+	"backup":          {runBackup, needsDB},
+	"backups":         {runBackups, needsConfig},
+	"restore":         {runRestore, needsConfig},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	var err error
+	if cfg, err = config.Load(""); err != nil {
+		fmt.Fprintf(os.Stderr, "config: %v\n", err)
+		os.Exit(1)
+	}
+	if cmd.needs == needsDB {
+		if _, err := database.Connect(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "database: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// newService builds an AuthService for commands that never sign tokens.
+func newService() *services.AuthService {
+	return services.NewAuthService(func(string, string) (string, error) {
+		return "", errors.New("admin commands do not issue tokens")
+	})
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+func requireUsername(fs *flag.FlagSet, username string) error {
+	if strings.TrimSpace(username) == "" {
+		return fmt.Errorf("-username is required")
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+// readPassword takes the first line of stdin, or generates a password when fromStdin is false.
+// Passwords follow the same 8-72 byte rule as the HTTP routes.
+func readPassword(stdin io.Reader, fromStdin bool) (password string, generated bool, err error) {
+	if !fromStdin {
+		b := make([]byte, 18)
+		if _, err := rand.Read(b); err != nil {
+			return "", false, err
+		}
+		return base64.RawURLEncoding.EncodeToString(b), true, nil
+	}
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", false, err
+	}
+	password = strings.TrimRight(line, "\r\n")
+	if len(password) < 8 || len(password) > 72 {
+		return "", false, errors.New("password must be between 8 and 72 bytes")
+	}
+	return password, false, nil
+}
+
+func runUsers(args []string) error {
+	fs := newFlagSet("users")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	users, err := newService().Users()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\t2FA\tCREATED\tSTATUS")
+	for _, user := range users {
+		status := "active"
+		if user.Disabled() {
+			status = "disabled " + user.DisabledAt.Format(time.DateOnly)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\t%s\n", user.ID, user.Username, user.Email, user.TOTPEnabled, user.CreatedAt.Format(time.DateOnly), status)
+	}
+	return w.Flush()
+}
+
+func runCreateUser(args []string) error {
+	fs := newFlagSet("create-user")
+	username := fs.String("username", "", "account username")
+	email := fs.String("email", "", "account email, used for password resets")
+	fromStdin := fs.Bool("password-stdin", false, "read the password from the first line of stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireUsername(fs, *username); err != nil {
+		return err
+	}
+	password, generated, err := readPassword(os.Stdin, *fromStdin)
+	if err != nil {
+		return err
+	}
+	user, err := newService().CreateUser(services.RegisterInput{
+		Username: strings.TrimSpace(*username),
+		Password: password,
+		Email:    strings.TrimSpace(*email),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created user %q (id %d)\n", user.Username, user.ID)
+	if generated {
+		fmt.Printf("password: %s\n", password)
+	}
+	return nil
+}
+
+func runResetPassword(args []string) error {
+	fs := newFlagSet("reset-password")
+	username := fs.String("username", "", "account username")
+	fromStdin := fs.Bool("password-stdin", false, "read the password from the first line of stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireUsername(fs, *username); err != nil {
+		return err
+	}
+	service := newService()
+	user, err := service.UserByUsername(*username)
+	if err != nil {
+		return fmt.Errorf("find user %q: %w", *username, err)
+	}
+	password, generated, err := readPassword(os.Stdin, *fromStdin)
+	if err != nil {
+		return err
+	}
+	if err := service.SetPassword(user.ID, password); err != nil {
+		return err
+	}
+	fmt.Printf("password reset for %q; all sessions signed out\n", user.Username)
+	if generated {
+		fmt.Printf("password: %s\n", password)
+	}
+	return nil
+}
+
+func runDisable(args []string) error {
+	fs := newFlagSet("disable")
+	username := fs.String("username", "", "account username")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireUsername(fs, *username); err != nil {
+		return err
+	}
+	service := newService()
+	user, err := service.UserByUsername(*username)
+	if err != nil {
+		return fmt.Errorf("find user %q: %w", *username, err)
+	}
+	if err := service.DisableUser(user.ID); err != nil {
+		return err
+	}
+	fmt.Printf("disabled %q; all sessions signed out\n", user.Username)
+	return nil
+}
+
+func runEnable(args []string) error {
+	fs := newFlagSet("enable")
+	username := fs.String("username", "", "account username")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireUsername(fs, *username); err != nil {
+		return err
+	}
+	service := newService()
+	user, err := service.UserByUsername(*username)
+	if err != nil {
+		return fmt.Errorf("find user %q: %w", *username, err)
+	}
+	if err := service.EnableUser(user.ID); err != nil {
+		return err
+	}
+	fmt.Printf("enabled %q\n", user.Username)
+	return nil
+}
+
+func runSessions(args []string) error {
+	fs := newFlagSet("sessions")
+	username := fs.String("username", "", "account username")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireUsername(fs, *username); err != nil {
+		return err
+	}
+	service := newService()
+	user, err := service.UserByUsername(*username)
+	if err != nil {
+		return fmt.Errorf("find user %q: %w", *username, err)
+	}
+	sessions, err := service.Sessions(user.ID)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tIP\tCREATED\tLAST SEEN\tUSER AGENT")
+	for _, session := range sessions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", session.ID, session.IP, session.CreatedAt.Format(time.DateTime), session.LastSeenAt.Format(time.DateTime), session.UserAgent)
+	}
+	return w.Flush()
+}
+
+func runRevokeSessions(args []string) error {
+	fs := newFlagSet("revoke-sessions")
+	username := fs.String("username", "", "account username")
+	id := fs.Uint("id", 0, "session id to revoke; all sessions when omitted")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requireUsername(fs, *username); err != nil {
+		return err
+	}
+	service := newService()
+	user, err := service.UserByUsername(*username)
+	if err != nil {
+		return fmt.Errorf("find user %q: %w", *username, err)
+	}
+	if *id != 0 {
+		if err := service.RevokeSession(user.ID, *id); err != nil {
+			return fmt.Errorf("revoke session %d: %w", *id, err)
+		}
+		fmt.Printf("revoked session %d for %q\n", *id, user.Username)
+		return nil
+	}
+	revoked, err := service.RevokeAllSessions(user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("revoked %d sessions for %q\n", revoked, user.Username)
+	return nil
+}
+
+func runCheckConfig(args []string) error {
+	fs := newFlagSet("check-config")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fmt.Printf("environment: %s\n", cfg.Environment)
+	ring, err := auth.LoadKeyring(cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("jwt keyring: %w", err)
+	}
+	fmt.Printf("jwt signing key: %s\n", ring.Current.ID)
+	for _, key := range ring.Previous {
+		fmt.Printf("jwt verify-only key: %s\n", key.ID)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	fmt.Println("config ok")
+	return nil
+}
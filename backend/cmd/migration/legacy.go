@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"be-simpletracker/internal/database/migrate"
+
+	"gorm.io/gorm"
+)
+
+// adoptLegacy brings a database last started by a release from before versioned migrations,
+// whichever one, to 001_initial_schema. It works from that migration's SQL and the clean-ups
+// those releases ran after AutoMigrate, written out here against the tables as they were then:
+// today's models already carry the changes of later migrations, which `up` has to make itself.
+func adoptLegacy(ctx context.Context, db *gorm.DB, runner *migrate.Runner) error {
+	if err := runner.CreateMissing(ctx); err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, index := range legacyIndexes {
+			if err := tx.Exec(`DROP INDEX IF EXISTS "` + index + `"`).Error; err != nil {
+				return fmt.Errorf("drop index %s: %w", index, err)
+			}
+		}
+		if err := assignUnownedRows(tx); err != nil {
+			return err
+		}
+		// The workout clean-ups only apply where its tables exist: a module disabled with
+		// DISABLED_MODULES has none unless an older release created them.
+		if !tx.Migrator().HasTable("workout_plans") {
+			return nil
+		}
+		if err := tx.Exec(`UPDATE exercises SET load_type = 'plate_loaded_with_bar' WHERE load_type IS NULL OR load_type = ''`).Error; err != nil {
+			return err
+		}
+		var owners []uint
+		if err := tx.Raw(`
+			SELECT user_id FROM workout_programs WHERE user_id IS NOT NULL AND user_id <> 0
+			UNION
+			SELECT user_id FROM workout_plans WHERE user_id IS NOT NULL AND user_id <> 0
+		`).Scan(&owners).Error; err != nil {
+			return err
+		}
+		for _, owner := range owners {
+			if err := adoptPrograms(tx, owner); err != nil {
+				return fmt.Errorf("user %d: %w", owner, err)
+			}
+		}
+		return nil
+	})
+}
+
+// legacyIndexes are the single-column unique indexes from before data was per user.
+var legacyIndexes = []string{
+	"idx_days_date",
+	"idx_foods_name",
+	"idx_composite_foods_name",
+	"idx_day_of_week",
+	"idx_program_day_of_week",
+	"idx_exercises_name",
+	"idx_step_logs_date",
+	"idx_body_weight_logs_date",
+	"idx_investment_account_types_name",
+}
+
+// ownedTables are the tables that gained user_id when data became per user.
+var ownedTables = []string{
+	"plans", "days", "meals", "meal_items", "saved_meals", "saved_meal_items", "planned_meals",
+	"day_logs", "foods", "composite_foods", "composite_food_items",
+	"workout_programs", "exercises", "workout_plans", "workout_plan_days", "workout_plan_exercises",
+	"logged_exercises", "logged_sets", "workout_logs", "cardios",
+	"grocery_items", "step_logs", "body_weight_logs", "water_logs", "drink_size_presets", "user_profiles",
+	"investment_account_types", "investment_accounts", "investment_deposits", "investment_contribution_rules",
+}
+
+// assignUnownedRows hands rows written before data was per user to the first user. With such
+// rows and no user to take them it fails, rolling the baseline back, rather than leave them
+// where no account can see them. Tables of a disabled module that were never created are
+// skipped.
+func assignUnownedRows(tx *gorm.DB) error {
+	var owners []uint
+	if err := tx.Raw(`SELECT id FROM users WHERE deleted_at IS NULL ORDER BY id ASC LIMIT 1`).Scan(&owners).Error; err != nil {
+		return err
+	}
+	for _, table := range ownedTables {
+		if !tx.Migrator().HasTable(table) {
+			continue
+		}
+		if len(owners) == 0 {
+			var unowned int64
+			if err := tx.Table(table).Where("user_id IS NULL OR user_id = 0").Count(&unowned).Error; err != nil {
+				return fmt.Errorf("count unowned rows in %s: %w", table, err)
+			}
+			if unowned > 0 {
+				return fmt.Errorf("%w: %s has %d", errNoOwner, table, unowned)
+			}
+			continue
+		}
+		if err := tx.Exec(`UPDATE "`+table+`" SET user_id = ? WHERE user_id IS NULL OR user_id = 0`, owners[0]).Error; err != nil {
+			return fmt.Errorf("assign owner in %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+var errNoOwner = errors.New("rows without an owner and no user to give them to; register an account, then run baseline again")
+
+// adoptPrograms leaves owner with exactly one active workout program, holding every plan
+// without one, and moves each plan's legacy day_of_week into workout_plan_days.
+func adoptPrograms(tx *gorm.DB, owner uint) error {
+	now := time.Now()
+	var programs []uint
+	if err := tx.Raw(`
+		SELECT id FROM workout_programs WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY CASE WHEN is_active THEN 0 ELSE 1 END, id LIMIT 1
+	`, owner).Scan(&programs).Error; err != nil {
+		return err
+	}
+	if len(programs) == 0 {
+		if err := tx.Raw(`
+			INSERT INTO workout_programs (created_at, updated_at, user_id, name, is_active)
+			VALUES (?, ?, ?, 'Default', ?) RETURNING id
+		`, now, now, owner, true).Scan(&programs).Error; err != nil {
+			return err
+		}
+	}
+	program := programs[0]
+	if err := tx.Exec(`UPDATE workout_programs SET is_active = (id = ?), updated_at = ? WHERE user_id = ? AND deleted_at IS NULL`,
+		program, now, owner).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`UPDATE workout_plans SET workout_program_id = ?, updated_at = ? WHERE user_id = ? AND workout_program_id IS NULL AND deleted_at IS NULL`,
+		program, now, owner).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`
+		INSERT INTO workout_plan_days (user_id, workout_plan_id, day_of_week, created_at)
+		SELECT p.user_id, p.id, p.day_of_week, ? FROM workout_plans p
+		WHERE p.user_id = ? AND p.day_of_week IS NOT NULL AND p.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM workout_plan_days d WHERE d.workout_plan_id = p.id AND d.day_of_week = p.day_of_week)
+	`, now, owner).Error
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"be-simpletracker/internal/core/module"
+	"be-simpletracker/internal/database/dbtest"
+)
+
+func TestBaselineThenUpOnLegacyDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+	modules, err := module.NewRegistry(module.All(db), module.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := modules.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A database last started by a release from before data was per user: 001's tables
+	// without workout_plan_days or foods.user_id, with the old unique index on food names.
+	if err := runner.CreateMissing(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`DROP TABLE workout_plan_days`,
+		`DROP INDEX "idx_foods_user_name"`,
+		`ALTER TABLE foods DROP COLUMN user_id`,
+		`CREATE UNIQUE INDEX "idx_foods_name" ON foods (name)`,
+		`INSERT INTO users (id, username, password) VALUES (7, 'alice', 'x')`,
+		`INSERT INTO foods (name, serving_type, serving_amount, calories) VALUES ('oats', 'g', 100, 380)`,
+		`INSERT INTO workout_plans (name, day_of_week) VALUES ('legs', 2)`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	if err := adoptLegacy(ctx, db, runner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Baseline(ctx, runner.Migrations()[0].Version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := runner.Version(ctx); err != nil || version != runner.Latest() {
+		t.Fatalf("version %d, err %v; want %d", version, err, runner.Latest())
+	}
+
+	var foodOwner, planOwner, planDays int
+	var program string
+	db.Raw(`SELECT user_id FROM foods WHERE name = 'oats'`).Scan(&foodOwner)
+	db.Raw(`SELECT user_id FROM workout_plans WHERE name = 'legs'`).Scan(&planOwner)
+	db.Raw(`SELECT p.name FROM workout_programs p JOIN workout_plans w ON w.workout_program_id = p.id WHERE w.name = 'legs' AND p.is_active`).Scan(&program)
+	db.Raw(`SELECT COUNT(*) FROM workout_plan_days WHERE user_id = 7 AND day_of_week = 2`).Scan(&planDays)
+	if foodOwner != 7 || planOwner != 7 || program != "Default" || planDays != 1 {
+		t.Fatalf("food owner %d, plan owner %d, program %q, plan days %d", foodOwner, planOwner, program, planDays)
+	}
+	// The old index is gone: another user may now have a food of the same name.
+	if err := db.Exec(`INSERT INTO foods (user_id, name, serving_type, serving_amount, calories) VALUES (8, 'oats', 'g', 100, 380)`).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBaselineRefusesUnownedRowsWithoutAUser(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+	modules, err := module.NewRegistry(module.All(db), module.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := modules.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.CreateMissing(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`INSERT INTO foods (name, serving_type, serving_amount, calories) VALUES ('oats', 'g', 100, 380)`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := adoptLegacy(ctx, db, runner); !errors.Is(err, errNoOwner) {
+		t.Fatalf("err = %v, want %v", err, errNoOwner)
+	}
+	var unowned int64
+	db.Raw(`SELECT COUNT(*) FROM foods WHERE user_id IS NULL`).Scan(&unowned)
+	if unowned != 1 {
+		t.Fatalf("%d unowned foods after the failed baseline", unowned)
+	}
+}
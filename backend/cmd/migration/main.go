@@ -1,133 +1,219 @@
+// Standalone command: applies the versioned SQL migrations in internal/database/migrations and
+// those of every module DISABLED_MODULES leaves enabled.
+// Usage: go run ./cmd/migration <up|down|status|create|baseline> [flags]   (from backend/)
+// Uses the same .env, CONFIG_FILE and DATABASE_DRIVER settings as the API server.
 package main
 
 import (
+	"cmp"
+	"context"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
+
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/core/module"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/migrate"
 )
 
-type Migrations struct {
-	version   string
-	appliedAt time.Time
-}
+const usage = `usage: migration <command> [flags]
+
+commands:
+  up [-to N]            apply pending migrations (up to version N)
+  down [-steps N]       roll back the last N applied migrations (default 1)
+  status                list migrations and whether they are applied
+  create [-module M] NAME
+                        add an empty NNN_NAME.up.sql / .down.sql pair for each driver to
+                        the core schema, or to module M's migrations
+  baseline              adopt a database created by the old AutoMigrate startup: bring the
+                        core and enabled modules' tables to 001_initial_schema and record
+                        that as applied; then run up
+`
 
 func main() {
-	// db, err := database.ConnectToPostgres()
-	// if err != nil {
-	// 	panic("Database unreachable")
-	// }
-
-	// // fetch the last migration and ensure table exists
-	// var lastMigration Migrations
-
-	// db.Last(&lastMigration)
-	// // db.Raw("SELECT * FROM migrations ORDER BY applied_at").Scan(&lastMigration)
-
-	// fmt.Println(lastMigration)
-
-	// // if lastMigration.version == "" {
-	// // 	panic("No migrations table found")
-	// // }
-
-	args := os.Args[1:]
-
-	migrationsPath := "."
-	if len(args) >= 1 {
-		migrationsPath = args[0]
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command, args := os.Args[1], os.Args[2:]
+	var err error
+	switch command {
+	case "create":
+		err = runCreate(args)
+	case "up", "down", "status", "baseline":
+		err = runWithDB(command, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
 	}
-
-	migrations, err := os.ReadDir(migrationsPath)
 	if err != nil {
-		panic("Failed to read migrations directory")
+		fmt.Fprintf(os.Stderr, "%s: %v\n", command, err)
+		os.Exit(1)
 	}
+}
 
-	if !CheckIfInitialized(migrationsPath) {
-		return
+func runWithDB(command string, args []string) error {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	to := fs.Int("to", 0, "up: stop after this version")
+	steps := fs.Int("steps", 1, "down: number of migrations to roll back")
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-
-	for _, migration := range migrations {
-		if len(migration.Name()) <= 3 {
-			continue
+	cfg, err := config.Load("")
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	db, err := database.Connect(cfg)
+	if err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	modules, err := module.NewRegistry(module.All(db), module.Config{Disabled: cfg.Server.DisabledModules})
+	if err != nil {
+		return fmt.Errorf("config: DISABLED_MODULES: %w", err)
+	}
+	runner, err := modules.Runner(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		ran, err := runner.Up(ctx, *to)
+		printRan("applied", ran)
+		return err
+	case "down":
+		if *steps < 1 {
+			return fmt.Errorf("-steps must be at least 1")
 		}
-		migrationNum := migration.Name()[0:3]
-
-		// Dont run init migration
-		if migrationNum != "000" {
-			res, err := os.ReadFile(filepath.Join(migrationsPath, migration.Name()))
-			if err != nil {
-				panic("Failed to read migration file")
+		ran, err := runner.Down(ctx, *steps)
+		printRan("rolled back", ran)
+		return err
+	case "status":
+		return printStatus(ctx, runner)
+	case "baseline":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.AppliedAt != nil {
+				return migrate.ErrHasHistory
 			}
-
-			fmt.Println(string(res))
 		}
+		first := runner.Migrations()[0]
+		if err := adoptLegacy(ctx, db, runner); err != nil {
+			return fmt.Errorf("bring schema to %s: %w", first, err)
+		}
+		recorded, err := runner.Baseline(ctx, first.Version)
+		printRan("recorded", recorded)
+		return err
 	}
-
+	return nil
 }
 
-var initText = `-- Init creates the migrations table
--- v.000
--- <date>
-
-CREATE TABLE IF NOT EXISTS migrations (
-    version VARCHAR(15) PRIMARY KEY,
-    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-);
-`
-
-// Init creates the migrations table
-// It writes the initial migration file to the migrations directory
-func CheckIfInitialized(migrationsPath string) bool {
-	// Get all migration files in migration directory
-	migrations, err := os.ReadDir(migrationsPath)
-	if err != nil {
-		panic("Failed to read migrations directory")
+func printRan(verb string, ran []migrate.Migration) {
+	for _, m := range ran {
+		fmt.Printf("%s %s\n", verb, m)
 	}
-
-	// if there are migration files the migration system is initialized
-	if len(migrations) > 0 {
-		return true
+	if len(ran) == 0 {
+		fmt.Printf("nothing %s\n", verb)
 	}
+}
 
-	fmt.Println("[INFO] Initializing migration system...")
-
-	// Insert current date into init file comments
-	initText = strings.Replace(initText, "<date>", time.Now().Format("01/02/2006"), 1)
-
-	iB := []byte(initText)
-	migPath := filepath.Join(migrationsPath, "000_init.sql")
-	err = os.WriteFile(migPath, iB, 0644)
+func printStatus(ctx context.Context, runner *migrate.Runner) error {
+	statuses, err := runner.Status(ctx)
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	// Return system was not initialized
-	return false
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tMODULE\tNAME\tSTATUS")
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Missing:
+			state = "applied, file missing"
+		case s.Modified:
+			state = "applied, file modified since " + s.AppliedAt.Format(time.DateTime)
+		case s.AppliedAt != nil:
+			state = "applied " + s.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", s.Version, cmp.Or(s.Module, "core"), s.Name, state)
+	}
+	return w.Flush()
 }
 
-var newMigText = `--
--- v.<version>
--- <date>
-`
+var nonNameChars = regexp.MustCompile(`[^a-z0-9]+`)
 
-// NewMigration creates a new migration file
-// It writes the migration text to the migrations directory
-func NewMigration(previousVerion string, migrationsPath string) {
-	newMigText = strings.Replace(newMigText, "<version>", IncrementVersion(previousVerion), 1)
-	newMigText = strings.Replace(newMigText, "<date>", time.Now().Format("01/02/2006"), 1)
+var drivers = []string{"postgres", "sqlite"}
 
-	iB := []byte(newMigText)
-	migPath := filepath.Join(migrationsPath, IncrementVersion(previousVerion)+"_banada.sql")
-	err := os.WriteFile(migPath, iB, 0644)
-	if err != nil {
-		panic(err)
+func runCreate(args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	moduleName := fs.String("module", "", "module whose tables the migration changes (default: the core schema)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	dir := filepath.Join("internal", "database", "migrations")
+	dirs := []string{dir}
+	found := *moduleName == ""
+	for _, m := range module.All(nil) {
+		moduleDir := filepath.Join("internal", "core", m.Name(), "migrations")
+		dirs = append(dirs, moduleDir)
+		if m.Name() == *moduleName {
+			dir, found = moduleDir, true
+		}
 	}
+	if !found {
+		return fmt.Errorf("%w %q", module.ErrUnknownModule, *moduleName)
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one migration name")
+	}
+	name := strings.Trim(nonNameChars.ReplaceAllString(strings.ToLower(fs.Arg(0)), "_"), "_")
+	if name == "" {
+		return fmt.Errorf("migration name %q has no letters or digits", fs.Arg(0))
+	}
+	// Both drivers, the core schema and every module share one version sequence, so each new
+	// migration is written for both drivers and numbered after the latest of any set.
+	latest := 0
+	for _, d := range dirs {
+		for _, driver := range drivers {
+			existing, err := migrate.Load(os.DirFS(filepath.Join(d, driver)))
+			if err != nil {
+				return err
+			}
+			if len(existing) > 0 && existing[len(existing)-1].Version > latest {
+				latest = existing[len(existing)-1].Version
+			}
+		}
+	}
+	version := IncrementVersion(fmt.Sprintf("%03d", latest))
+	for _, driver := range drivers {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, driver, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+			header := fmt.Sprintf("-- %s (%s, %s)\n\n", name, driver, direction)
+			if err := os.WriteFile(path, []byte(header), 0o644); err != nil {
+				return err
+			}
+			fmt.Println("created", path)
+		}
+	}
+	return nil
 }
 
+// IncrementVersion returns the zero-padded version after version, or "" if version is not a number.
 func IncrementVersion(version string) string {
-	versionInt, _ := strconv.Atoi(version)
+	versionInt, err := strconv.Atoi(version)
+	if err != nil {
+		return ""
+	}
 	versionInt++
 	return fmt.Sprintf("%03d", versionInt)
 }
@@ -1,57 +1,114 @@
-// Standalone command: seeds the workout database (drops workout tables first - destructive).
-// Usage: go run ./cmd/seed   (from backend/)
-// Set DATABASE_URL to match the API server if not using defaults.
+// Standalone command: fills a demo account with months of realistic tracker data.
+// Usage: go run ./cmd/seed [-username demo] [-months 6] [-seed 1] [-end YYYY-MM-DD]   (from backend/)
+// Applies pending migrations first, for every module whatever DISABLED_MODULES says as the demo
+// data spans them all, and creates the account if it does not exist. The same flags always
+// produce the same data. Uses the same .env, CONFIG_FILE and DATABASE_DRIVER settings as the API server.
 package main
 
 import (
-	dietmodels "be-simpletracker/internal/core/diet/models"
-	"be-simpletracker/internal/core/tracking/steps"
-	"be-simpletracker/internal/core/tracking/water"
-	"be-simpletracker/internal/core/tracking/weight"
-	workout "be-simpletracker/internal/core/workout"
-	"be-simpletracker/internal/database"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"text/tabwriter"
+	"time"
+
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/core/auth/models"
+	"be-simpletracker/internal/core/auth/services"
+	"be-simpletracker/internal/core/module"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/seed"
+
+	"gorm.io/gorm"
 )
 
 func main() {
-	db, err := database.ConnectToPostgres()
+	username := flag.String("username", "demo", "account to fill; created when missing")
+	months := flag.Int("months", 6, "months of history")
+	seedValue := flag.Uint64("seed", 1, "random seed")
+	endDate := flag.String("end", "", "last day of history, YYYY-MM-DD (default today)")
+	flag.Parse()
+
+	end := time.Now()
+	if *endDate != "" {
+		parsed, err := time.ParseInLocation(time.DateOnly, *endDate, time.Local)
+		if err != nil {
+			fatal("-end", err)
+		}
+		end = parsed
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		fatal("config", err)
+	}
+	db, err := database.Connect(cfg)
+	if err != nil {
+		fatal("database", err)
+	}
+	modules, err := module.NewRegistry(module.All(db), module.Config{})
+	if err != nil {
+		fatal("modules", err)
+	}
+	runner, err := modules.Runner(db)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "database: %v\n", err)
-		os.Exit(1)
-	}
-	// if err := workoutseed.Run(db); err != nil {
-	// 	fmt.Fprintf(os.Stderr, "seed: %v\n", err)
-	// 	os.Exit(1)
-	// }
-
-	// db.AutoMigrate(&models.User{})
-
-	if err := workout.Migrate(db); err != nil {
-		fmt.Fprintf(os.Stderr, "AutoMigrate: %v\n", err)
-		os.Exit(1)
-	}
-
-	if err := db.AutoMigrate(
-		&dietmodels.Plan{},
-		&dietmodels.DietDay{},
-		&dietmodels.Meal{},
-		&dietmodels.MealItem{},
-		&dietmodels.SavedMeal{},
-		&dietmodels.SavedMealItem{},
-		&dietmodels.PlannedMeal{},
-		&dietmodels.DayLog{},
-		&dietmodels.Food{},
-		&dietmodels.CompositeFood{},
-		&dietmodels.CompositeFoodItem{},
-		&steps.StepLog{},
-		&weight.BodyWeightLog{},
-		&water.WaterLog{},
-		&water.DrinkSizePreset{},
-	); err != nil {
-		fmt.Fprintf(os.Stderr, "AutoMigrate: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Println("Workout seed completed.")
+		fatal("migrations", err)
+	}
+	if _, err := runner.Up(context.Background(), 0); err != nil {
+		fatal("migrations", err)
+	}
+
+	user, err := findOrCreateUser(*username)
+	if err != nil {
+		fatal("user", err)
+	}
+	started := time.Now()
+	summary, err := seed.Generate(context.Background(), db, user.ID, seed.Options{Months: *months, Seed: *seedValue, End: end})
+	if err != nil {
+		fatal("seed", err)
+	}
+
+	fmt.Printf("seeded %q from %s to %s in %s\n", user.Username, summary.From.Format(time.DateOnly), summary.To.Format(time.DateOnly), time.Since(started).Round(time.Millisecond))
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tROWS")
+	var total int64
+	for _, table := range seed.Tables() {
+		fmt.Fprintf(w, "%s\t%d\n", table, summary.Rows[table])
+		total += summary.Rows[table]
+	}
+	fmt.Fprintf(w, "total\t%d\n", total)
+	w.Flush()
+}
+
+func findOrCreateUser(username string) (models.User, error) {
+	service := services.NewAuthService(func(string, string) (string, error) {
+		return "", errors.New("seed does not issue tokens")
+	})
+	user, err := service.UserByUsername(username)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.User{}, err
+	}
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return models.User{}, err
+	}
+	password := base64.RawURLEncoding.EncodeToString(b)
+	user, err = service.CreateUser(services.RegisterInput{Username: username, Password: password})
+	if err != nil {
+		return models.User{}, err
+	}
+	fmt.Printf("created user %q with password %s\n", user.Username, password)
+	return user, nil
+}
+
+func fatal(what string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", what, err)
+	os.Exit(1)
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"be-simpletracker/internal/core/module"
+	"be-simpletracker/internal/database/migrate"
+	"be-simpletracker/internal/metrics"
+
+	"gorm.io/gorm"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// probes is the server's root handler. It answers /livez and /readyz itself and hands every
+// other request to the app once startup has finished; until then they get 503, so the
+// listener can be up while startup is still waiting for the database.
+type probes struct {
+	app      atomic.Pointer[http.Handler]
+	deps     atomic.Pointer[dependencies]
+	stopping atomic.Bool
+}
+
+// dependencies is what readiness checks once the database is connected.
+type dependencies struct {
+	db      *gorm.DB
+	runner  *migrate.Runner
+	modules *module.Registry
+}
+
+func (p *probes) ready(app http.Handler, deps dependencies) {
+	p.deps.Store(&deps)
+	p.app.Store(&app)
+}
+
+func (p *probes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/livez":
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+		return
+	case "/readyz", "/health":
+		p.serveReadiness(w, r)
+		return
+	}
+	app := p.app.Load()
+	if app == nil {
+		w.Header().Set("Retry-After", "5")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "server is starting"})
+		return
+	}
+	(*app).ServeHTTP(w, r)
+}
+
+// serveReadiness answers 200 once the database responds, has every migration this build
+// knows about, and each module reports healthy. It answers 503 while starting up and from
+// the moment shutdown begins, so the load balancer stops sending traffic before requests are
+// drained.
+func (p *probes) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	deps := p.deps.Load()
+	switch {
+	case p.stopping.Load():
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "stopping"})
+		return
+	case deps == nil:
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "starting"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	if problems := deps.check(ctx); len(problems) > 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "unavailable", "checks": problems})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func (d *dependencies) check(ctx context.Context) map[string]any {
+	problems := map[string]any{}
+	sqlDB, err := d.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		problems["database"] = err.Error()
+		return problems
+	}
+	if version, err := d.runner.Version(ctx); err != nil {
+		problems["migrations"] = err.Error()
+	} else if version < d.runner.Latest() {
+		problems["migrations"] = fmt.Sprintf("schema is at version %d, want %d", version, d.runner.Latest())
+	}
+	if modules := d.modules.CheckHealth(ctx); len(modules) > 0 {
+		problems["modules"] = modules
+	}
+	return problems
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// metricsMux serves /metrics alone, for the METRICS_ADDR listener.
+func metricsMux() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	return mux
+}
@@ -1,18 +1,30 @@
 package main
 
 import (
+	"be-simpletracker/internal/backup"
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/core/archive"
 	"be-simpletracker/internal/core/auth"
-	diet "be-simpletracker/internal/core/diet"
-	money "be-simpletracker/internal/core/money"
-	tracking "be-simpletracker/internal/core/tracking"
-	workout "be-simpletracker/internal/core/workout"
+	"be-simpletracker/internal/core/deltasync"
+	"be-simpletracker/internal/core/module"
+	"be-simpletracker/internal/core/trash"
 	"be-simpletracker/internal/database"
-	"be-simpletracker/internal/env"
+	"be-simpletracker/internal/database/migrate"
+	"be-simpletracker/internal/idempotency"
+	"be-simpletracker/internal/metrics"
 	"be-simpletracker/internal/utils"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"strings"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+	// Users pick their timezone; embed the zone database for hosts that lack one.
+	_ "time/tzdata"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -20,93 +32,172 @@ import (
 )
 
 func main() {
-	if err := env.Load(); err != nil {
-		log.Fatal(err)
-	}
-	if _, err := env.String("JWT_SECRET"); err != nil {
+	configFile := flag.String("config", "", "YAML or TOML config file (default: $CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration, secrets redacted, and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
 		log.Fatalf("config: %v", err)
 	}
-	if err := auth.ValidateProductionConfig(); err != nil {
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("config:\n%v", err)
+	}
+	if err := auth.Configure(cfg); err != nil {
 		log.Fatalf("config: %v", err)
 	}
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 
-	if !env.IsProduction() {
-		router.Use(utils.BenchmarkMiddleware(router))
-	}
-
-	defaultCORS := "http://localhost:5173,http://localhost:3000,http://192.168.4.78:3000,http://192.168.4.64:3000"
-	corsOrigins := env.StringOr("CORS_ORIGINS", defaultCORS)
-	origins := splitString(corsOrigins, ",")
+	router.Use(metrics.Middleware())
 
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     origins,
+		AllowOrigins:     cfg.Server.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", idempotency.Header},
 		AllowCredentials: true,
-		ExposeHeaders:    []string{"Set-Cookie"},
+		ExposeHeaders:    []string{"Set-Cookie", "ETag", idempotency.ReplayedHeader},
 		MaxAge:           12 * time.Hour,
 	}))
 
-	trustedProxies := env.SliceOr("TRUSTED_PROXIES", ",", nil)
-	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
 		log.Fatalf("config: invalid TRUSTED_PROXIES: %v", err)
 	}
-	if env.IsProduction() && len(trustedProxies) == 0 {
+	if cfg.IsProduction() && len(cfg.Server.TrustedProxies) == 0 {
 		log.Printf("config: TRUSTED_PROXIES is empty; login protection will use the direct peer IP")
 	}
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
 
-	db, err := database.ConnectToPostgres()
+	// Listen straight away so the platform sees a live process, and keep trying the database
+	// for as long as it takes rather than exiting into a restart loop.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	root := &probes{}
+	server := &http.Server{Addr: cfg.Server.ListenAddr, Handler: root, ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 2)
+	go func() { serveErr <- server.ListenAndServe() }()
+	fmt.Println("Server is running on port", cfg.Server.ListenAddr)
+	servers := []*http.Server{server}
+	if cfg.Metrics.Addr != "" {
+		metricsServer := &http.Server{Addr: cfg.Metrics.Addr, Handler: metricsMux(), ReadHeaderTimeout: 10 * time.Second}
+		go func() { serveErr <- metricsServer.ListenAndServe() }()
+		servers = append(servers, metricsServer)
+		log.Printf("metrics: serving /metrics on %s", cfg.Metrics.Addr)
+	}
+
+	db, err := database.ConnectRetrying(ctx, cfg, 0)
 	if err != nil {
-		panic("Database unreachable")
+		if ctx.Err() != nil {
+			shutdown(root, nil, cfg.Server.ShutdownTimeoutSec, servers...)
+			return
+		}
+		log.Fatalf("database: %v", err)
+	}
+	if err := db.Use(metrics.GORMPlugin{}); err != nil {
+		log.Fatalf("metrics: %v", err)
 	}
-	if err := workout.Migrate(db); err != nil {
-		panic(err)
+	if err := metrics.WatchPool(db); err != nil {
+		log.Fatalf("metrics: %v", err)
+	}
+	modules, err := module.NewRegistry(module.All(db), module.Config{Disabled: cfg.Server.DisabledModules})
+	if err != nil {
+		log.Fatalf("config: DISABLED_MODULES: %v", err)
 	}
-	if err := auth.Migrate(db); err != nil {
-		panic(err)
+	runner, err := applyMigrations(ctx, db, modules, cfg.Server.MigrateOnStart)
+	if err != nil {
+		log.Fatalf("migrations: %v", err)
+	}
+	backups, err := backup.Job(db, cfg.Backup)
+	if err != nil {
+		log.Fatalf("config: %v", err)
 	}
+	trashPurge, err := trash.Job(db, cfg.Trash)
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	modules.StartBackgroundJobs(ctx, backups, trashPurge, idempotency.Job(db))
 
-	CreateFeatures(db, router)
+	CreateFeatures(db, router, modules, cfg)
+	if cfg.Metrics.Token != "" {
+		router.GET("/metrics", metrics.RequireToken(cfg.Metrics.Token), gin.WrapH(metrics.Handler()))
+	}
+	if cfg.Metrics.Addr == "" && cfg.Metrics.Token == "" {
+		log.Printf("metrics: off; set METRICS_ADDR or METRICS_TOKEN to serve /metrics")
+	}
+	root.ready(router, dependencies{db: db, runner: runner, modules: modules})
 
-	addr := env.StringOr("LISTEN_ADDR", "0.0.0.0:8080")
-	fmt.Println("Server is running on port", addr)
-	router.Run(addr)
+	select {
+	case <-ctx.Done():
+	case err := <-serveErr:
+		log.Fatalf("server: %v", err)
+	}
+	shutdown(root, db, cfg.Server.ShutdownTimeoutSec, servers...)
 }
 
-func splitString(s, sep string) []string {
-	var result []string
-	for _, part := range strings.Split(s, sep) {
-		trimmed := strings.TrimSpace(part)
-		if trimmed != "" {
-			result = append(result, trimmed)
+// shutdown fails readiness, waits up to timeoutSec for requests in flight to finish, then
+// closes the database.
+func shutdown(root *probes, db *gorm.DB, timeoutSec int, servers ...*http.Server) {
+	log.Printf("server: shutting down")
+	root.stopping.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
+	defer cancel()
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("server: requests to %s still running after %ds: %v", server.Addr, timeoutSec, err)
+		}
+	}
+	if db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
 		}
 	}
-	return result
 }
 
-func CreateFeatures(db *gorm.DB, router *gin.Engine) {
-	auth.RegisterRoutes(router)
+func CreateFeatures(db *gorm.DB, router *gin.Engine, modules *module.Registry, cfg *config.Config) {
+	auth.RegisterRoutes(router, cfg)
 
-	authMW := auth.AuthMiddleware()
+	authMW := auth.CookieOrAccessTokenMiddleware()
 
-	diet.RegisterRoutes(router, authMW)
+	modules.RegisterRoutes(router, authMW, utils.IfMatchMiddleware(cfg.Server.IfMatchRequired))
 
-	workout.RegisterRoutes(router, authMW)
+	archive.RegisterRoutes(router, db, modules.HasTable, authMW)
 
-	trackingHandler := tracking.NewHandler(db)
-	if err := trackingHandler.Migrate(); err != nil {
-		panic(err)
-	}
-	trackingHandler.RegisterRoutes(router, authMW)
+	trash.RegisterRoutes(router, db, modules.HasTable, authMW)
 
-	moneyHandler := money.NewHandler(db)
-	if err := moneyHandler.Migrate(); err != nil {
-		panic(err)
+	deltasync.RegisterRoutes(router, db, modules.HasTable, authMW)
+}
+
+// applyMigrations brings the core schema and the enabled modules' tables up to date. With
+// MIGRATE_ON_START=false it leaves that to `migration up`, and /readyz fails until someone has
+// run it; likewise for a database the old AutoMigrate startup created, until it is baselined.
+func applyMigrations(ctx context.Context, db *gorm.DB, modules *module.Registry, migrateOnStart bool) (*migrate.Runner, error) {
+	runner, err := modules.Runner(db)
+	if err != nil {
+		return nil, err
+	}
+	if !migrateOnStart {
+		pending, err := runner.Pending(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if pending > 0 {
+			log.Printf("migrations: %d pending; not ready until `migration up` has run", pending)
+		}
+		return runner, nil
+	}
+	ran, err := runner.Up(ctx, 0)
+	for _, m := range ran {
+		log.Printf("migrations: applied %s", m)
+	}
+	if errors.Is(err, migrate.ErrNeedsBaseline) {
+		// Exiting would only restart into the same error, so stay up and unready instead.
+		log.Printf("migrations: this database was created before versioned migrations; run `migration baseline` once and restart; not ready until then")
+		return runner, nil
 	}
-	moneyHandler.RegisterRoutes(router, authMW)
+	return runner, err
 }
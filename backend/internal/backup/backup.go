@@ -0,0 +1,210 @@
+// Package backup takes whole-database snapshots into a local directory: pg_dump's custom
+// format on Postgres, a VACUUM INTO copy on SQLite. Each snapshot NAME gets a NAME.sha256
+// file in sha256sum format, and old snapshots are pruned by a Policy.
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	filePrefix = "simpletracker-"
+	timeLayout = "20060102T150405Z"
+
+	postgresExt = ".pgdump"
+	sqliteExt   = ".sqlite"
+	checksumExt = ".sha256"
+)
+
+var (
+	ErrNoChecksum       = errors.New("snapshot has no checksum file")
+	ErrChecksumMismatch = errors.New("snapshot does not match its checksum")
+	ErrUnknownSnapshot  = errors.New("not a snapshot file (want .pgdump or .sqlite)")
+)
+
+// Snapshot is one snapshot file in a backup directory.
+type Snapshot struct {
+	Path    string
+	Name    string
+	TakenAt time.Time
+	Size    int64
+}
+
+// Driver is the database the snapshot was taken from, going by its extension.
+func (s Snapshot) Driver() string { return driverOf(s.Path) }
+
+func driverOf(path string) string {
+	switch filepath.Ext(path) {
+	case postgresExt:
+		return "postgres"
+	case sqliteExt:
+		return "sqlite"
+	}
+	return ""
+}
+
+// Create writes a snapshot of db to dir along with its checksum file.
+func Create(ctx context.Context, db *gorm.DB, dir string) (Snapshot, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return Snapshot{}, err
+	}
+	takenAt := time.Now().UTC().Truncate(time.Second)
+	var ext string
+	var write func(tmp string) error
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		dialector, ok := db.Dialector.(*postgres.Dialector)
+		if !ok || dialector.DSN == "" {
+			return Snapshot{}, errors.New("postgres connection has no DSN to pass to pg_dump")
+		}
+		ext = postgresExt
+		write = func(tmp string) error { return dumpPostgres(ctx, dialector.DSN, tmp) }
+	case "sqlite":
+		ext = sqliteExt
+		write = func(tmp string) error { return db.WithContext(ctx).Exec("VACUUM INTO ?", tmp).Error }
+	default:
+		return Snapshot{}, fmt.Errorf("cannot back up a %s database", name)
+	}
+
+	name := filePrefix + takenAt.Format(timeLayout) + ext
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return Snapshot{}, fmt.Errorf("%s already exists", path)
+	}
+	// Written under a name List ignores, so a failed snapshot is never mistaken for one.
+	tmp := filepath.Join(dir, "."+name+".tmp")
+	os.Remove(tmp)
+	if err := write(tmp); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+	sum, err := fileChecksum(tmp)
+	if err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+	if err := os.WriteFile(path+checksumExt, []byte(sum+"  "+name+"\n"), 0o600); err != nil {
+		return Snapshot{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Path: path, Name: name, TakenAt: takenAt, Size: info.Size()}, nil
+}
+
+// List returns the snapshots in dir, newest first. Other files are ignored, and a missing
+// directory has no snapshots.
+func List(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		takenAt, ok := parseName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, Snapshot{
+			Path:    filepath.Join(dir, entry.Name()),
+			Name:    entry.Name(),
+			TakenAt: takenAt,
+			Size:    info.Size(),
+		})
+	}
+	sortNewestFirst(snapshots)
+	return snapshots, nil
+}
+
+func sortNewestFirst(snapshots []Snapshot) {
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].TakenAt.After(snapshots[j].TakenAt) })
+}
+
+func parseName(name string) (time.Time, bool) {
+	if driverOf(name) == "" || !strings.HasPrefix(name, filePrefix) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), filepath.Ext(name))
+	takenAt, err := time.Parse(timeLayout, stamp)
+	return takenAt, err == nil
+}
+
+// VerifyChecksum compares the snapshot at path with the sum in path.sha256.
+func VerifyChecksum(path string) error {
+	recorded, err := os.ReadFile(path + checksumExt)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s%s", ErrNoChecksum, filepath.Base(path), checksumExt)
+	}
+	if err != nil {
+		return err
+	}
+	want, _, _ := strings.Cut(strings.TrimSpace(string(recorded)), " ")
+	got, err := fileChecksum(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(want, got) {
+		return fmt.Errorf("%w: recorded %s, file is %s", ErrChecksumMismatch, want, got)
+	}
+	return nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
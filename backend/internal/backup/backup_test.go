@@ -0,0 +1,195 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"be-simpletracker/internal/core/module"
+	"be-simpletracker/internal/core/tracking/steps"
+	"be-simpletracker/internal/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMigratedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "live.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closeDB(db) })
+	modules, err := module.NewRegistry(module.All(db), module.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := modules.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func check(t *testing.T, report Report, name string) Check {
+	t.Helper()
+	for _, c := range report.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no %s check in %+v", name, report.Checks)
+	return Check{}
+}
+
+func TestSQLiteSnapshotVerifies(t *testing.T) {
+	db := openMigratedDB(t)
+	if err := db.Exec("INSERT INTO users (username, password, created_at, updated_at) VALUES ('alice', 'x', ?, ?)", time.Now(), time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&steps.StepLog{UserID: 1, Date: database.DateOf(time.Now()), Steps: 9000}).Error; err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	snapshot, err := Create(context.Background(), db, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, err := List(dir)
+	if err != nil || len(listed) != 1 || listed[0].Name != snapshot.Name {
+		t.Fatalf("List = %+v, %v", listed, err)
+	}
+
+	report, err := Verify(context.Background(), snapshot.Path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("checks failed: %+v", report.Checks)
+	}
+	if report.Rows["step_logs"] != 1 || report.Rows["users"] != 1 || report.SchemaVersion == 0 {
+		t.Fatalf("report %+v", report)
+	}
+}
+
+func TestVerifyFailsOnCorruptSnapshot(t *testing.T) {
+	db := openMigratedDB(t)
+	snapshot, err := Create(context.Background(), db, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(snapshot.Path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte("garbage"), 100)
+	f.Close()
+
+	report, err := Verify(context.Background(), snapshot.Path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || check(t, report, "checksum").OK || len(report.Checks) != 1 {
+		t.Fatalf("checks %+v", report.Checks)
+	}
+	if !errors.Is(VerifyChecksum(snapshot.Path), ErrChecksumMismatch) {
+		t.Fatal("want ErrChecksumMismatch")
+	}
+}
+
+func TestRestoreRefusesExistingFile(t *testing.T) {
+	db := openMigratedDB(t)
+	snapshot, err := Create(context.Background(), db, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(t.TempDir(), "existing.db")
+	os.WriteFile(target, []byte("keep me"), 0o600)
+
+	report, err := Restore(context.Background(), snapshot.Path, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || check(t, report, "restore").OK {
+		t.Fatalf("checks %+v", report.Checks)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "keep me" {
+		t.Fatal("restore overwrote the target")
+	}
+}
+
+func TestPolicyKeepsDailyWeeklyMonthly(t *testing.T) {
+	// A snapshot every day at 03:00 for a year, plus a second one on the last day.
+	last := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+	var snapshots []Snapshot
+	for day := 0; day < 365; day++ {
+		at := last.AddDate(0, 0, -day)
+		snapshots = append(snapshots, Snapshot{Name: at.Format(timeLayout), TakenAt: at})
+	}
+	latest := last.Add(12 * time.Hour)
+	snapshots = append(snapshots, Snapshot{Name: latest.Format(timeLayout), TakenAt: latest})
+
+	keep := Policy{Daily: 7, Weekly: 4, Monthly: 12}.Keep(snapshots)
+	kept := func(at time.Time) bool { return keep[at.Format(timeLayout)] }
+
+	if !kept(latest) || kept(last) {
+		t.Fatal("want only the newest snapshot of the last day")
+	}
+	for day := 1; day < 7; day++ {
+		if !kept(last.AddDate(0, 0, -day)) {
+			t.Fatalf("daily snapshot %d days back was dropped", day)
+		}
+	}
+	// 2026-10-17 is a Saturday; earlier weeks keep their Sunday snapshot.
+	for _, at := range []time.Time{
+		time.Date(2026, 10, 11, 3, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 4, 3, 0, 0, 0, time.UTC),
+		time.Date(2026, 9, 27, 3, 0, 0, 0, time.UTC),
+		time.Date(2026, 9, 30, 3, 0, 0, 0, time.UTC),
+		time.Date(2025, 11, 30, 3, 0, 0, 0, time.UTC),
+	} {
+		if !kept(at) {
+			t.Fatalf("%s was dropped", at.Format(time.DateOnly))
+		}
+	}
+	if kept(time.Date(2026, 10, 9, 3, 0, 0, 0, time.UTC)) || kept(time.Date(2025, 10, 31, 3, 0, 0, 0, time.UTC)) {
+		t.Fatal("kept a snapshot no rule asks for")
+	}
+	// 7 days; the two weeks before those; September back to last November.
+	if len(keep) != 7+2+11 {
+		t.Fatalf("kept %d snapshots", len(keep))
+	}
+}
+
+func TestPruneRemovesSnapshotAndChecksum(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"simpletracker-20261017T030000Z.sqlite",
+		"simpletracker-20261016T030000Z.sqlite",
+		"simpletracker-20261015T030000Z.sqlite",
+	}
+	for _, name := range names {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600)
+		os.WriteFile(filepath.Join(dir, name+checksumExt), []byte("x"), 0o600)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o600)
+
+	removed, err := Prune(dir, Policy{Daily: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].Name != names[2] {
+		t.Fatalf("removed %+v", removed)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 5 {
+		t.Fatalf("%d files left", len(entries))
+	}
+}
@@ -0,0 +1,86 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func dumpPostgres(ctx context.Context, dsn, path string) error {
+	return runPostgresTool(ctx, "pg_dump", dsn, "--format=custom", "--no-owner", "--no-privileges", "--file="+path)
+}
+
+func restorePostgres(ctx context.Context, dsn, path string) error {
+	return runPostgresTool(ctx, "pg_restore", dsn, "--no-owner", "--no-privileges", "--exit-on-error", path)
+}
+
+// runPostgresTool runs one of the client programs against dsn. A password in the URL is
+// handed over in PGPASSWORD rather than on the command line, where ps would show it.
+func runPostgresTool(ctx context.Context, program, dsn string, args ...string) error {
+	var environ []string
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			environ = append(environ, "PGPASSWORD="+password)
+			u.User = url.User(u.User.Username())
+			dsn = u.String()
+		}
+	}
+	cmd := exec.CommandContext(ctx, program, append(args, "--dbname="+dsn)...)
+	cmd.Env = append(os.Environ(), environ...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("%s is not installed; snapshots of a Postgres database need the postgres client tools", program)
+		}
+		return fmt.Errorf("%s: %w: %s", program, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func openPostgres(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+}
+
+// scratchDatabase creates an empty database on server's Postgres server and returns its DSN
+// and a function that drops it again.
+func scratchDatabase(ctx context.Context, server string) (string, func(), error) {
+	u, err := url.Parse(server)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return "", nil, errors.New("the scratch database is created from a postgres:// URL; pass one explicitly")
+	}
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	name := "simpletracker_verify_" + hex.EncodeToString(suffix)
+
+	admin, err := openPostgres(server)
+	if err != nil {
+		return "", nil, err
+	}
+	closeAdmin := func() {
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	if err := admin.WithContext(ctx).Exec("CREATE DATABASE " + name).Error; err != nil {
+		closeAdmin()
+		return "", nil, fmt.Errorf("create scratch database: %w", err)
+	}
+	u.Path = "/" + name
+	drop := func() {
+		admin.Exec("DROP DATABASE IF EXISTS " + name)
+		closeAdmin()
+	}
+	return u.String(), drop, nil
+}
@@ -0,0 +1,79 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Policy keeps the newest snapshot of each of the last Daily days, Weekly ISO weeks and
+// Monthly months that have one. The newest snapshot overall is always kept.
+type Policy struct {
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+var DefaultPolicy = Policy{Daily: 7, Weekly: 4, Monthly: 12}
+
+// Keep reports which of snapshots the policy keeps, by name. Times are bucketed in UTC.
+func (p Policy) Keep(snapshots []Snapshot) map[string]bool {
+	newest := make([]Snapshot, len(snapshots))
+	copy(newest, snapshots)
+	sortNewestFirst(newest)
+
+	keep := map[string]bool{}
+	if len(newest) > 0 {
+		keep[newest[0].Name] = true
+	}
+	buckets := []struct {
+		limit int
+		key   func(time.Time) string
+	}{
+		{p.Daily, func(t time.Time) string { return t.Format(time.DateOnly) }},
+		{p.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{p.Monthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, bucket := range buckets {
+		seen := map[string]bool{}
+		for _, s := range newest {
+			if len(seen) >= bucket.limit {
+				break
+			}
+			key := bucket.key(s.TakenAt.UTC())
+			if !seen[key] {
+				seen[key] = true
+				keep[s.Name] = true
+			}
+		}
+	}
+	return keep
+}
+
+// Prune deletes the snapshots in dir the policy does not keep, with their checksum files,
+// and returns what it deleted.
+func Prune(dir string, p Policy) ([]Snapshot, error) {
+	snapshots, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	keep := p.Keep(snapshots)
+	var removed []Snapshot
+	for _, s := range snapshots {
+		if keep[s.Name] {
+			continue
+		}
+		if err := os.Remove(s.Path); err != nil {
+			return removed, err
+		}
+		if err := os.Remove(s.Path + checksumExt); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed = append(removed, s)
+	}
+	return removed, nil
+}
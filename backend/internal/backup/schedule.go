@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/core/module"
+
+	"gorm.io/gorm"
+)
+
+// Config is the server's snapshot schedule.
+type Config struct {
+	Dir      string
+	Interval time.Duration
+	Policy   Policy
+}
+
+// ConfigFrom reads the BACKUP_* settings. Scheduled snapshots are off unless BACKUP_DIR is
+// set.
+func ConfigFrom(cfg config.Backup) (Config, bool, error) {
+	if cfg.Dir == "" {
+		return Config{}, false, nil
+	}
+	schedule := Config{
+		Dir:      cfg.Dir,
+		Interval: time.Duration(cfg.IntervalHours) * time.Hour,
+		Policy:   PolicyFrom(cfg),
+	}
+	if schedule.Interval <= 0 {
+		return Config{}, false, fmt.Errorf("BACKUP_INTERVAL_HOURS must be positive")
+	}
+	return schedule, true, nil
+}
+
+// PolicyFrom reads BACKUP_KEEP_DAILY, BACKUP_KEEP_WEEKLY and BACKUP_KEEP_MONTHLY.
+func PolicyFrom(cfg config.Backup) Policy {
+	return Policy{Daily: cfg.KeepDaily, Weekly: cfg.KeepWeekly, Monthly: cfg.KeepMonthly}
+}
+
+// Job is the BACKUP_DIR snapshot schedule as a background job, or nil when none is configured.
+// Snapshots cover the whole database, whichever modules are enabled.
+func Job(db *gorm.DB, cfg config.Backup) (module.Job, error) {
+	schedule, enabled, err := ConfigFrom(cfg)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	log.Printf("backup: snapshots every %s to %s", schedule.Interval, schedule.Dir)
+	return func(ctx context.Context, _ func(string) bool) { Run(ctx, db, schedule) }, nil
+}
+
+// Run takes a snapshot every config.Interval until ctx is done, pruning after each one. The
+// first is taken as soon as the newest snapshot in the directory is an interval old, so
+// restarts do not reset the schedule.
+func Run(ctx context.Context, db *gorm.DB, config Config) {
+	wait := config.Interval
+	if snapshots, err := List(config.Dir); err != nil {
+		log.Printf("backup: %v", err)
+	} else if len(snapshots) == 0 {
+		wait = 0
+	} else {
+		wait = max(0, config.Interval-time.Since(snapshots[0].TakenAt))
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		runOnce(ctx, db, config)
+		timer.Reset(config.Interval)
+	}
+}
+
+func runOnce(ctx context.Context, db *gorm.DB, config Config) {
+	started := time.Now()
+	snapshot, err := Create(ctx, db, config.Dir)
+	if err != nil {
+		log.Printf("backup: snapshot failed: %v", err)
+		return
+	}
+	log.Printf("backup: wrote %s (%d bytes) in %s", snapshot.Name, snapshot.Size, time.Since(started).Round(time.Millisecond))
+	removed, err := Prune(config.Dir, config.Policy)
+	for _, s := range removed {
+		log.Printf("backup: pruned %s", s.Name)
+	}
+	if err != nil {
+		log.Printf("backup: prune failed: %v", err)
+	}
+}
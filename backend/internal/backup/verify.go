@@ -0,0 +1,271 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"be-simpletracker/internal/core/archive"
+	"be-simpletracker/internal/core/module"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Check is one step of loading a snapshot and inspecting the result.
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type Report struct {
+	Snapshot string `json:"snapshot"`
+	// Target is the database the snapshot was restored into and left in, if any.
+	Target        string           `json:"target,omitempty"`
+	SchemaVersion int              `json:"schema_version,omitempty"`
+	Rows          map[string]int64 `json:"rows,omitempty"`
+	Checks        []Check          `json:"checks"`
+}
+
+// OK is true when every check passed.
+func (r Report) OK() bool {
+	for _, c := range r.Checks {
+		if !c.OK {
+			return false
+		}
+	}
+	return len(r.Checks) > 0
+}
+
+func (r *Report) add(name string, err error, detail string) bool {
+	check := Check{Name: name, OK: err == nil, Detail: detail}
+	if err != nil {
+		check.Detail = err.Error()
+	}
+	r.Checks = append(r.Checks, check)
+	return check.OK
+}
+
+// Verify loads the snapshot at path into a scratch database, checks it, and throws the
+// scratch database away. Postgres snapshots are restored into a new database on server's
+// Postgres server, so the role in server needs CREATEDB. The live database is never touched.
+func Verify(ctx context.Context, path, server string) (Report, error) {
+	return restore(ctx, path, "", server)
+}
+
+// Restore loads the snapshot at path into into and checks it there: a Postgres URL naming an
+// empty database, or for SQLite a path that does not exist yet.
+func Restore(ctx context.Context, path, into string) (Report, error) {
+	if into == "" {
+		return Report{}, errors.New("no database to restore into")
+	}
+	return restore(ctx, path, into, "")
+}
+
+func restore(ctx context.Context, path, into, server string) (Report, error) {
+	driver := driverOf(path)
+	if driver == "" {
+		return Report{}, fmt.Errorf("%w: %s", ErrUnknownSnapshot, filepath.Base(path))
+	}
+	report := Report{Snapshot: path, Target: into}
+	if !report.add("checksum", VerifyChecksum(path), "matches "+filepath.Base(path)+checksumExt) {
+		return report, nil
+	}
+
+	var db *gorm.DB
+	var cleanup func()
+	var err error
+	if driver == "postgres" {
+		db, cleanup, err = loadPostgres(ctx, path, into, server)
+	} else {
+		db, cleanup, err = loadSQLite(path, into)
+	}
+	loaded := "loaded into a scratch database"
+	if into != "" {
+		loaded = "loaded into " + into
+	}
+	if !report.add("restore", err, loaded) {
+		return report, nil
+	}
+	defer cleanup()
+
+	if driver == "sqlite" {
+		report.add("integrity", sqliteIntegrity(ctx, db), "integrity_check and foreign_key_check clean")
+	}
+	version, detail, err := migrationState(ctx, db)
+	report.SchemaVersion = version
+	report.add("migrations", err, detail)
+	report.add("references", danglingReferences(ctx, db), "every reference points at an existing row")
+	report.Rows, err = countRows(ctx, db)
+	report.add("tables", err, fmt.Sprintf("%d tables readable", len(report.Rows)))
+	return report, nil
+}
+
+func loadSQLite(path, into string) (*gorm.DB, func(), error) {
+	target := into
+	var scratch string
+	if target == "" {
+		dir, err := os.MkdirTemp("", "simpletracker-verify-")
+		if err != nil {
+			return nil, nil, err
+		}
+		scratch = dir
+		target = filepath.Join(dir, filepath.Base(path))
+	}
+	removeScratch := func() {
+		if scratch != "" {
+			os.RemoveAll(scratch)
+		}
+	}
+	if err := copyFile(path, target); err != nil {
+		removeScratch()
+		return nil, nil, err
+	}
+	db, err := gorm.Open(sqlite.Open(target), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		removeScratch()
+		return nil, nil, err
+	}
+	return db, func() {
+		closeDB(db)
+		removeScratch()
+	}, nil
+}
+
+func loadPostgres(ctx context.Context, path, into, server string) (*gorm.DB, func(), error) {
+	target := into
+	drop := func() {}
+	if target == "" {
+		dsn, dropScratch, err := scratchDatabase(ctx, server)
+		if err != nil {
+			return nil, nil, err
+		}
+		target, drop = dsn, dropScratch
+	}
+	if err := restorePostgres(ctx, target, path); err != nil {
+		drop()
+		return nil, nil, err
+	}
+	db, err := openPostgres(target)
+	if err != nil {
+		drop()
+		return nil, nil, err
+	}
+	return db, func() {
+		closeDB(db)
+		drop()
+	}, nil
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+func sqliteIntegrity(ctx context.Context, db *gorm.DB) error {
+	var problems []string
+	if err := db.WithContext(ctx).Raw("PRAGMA integrity_check").Scan(&problems).Error; err != nil {
+		return err
+	}
+	if len(problems) != 1 || problems[0] != "ok" {
+		return fmt.Errorf("integrity_check: %s", strings.Join(problems, "; "))
+	}
+	var violations []struct {
+		Table  string
+		Parent string
+	}
+	if err := db.WithContext(ctx).Raw("PRAGMA foreign_key_check").Scan(&violations).Error; err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("foreign_key_check: %d rows, first in %s referencing %s", len(violations), violations[0].Table, violations[0].Parent)
+	}
+	return nil
+}
+
+// migrationState fails on history that does not match this build's migration files, as when
+// the snapshot came from a newer version. Pending migrations are fine: the server applies
+// them when it starts on the restored database. Every module's migrations are checked, as the
+// snapshot may come from a server that disables different modules than this one.
+func migrationState(ctx context.Context, db *gorm.DB) (int, string, error) {
+	modules, err := module.NewRegistry(module.All(db), module.Config{})
+	if err != nil {
+		return 0, "", err
+	}
+	runner, err := modules.Runner(db)
+	if err != nil {
+		return 0, "", err
+	}
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	version, pending := 0, 0
+	var problems []string
+	for _, s := range statuses {
+		switch {
+		case s.Missing:
+			problems = append(problems, fmt.Sprintf("%s is not in this build", s))
+		case s.Modified:
+			problems = append(problems, fmt.Sprintf("%s differs from this build", s))
+		}
+		if s.AppliedAt == nil {
+			pending++
+		} else {
+			version = s.Version
+		}
+	}
+	if len(problems) > 0 {
+		return version, "", errors.New(strings.Join(problems, "; "))
+	}
+	if version == 0 {
+		return 0, "", errors.New("no migration history")
+	}
+	detail := fmt.Sprintf("schema version %d", version)
+	if pending > 0 {
+		detail += fmt.Sprintf(", %d pending (applied when the server starts)", pending)
+	}
+	return version, detail, nil
+}
+
+func danglingReferences(ctx context.Context, db *gorm.DB) error {
+	found, err := archive.DanglingReferences(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	var problems []string
+	for _, d := range found {
+		problems = append(problems, fmt.Sprintf("%s.%s: %d rows without a %s row", d.Table, d.Column, d.Rows, d.References))
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+func countRows(ctx context.Context, db *gorm.DB) (map[string]int64, error) {
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tables)
+	rows := map[string]int64{}
+	for _, table := range tables {
+		if strings.HasPrefix(table, "sqlite_") {
+			continue
+		}
+		var count int64
+		if err := db.WithContext(ctx).Table(table).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		rows[table] = count
+	}
+	return rows, nil
+}
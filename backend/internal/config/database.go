@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PostgresDSN is the connection string for the Postgres database: DATABASE_URL when set,
+// otherwise DATABASE_URL_PRODUCTION in production and DATABASE_URL_DEVELOPMENT elsewhere.
+// Production connections must use TLS.
+func (c *Config) PostgresDSN() (string, error) {
+	if d := c.Database.URL; d != "" {
+		if c.IsProduction() {
+			if err := validateProductionPostgresDSN(d); err != nil {
+				return "", err
+			}
+		}
+		return d, nil
+	}
+	if c.IsProduction() {
+		d := c.Database.URLProd
+		if d == "" {
+			return "", errors.New("production database: DATABASE_URL_PRODUCTION is not set")
+		}
+		if err := validateProductionPostgresDSN(d); err != nil {
+			return "", err
+		}
+		return d, nil
+	}
+	return c.Database.URLDev, nil
+}
+
+func validateProductionPostgresDSN(dsn string) error {
+	mode := postgresSSLMode(dsn)
+	switch mode {
+	case "require", "verify-ca", "verify-full":
+		return nil
+	default:
+		return fmt.Errorf("production database must use sslmode=require, verify-ca, or verify-full")
+	}
+}
+
+func postgresSSLMode(dsn string) string {
+	if parsed, err := url.Parse(dsn); err == nil && (parsed.Scheme == "postgres" || parsed.Scheme == "postgresql") {
+		return strings.ToLower(strings.TrimSpace(parsed.Query().Get("sslmode")))
+	}
+	for _, field := range strings.Fields(dsn) {
+		key, value, ok := strings.Cut(field, "=")
+		if ok && strings.EqualFold(key, "sslmode") {
+			return strings.ToLower(strings.Trim(value, "'\""))
+		}
+	}
+	return ""
+}
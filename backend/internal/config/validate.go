@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// minProductionSecretLen is the shortest JWT secret accepted in production, in bytes.
+const minProductionSecretLen = 32
+
+const minMetricsTokenLen = 16
+
+// Validate reports every setting that would stop the server from running correctly, and in
+// production every development escape hatch that is still open.
+func (c *Config) Validate() error {
+	var problems []error
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+	positive := func(key string, n int) {
+		if n <= 0 {
+			fail("%s must be positive", key)
+		}
+	}
+
+	positive("SHUTDOWN_TIMEOUT_SEC", c.Server.ShutdownTimeoutSec)
+	if c.Auth.JWTSecret == "" {
+		fail("JWT_SECRET must be set")
+	}
+	switch strings.ToLower(c.Auth.CookieSameSite) {
+	case "", "lax", "strict", "none":
+	default:
+		fail("AUTH_COOKIE_SAMESITE must be lax, strict or none")
+	}
+	positive("AUTH_COOKIE_MAX_AGE_SEC", c.Auth.CookieMaxAgeSec)
+
+	switch driver := strings.ToLower(c.Database.Driver); driver {
+	case "postgres":
+		if _, err := c.PostgresDSN(); err != nil {
+			fail("database: %v", err)
+		}
+	case "sqlite":
+		positive("DATABASE_BUSY_TIMEOUT_MS", c.Database.BusyTimeoutMS)
+	default:
+		fail("DATABASE_DRIVER must be postgres or sqlite, not %q", driver)
+	}
+
+	switch c.LoginProtection.Store {
+	case "memory", "database", "postgres":
+	default:
+		fail("LOGIN_PROTECTION_STORE must be memory or database, not %q", c.LoginProtection.Store)
+	}
+	positive("LOGIN_RATE_LIMIT_WINDOW_SEC", c.LoginProtection.WindowSec)
+	positive("LOGIN_RATE_LIMIT_MAX_IP", c.LoginProtection.MaxPerIP)
+	positive("LOGIN_RATE_LIMIT_MAX_USERNAME", c.LoginProtection.MaxPerUsername)
+	positive("LOGIN_SPRAY_WINDOW_SEC", c.LoginProtection.SprayWindowSec)
+	positive("LOGIN_SPRAY_MAX_USERNAMES", c.LoginProtection.SprayMaxUsernames)
+	positive("LOGIN_SPRAY_BLOCK_SEC", c.LoginProtection.SprayBlockSec)
+	positive("INVITE_RATE_LIMIT_MAX_IP", c.LoginProtection.InviteMaxPerIP)
+	positive("INVITE_RATE_LIMIT_MAX_TOTAL", c.LoginProtection.InviteMaxTotal)
+
+	switch driver := strings.ToLower(c.Mail.Driver); driver {
+	case "outbox":
+	case "smtp":
+		if c.Mail.SMTPHost == "" || c.Mail.From == "" {
+			fail("MAIL_DRIVER=smtp needs SMTP_HOST and MAIL_FROM")
+		}
+		positive("SMTP_PORT", c.Mail.SMTPPort)
+	default:
+		fail("MAIL_DRIVER must be smtp or outbox, not %q", driver)
+	}
+
+	if c.Backup.Dir != "" {
+		positive("BACKUP_INTERVAL_HOURS", c.Backup.IntervalHours)
+		positive("BACKUP_KEEP_DAILY", c.Backup.KeepDaily)
+		positive("BACKUP_KEEP_WEEKLY", c.Backup.KeepWeekly)
+		positive("BACKUP_KEEP_MONTHLY", c.Backup.KeepMonthly)
+	}
+	if c.Metrics.Token != "" && len(c.Metrics.Token) < minMetricsTokenLen {
+		fail("METRICS_TOKEN must contain at least %d bytes", minMetricsTokenLen)
+	}
+	if c.Trash.RetentionDays < 0 {
+		fail("TRASH_RETENTION_DAYS must not be negative")
+	}
+
+	if c.IsProduction() {
+		problems = append(problems, c.validateProduction()...)
+	}
+	return errors.Join(problems...)
+}
+
+func (c *Config) validateProduction() []error {
+	var problems []error
+	if c.Auth.AllowBypass {
+		problems = append(problems, errors.New("ALLOW_BYPASS must be false in production"))
+	}
+	if c.Auth.DevAuthToken != "" {
+		problems = append(problems, errors.New("DEV_AUTH_TOKEN must not be set in production"))
+	}
+	if c.Auth.RegisterEnabled {
+		problems = append(problems, errors.New("REGISTER_ENABLED must be false in production"))
+	}
+	if len(c.Auth.JWTSecret) < minProductionSecretLen {
+		problems = append(problems, fmt.Errorf("JWT_SECRET must contain at least %d bytes in production", minProductionSecretLen))
+	}
+	for i, secret := range c.Auth.JWTPreviousSecrets {
+		if len(secret) < minProductionSecretLen {
+			problems = append(problems, fmt.Errorf("JWT_PREVIOUS_SECRETS entry %d must contain at least %d bytes in production", i+1, minProductionSecretLen))
+		}
+	}
+	if c.sources["CORS_ORIGINS"] == sourceDefault {
+		problems = append(problems, errors.New("CORS_ORIGINS must be set in production"))
+	} else {
+		for _, origin := range c.Server.CORSOrigins {
+			parsed, err := url.Parse(origin)
+			if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+				problems = append(problems, fmt.Errorf("production CORS origin must use HTTPS: %q", origin))
+			}
+		}
+	}
+	return problems
+}
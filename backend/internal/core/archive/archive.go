@@ -0,0 +1,199 @@
+// Package archive moves one account's data in and out of the tracker as a zip holding a
+// manifest and one NDJSON file per table. Rows are keyed by column name and point at each
+// other with the IDs they had when exported; Import gives every row a new ID in the target
+// account and rewrites the references to match.
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	dietmodels "be-simpletracker/internal/core/diet/models"
+	"be-simpletracker/internal/core/module"
+	moneymodels "be-simpletracker/internal/core/money/models"
+	"be-simpletracker/internal/core/tracking/grocery"
+	"be-simpletracker/internal/core/tracking/profile"
+	"be-simpletracker/internal/core/tracking/steps"
+	"be-simpletracker/internal/core/tracking/water"
+	"be-simpletracker/internal/core/tracking/weight"
+	workoutmodels "be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database/migrate"
+	"be-simpletracker/internal/database/migrations"
+
+	"gorm.io/gorm"
+)
+
+const (
+	Format        = "simpletracker-export"
+	FormatVersion = 1
+
+	manifestFile = "manifest.json"
+	ownerColumn  = "user_id"
+)
+
+var (
+	ErrNotAnArchive  = errors.New("not a simpletracker export")
+	ErrNewerArchive  = errors.New("archive was written by a newer version")
+	ErrCorruptRecord = errors.New("corrupt archive record")
+)
+
+type Manifest struct {
+	Format        string       `json:"format"`
+	FormatVersion int          `json:"format_version"`
+	SchemaVersion int          `json:"schema_version"`
+	ExportedAt    time.Time    `json:"exported_at"`
+	Username      string       `json:"username,omitempty"`
+	Entities      []EntityFile `json:"entities"`
+}
+
+type EntityFile struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Rows int    `json:"rows"`
+}
+
+// entity is one exported table. They are listed parents first, which is the order Import
+// inserts them in.
+type entity struct {
+	name  string
+	model any
+	// refs maps a column to the entity whose IDs it holds.
+	refs map[string]string
+	// key is a unique index within the account (user_id aside). An imported row whose key
+	// is already taken is matched to the existing row rather than inserted.
+	key []string
+	// single entities hold at most one row per account, so any existing row is a match.
+	single bool
+	// groups are columns numbering rows that belong together. Imported values are
+	// renumbered above the largest one in use.
+	groups []string
+	// adjust fixes up an imported row before it is inserted.
+	adjust func(tx *gorm.DB, userID uint, row any) error
+}
+
+func (e entity) file() string { return e.name + ".ndjson" }
+
+var entities = []entity{
+	{name: "plans", model: &dietmodels.Plan{}},
+	{name: "foods", model: &dietmodels.Food{}, key: []string{"name"}, groups: []string{"variant_group_id"}},
+	{name: "composite_foods", model: &dietmodels.CompositeFood{}, key: []string{"name"}},
+	{name: "composite_food_items", model: &dietmodels.CompositeFoodItem{}, refs: map[string]string{
+		"composite_food_id": "composite_foods",
+		"food_id":           "foods",
+	}},
+	{name: "meals", model: &dietmodels.Meal{}},
+	{name: "meal_items", model: &dietmodels.MealItem{}, refs: map[string]string{
+		"meal_id":           "meals",
+		"food_id":           "foods",
+		"composite_food_id": "composite_foods",
+	}},
+	{name: "saved_meals", model: &dietmodels.SavedMeal{}},
+	{name: "saved_meal_items", model: &dietmodels.SavedMealItem{}, refs: map[string]string{
+		"saved_meal_id":     "saved_meals",
+		"food_id":           "foods",
+		"composite_food_id": "composite_foods",
+	}},
+	{name: "days", model: &dietmodels.DietDay{}, key: []string{"date"}, refs: map[string]string{
+		"plan_id": "plans",
+	}},
+	{name: "planned_meals", model: &dietmodels.PlannedMeal{}, refs: map[string]string{
+		"day_id":        "days",
+		"meal_id":       "meals",
+		"saved_meal_id": "saved_meals",
+	}},
+	{name: "day_logs", model: &dietmodels.DayLog{}, refs: map[string]string{
+		"day_id":  "days",
+		"meal_id": "meals",
+	}},
+
+	{name: "exercises", model: &workoutmodels.Exercise{}, key: []string{"name"}},
+	{name: "workout_programs", model: &workoutmodels.WorkoutProgram{}, adjust: deactivateImportedProgram},
+	{name: "workout_plans", model: &workoutmodels.WorkoutPlan{}, refs: map[string]string{
+		"workout_program_id": "workout_programs",
+	}},
+	{name: "workout_plan_days", model: &workoutmodels.WorkoutPlanDay{}, refs: map[string]string{
+		"workout_plan_id": "workout_plans",
+	}},
+	{name: "workout_plan_exercises", model: &workoutmodels.WorkoutPlanExercise{}, refs: map[string]string{
+		"workout_plan_id": "workout_plans",
+		"exercise_id":     "exercises",
+	}},
+	{name: "workout_logs", model: &workoutmodels.WorkoutLog{}, refs: map[string]string{
+		"workout_plan_id": "workout_plans",
+	}},
+	{name: "logged_exercises", model: &workoutmodels.LoggedExercise{}, refs: map[string]string{
+		"workout_log_id": "workout_logs",
+		"exercise_id":    "exercises",
+	}},
+	{name: "logged_sets", model: &workoutmodels.LoggedSet{}, refs: map[string]string{
+		"logged_exercise_id": "logged_exercises",
+	}},
+	{name: "cardios", model: &workoutmodels.Cardio{}, refs: map[string]string{
+		"workout_log_id": "workout_logs",
+	}},
+
+	{name: "grocery_items", model: &grocery.GroceryItem{}},
+	{name: "step_logs", model: &steps.StepLog{}, key: []string{"date"}},
+	{name: "body_weight_logs", model: &weight.BodyWeightLog{}, key: []string{"date"}},
+	{name: "drink_size_presets", model: &water.DrinkSizePreset{}},
+	{name: "water_logs", model: &water.WaterLog{}, refs: map[string]string{
+		"preset_id": "drink_size_presets",
+	}},
+	{name: "user_profiles", model: &profile.UserProfile{}, single: true},
+
+	{name: "investment_account_types", model: &moneymodels.InvestmentAccountType{}, key: []string{"name"}},
+	{name: "investment_contribution_rules", model: &moneymodels.ContributionRule{}, key: []string{"investment_account_type_id", "year"}, refs: map[string]string{
+		"investment_account_type_id": "investment_account_types",
+	}},
+	{name: "investment_accounts", model: &moneymodels.InvestmentAccount{}, refs: map[string]string{
+		"investment_account_type_id": "investment_account_types",
+	}},
+	{name: "investment_deposits", model: &moneymodels.InvestmentDeposit{}, refs: map[string]string{
+		"account_id": "investment_accounts",
+	}},
+}
+
+// deactivateImportedProgram keeps the account's current program active when it has one.
+func deactivateImportedProgram(tx *gorm.DB, userID uint, row any) error {
+	program := row.(*workoutmodels.WorkoutProgram)
+	if !program.IsActive {
+		return nil
+	}
+	var active int64
+	if err := tx.Model(&workoutmodels.WorkoutProgram{}).
+		Where("user_id = ? AND is_active = ?", userID, true).
+		Count(&active).Error; err != nil {
+		return err
+	}
+	program.IsActive = active == 0
+	return nil
+}
+
+// SchemaVersion is the latest migration this build knows about, in the core schema or any
+// module's.
+func SchemaVersion() (int, error) {
+	sets := []fs.FS{migrations.FS}
+	for _, m := range module.All(nil) {
+		sets = append(sets, m.Migrations())
+	}
+	latest := 0
+	for _, fsys := range sets {
+		sub, err := fs.Sub(fsys, "postgres")
+		if err != nil {
+			return 0, err
+		}
+		loaded, err := migrate.Load(sub)
+		if err != nil {
+			return 0, err
+		}
+		if len(loaded) > 0 {
+			latest = max(latest, loaded[len(loaded)-1].Version)
+		}
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations embedded")
+	}
+	return latest, nil
+}
@@ -0,0 +1,311 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	dietmodels "be-simpletracker/internal/core/diet/models"
+	moneymodels "be-simpletracker/internal/core/money/models"
+	workoutmodels "be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/dbtest"
+
+	"gorm.io/gorm"
+)
+
+const (
+	alice uint = 1
+	bob   uint = 2
+)
+
+// everyTable leaves every module enabled.
+func everyTable(string) bool { return true }
+
+func setupArchiveDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := dbtest.Open(t)
+	for _, e := range entities {
+		if err := db.AutoMigrate(e.model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func create(t *testing.T, db *gorm.DB, value any) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// seedAlice writes one row down each reference chain the importer has to rewrite.
+func seedAlice(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	day := database.Date{Year: 2026, Month: time.March, Day: 2}
+	plan := dietmodels.Plan{UserID: alice, Name: "Cut", Calories: 2100}
+	create(t, db, &plan)
+	group := uint(7)
+	oats := dietmodels.Food{UserID: alice, Name: "Oats", ServingType: "g", ServingAmount: 40, Calories: 150, VariantGroupID: &group}
+	create(t, db, &oats)
+	meal := dietmodels.Meal{UserID: alice, Name: "Breakfast", Items: []dietmodels.MealItem{{UserID: alice, FoodID: oats.ID, Amount: 2}}}
+	create(t, db, &meal)
+	dietDay := dietmodels.DietDay{UserID: alice, Date: day, PlanID: plan.ID}
+	create(t, db, &dietDay)
+	create(t, db, &dietmodels.DayLog{UserID: alice, DayID: dietDay.ID, MealID: meal.ID})
+
+	squat := workoutmodels.Exercise{UserID: alice, Name: "Squat"}
+	create(t, db, &squat)
+	log := workoutmodels.WorkoutLog{UserID: alice, Date: day, PreMobilityChecked: []string{"hips"}}
+	create(t, db, &log)
+	logged := workoutmodels.LoggedExercise{UserID: alice, WorkoutLogID: log.ID, ExerciseID: squat.ID}
+	create(t, db, &logged)
+	create(t, db, &workoutmodels.LoggedSet{UserID: alice, LoggedExerciseID: logged.ID, Reps: 5, Weight: 225})
+
+	account := moneymodels.InvestmentAccount{UserID: alice, Name: "Brokerage", CurrentBalance: 1500.25}
+	create(t, db, &account)
+	create(t, db, &moneymodels.InvestmentDeposit{UserID: alice, AccountID: account.ID, Amount: 250.5, Date: day})
+}
+
+func exportFor(t *testing.T, db *gorm.DB, userID uint) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := Export(context.Background(), db, everyTable, userID, "alice", &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func importArchive(t *testing.T, db *gorm.DB, userID uint, data []byte, dryRun bool) Report {
+	t.Helper()
+	report, err := Import(context.Background(), db, everyTable, userID, bytes.NewReader(data), int64(len(data)), dryRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func entityReport(t *testing.T, report Report, name string) EntityReport {
+	t.Helper()
+	for _, e := range report.Entities {
+		if e.Name == name {
+			return e
+		}
+	}
+	t.Fatalf("no %s in report", name)
+	return EntityReport{}
+}
+
+func TestImportRemapsReferencesIntoAnotherAccount(t *testing.T) {
+	db := setupArchiveDB(t)
+	seedAlice(t, db)
+	// Give bob's rows different IDs than alice's so a missed remap points at the wrong row.
+	create(t, db, &dietmodels.Food{UserID: bob, Name: "Rice", ServingType: "g", ServingAmount: 100, Calories: 130})
+	create(t, db, &workoutmodels.Exercise{UserID: bob, Name: "Bench"})
+
+	report := importArchive(t, db, bob, exportFor(t, db, alice), false)
+	if got := entityReport(t, report, "meal_items"); got.Created != 1 {
+		t.Fatalf("meal_items %+v", got)
+	}
+
+	var item dietmodels.MealItem
+	if err := db.Where("user_id = ?", bob).Preload("Food").Preload("Meal").First(&item).Error; err != nil {
+		t.Fatal(err)
+	}
+	if item.Food.Name != "Oats" || item.Food.UserID != bob || item.Meal.UserID != bob {
+		t.Fatalf("meal item points at %+v / %+v", item.Food, item.Meal)
+	}
+	if item.Food.VariantGroupID == nil || *item.Food.VariantGroupID == 7 {
+		t.Fatalf("variant group %v was not renumbered", item.Food.VariantGroupID)
+	}
+	var dayLog dietmodels.DayLog
+	if err := db.Where("user_id = ?", bob).First(&dayLog).Error; err != nil {
+		t.Fatal(err)
+	}
+	var day dietmodels.DietDay
+	if err := db.Preload("Plan").First(&day, dayLog.DayID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if day.UserID != bob || day.Plan.UserID != bob || dayLog.MealID != item.MealID {
+		t.Fatalf("day log %+v day %+v", dayLog, day)
+	}
+
+	var set workoutmodels.LoggedSet
+	if err := db.Where("user_id = ?", bob).First(&set).Error; err != nil {
+		t.Fatal(err)
+	}
+	var logged workoutmodels.LoggedExercise
+	if err := db.Preload("Exercise").First(&logged, set.LoggedExerciseID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var workoutLog workoutmodels.WorkoutLog
+	if err := db.First(&workoutLog, logged.WorkoutLogID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if logged.UserID != bob || logged.Exercise.Name != "Squat" || logged.Exercise.UserID != bob || workoutLog.UserID != bob {
+		t.Fatalf("logged exercise %+v log %+v", logged, workoutLog)
+	}
+	if len(workoutLog.PreMobilityChecked) != 1 || workoutLog.PreMobilityChecked[0] != "hips" {
+		t.Fatalf("mobility %v", workoutLog.PreMobilityChecked)
+	}
+
+	var deposit moneymodels.InvestmentDeposit
+	if err := db.Where("user_id = ?", bob).First(&deposit).Error; err != nil {
+		t.Fatal(err)
+	}
+	var account moneymodels.InvestmentAccount
+	if err := db.First(&account, deposit.AccountID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if account.UserID != bob || account.CurrentBalance != 1500.25 || deposit.Amount != 250.5 {
+		t.Fatalf("account %+v deposit %+v", account, deposit)
+	}
+}
+
+func TestImportDryRunChangesNothing(t *testing.T) {
+	db := setupArchiveDB(t)
+	seedAlice(t, db)
+	report := importArchive(t, db, bob, exportFor(t, db, alice), true)
+	if !report.DryRun || entityReport(t, report, "logged_sets").Created != 1 {
+		t.Fatalf("report %+v", report)
+	}
+	var count int64
+	if err := db.Model(&dietmodels.Food{}).Where("user_id = ?", bob).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("dry run created %d foods", count)
+	}
+}
+
+func TestImportMergesIntoExistingRows(t *testing.T) {
+	db := setupArchiveDB(t)
+	seedAlice(t, db)
+	data := exportFor(t, db, alice)
+
+	report := importArchive(t, db, alice, data, false)
+	for _, name := range []string{"foods", "exercises", "days"} {
+		if got := entityReport(t, report, name); got.Matched != 1 || got.Created != 0 {
+			t.Fatalf("%s %+v", name, got)
+		}
+	}
+	var items []dietmodels.MealItem
+	if err := db.Where("user_id = ?", alice).Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].FoodID != items[1].FoodID {
+		t.Fatalf("meal items %+v should share the existing food", items)
+	}
+}
+
+func TestImportRejectsNewerSchema(t *testing.T) {
+	db := setupArchiveDB(t)
+	version, err := SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewEncoder(w).Encode(Manifest{Format: Format, FormatVersion: FormatVersion, SchemaVersion: version + 1})
+	zw.Close()
+
+	_, err = Import(context.Background(), db, everyTable, bob, bytes.NewReader(buf.Bytes()), int64(buf.Len()), false)
+	if !errors.Is(err, ErrNewerArchive) {
+		t.Fatalf("got %v, want ErrNewerArchive", err)
+	}
+}
+
+func TestDanglingReferencesFindsOrphans(t *testing.T) {
+	db := setupArchiveDB(t)
+	seedAlice(t, db)
+	if found, err := DanglingReferences(context.Background(), db); err != nil || len(found) != 0 {
+		t.Fatalf("clean data reported %+v, %v", found, err)
+	}
+	if err := db.Unscoped().Where("name = ?", "Squat").Delete(&workoutmodels.Exercise{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	found, err := DanglingReferences(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Table != "logged_exercises" || found[0].Column != "exercise_id" || found[0].Rows != 1 {
+		t.Fatalf("got %+v", found)
+	}
+}
+
+func TestImportRestoresTrashedRowsHoldingTheKey(t *testing.T) {
+	db := setupArchiveDB(t)
+	seedAlice(t, db)
+	data := exportFor(t, db, alice)
+	if err := db.Where("user_id = ? AND name = ?", alice, "Oats").Delete(&dietmodels.Food{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var trashed dietmodels.Food
+	if err := db.Unscoped().Where("user_id = ? AND name = ?", alice, "Oats").Take(&trashed).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	report := importArchive(t, db, alice, data, false)
+	if got := entityReport(t, report, "foods"); got.Restored != 1 || got.Matched != 0 || got.Created != 0 {
+		t.Fatalf("foods %+v", got)
+	}
+	var oats dietmodels.Food
+	if err := db.Where("user_id = ? AND name = ?", alice, "Oats").Take(&oats).Error; err != nil {
+		t.Fatalf("imported food still in the trash: %v", err)
+	}
+	if oats.ID != trashed.ID || oats.Calories != 150 {
+		t.Fatalf("restored %+v, want row %d with the archive's values", oats, trashed.ID)
+	}
+	var items []dietmodels.MealItem
+	if err := db.Where("user_id = ?", alice).Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		if item.FoodID != oats.ID {
+			t.Fatalf("meal item %+v points away from the restored food", item)
+		}
+	}
+}
+
+func TestDisabledModuleIsLeftOutOfExportAndImport(t *testing.T) {
+	db := setupArchiveDB(t)
+	seedAlice(t, db)
+	noMoney := func(table string) bool { return !strings.HasPrefix(table, "investment_") }
+
+	var buf bytes.Buffer
+	manifest, err := Export(context.Background(), db, noMoney, alice, "alice", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range manifest.Entities {
+		if !noMoney(e.Name) {
+			t.Fatalf("exported %s", e.Name)
+		}
+	}
+
+	data := exportFor(t, db, alice)
+	report, err := Import(context.Background(), db, noMoney, bob, bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Warnings) == 0 || !strings.Contains(strings.Join(report.Warnings, "\n"), "investment_accounts: its module is disabled") {
+		t.Fatalf("warnings %v", report.Warnings)
+	}
+	var accounts int64
+	if err := db.Model(&moneymodels.InvestmentAccount{}).Where("user_id = ?", bob).Count(&accounts).Error; err != nil {
+		t.Fatal(err)
+	}
+	if accounts != 0 || entityReport(t, report, "foods").Created != 1 {
+		t.Fatalf("imported %d accounts, report %+v", accounts, report.Entities)
+	}
+}
@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Export writes every row the user owns in the tables of enabled modules, soft-deleted ones
+// included, as a zip archive.
+func Export(ctx context.Context, db *gorm.DB, enabled func(table string) bool, userID uint, username string, w io.Writer) (Manifest, error) {
+	version, err := SchemaVersion()
+	if err != nil {
+		return Manifest{}, err
+	}
+	manifest := Manifest{
+		Format:        Format,
+		FormatVersion: FormatVersion,
+		SchemaVersion: version,
+		ExportedAt:    time.Now().UTC(),
+		Username:      username,
+	}
+	db = db.WithContext(ctx)
+	zw := zip.NewWriter(w)
+	for _, e := range entities {
+		if !enabled(e.name) {
+			continue
+		}
+		out, err := createFile(zw, e.file(), manifest.ExportedAt)
+		if err != nil {
+			return Manifest{}, err
+		}
+		rows, err := exportEntity(db, e, userID, out)
+		if err != nil {
+			return Manifest{}, err
+		}
+		manifest.Entities = append(manifest.Entities, EntityFile{Name: e.name, File: e.file(), Rows: rows})
+	}
+	out, err := createFile(zw, manifestFile, manifest.ExportedAt)
+	if err != nil {
+		return Manifest{}, err
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, zw.Close()
+}
+
+func createFile(zw *zip.Writer, name string, modified time.Time) (io.Writer, error) {
+	return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+}
+
+func exportEntity(db *gorm.DB, e entity, userID uint, w io.Writer) (int, error) {
+	sch, err := parseSchema(db, e.model)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := db.Unscoped().Model(e.model).
+		Where(ownerColumn+" = ?", userID).
+		Order(strings.Join(sch.PrimaryFieldDBNames, ", ")).
+		Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	count := 0
+	for rows.Next() {
+		row := reflect.New(sch.ModelType)
+		if err := db.ScanRows(rows, row.Interface()); err != nil {
+			return 0, err
+		}
+		record := map[string]any{}
+		for _, field := range columns(sch) {
+			record[field.DBName] = field.ReflectValueOf(db.Statement.Context, row.Elem()).Interface()
+		}
+		if err := enc.Encode(record); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return count, buf.Flush()
+}
+
+func parseSchema(db *gorm.DB, model any) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// columns are the fields stored in the table, less the owner, which Import sets itself.
+func columns(sch *schema.Schema) []*schema.Field {
+	var out []*schema.Field
+	for _, name := range sch.DBNames {
+		if name == ownerColumn {
+			continue
+		}
+		out = append(out, sch.FieldsByDBName[name])
+	}
+	return out
+}
@@ -0,0 +1,368 @@
+package archive
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"be-simpletracker/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type Report struct {
+	DryRun   bool           `json:"dry_run"`
+	Manifest Manifest       `json:"manifest"`
+	Entities []EntityReport `json:"entities"`
+	Warnings []string       `json:"warnings,omitempty"`
+}
+
+// EntityReport counts what happened to one table's rows: Created were inserted, Matched
+// already existed in the account under the same unique key and were left as they were, and
+// Restored had a key held by a row in the trash, which was brought back with the archive's
+// values.
+type EntityReport struct {
+	Name     string `json:"name"`
+	Rows     int    `json:"rows"`
+	Created  int    `json:"created"`
+	Matched  int    `json:"matched"`
+	Restored int    `json:"restored"`
+}
+
+var errDryRun = errors.New("dry run")
+
+// Import adds the archive's rows to the user's account in one transaction, skipping the tables
+// of modules enabled does not report as enabled. With dryRun the transaction is rolled back, so
+// the report says exactly what a real import would do.
+func Import(ctx context.Context, db *gorm.DB, enabled func(table string) bool, userID uint, r io.ReaderAt, size int64, dryRun bool) (Report, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return Report{}, fmt.Errorf("%w: %v", ErrNotAnArchive, err)
+	}
+	manifest, err := readManifest(zr)
+	if err != nil {
+		return Report{}, err
+	}
+	report := Report{DryRun: dryRun, Manifest: manifest}
+	files := map[string]EntityFile{}
+	for _, f := range manifest.Entities {
+		files[f.Name] = f
+	}
+	known := map[string]bool{}
+	for _, e := range entities {
+		known[e.name] = true
+	}
+	for _, f := range manifest.Entities {
+		switch {
+		case !known[f.Name]:
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: not a known table, skipped", f.Name))
+		case !enabled(f.Name):
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: its module is disabled, skipped", f.Name))
+		}
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		im := importer{tx: tx, userID: userID, ids: map[string]map[uint]uint{}}
+		for _, e := range entities {
+			f, ok := files[e.name]
+			if !ok || !enabled(e.name) {
+				continue
+			}
+			entityReport, err := im.importEntity(zr, e, f, &report)
+			if err != nil {
+				return fmt.Errorf("%s: %w", e.name, err)
+			}
+			report.Entities = append(report.Entities, entityReport)
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+func readManifest(zr *zip.Reader) (Manifest, error) {
+	f, err := zr.Open(manifestFile)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%w: no %s", ErrNotAnArchive, manifestFile)
+	}
+	defer f.Close()
+	var manifest Manifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("%w: %v", ErrNotAnArchive, err)
+	}
+	if manifest.Format != Format {
+		return Manifest{}, fmt.Errorf("%w: format %q", ErrNotAnArchive, manifest.Format)
+	}
+	if manifest.FormatVersion > FormatVersion {
+		return Manifest{}, fmt.Errorf("%w: format version %d, this build reads up to %d", ErrNewerArchive, manifest.FormatVersion, FormatVersion)
+	}
+	version, err := SchemaVersion()
+	if err != nil {
+		return Manifest{}, err
+	}
+	if manifest.SchemaVersion > version {
+		return Manifest{}, fmt.Errorf("%w: schema version %d, this build is at %d", ErrNewerArchive, manifest.SchemaVersion, version)
+	}
+	return manifest, nil
+}
+
+type importer struct {
+	tx     *gorm.DB
+	userID uint
+	// ids maps each entity's exported IDs to the IDs the rows have in this database.
+	ids map[string]map[uint]uint
+}
+
+func (im *importer) importEntity(zr *zip.Reader, e entity, f EntityFile, report *Report) (EntityReport, error) {
+	result := EntityReport{Name: e.name}
+	sch, err := parseSchema(im.tx, e.model)
+	if err != nil {
+		return result, err
+	}
+	in, err := zr.Open(f.File)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", ErrNotAnArchive, err)
+	}
+	defer in.Close()
+
+	groups, err := im.groupAllocators(e, sch)
+	if err != nil {
+		return result, err
+	}
+	ids := map[uint]uint{}
+	im.ids[e.name] = ids
+	unknown := map[string]bool{}
+	idField := sch.LookUpField("ID")
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		result.Rows++
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return result, fmt.Errorf("%w: row %d: %v", ErrCorruptRecord, result.Rows, err)
+		}
+		row := reflect.New(sch.ModelType)
+		if err := im.decode(sch, record, row.Elem(), unknown); err != nil {
+			return result, fmt.Errorf("%w: row %d: %v", ErrCorruptRecord, result.Rows, err)
+		}
+		for column, target := range e.refs {
+			if err := im.remap(sch.LookUpField(column), row.Elem(), target); err != nil {
+				return result, fmt.Errorf("row %d: %w", result.Rows, err)
+			}
+		}
+		for column, next := range groups {
+			renumber(im.tx, sch.LookUpField(column), row.Elem(), next)
+		}
+		if err := im.setOwner(sch, row.Elem()); err != nil {
+			return result, err
+		}
+
+		var exportedID uint
+		if idField != nil {
+			exportedID = uintOf(idField.ReflectValueOf(im.tx.Statement.Context, row.Elem()))
+		}
+		existing, trashed, err := im.findExisting(e, sch, row.Elem())
+		if err != nil {
+			return result, err
+		}
+		if existing != 0 && !trashed {
+			ids[exportedID] = existing
+			result.Matched++
+			continue
+		}
+		if e.adjust != nil {
+			if err := e.adjust(im.tx, im.userID, row.Interface()); err != nil {
+				return result, err
+			}
+		}
+		if trashed {
+			if err := im.restore(e, sch, row.Elem(), existing); err != nil {
+				return result, fmt.Errorf("row %d: %w", result.Rows, err)
+			}
+			ids[exportedID] = existing
+			result.Restored++
+			continue
+		}
+		if idField != nil {
+			idField.ReflectValueOf(im.tx.Statement.Context, row.Elem()).SetUint(0)
+		}
+		if err := im.tx.Omit(clause.Associations).Create(row.Interface()).Error; err != nil {
+			return result, fmt.Errorf("row %d: %w", result.Rows, err)
+		}
+		if idField != nil {
+			ids[exportedID] = uintOf(idField.ReflectValueOf(im.tx.Statement.Context, row.Elem()))
+		}
+		result.Created++
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("%w: %v", ErrCorruptRecord, err)
+	}
+	if result.Rows != f.Rows {
+		return result, fmt.Errorf("%w: manifest lists %d rows, file has %d", ErrCorruptRecord, f.Rows, result.Rows)
+	}
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s: column %s is not in this schema, ignored", e.name, name))
+	}
+	return result, nil
+}
+
+// decode fills row from an exported record. Columns missing from older archives keep
+// their zero value.
+func (im *importer) decode(sch *schema.Schema, record map[string]json.RawMessage, row reflect.Value, unknown map[string]bool) error {
+	for name, raw := range record {
+		field := sch.FieldsByDBName[name]
+		if field == nil || name == ownerColumn {
+			unknown[name] = true
+			continue
+		}
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		field.ReflectValueOf(im.tx.Statement.Context, row).Set(value.Elem())
+	}
+	return nil
+}
+
+// remap points a reference column at the new ID of the row it referred to. Zero and null
+// references are left alone.
+func (im *importer) remap(field *schema.Field, row reflect.Value, target string) error {
+	value := field.ReflectValueOf(im.tx.Statement.Context, row)
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	old := uint(value.Uint())
+	if old == 0 {
+		return nil
+	}
+	id, ok := im.ids[target][old]
+	if !ok {
+		return fmt.Errorf("%w: %s %d refers to %s that is not in the archive", ErrCorruptRecord, field.DBName, old, target)
+	}
+	value.SetUint(uint64(id))
+	return nil
+}
+
+func (im *importer) groupAllocators(e entity, sch *schema.Schema) (map[string]func(uint) uint, error) {
+	allocators := map[string]func(uint) uint{}
+	for _, column := range e.groups {
+		var highest uint
+		if err := im.tx.Unscoped().Model(e.model).Select("COALESCE(MAX(" + column + "), 0)").Scan(&highest).Error; err != nil {
+			return nil, err
+		}
+		assigned := map[uint]uint{}
+		allocators[column] = func(old uint) uint {
+			if id, ok := assigned[old]; ok {
+				return id
+			}
+			highest++
+			assigned[old] = highest
+			return highest
+		}
+	}
+	return allocators, nil
+}
+
+func renumber(tx *gorm.DB, field *schema.Field, row reflect.Value, next func(uint) uint) {
+	value := field.ReflectValueOf(tx.Statement.Context, row)
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	if old := uint(value.Uint()); old != 0 {
+		value.SetUint(uint64(next(old)))
+	}
+}
+
+func (im *importer) setOwner(sch *schema.Schema, row reflect.Value) error {
+	field := sch.LookUpField(ownerColumn)
+	if field == nil {
+		return fmt.Errorf("%s has no %s column", sch.Table, ownerColumn)
+	}
+	field.ReflectValueOf(im.tx.Statement.Context, row).SetUint(uint64(im.userID))
+	return nil
+}
+
+// findExisting returns the ID of the account's row that already holds row's unique key, and
+// whether that row is in the trash. A live row is preferred when both exist.
+func (im *importer) findExisting(e entity, sch *schema.Schema, row reflect.Value) (uint, bool, error) {
+	if len(e.key) == 0 && !e.single {
+		return 0, false, nil
+	}
+	query := im.tx.Unscoped().Model(e.model).Where(ownerColumn+" = ?", im.userID)
+	for _, column := range e.key {
+		value := sch.LookUpField(column).ReflectValueOf(im.tx.Statement.Context, row).Interface()
+		query = query.Where(clause.Eq{Column: clause.Column{Name: column}, Value: value})
+	}
+	if sch.LookUpField("DeletedAt") == nil {
+		var ids []uint
+		if err := query.Order("id ASC").Limit(1).Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			return 0, false, err
+		}
+		return ids[0], false, nil
+	}
+	var found []struct {
+		ID        uint
+		DeletedAt gorm.DeletedAt
+	}
+	if err := query.Select("id", "deleted_at").Order("deleted_at IS NOT NULL, id ASC").Limit(1).Scan(&found).Error; err != nil {
+		return 0, false, err
+	}
+	if len(found) == 0 {
+		return 0, false, nil
+	}
+	return found[0].ID, found[0].DeletedAt.Valid, nil
+}
+
+// restore brings back the trashed row id with the imported row's values. The unique indexes
+// cover trashed rows too, so the imported row cannot be inserted beside it, and matching it
+// would leave the data hidden and due to be purged.
+func (im *importer) restore(e entity, sch *schema.Schema, row reflect.Value, id uint) error {
+	ctx := im.tx.Statement.Context
+	sch.LookUpField("ID").ReflectValueOf(ctx, row).SetUint(uint64(id))
+	sch.LookUpField("DeletedAt").ReflectValueOf(ctx, row).SetZero()
+	err := im.tx.Unscoped().Model(row.Addr().Interface()).
+		Select("*").Omit("id", "created_at", "version", clause.Associations).
+		Updates(row.Addr().Interface()).Error
+	if err != nil {
+		return err
+	}
+	if sch.LookUpField("Version") != nil {
+		_, err = database.ClaimVersion(im.tx, e.model, id)
+	}
+	return err
+}
+
+func uintOf(value reflect.Value) uint {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return 0
+		}
+		value = value.Elem()
+	}
+	return uint(value.Uint())
+}
@@ -0,0 +1,63 @@
+package archive
+
+import (
+	"context"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Dangling counts rows whose reference column names a row that does not exist.
+type Dangling struct {
+	Table      string `json:"table"`
+	Column     string `json:"column"`
+	References string `json:"references"`
+	Rows       int64  `json:"rows"`
+}
+
+// Reference is a column of Table holding IDs of rows in Parent.
+type Reference struct {
+	Table  string
+	Column string
+	Parent string
+}
+
+// References lists every reference an export follows, including the ones the schema declares
+// no foreign key for, parents first.
+func References() []Reference {
+	var out []Reference
+	for _, e := range entities {
+		columns := make([]string, 0, len(e.refs))
+		for column := range e.refs {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		for _, column := range columns {
+			out = append(out, Reference{Table: e.name, Column: column, Parent: e.refs[column]})
+		}
+	}
+	return out
+}
+
+// DanglingReferences checks every reference in References. Tables missing from db are skipped.
+func DanglingReferences(ctx context.Context, db *gorm.DB) ([]Dangling, error) {
+	db = db.WithContext(ctx)
+	var found []Dangling
+	for _, ref := range References() {
+		if !db.Migrator().HasTable(ref.Table) || !db.Migrator().HasTable(ref.Parent) {
+			continue
+		}
+		var rows int64
+		err := db.Table(ref.Table + " AS child").
+			Where("child." + ref.Column + " IS NOT NULL AND child." + ref.Column + " <> 0").
+			Where("NOT EXISTS (SELECT 1 FROM " + ref.Parent + " AS parent WHERE parent.id = child." + ref.Column + ")").
+			Count(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		if rows > 0 {
+			found = append(found, Dangling{Table: ref.Table, Column: ref.Column, References: ref.Parent, Rows: rows})
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,33 @@
+package archive
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RegisterRoutes mounts the export of the tables enabled reports as belonging to an enabled
+// module.
+func RegisterRoutes(router *gin.Engine, db *gorm.DB, enabled func(table string) bool, authMiddleware gin.HandlerFunc) {
+	router.GET("/export", authMiddleware, func(c *gin.Context) {
+		exportAccount(c, db, enabled)
+	})
+}
+
+// exportAccount streams the archive as it is built, so a failure part way through can only
+// be logged: the client is left with a truncated zip that Import rejects.
+func exportAccount(c *gin.Context, db *gorm.DB, enabled func(table string) bool) {
+	username := c.GetString("username")
+	filename := fmt.Sprintf("simpletracker-%s-%s.zip", username, time.Now().UTC().Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	if _, err := Export(c.Request.Context(), db, enabled, c.GetUint("user_id"), username, c.Writer); err != nil {
+		log.Printf("[archive] export for %s failed: %v", username, err)
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"be-simpletracker/internal/core/auth/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessTokenMiddleware authenticates `Authorization: Bearer` personal access tokens. It is a
+// separate path from AuthMiddleware: it never reads the auth cookie, and the route must fall
+// inside one of the token's scopes (see requiredScope).
+func AccessTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, ok := bearerToken(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		token, user, err := services.AuthenticateAccessToken(raw)
+		if errors.Is(err, services.ErrInvalidAccessToken) || errors.Is(err, services.ErrAccessTokenExpired) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			c.Abort()
+			return
+		}
+
+		scope, ok := requiredScope(c)
+		if !ok || !services.HasScope(token, scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token is missing the required scope", "required_scope": scope})
+			c.Abort()
+			return
+		}
+
+		setAuthenticatedUser(c, user.ID, user.Username, token.CreatedAt.Unix())
+		c.Set("access_token_id", token.ID)
+		c.Next()
+	}
+}
+
+// CookieOrAccessTokenMiddleware guards module routes: requests carrying a Bearer token go
+// through AccessTokenMiddleware, everything else through the cookie-based AuthMiddleware.
+func CookieOrAccessTokenMiddleware() gin.HandlerFunc {
+	cookieAuth := AuthMiddleware()
+	tokenAuth := AccessTokenMiddleware()
+	return func(c *gin.Context) {
+		if _, ok := bearerToken(c); ok {
+			tokenAuth(c)
+			return
+		}
+		cookieAuth(c)
+	}
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// requiredScope maps the matched route to "<module>:read" for safe methods and
+// "<module>:write" otherwise, where module is the first path segment (/tracking/weight →
+// tracking). Routes outside ScopedModules, such as /auth, cannot be reached with a token.
+func requiredScope(c *gin.Context) (string, bool) {
+	module, _, _ := strings.Cut(strings.TrimPrefix(c.FullPath(), "/"), "/")
+	access := "write"
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		access = "read"
+	}
+	scope := module + ":" + access
+	for _, scoped := range services.ScopedModules {
+		if scoped == module {
+			return scope, true
+		}
+	}
+	return scope, false
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"be-simpletracker/internal/core/auth/services"
+	"be-simpletracker/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func createTestAccessToken(t *testing.T, username string, scopes []string, expiresAt *time.Time) string {
+	t.Helper()
+	user := createTestUser(t, username)
+	service := services.NewAuthService(GenerateToken)
+	created, err := service.CreateAccessToken(user.ID, services.CreateAccessTokenInput{
+		Name:      "cron",
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created.Token
+}
+
+func accessTokenRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	group := r.Group("/tracking", CookieOrAccessTokenMiddleware())
+	group.GET("/weight", func(c *gin.Context) {
+		userID, _ := database.UserIDFrom(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"user_id": userID})
+	})
+	group.POST("/weight", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	r.GET("/auth/me", CookieOrAccessTokenMiddleware(), func(c *gin.Context) {})
+	return r
+}
+
+func serveWithBearer(r *gin.Engine, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAccessToken_writeScopeAllowsPushAndRead(t *testing.T) {
+	tok := createTestAccessToken(t, "scale", []string{"tracking:write"}, nil)
+	r := accessTokenRouter()
+	if code := serveWithBearer(r, http.MethodPost, "/tracking/weight", tok); code != http.StatusCreated {
+		t.Fatalf("POST status %d want %d", code, http.StatusCreated)
+	}
+	if code := serveWithBearer(r, http.MethodGet, "/tracking/weight", tok); code != http.StatusOK {
+		t.Fatalf("GET status %d want %d", code, http.StatusOK)
+	}
+}
+
+func TestAccessToken_readScopeCannotWrite(t *testing.T) {
+	tok := createTestAccessToken(t, "reader", []string{"tracking:read"}, nil)
+	r := accessTokenRouter()
+	if code := serveWithBearer(r, http.MethodPost, "/tracking/weight", tok); code != http.StatusForbidden {
+		t.Fatalf("status %d want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestAccessToken_otherModuleScopeForbidden(t *testing.T) {
+	tok := createTestAccessToken(t, "lifter", []string{"workout:write"}, nil)
+	r := accessTokenRouter()
+	if code := serveWithBearer(r, http.MethodGet, "/tracking/weight", tok); code != http.StatusForbidden {
+		t.Fatalf("status %d want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestAccessToken_cannotReachAuthRoutes(t *testing.T) {
+	tok := createTestAccessToken(t, "sneaky", []string{"tracking:write"}, nil)
+	r := accessTokenRouter()
+	if code := serveWithBearer(r, http.MethodGet, "/auth/me", tok); code != http.StatusForbidden {
+		t.Fatalf("status %d want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestAccessToken_expiredRejected(t *testing.T) {
+	tok := createTestAccessToken(t, "lapsed", []string{"tracking:write"}, nil)
+	past := time.Now().Add(-time.Hour)
+	if err := database.GetDB().Table("access_tokens").Where("1 = 1").Update("expires_at", past).Error; err != nil {
+		t.Fatal(err)
+	}
+	r := accessTokenRouter()
+	if code := serveWithBearer(r, http.MethodGet, "/tracking/weight", tok); code != http.StatusUnauthorized {
+		t.Fatalf("status %d want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestAccessToken_unknownTokenRejected(t *testing.T) {
+	r := accessTokenRouter()
+	if code := serveWithBearer(r, http.MethodGet, "/tracking/weight", services.AccessTokenPrefix+"nope"); code != http.StatusUnauthorized {
+		t.Fatalf("status %d want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestAccessToken_sessionJWTAsBearerRejected(t *testing.T) {
+	tok, _ := issueTestToken(t, createTestUser(t, "browser"))
+	r := accessTokenRouter()
+	if code := serveWithBearer(r, http.MethodGet, "/tracking/weight", tok); code != http.StatusUnauthorized {
+		t.Fatalf("status %d want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -1,20 +1,35 @@
 package auth
 
 import (
+	"log"
+
+	"be-simpletracker/internal/config"
 	"be-simpletracker/internal/core/auth/controller"
-	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
 	"be-simpletracker/internal/core/auth/services"
-	"be-simpletracker/internal/env"
+	"be-simpletracker/internal/mailer"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func RegisterRoutes(router *gin.Engine) {
+// RegisterRoutes mounts /auth. Configure must have been called with the same cfg.
+func RegisterRoutes(router *gin.Engine, cfg *config.Config) {
 	group := router.Group("/")
-	registerEnabled := !env.IsProduction() && env.StringOr("REGISTER_ENABLED", "false") == "true"
-	service := services.NewAuthService(GenerateToken)
-	loginProtection := controller.NewLoginProtection(controller.LoginProtectionConfigFromEnv(), nil)
+	registerEnabled := !cfg.IsProduction() && cfg.Auth.RegisterEnabled
+	service := services.NewAuthService(GenerateToken).
+		WithTOTPIssuer(cfg.Auth.TOTPIssuer).
+		WithEnvironment(cfg.Environment)
+	mail, err := mailer.FromConfig(cfg)
+	if err != nil {
+		log.Printf("[auth] password reset email disabled: %v", err)
+	} else {
+		service.WithMailer(mail)
+	}
+	loginProtection := controller.NewLoginProtection(controller.LoginProtectionConfigFrom(cfg.LoginProtection), nil).
+		WithAttemptRecorder(authrepo.LoginAttemptLog{})
+	if store := cfg.LoginProtection.Store; store == "database" || store == "postgres" {
+		loginProtection.WithStore(authrepo.PostgresLoginStore{})
+	}
 	cookie := controller.CookieConfig{
 		Name:     AuthTokenCookieName,
 		MaxAge:   CookieMaxAgeSeconds(),
@@ -28,12 +43,28 @@ func RegisterRoutes(router *gin.Engine) {
 			auth.POST("/register", func(c *gin.Context) {
 				controller.Register(c, service, cookie)
 			})
+		} else {
+			auth.POST("/register", func(c *gin.Context) {
+				controller.RegisterWithInvite(c, service, cookie, loginProtection)
+			})
 		}
 		auth.POST("/login", func(c *gin.Context) {
 			controller.Login(c, service, cookie, loginProtection)
 		})
+		auth.POST("/login/2fa", func(c *gin.Context) {
+			controller.LoginTwoFactor(c, service, cookie, loginProtection)
+		})
 		auth.POST("/logout", func(c *gin.Context) {
-			controller.Logout(c, cookie)
+			controller.Logout(c, service, cookie, currentTokenID(c))
+		})
+		auth.PATCH("/password", AuthMiddleware(), func(c *gin.Context) {
+			controller.ChangePassword(c, service, cookie, loginProtection)
+		})
+		auth.POST("/password/forgot", func(c *gin.Context) {
+			controller.RequestPasswordReset(c, service, loginProtection, cfg.Auth.PasswordResetURL)
+		})
+		auth.POST("/password/reset", func(c *gin.Context) {
+			controller.ResetPassword(c, service)
 		})
 		auth.GET("/me", AuthMiddleware(), func(c *gin.Context) {
 			controller.GetCurrentUser(c, service)
@@ -41,9 +72,53 @@ func RegisterRoutes(router *gin.Engine) {
 		auth.PATCH("/me", AuthMiddleware(), func(c *gin.Context) {
 			controller.UpdateCurrentUser(c, service)
 		})
+		auth.GET("/preferences", AuthMiddleware(), func(c *gin.Context) {
+			controller.GetPreferences(c, service)
+		})
+		auth.PUT("/preferences", AuthMiddleware(), func(c *gin.Context) {
+			controller.UpdatePreferences(c, service)
+		})
+		auth.GET("/login-history", AuthMiddleware(), func(c *gin.Context) {
+			controller.ListLoginHistory(c, service)
+		})
+		auth.POST("/login-history/acknowledge", AuthMiddleware(), func(c *gin.Context) {
+			controller.AcknowledgeNewDeviceLogins(c, service)
+		})
+		auth.GET("/sessions", AuthMiddleware(), func(c *gin.Context) {
+			controller.ListSessions(c, service)
+		})
+		auth.DELETE("/sessions", AuthMiddleware(), func(c *gin.Context) {
+			controller.RevokeAllSessions(c, service, cookie)
+		})
+		auth.DELETE("/sessions/:id", AuthMiddleware(), func(c *gin.Context) {
+			controller.RevokeSession(c, service, cookie)
+		})
+		auth.POST("/2fa/setup", AuthMiddleware(), func(c *gin.Context) {
+			controller.SetupTOTP(c, service)
+		})
+		auth.POST("/2fa/verify", AuthMiddleware(), func(c *gin.Context) {
+			controller.VerifyTOTP(c, service)
+		})
+		auth.POST("/2fa/disable", AuthMiddleware(), func(c *gin.Context) {
+			controller.DisableTOTP(c, service)
+		})
+		auth.GET("/invites", AuthMiddleware(), func(c *gin.Context) {
+			controller.ListInvites(c, service)
+		})
+		auth.POST("/invites", AuthMiddleware(), func(c *gin.Context) {
+			controller.CreateInvite(c, service)
+		})
+		auth.DELETE("/invites/:id", AuthMiddleware(), func(c *gin.Context) {
+			controller.RevokeInvite(c, service)
+		})
+		auth.GET("/tokens", AuthMiddleware(), func(c *gin.Context) {
+			controller.ListAccessTokens(c, service)
+		})
+		auth.POST("/tokens", AuthMiddleware(), func(c *gin.Context) {
+			controller.CreateAccessToken(c, service)
+		})
+		auth.DELETE("/tokens/:id", AuthMiddleware(), func(c *gin.Context) {
+			controller.RevokeAccessToken(c, service)
+		})
 	}
 }
-
-func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(&models.User{})
-}
@@ -3,20 +3,26 @@ package auth
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"be-simpletracker/internal/config"
 	"github.com/gin-gonic/gin"
 )
 
-func TestRegisterRouteIsNeverEnabledInProduction(t *testing.T) {
-	t.Setenv("APP_ENV", "production")
-	t.Setenv("REGISTER_ENABLED", "true")
+func TestOpenRegistrationIsNeverEnabledInProduction(t *testing.T) {
+	cfg := configure(t, func(cfg *config.Config) {
+		cfg.Environment = "production"
+		cfg.Auth.RegisterEnabled = true
+	})
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
-	RegisterRoutes(router)
+	RegisterRoutes(router, cfg)
 	recorder := httptest.NewRecorder()
-	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/auth/register", nil))
-	if recorder.Code != http.StatusNotFound {
-		t.Fatalf("got status %d, want 404", recorder.Code)
+	request := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(`{"username":"mallory","password":"secret"}`))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "invite_code") {
+		t.Fatalf("got status %d body %s, want invite_code to be required", recorder.Code, recorder.Body.String())
 	}
 }
@@ -1,12 +1,22 @@
 package auth
 
 import (
+	"errors"
+	"log"
 	"net/http"
 
+	authrepo "be-simpletracker/internal/core/auth/repository"
+	"be-simpletracker/internal/core/auth/services"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/utils"
+
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // AuthMiddleware validates the JWT stored in the httpOnly auth_token cookie from the SPA.
+// The token's jti must still name a session row, so logging out or revoking a device
+// invalidates it before it expires.
 //
 // The default is strict: missing or invalid tokens always produce 401.
 func AuthMiddleware() gin.HandlerFunc {
@@ -31,8 +41,68 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("username", claims.Username)
-		c.Set("timestamp", claims.Timestamp)
+		user, err := authrepo.FindUserByUsername(claims.Username)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: unknown user"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			c.Abort()
+			return
+		}
+		if user.Disabled() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: account disabled"})
+			c.Abort()
+			return
+		}
+
+		session, err := authrepo.FindSessionByTokenID(claims.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && session.UserID != user.ID) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: session revoked"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			c.Abort()
+			return
+		}
+		client := services.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+		if err := services.TouchSession(session, client); err != nil {
+			log.Printf("[auth] touch session %d: %v", session.ID, err)
+		}
+
+		setAuthenticatedUser(c, user.ID, claims.Username, claims.Timestamp)
+		c.Set("session_id", session.ID)
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// setAuthenticatedUser exposes the caller to handlers, scopes every query made with the
+// request context to their rows, and counts days on their calendar.
+func setAuthenticatedUser(c *gin.Context, userID uint, username string, timestamp int64) {
+	c.Set("user_id", userID)
+	c.Set("username", username)
+	c.Set("timestamp", timestamp)
+	calendar, err := services.CalendarFor(userID)
+	if err != nil {
+		log.Printf("[auth] preferences of user %d: %v", userID, err)
+	}
+	ctx := database.WithUserID(c.Request.Context(), userID)
+	c.Request = c.Request.WithContext(utils.WithCalendar(ctx, calendar))
+}
+
+// currentTokenID returns the jti of a valid auth cookie on the request, or "" if there is none.
+func currentTokenID(c *gin.Context) string {
+	token, _ := c.Cookie(AuthTokenCookieName)
+	if token == "" {
+		return ""
+	}
+	claims, err := VerifyToken(token)
+	if err != nil {
+		return ""
+	}
+	return claims.ID
+}
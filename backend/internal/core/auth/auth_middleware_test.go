@@ -7,18 +7,20 @@ import (
 	"strings"
 	"testing"
 
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/database"
+
 	"github.com/gin-gonic/gin"
 )
 
 const testDevAuthToken = "dev-bypass-token-for-tests-only"
 
 func TestAuthMiddleware_validCookie_setsUsername(t *testing.T) {
-	t.Setenv("APP_ENV", "prod")
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "prod"
+	})
 	gin.SetMode(gin.TestMode)
-	tok, err := GenerateToken("wanda")
-	if err != nil {
-		t.Fatal(err)
-	}
+	tok, _ := issueTestToken(t, createTestUser(t, "wanda"))
 	r := gin.New()
 	r.GET("/x", AuthMiddleware(), func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"u": c.GetString("username")})
@@ -33,7 +35,9 @@ func TestAuthMiddleware_validCookie_setsUsername(t *testing.T) {
 }
 
 func TestAuthMiddleware_missingCookie_unauthorized(t *testing.T) {
-	t.Setenv("APP_ENV", "prod")
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "prod"
+	})
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
@@ -46,9 +50,11 @@ func TestAuthMiddleware_missingCookie_unauthorized(t *testing.T) {
 }
 
 func TestAuthMiddleware_authorizationBearerIgnored_withoutCookie(t *testing.T) {
-	t.Setenv("APP_ENV", "prod")
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "prod"
+	})
 	gin.SetMode(gin.TestMode)
-	tok, err := GenerateToken("zeus")
+	tok, err := GenerateToken("zeus", "zeus-session")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -64,7 +70,9 @@ func TestAuthMiddleware_authorizationBearerIgnored_withoutCookie(t *testing.T) {
 }
 
 func TestAuthMiddleware_invalidCookie_returnsInvalidTokenError(t *testing.T) {
-	t.Setenv("APP_ENV", "prod")
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "prod"
+	})
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
@@ -87,8 +95,7 @@ func TestAuthMiddleware_invalidCookie_returnsInvalidTokenError(t *testing.T) {
 }
 
 func TestAuthMiddleware_devTokenDisabledByDefault_evenInDevEnv(t *testing.T) {
-	t.Setenv("ALLOW_BYPASS", "")
-	t.Setenv("DEV_AUTH_TOKEN", "")
+	configure(t, func(cfg *config.Config) {})
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
@@ -101,8 +108,9 @@ func TestAuthMiddleware_devTokenDisabledByDefault_evenInDevEnv(t *testing.T) {
 }
 
 func TestAuthMiddleware_devToken_unsetAllowBypass_disabled(t *testing.T) {
-	t.Setenv("ALLOW_BYPASS", "")
-	t.Setenv("DEV_AUTH_TOKEN", testDevAuthToken)
+	configure(t, func(cfg *config.Config) {
+		cfg.Auth.DevAuthToken = testDevAuthToken
+	})
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
@@ -116,9 +124,11 @@ func TestAuthMiddleware_devToken_unsetAllowBypass_disabled(t *testing.T) {
 }
 
 func TestAuthMiddleware_devToken_cookieAuthorizes(t *testing.T) {
-	t.Setenv("APP_ENV", "development")
-	t.Setenv("ALLOW_BYPASS", "true")
-	t.Setenv("DEV_AUTH_TOKEN", testDevAuthToken)
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "development"
+		cfg.Auth.AllowBypass = true
+		cfg.Auth.DevAuthToken = testDevAuthToken
+	})
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/x", AuthMiddleware(), func(c *gin.Context) {
@@ -143,9 +153,11 @@ func TestAuthMiddleware_devToken_cookieAuthorizes(t *testing.T) {
 }
 
 func TestAuthMiddleware_devTokenNeverAuthorizesInProduction(t *testing.T) {
-	t.Setenv("APP_ENV", "production")
-	t.Setenv("ALLOW_BYPASS", "true")
-	t.Setenv("DEV_AUTH_TOKEN", testDevAuthToken)
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "production"
+		cfg.Auth.AllowBypass = true
+		cfg.Auth.DevAuthToken = testDevAuthToken
+	})
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
@@ -159,9 +171,11 @@ func TestAuthMiddleware_devTokenNeverAuthorizesInProduction(t *testing.T) {
 }
 
 func TestAuthMiddleware_devToken_overrideUser(t *testing.T) {
-	t.Setenv("ALLOW_BYPASS", "true")
-	t.Setenv("DEV_AUTH_TOKEN", testDevAuthToken)
-	t.Setenv("DEV_AUTH_USER", "alice")
+	configure(t, func(cfg *config.Config) {
+		cfg.Auth.AllowBypass = true
+		cfg.Auth.DevAuthToken = testDevAuthToken
+		cfg.Auth.DevAuthUser = "alice"
+	})
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/x", AuthMiddleware(), func(c *gin.Context) {
@@ -186,8 +200,10 @@ func TestAuthMiddleware_devToken_overrideUser(t *testing.T) {
 }
 
 func TestAuthMiddleware_devToken_allowBypassFalse_disabled(t *testing.T) {
-	t.Setenv("ALLOW_BYPASS", "false")
-	t.Setenv("DEV_AUTH_TOKEN", testDevAuthToken)
+	configure(t, func(cfg *config.Config) {
+		cfg.Auth.AllowBypass = false
+		cfg.Auth.DevAuthToken = testDevAuthToken
+	})
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
@@ -199,3 +215,118 @@ func TestAuthMiddleware_devToken_allowBypassFalse_disabled(t *testing.T) {
 		t.Fatalf("ALLOW_BYPASS=false must disable bypass: got %d want 401", rec.Code)
 	}
 }
+
+func TestAuthMiddleware_validCookie_scopesRequestToUser(t *testing.T) {
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "prod"
+	})
+	gin.SetMode(gin.TestMode)
+	user := createTestUser(t, "hera")
+	tok, _ := issueTestToken(t, user)
+	var gotCtx, gotKey uint
+	r := gin.New()
+	r.GET("/", AuthMiddleware(), func(c *gin.Context) {
+		gotCtx, _ = database.UserIDFrom(c.Request.Context())
+		gotKey = c.GetUint("user_id")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: AuthTokenCookieName, Value: tok})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body.String())
+	}
+	if gotCtx != user.ID || gotKey != user.ID {
+		t.Fatalf("user id: ctx=%d key=%d want %d", gotCtx, gotKey, user.ID)
+	}
+}
+
+func TestAuthMiddleware_tokenForUnknownUser_unauthorized(t *testing.T) {
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "prod"
+	})
+	gin.SetMode(gin.TestMode)
+	tok, err := GenerateToken("nobody", "nobody-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: AuthTokenCookieName, Value: tok})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthMiddleware_tokenWithoutSession_unauthorized(t *testing.T) {
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "prod"
+	})
+	gin.SetMode(gin.TestMode)
+	createTestUser(t, "iris")
+	tok, err := GenerateToken("iris", "never-issued")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: AuthTokenCookieName, Value: tok})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthMiddleware_revokedSession_unauthorized(t *testing.T) {
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "prod"
+	})
+	gin.SetMode(gin.TestMode)
+	user := createTestUser(t, "juno")
+	tok, session := issueTestToken(t, user)
+	r := gin.New()
+	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: AuthTokenCookieName, Value: tok})
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("before revoke: status %d", code)
+	}
+	if err := database.GetDB().Unscoped().Delete(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(); code != http.StatusUnauthorized {
+		t.Fatalf("after revoke: status %d want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthMiddleware_sessionOfAnotherUser_unauthorized(t *testing.T) {
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "prod"
+	})
+	gin.SetMode(gin.TestMode)
+	_, session := issueTestToken(t, createTestUser(t, "kore"))
+	createTestUser(t, "leto")
+	tok, err := GenerateToken("leto", session.TokenID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.GET("/", AuthMiddleware(), func(c *gin.Context) {})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: AuthTokenCookieName, Value: tok})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -3,9 +3,72 @@ package auth
 import (
 	"os"
 	"testing"
+	"time"
+
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/core/auth/models"
+	"be-simpletracker/internal/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
 )
 
+const testJWTSecret = "test-jwt-secret-32-characters!!"
+
 func TestMain(m *testing.M) {
-	os.Setenv("JWT_SECRET", "test-jwt-secret-32-characters!!")
+	if err := Configure(testConfig()); err != nil {
+		panic(err)
+	}
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		panic(err)
+	}
+	database.SetDB(db)
 	os.Exit(m.Run())
 }
+
+// testConfig is the default configuration with a signing key, as the tests run by default.
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = testJWTSecret
+	return cfg
+}
+
+// configure gives the package testConfig as changed by edit, for the rest of the test.
+func configure(t *testing.T, edit func(cfg *config.Config)) *config.Config {
+	t.Helper()
+	saved := settings
+	t.Cleanup(func() { settings = saved })
+	cfg := testConfig()
+	edit(cfg)
+	if err := Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func createTestUser(t *testing.T, username string) models.User {
+	t.Helper()
+	user := models.User{Username: username, Password: "hash", Email: username + "@example.com"}
+	if err := database.GetDB().Where("username = ?", username).FirstOrCreate(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// issueTestToken signs a token for user backed by a live session row.
+func issueTestToken(t *testing.T, user models.User) (string, models.Session) {
+	t.Helper()
+	session := models.Session{UserID: user.ID, TokenID: user.Username + "-" + t.Name(), LastSeenAt: time.Now()}
+	if err := database.GetDB().Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	tok, err := GenerateToken(user.Username, session.TokenID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok, session
+}
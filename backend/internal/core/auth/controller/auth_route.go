@@ -4,11 +4,11 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"be-simpletracker/internal/core/auth/models"
 	"be-simpletracker/internal/core/auth/services"
-	"be-simpletracker/internal/env"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,9 +21,10 @@ type CookieConfig struct {
 }
 
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required,max=128"`
-	Password string `json:"password" binding:"required,max=72"`
-	Email    string `json:"email" binding:"max=254"`
+	Username   string `json:"username" binding:"required,max=128"`
+	Password   string `json:"password" binding:"required,max=72"`
+	Email      string `json:"email" binding:"max=254"`
+	InviteCode string `json:"invite_code" binding:"max=64"`
 }
 
 func Register(c *gin.Context, service *services.AuthService, cookie CookieConfig) {
@@ -39,31 +40,84 @@ func Register(c *gin.Context, service *services.AuthService, cookie CookieConfig
 		Username: req.Username,
 		Password: req.Password,
 		Email:    req.Email,
+		Client:   clientInfo(c),
 	})
 	if err != nil {
-		switch {
-		case errors.Is(err, services.ErrUsernameExists):
-			c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
-		case errors.Is(err, services.ErrPasswordHash):
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-		case errors.Is(err, services.ErrUserCreation):
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
-		case errors.Is(err, services.ErrTokenGeneration):
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		respondRegisterError(c, err)
+		return
+	}
+	respondRegistered(c, service, cookie, result)
+}
+
+// RegisterWithInvite is the registration endpoint when open sign-up is off. Guesses at invite
+// codes are limited per client IP and across all clients, never by the username the request
+// names, which the guesser chooses and which would lock out an account it matched.
+func RegisterWithInvite(c *gin.Context, service *services.AuthService, cookie CookieConfig, protection *LoginProtection) {
+	setAuthResponseHeaders(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 16*1024)
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.InviteCode) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invite_code is required"})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	decision := protection.AllowInvite(clientIP)
+	if !decision.Allowed {
+		if decision.LogRejection {
+			protection.LogAttempt(c.Request.Context(), "rate_limited", clientIP, req.Username, c.Request.UserAgent())
 		}
+		respondLoginRateLimited(c, decision.RetryAfter)
 		return
 	}
 
+	result, err := service.RegisterWithInvite(services.RegisterInput{
+		Username: req.Username,
+		Password: req.Password,
+		Email:    req.Email,
+		Client:   clientInfo(c),
+	}, req.InviteCode)
+	if errors.Is(err, services.ErrInvalidInvite) {
+		protection.LogAttempt(c.Request.Context(), "invalid_invite_code", clientIP, req.Username, c.Request.UserAgent())
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired invite code"})
+		return
+	}
+	if err != nil {
+		respondRegisterError(c, err)
+		return
+	}
+	protection.LogAttempt(c.Request.Context(), "registered", clientIP, req.Username, c.Request.UserAgent())
+	respondRegistered(c, service, cookie, result)
+}
+
+func respondRegistered(c *gin.Context, service *services.AuthService, cookie CookieConfig, result services.AuthResult) {
 	setAuthCookie(c, cookie, result.Token)
 	c.JSON(http.StatusCreated, AuthResponse{
 		User:        result.User,
 		Username:    result.User.Username,
-		Environment: currentEnvironment(),
+		Environment: service.Environment(),
 	})
 }
 
+func respondRegisterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUsernameExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+	case errors.Is(err, services.ErrPasswordHash):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+	case errors.Is(err, services.ErrUserCreation):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	case errors.Is(err, services.ErrTokenGeneration):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	}
+}
+
 type AuthResponse struct {
 	User        models.User `json:"user"`
 	Username    string      `json:"username"`
@@ -92,7 +146,7 @@ func Login(c *gin.Context, service *services.AuthService, cookie CookieConfig, p
 			if decision.Reason == "credential_spray" {
 				outcome = "credential_spray_blocked"
 			}
-			protection.LogAttempt(c.Request.Context(), outcome, clientIP, req.Username)
+			protection.LogAttempt(c.Request.Context(), outcome, clientIP, req.Username, c.Request.UserAgent())
 		}
 		respondLoginRateLimited(c, decision.RetryAfter)
 		return
@@ -101,6 +155,7 @@ func Login(c *gin.Context, service *services.AuthService, cookie CookieConfig, p
 	result, err := service.Login(services.LoginInput{
 		Username: req.Username,
 		Password: req.Password,
+		Client:   clientInfo(c),
 	})
 	if err != nil {
 		switch {
@@ -108,30 +163,43 @@ func Login(c *gin.Context, service *services.AuthService, cookie CookieConfig, p
 			sprayDecision := protection.RecordFailure(clientIP, req.Username)
 			if !sprayDecision.Allowed {
 				if sprayDecision.LogRejection {
-					protection.LogAttempt(c.Request.Context(), "credential_spray_blocked", clientIP, req.Username)
+					protection.LogAttempt(c.Request.Context(), "credential_spray_blocked", clientIP, req.Username, c.Request.UserAgent())
 				}
 				respondLoginRateLimited(c, sprayDecision.RetryAfter)
 				return
 			}
-			protection.LogAttempt(c.Request.Context(), "invalid_credentials", clientIP, req.Username)
+			protection.LogAttempt(c.Request.Context(), "invalid_credentials", clientIP, req.Username, c.Request.UserAgent())
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		case errors.Is(err, services.ErrAccountDisabled):
+			protection.LogAttempt(c.Request.Context(), "account_disabled", clientIP, req.Username, c.Request.UserAgent())
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
 		case errors.Is(err, services.ErrTokenGeneration):
-			protection.LogAttempt(c.Request.Context(), "server_error", clientIP, req.Username)
+			protection.LogAttempt(c.Request.Context(), "server_error", clientIP, req.Username, c.Request.UserAgent())
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		default:
-			protection.LogAttempt(c.Request.Context(), "server_error", clientIP, req.Username)
+			protection.LogAttempt(c.Request.Context(), "server_error", clientIP, req.Username, c.Request.UserAgent())
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		}
 		return
 	}
 
+	if result.TwoFactorRequired {
+		// No RecordSuccess yet: the username limit keeps counting until the second factor passes.
+		protection.LogAttempt(c.Request.Context(), "two_factor_required", clientIP, req.Username, c.Request.UserAgent())
+		c.JSON(http.StatusOK, gin.H{
+			"two_factor_required": true,
+			"pending_token":       result.PendingToken,
+		})
+		return
+	}
+
 	setAuthCookie(c, cookie, result.Token)
 	protection.RecordSuccess(clientIP, result.User.Username)
-	protection.LogAttempt(c.Request.Context(), "success", clientIP, result.User.Username)
+	protection.LogAttempt(c.Request.Context(), "success", clientIP, result.User.Username, c.Request.UserAgent())
 	c.JSON(http.StatusOK, AuthResponse{
 		User:        result.User,
 		Username:    result.User.Username,
-		Environment: currentEnvironment(),
+		Environment: service.Environment(),
 	})
 }
 
@@ -153,10 +221,16 @@ func GetCurrentUser(c *gin.Context, service *services.AuthService) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
+	newDeviceLogins, err := service.NewDeviceLogins(user.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"user":        user,
-		"username":    user.Username,
-		"environment": currentEnvironment(),
+		"user":              user,
+		"username":          user.Username,
+		"environment":       service.Environment(),
+		"new_device_logins": newDeviceLogins,
 	})
 }
 
@@ -182,10 +256,26 @@ func UpdateCurrentUser(c *gin.Context, service *services.AuthService) {
 	c.JSON(http.StatusOK, gin.H{"user": user})
 }
 
-func Logout(c *gin.Context, cookie CookieConfig) {
+// Logout revokes the session behind tokenID (empty when the request had no valid cookie)
+// and clears the cookie.
+func Logout(c *gin.Context, service *services.AuthService, cookie CookieConfig, tokenID string) {
+	if tokenID != "" {
+		if err := service.RevokeSessionByTokenID(tokenID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+	clearAuthCookie(c, cookie)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+func clearAuthCookie(c *gin.Context, cookie CookieConfig) {
 	c.SetSameSite(cookie.SameSite)
 	c.SetCookie(cookie.Name, "", -1, "/", "", cookie.Secure, true)
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
 }
 
 func setAuthCookie(c *gin.Context, cookie CookieConfig, token string) {
@@ -206,7 +296,3 @@ func respondLoginRateLimited(c *gin.Context, retryAfter time.Duration) {
 	c.Header("Retry-After", strconv.Itoa(retryAfterSeconds))
 	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many login attempts. Try again later"})
 }
-
-func currentEnvironment() string {
-	return env.StringOr("APP_ENV", env.StringOr("GO_ENV", "development"))
-}
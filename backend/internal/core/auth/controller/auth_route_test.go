@@ -2,6 +2,7 @@ package controller
 
 import (
 	"bytes"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
 	"be-simpletracker/internal/core/auth/services"
 	"be-simpletracker/internal/database"
 
@@ -24,7 +26,7 @@ func setupLoginRouteTest(t *testing.T, config LoginProtectionConfig) (*gin.Engin
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := db.AutoMigrate(&models.User{}); err != nil {
+	if err := db.AutoMigrate(models.All()...); err != nil {
 		t.Fatal(err)
 	}
 	database.SetDB(db)
@@ -32,7 +34,7 @@ func setupLoginRouteTest(t *testing.T, config LoginProtectionConfig) (*gin.Engin
 		database.SetDB(nil)
 	})
 
-	service := services.NewAuthService(func(string) (string, error) {
+	service := services.NewAuthService(func(string, string) (string, error) {
 		return "server-only-token", nil
 	})
 	if _, err := service.Register(services.RegisterInput{
@@ -44,7 +46,7 @@ func setupLoginRouteTest(t *testing.T, config LoginProtectionConfig) (*gin.Engin
 
 	var logs bytes.Buffer
 	logger := slog.New(slog.NewJSONHandler(&logs, nil))
-	protection := NewLoginProtection(config, logger)
+	protection := NewLoginProtection(config, logger).WithAttemptRecorder(authrepo.LoginAttemptLog{})
 	cookie := CookieConfig{
 		Name:     "auth_token",
 		MaxAge:   3600,
@@ -55,6 +57,9 @@ func setupLoginRouteTest(t *testing.T, config LoginProtectionConfig) (*gin.Engin
 	router.POST("/login", func(c *gin.Context) {
 		Login(c, service, cookie, protection)
 	})
+	router.POST("/login/2fa", func(c *gin.Context) {
+		LoginTwoFactor(c, service, cookie, protection)
+	})
 	return router, &logs
 }
 
@@ -115,3 +120,225 @@ func TestLoginRateLimitLogsFailureAndReturnsRetryAfter(t *testing.T) {
 		t.Fatalf("password appeared in login audit log: %s", logged)
 	}
 }
+
+func TestLogoutRevokesSession(t *testing.T) {
+	router, _ := setupLoginRouteTest(t, LoginProtectionConfig{
+		Window:         time.Minute,
+		MaxPerIP:       10,
+		MaxPerUsername: 10,
+	})
+	var session models.Session
+	if err := database.GetDB().First(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	service := services.NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	router.POST("/logout", func(c *gin.Context) {
+		Logout(c, service, CookieConfig{Name: "auth_token"}, session.TokenID)
+	})
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/logout", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", recorder.Code, recorder.Body.String())
+	}
+	var count int64
+	if err := database.GetDB().Model(&models.Session{}).Where("token_id = ?", session.TokenID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatal("logout left the session in place")
+	}
+}
+
+func TestLoginTwoFactorStepCountsTowardUsernameLimit(t *testing.T) {
+	router, logs := setupLoginRouteTest(t, LoginProtectionConfig{
+		Window:         time.Minute,
+		MaxPerIP:       10,
+		MaxPerUsername: 3,
+	})
+	if err := database.GetDB().Model(&models.User{}).Where("username = ?", "alice").
+		Updates(map[string]any{"totp_secret": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "totp_enabled": true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := performLogin(router, "alice", "correct-password")
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"two_factor_required":true`) {
+		t.Fatalf("status %d body %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Set-Cookie") != "" {
+		t.Fatal("password step set the auth cookie before the second factor")
+	}
+	var pending struct {
+		PendingToken string `json:"pending_token"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &pending); err != nil {
+		t.Fatal(err)
+	}
+
+	submit := func() *httptest.ResponseRecorder {
+		body := `{"pending_token":"` + pending.PendingToken + `","code":"abcdef"}`
+		request := httptest.NewRequest(http.MethodPost, "/login/2fa", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	// The password step used one of the three attempts.
+	for i := 0; i < 2; i++ {
+		if recorder := submit(); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status %d body %s", i+1, recorder.Code, recorder.Body.String())
+		}
+	}
+	if recorder := submit(); recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("third attempt: status %d want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	if !strings.Contains(logs.String(), `"outcome":"invalid_two_factor_code"`) {
+		t.Fatalf("missing 2fa audit event: %s", logs.String())
+	}
+}
+
+func TestLoginHistoryListsAttemptsAndMeSurfacesNewDevice(t *testing.T) {
+	router, _ := setupLoginRouteTest(t, LoginProtectionConfig{
+		Window:         time.Minute,
+		MaxPerIP:       10,
+		MaxPerUsername: 10,
+	})
+	service := services.NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	authenticated := func(c *gin.Context) {
+		c.Set("username", "alice")
+	}
+	router.GET("/me", authenticated, func(c *gin.Context) {
+		GetCurrentUser(c, service)
+	})
+	router.GET("/login-history", authenticated, func(c *gin.Context) {
+		ListLoginHistory(c, service)
+	})
+	router.POST("/login-history/acknowledge", authenticated, func(c *gin.Context) {
+		AcknowledgeNewDeviceLogins(c, service)
+	})
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+	loginFrom := func(userAgent string) {
+		body := `{"username":"alice","password":"correct-password"}`
+		request := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("User-Agent", userAgent)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("login status %d body %s", recorder.Code, recorder.Body.String())
+		}
+	}
+
+	loginFrom("firefox")
+	performLogin(router, "alice", "wrong-password")
+	loginFrom("new-phone")
+
+	var history struct {
+		Attempts []models.LoginAttempt `json:"attempts"`
+	}
+	recorder := get("/login-history?limit=2")
+	if err := json.Unmarshal(recorder.Body.Bytes(), &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Attempts) != 2 || history.Attempts[0].UserAgent != "new-phone" || history.Attempts[1].Outcome != "invalid_credentials" {
+		t.Fatalf("history: %s", recorder.Body.String())
+	}
+	if recorder := get("/login-history?limit=abc"); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("invalid limit status %d", recorder.Code)
+	}
+
+	var me struct {
+		NewDeviceLogins []models.LoginAttempt `json:"new_device_logins"`
+	}
+	if err := json.Unmarshal(get("/me").Body.Bytes(), &me); err != nil {
+		t.Fatal(err)
+	}
+	if len(me.NewDeviceLogins) != 1 || !me.NewDeviceLogins[0].NewUserAgent || me.NewDeviceLogins[0].NewIP {
+		t.Fatalf("new device logins: %+v", me.NewDeviceLogins)
+	}
+
+	acknowledge := httptest.NewRecorder()
+	router.ServeHTTP(acknowledge, httptest.NewRequest(http.MethodPost, "/login-history/acknowledge", nil))
+	if acknowledge.Code != http.StatusOK {
+		t.Fatalf("acknowledge status %d", acknowledge.Code)
+	}
+	if body := get("/me").Body.String(); !strings.Contains(body, `"new_device_logins":[]`) {
+		t.Fatalf("acknowledged login still surfaced: %s", body)
+	}
+}
+
+func TestRegisterWithInviteLimitsCodeGuessing(t *testing.T) {
+	router, _ := setupLoginRouteTest(t, LoginProtectionConfig{
+		Window:         time.Minute,
+		MaxPerIP:       10,
+		MaxPerUsername: 10,
+	})
+	service := services.NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	var logs bytes.Buffer
+	protection := NewLoginProtection(LoginProtectionConfig{
+		Window:          time.Minute,
+		MaxInvitesPerIP: 3,
+		MaxInvites:      6,
+	}, slog.New(slog.NewJSONHandler(&logs, nil)))
+	router.POST("/register", func(c *gin.Context) {
+		RegisterWithInvite(c, service, CookieConfig{Name: "auth_token"}, protection)
+	})
+	register := func(ip, username, code string) *httptest.ResponseRecorder {
+		body := `{"username":"` + username + `","password":"secret","email":"` + username + `@example.com","invite_code":"` + code + `"}`
+		request := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.RemoteAddr = ip + ":1234"
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	var owner models.User
+	if err := database.GetDB().Where("username = ?", "alice").First(&owner).Error; err != nil {
+		t.Fatal(err)
+	}
+	created, err := service.CreateInvite(owner.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder := register("192.0.2.1", "newcomer", created.Code); recorder.Code != http.StatusCreated {
+		t.Fatalf("valid code: status %d body %s", recorder.Code, recorder.Body.String())
+	}
+
+	if recorder := register("192.0.2.1", "alice", "inv_guess"); recorder.Code != http.StatusForbidden {
+		t.Fatalf("wrong code for taken username: status %d body %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := register("192.0.2.1", "guest", "inv_guess"); recorder.Code != http.StatusForbidden {
+		t.Fatalf("wrong code: status %d", recorder.Code)
+	}
+	// A fresh username does not buy the same address another guess.
+	if recorder := register("192.0.2.1", "guest2", "inv_guess"); recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("fourth attempt from one IP: status %d want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	// Nor do fresh addresses, once the budget shared by all clients is spent.
+	if recorder := register("192.0.2.2", "guest3", "inv_guess"); recorder.Code != http.StatusForbidden {
+		t.Fatalf("wrong code from a second IP: status %d", recorder.Code)
+	}
+	if recorder := register("192.0.2.3", "guest4", "inv_guess"); recorder.Code != http.StatusForbidden {
+		t.Fatalf("wrong code from a third IP: status %d", recorder.Code)
+	}
+	if recorder := register("192.0.2.4", "guest5", "inv_guess"); recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("attempt past the shared budget: status %d want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	if !strings.Contains(logs.String(), `"outcome":"invalid_invite_code"`) {
+		t.Fatalf("missing invite audit event: %s", logs.String())
+	}
+	// Failed codes do not count toward the existing account's login limit.
+	if recorder := performLogin(router, "alice", "correct-password"); recorder.Code != http.StatusOK {
+		t.Fatalf("login after invite guesses: status %d", recorder.Code)
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	"be-simpletracker/internal/core/auth/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type inviteResponse struct {
+	ID        uint       `json:"id"`
+	Prefix    string     `json:"prefix"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+func newInviteResponse(invite models.Invite) inviteResponse {
+	return inviteResponse{
+		ID:        invite.ID,
+		Prefix:    invite.Prefix,
+		CreatedAt: invite.CreatedAt,
+		ExpiresAt: invite.ExpiresAt,
+		UsedAt:    invite.UsedAt,
+	}
+}
+
+type createInviteRequest struct {
+	ExpiresInHours int `json:"expires_in_hours"`
+}
+
+func ListInvites(c *gin.Context, service *services.AuthService) {
+	setAuthResponseHeaders(c)
+	invites, err := service.Invites(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	response := make([]inviteResponse, 0, len(invites))
+	for _, invite := range invites {
+		response = append(response, newInviteResponse(invite))
+	}
+	c.JSON(http.StatusOK, gin.H{"invites": response})
+}
+
+// CreateInvite returns the plaintext code. It is not stored and cannot be shown again.
+func CreateInvite(c *gin.Context, service *services.AuthService) {
+	setAuthResponseHeaders(c)
+	var req createInviteRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	created, err := service.CreateInvite(c.GetUint("user_id"), time.Duration(req.ExpiresInHours)*time.Hour)
+	if err != nil {
+		if errors.Is(err, services.ErrTokenGeneration) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate invite"})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidInviteInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"code":   created.Code,
+		"invite": newInviteResponse(created.Invite),
+	})
+}
+
+func RevokeInvite(c *gin.Context, service *services.AuthService) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite id"})
+		return
+	}
+	if err := service.RevokeInvite(c.GetUint("user_id"), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Invite revoked"})
+}
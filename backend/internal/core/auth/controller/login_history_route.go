@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"net/http"
+
+	"be-simpletracker/internal/core/auth/services"
+	"be-simpletracker/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListLoginHistory(c *gin.Context, service *services.AuthService) {
+	setAuthResponseHeaders(c)
+	limit, err := utils.ParseQueryInt(c, utils.QueryIntVar{
+		Key:        "limit",
+		Default:    services.DefaultLoginHistoryLimit,
+		ErrInvalid: "limit must be an integer",
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be positive"})
+		return
+	}
+	attempts, err := service.LoginHistory(c.GetString("username"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"attempts": attempts})
+}
+
+// AcknowledgeNewDeviceLogins clears the new-device notices shown on /auth/me.
+func AcknowledgeNewDeviceLogins(c *gin.Context, service *services.AuthService) {
+	acknowledged, err := service.AcknowledgeNewDeviceLogins(c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"acknowledged": acknowledged})
+}
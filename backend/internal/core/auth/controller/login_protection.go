@@ -1,16 +1,17 @@
 package controller
 
 import (
-	"be-simpletracker/internal/env"
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/core/auth/models"
+	"be-simpletracker/internal/metrics"
 	"context"
+	"errors"
 	"log/slog"
 	"strings"
 	"sync"
 	"time"
 )
 
-const maxLoginRateEntries = 10000
-
 type LoginProtectionConfig struct {
 	Window                  time.Duration
 	MaxPerIP                int
@@ -18,16 +19,25 @@ type LoginProtectionConfig struct {
 	SprayWindow             time.Duration
 	MaxDistinctUsernames    int
 	CredentialSprayBlockFor time.Duration
+	MaxInvitesPerIP         int
+	MaxInvites              int
+}
+
+// LoginAttemptRecorder keeps an audit trail of login attempts alongside the log.
+type LoginAttemptRecorder interface {
+	RecordLoginAttempt(ctx context.Context, attempt *models.LoginAttempt) error
 }
 
-func LoginProtectionConfigFromEnv() LoginProtectionConfig {
+func LoginProtectionConfigFrom(cfg config.LoginProtection) LoginProtectionConfig {
 	return LoginProtectionConfig{
-		Window:                  time.Duration(env.IntOr("LOGIN_RATE_LIMIT_WINDOW_SEC", 15*60)) * time.Second,
-		MaxPerIP:                env.IntOr("LOGIN_RATE_LIMIT_MAX_IP", 30),
-		MaxPerUsername:          env.IntOr("LOGIN_RATE_LIMIT_MAX_USERNAME", 5),
-		SprayWindow:             time.Duration(env.IntOr("LOGIN_SPRAY_WINDOW_SEC", 10*60)) * time.Second,
-		MaxDistinctUsernames:    env.IntOr("LOGIN_SPRAY_MAX_USERNAMES", 5),
-		CredentialSprayBlockFor: time.Duration(env.IntOr("LOGIN_SPRAY_BLOCK_SEC", 24*60*60)) * time.Second,
+		Window:                  time.Duration(cfg.WindowSec) * time.Second,
+		MaxPerIP:                cfg.MaxPerIP,
+		MaxPerUsername:          cfg.MaxPerUsername,
+		SprayWindow:             time.Duration(cfg.SprayWindowSec) * time.Second,
+		MaxDistinctUsernames:    cfg.SprayMaxUsernames,
+		CredentialSprayBlockFor: time.Duration(cfg.SprayBlockSec) * time.Second,
+		MaxInvitesPerIP:         cfg.InviteMaxPerIP,
+		MaxInvites:              cfg.InviteMaxTotal,
 	}
 }
 
@@ -38,24 +48,11 @@ type LoginDecision struct {
 	Reason       string
 }
 
-type loginRateWindow struct {
-	Count           int
-	ResetAt         time.Time
-	RejectionLogged bool
-}
-
-type credentialSprayWindow struct {
-	Usernames       map[string]struct{}
-	ResetAt         time.Time
-	BlockedUntil    time.Time
-	RejectionLogged bool
-}
-
 type LoginProtection struct {
 	mu                  sync.Mutex
 	config              LoginProtectionConfig
-	entries             map[string]*loginRateWindow
-	sprays              map[string]*credentialSprayWindow
+	store               LoginProtectionStore
+	attempts            LoginAttemptRecorder
 	logger              *slog.Logger
 	now                 func() time.Time
 	lastCleanup         time.Time
@@ -81,100 +78,176 @@ func NewLoginProtection(config LoginProtectionConfig, logger *slog.Logger) *Logi
 	if config.CredentialSprayBlockFor <= 0 {
 		config.CredentialSprayBlockFor = 24 * time.Hour
 	}
+	if config.MaxInvitesPerIP <= 0 {
+		config.MaxInvitesPerIP = 5
+	}
+	if config.MaxInvites <= 0 {
+		config.MaxInvites = 50
+	}
 	if logger == nil {
 		logger = slog.Default()
 	}
 	return &LoginProtection{
-		config:  config,
-		entries: make(map[string]*loginRateWindow),
-		sprays:  make(map[string]*credentialSprayWindow),
-		logger:  logger,
-		now:     time.Now,
+		config: config,
+		store:  NewMemoryLoginStore(),
+		logger: logger,
+		now:    time.Now,
 	}
 }
 
+// WithStore replaces the default in-memory store, e.g. with one shared by all replicas.
+func (p *LoginProtection) WithStore(store LoginProtectionStore) *LoginProtection {
+	p.store = store
+	return p
+}
+
+// WithAttemptRecorder persists every LogAttempt outcome in addition to logging it.
+func (p *LoginProtection) WithAttemptRecorder(recorder LoginAttemptRecorder) *LoginProtection {
+	p.attempts = recorder
+	return p
+}
+
 func (p *LoginProtection) Allow(clientIP, username string) LoginDecision {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	decision := p.allow(clientIP, username)
+	metrics.LoginProtectionDecision("attempt", decision.Reason)
+	return decision
+}
 
+func (p *LoginProtection) allow(clientIP, username string) LoginDecision {
+	ctx := context.Background()
 	now := p.now()
-	p.cleanupExpired(now)
+	p.cleanupExpired(ctx, now)
 	ipKey := "ip:" + normalizeLoginKey(clientIP)
 	usernameKey := "username:" + normalizeLoginKey(username)
 
-	if spray, ok := p.sprays[ipKey]; ok && now.Before(spray.BlockedUntil) {
-		logRejection := !spray.RejectionLogged
-		spray.RejectionLogged = true
+	blockedUntil, err := p.store.BlockedUntil(ctx, ipKey, now)
+	if err != nil {
+		return p.storeFailure(ctx, err)
+	}
+	if now.Before(blockedUntil) {
 		return LoginDecision{
-			Allowed:      false,
-			RetryAfter:   spray.BlockedUntil.Sub(now),
-			LogRejection: logRejection,
-			Reason:       "credential_spray",
+			Allowed:    false,
+			RetryAfter: blockedUntil.Sub(now),
+			Reason:     "credential_spray",
 		}
 	}
 
-	ipBlocked, ipRetry, ipLog := p.isBlocked(ipKey, p.config.MaxPerIP, now)
-	usernameBlocked, usernameRetry, usernameLog := p.isBlocked(usernameKey, p.config.MaxPerUsername, now)
-	if ipBlocked || usernameBlocked {
-		return LoginDecision{
-			Allowed:      false,
-			RetryAfter:   maxDuration(ipRetry, usernameRetry),
-			LogRejection: ipLog || usernameLog,
-			Reason:       "rate_limit",
-		}
+	ipCount, ipReset, err := p.store.Increment(ctx, ipKey, p.config.Window, now)
+	if err != nil {
+		return p.storeFailure(ctx, err)
+	}
+	usernameCount, usernameReset, err := p.store.Increment(ctx, usernameKey, p.config.Window, now)
+	if err != nil {
+		return p.storeFailure(ctx, err)
 	}
 
-	newEntries := 0
-	if _, ok := p.entries[ipKey]; !ok {
-		newEntries++
+	ipBlocked := ipCount > p.config.MaxPerIP
+	usernameBlocked := usernameCount > p.config.MaxPerUsername
+	if !ipBlocked && !usernameBlocked {
+		return LoginDecision{Allowed: true}
 	}
-	if _, ok := p.entries[usernameKey]; !ok {
-		newEntries++
+	var retryAfter time.Duration
+	if ipBlocked {
+		retryAfter = ipReset.Sub(now)
 	}
-	if len(p.entries)+len(p.sprays)+newEntries > maxLoginRateEntries {
-		logRejection := now.Sub(p.lastCapacityWarning) >= time.Minute
-		if logRejection {
-			p.lastCapacityWarning = now
-		}
+	if usernameBlocked {
+		retryAfter = maxDuration(retryAfter, usernameReset.Sub(now))
+	}
+	return LoginDecision{
+		Allowed:    false,
+		RetryAfter: retryAfter,
+		// Only the attempt that first crosses a limit is logged.
+		LogRejection: ipCount == p.config.MaxPerIP+1 || usernameCount == p.config.MaxPerUsername+1,
+		Reason:       "rate_limit",
+	}
+}
+
+// AllowInvite limits invite code guesses. Every attempt counts against the client IP and
+// against one budget shared by all clients, so neither fresh usernames nor many addresses buy
+// more guesses. An IP blocked for credential spraying stays blocked here too.
+func (p *LoginProtection) AllowInvite(clientIP string) LoginDecision {
+	decision := p.allowInvite(clientIP)
+	metrics.LoginProtectionDecision("invite", decision.Reason)
+	return decision
+}
+
+func (p *LoginProtection) allowInvite(clientIP string) LoginDecision {
+	ctx := context.Background()
+	now := p.now()
+	p.cleanupExpired(ctx, now)
+	ip := normalizeLoginKey(clientIP)
+
+	blockedUntil, err := p.store.BlockedUntil(ctx, "ip:"+ip, now)
+	if err != nil {
+		return p.storeFailure(ctx, err)
+	}
+	if now.Before(blockedUntil) {
 		return LoginDecision{
-			Allowed:      false,
-			RetryAfter:   p.config.Window,
-			LogRejection: logRejection,
-			Reason:       "capacity",
+			Allowed:    false,
+			RetryAfter: blockedUntil.Sub(now),
+			Reason:     "credential_spray",
 		}
 	}
 
-	p.increment(ipKey, now)
-	p.increment(usernameKey, now)
-	return LoginDecision{Allowed: true}
+	ipCount, ipReset, err := p.store.Increment(ctx, "invite-ip:"+ip, p.config.Window, now)
+	if err != nil {
+		return p.storeFailure(ctx, err)
+	}
+	totalCount, totalReset, err := p.store.Increment(ctx, "invite-total", p.config.Window, now)
+	if err != nil {
+		return p.storeFailure(ctx, err)
+	}
+
+	ipBlocked := ipCount > p.config.MaxInvitesPerIP
+	totalBlocked := totalCount > p.config.MaxInvites
+	if !ipBlocked && !totalBlocked {
+		return LoginDecision{Allowed: true}
+	}
+	var retryAfter time.Duration
+	if ipBlocked {
+		retryAfter = ipReset.Sub(now)
+	}
+	if totalBlocked {
+		retryAfter = maxDuration(retryAfter, totalReset.Sub(now))
+	}
+	return LoginDecision{
+		Allowed:      false,
+		RetryAfter:   retryAfter,
+		LogRejection: ipCount == p.config.MaxInvitesPerIP+1 || totalCount == p.config.MaxInvites+1,
+		Reason:       "rate_limit",
+	}
 }
 
 func (p *LoginProtection) RecordFailure(clientIP, username string) LoginDecision {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	decision := p.recordFailure(clientIP, username)
+	metrics.LoginProtectionDecision("failure", decision.Reason)
+	return decision
+}
 
+func (p *LoginProtection) recordFailure(clientIP, username string) LoginDecision {
+	ctx := context.Background()
 	now := p.now()
 	ipKey := "ip:" + normalizeLoginKey(clientIP)
-	usernameKey := normalizeLoginKey(username)
-	spray, ok := p.sprays[ipKey]
-	if !ok || (!now.Before(spray.ResetAt) && !now.Before(spray.BlockedUntil)) {
-		spray = &credentialSprayWindow{
-			Usernames: make(map[string]struct{}),
-			ResetAt:   now.Add(p.config.SprayWindow),
-		}
-		p.sprays[ipKey] = spray
+
+	blockedUntil, err := p.store.BlockedUntil(ctx, ipKey, now)
+	if err != nil {
+		return p.storeFailure(ctx, err)
 	}
-	if now.Before(spray.BlockedUntil) {
+	if now.Before(blockedUntil) {
 		return LoginDecision{
 			Allowed:    false,
-			RetryAfter: spray.BlockedUntil.Sub(now),
+			RetryAfter: blockedUntil.Sub(now),
 			Reason:     "credential_spray",
 		}
 	}
-	spray.Usernames[usernameKey] = struct{}{}
-	if len(spray.Usernames) >= p.config.MaxDistinctUsernames {
-		spray.BlockedUntil = now.Add(p.config.CredentialSprayBlockFor)
-		spray.RejectionLogged = true
+	distinct, err := p.store.AddSprayUsername(ctx, ipKey, normalizeLoginKey(username), p.config.SprayWindow, now)
+	if err != nil {
+		return p.storeFailure(ctx, err)
+	}
+	if distinct >= p.config.MaxDistinctUsernames {
+		if err := p.store.BlockIP(ctx, ipKey, now.Add(p.config.CredentialSprayBlockFor)); err != nil {
+			return p.storeFailure(ctx, err)
+		}
 		return LoginDecision{
 			Allowed:      false,
 			RetryAfter:   p.config.CredentialSprayBlockFor,
@@ -186,18 +259,20 @@ func (p *LoginProtection) RecordFailure(clientIP, username string) LoginDecision
 }
 
 func (p *LoginProtection) RecordSuccess(clientIP, username string) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	ctx := context.Background()
 	usernameKey := normalizeLoginKey(username)
-	delete(p.entries, "username:"+usernameKey)
-	if spray, ok := p.sprays["ip:"+normalizeLoginKey(clientIP)]; ok {
-		delete(spray.Usernames, usernameKey)
+	if err := p.store.Reset(ctx, "username:"+usernameKey); err != nil {
+		p.logger.ErrorContext(ctx, "login protection store failed", slog.String("error", err.Error()))
+	}
+	if err := p.store.RemoveSprayUsername(ctx, "ip:"+normalizeLoginKey(clientIP), usernameKey); err != nil {
+		p.logger.ErrorContext(ctx, "login protection store failed", slog.String("error", err.Error()))
 	}
 }
 
-func (p *LoginProtection) LogAttempt(ctx context.Context, outcome, clientIP, username string) {
+func (p *LoginProtection) LogAttempt(ctx context.Context, outcome, clientIP, username, userAgent string) {
+	metrics.LoginAttempt(outcome)
 	level := slog.LevelInfo
-	if outcome == "invalid_credentials" || outcome == "rate_limited" || outcome == "credential_spray_blocked" {
+	if outcome == "invalid_credentials" || outcome == "invalid_two_factor_code" || outcome == "invalid_invite_code" || outcome == "account_disabled" || outcome == "rate_limited" || outcome == "credential_spray_blocked" {
 		level = slog.LevelWarn
 	}
 	if outcome == "server_error" {
@@ -211,47 +286,62 @@ func (p *LoginProtection) LogAttempt(ctx context.Context, outcome, clientIP, use
 		slog.String("outcome", outcome),
 		slog.String("username", strings.TrimSpace(username)),
 		slog.String("client_ip", strings.TrimSpace(clientIP)),
+		slog.String("user_agent", userAgent),
 	)
-}
-
-func (p *LoginProtection) cleanupExpired(now time.Time) {
-	if len(p.entries)+len(p.sprays) < maxLoginRateEntries && !p.lastCleanup.IsZero() && now.Sub(p.lastCleanup) < time.Minute {
-		return
-	}
-	for key, entry := range p.entries {
-		if !now.Before(entry.ResetAt) {
-			delete(p.entries, key)
+	if p.attempts != nil {
+		attempt := &models.LoginAttempt{
+			CreatedAt: p.now(),
+			Outcome:   outcome,
+			Username:  strings.TrimSpace(username),
+			ClientIP:  strings.TrimSpace(clientIP),
+			UserAgent: userAgent,
 		}
-	}
-	for key, spray := range p.sprays {
-		blockExpired := spray.BlockedUntil.IsZero() || !now.Before(spray.BlockedUntil)
-		if blockExpired && !now.Before(spray.ResetAt) {
-			delete(p.sprays, key)
+		if err := p.attempts.RecordLoginAttempt(ctx, attempt); err != nil {
+			p.logger.ErrorContext(ctx, "record login attempt failed", slog.String("error", err.Error()))
 		}
 	}
-	p.lastCleanup = now
 }
 
-func (p *LoginProtection) isBlocked(key string, limit int, now time.Time) (bool, time.Duration, bool) {
-	entry, ok := p.entries[key]
-	if !ok || !now.Before(entry.ResetAt) || entry.Count < limit {
-		return false, 0, false
-	}
-	logRejection := !entry.RejectionLogged
-	entry.RejectionLogged = true
-	return true, entry.ResetAt.Sub(now), logRejection
+// storeFailure refuses the attempt: a login that cannot be counted is not allowed through.
+// A full in-memory store is reported at most once a minute.
+func (p *LoginProtection) storeFailure(ctx context.Context, err error) LoginDecision {
+	if errors.Is(err, ErrLoginStoreFull) {
+		p.mu.Lock()
+		now := p.now()
+		logRejection := now.Sub(p.lastCapacityWarning) >= time.Minute
+		if logRejection {
+			p.lastCapacityWarning = now
+		}
+		p.mu.Unlock()
+		return LoginDecision{
+			Allowed:      false,
+			RetryAfter:   p.config.Window,
+			LogRejection: logRejection,
+			Reason:       "capacity",
+		}
+	}
+	p.logger.ErrorContext(ctx, "login protection store failed", slog.String("error", err.Error()))
+	return LoginDecision{
+		Allowed:      false,
+		RetryAfter:   time.Minute,
+		LogRejection: true,
+		Reason:       "store_error",
+	}
 }
 
-func (p *LoginProtection) increment(key string, now time.Time) {
-	entry, ok := p.entries[key]
-	if !ok || !now.Before(entry.ResetAt) {
-		p.entries[key] = &loginRateWindow{
-			Count:   1,
-			ResetAt: now.Add(p.config.Window),
-		}
+func (p *LoginProtection) cleanupExpired(ctx context.Context, now time.Time) {
+	p.mu.Lock()
+	due := p.lastCleanup.IsZero() || now.Sub(p.lastCleanup) >= time.Minute
+	if due {
+		p.lastCleanup = now
+	}
+	p.mu.Unlock()
+	if !due {
 		return
 	}
-	entry.Count++
+	if err := p.store.Prune(ctx, now); err != nil {
+		p.logger.ErrorContext(ctx, "login protection prune failed", slog.String("error", err.Error()))
+	}
 }
 
 func normalizeLoginKey(value string) string {
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const maxLoginRateEntries = 10000
+
+// ErrLoginStoreFull is returned by a store that refuses to track another key.
+var ErrLoginStoreFull = errors.New("login protection store is full")
+
+// LoginProtectionStore holds the windows behind LoginProtection. Each method must be atomic
+// on its own, so replicas sharing a store see one consistent count per key.
+type LoginProtectionStore interface {
+	// Increment counts an attempt against key, starting a new window of the given length when
+	// the previous one has ended, and returns the count and when the window resets.
+	Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int, time.Time, error)
+	Reset(ctx context.Context, key string) error
+	// AddSprayUsername notes a failed username from an IP and returns how many distinct
+	// usernames that IP has failed with inside the window.
+	AddSprayUsername(ctx context.Context, ipKey, username string, window time.Duration, now time.Time) (int, error)
+	RemoveSprayUsername(ctx context.Context, ipKey, username string) error
+	BlockIP(ctx context.Context, ipKey string, until time.Time) error
+	// BlockedUntil returns the end of the IP's block, or the zero time if it is not blocked.
+	BlockedUntil(ctx context.Context, ipKey string, now time.Time) (time.Time, error)
+	// Prune drops state that has expired by now.
+	Prune(ctx context.Context, now time.Time) error
+}
+
+type loginRateWindow struct {
+	Count   int
+	ResetAt time.Time
+}
+
+// MemoryLoginStore is the default single-process store. It caps the number of tracked keys at
+// maxLoginRateEntries so a flood of random usernames cannot exhaust memory.
+type MemoryLoginStore struct {
+	mu      sync.Mutex
+	windows map[string]*loginRateWindow
+	sprays  map[string]map[string]time.Time
+	blocks  map[string]time.Time
+}
+
+func NewMemoryLoginStore() *MemoryLoginStore {
+	return &MemoryLoginStore{
+		windows: make(map[string]*loginRateWindow),
+		sprays:  make(map[string]map[string]time.Time),
+		blocks:  make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryLoginStore) size() int {
+	return len(s.windows) + len(s.sprays) + len(s.blocks)
+}
+
+func (s *MemoryLoginStore) Increment(_ context.Context, key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.windows[key]
+	if !ok && s.size() >= maxLoginRateEntries {
+		s.pruneLocked(now)
+		if s.size() >= maxLoginRateEntries {
+			return 0, time.Time{}, ErrLoginStoreFull
+		}
+	}
+	if !ok || !now.Before(entry.ResetAt) {
+		entry = &loginRateWindow{ResetAt: now.Add(window)}
+		s.windows[key] = entry
+	}
+	entry.Count++
+	return entry.Count, entry.ResetAt, nil
+}
+
+func (s *MemoryLoginStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.windows, key)
+	return nil
+}
+
+func (s *MemoryLoginStore) AddSprayUsername(_ context.Context, ipKey, username string, window time.Duration, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usernames, ok := s.sprays[ipKey]
+	if !ok {
+		if s.size() >= maxLoginRateEntries {
+			s.pruneLocked(now)
+			if s.size() >= maxLoginRateEntries {
+				return 0, ErrLoginStoreFull
+			}
+		}
+		usernames = make(map[string]time.Time)
+		s.sprays[ipKey] = usernames
+	}
+	usernames[username] = now.Add(window)
+	distinct := 0
+	for _, expiresAt := range usernames {
+		if now.Before(expiresAt) {
+			distinct++
+		}
+	}
+	return distinct, nil
+}
+
+func (s *MemoryLoginStore) RemoveSprayUsername(_ context.Context, ipKey, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sprays[ipKey], username)
+	return nil
+}
+
+func (s *MemoryLoginStore) BlockIP(_ context.Context, ipKey string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[ipKey] = until
+	return nil
+}
+
+func (s *MemoryLoginStore) BlockedUntil(_ context.Context, ipKey string, now time.Time) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.blocks[ipKey]
+	if !ok || !now.Before(until) {
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+func (s *MemoryLoginStore) Prune(_ context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+	return nil
+}
+
+func (s *MemoryLoginStore) pruneLocked(now time.Time) {
+	for key, entry := range s.windows {
+		if !now.Before(entry.ResetAt) {
+			delete(s.windows, key)
+		}
+	}
+	for key, usernames := range s.sprays {
+		for username, expiresAt := range usernames {
+			if !now.Before(expiresAt) {
+				delete(usernames, username)
+			}
+		}
+		if len(usernames) == 0 {
+			delete(s.sprays, key)
+		}
+	}
+	for key, until := range s.blocks {
+		if !now.Before(until) {
+			delete(s.blocks, key)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package controller
 import (
 	"bytes"
 	"context"
+	"errors"
 	"log/slog"
 	"strings"
 	"testing"
 	"time"
+
+	"be-simpletracker/internal/core/auth/models"
 )
 
 func TestLoginProtectionLimitsUsernameAndSuppressesRepeatedRejectionLogs(t *testing.T) {
@@ -112,7 +115,7 @@ func TestLoginAttemptLogContainsAuditFieldsOnly(t *testing.T) {
 	var output bytes.Buffer
 	logger := slog.New(slog.NewJSONHandler(&output, nil))
 	protection := NewLoginProtection(LoginProtectionConfig{}, logger)
-	protection.LogAttempt(context.Background(), "invalid_credentials", "192.0.2.1", "alice")
+	protection.LogAttempt(context.Background(), "invalid_credentials", "192.0.2.1", "alice", "")
 	logged := output.String()
 	for _, value := range []string{"auth.login", "invalid_credentials", "192.0.2.1", "alice"} {
 		if !strings.Contains(logged, value) {
@@ -125,3 +128,42 @@ func TestLoginAttemptLogContainsAuditFieldsOnly(t *testing.T) {
 		}
 	}
 }
+
+type fakeAttemptRecorder struct {
+	attempts []models.LoginAttempt
+}
+
+func (r *fakeAttemptRecorder) RecordLoginAttempt(_ context.Context, attempt *models.LoginAttempt) error {
+	r.attempts = append(r.attempts, *attempt)
+	return nil
+}
+
+func TestLoginAttemptIsRecordedWhenRecorderConfigured(t *testing.T) {
+	recorder := &fakeAttemptRecorder{}
+	protection := NewLoginProtection(LoginProtectionConfig{}, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))).
+		WithAttemptRecorder(recorder)
+	protection.LogAttempt(context.Background(), "success", " 192.0.2.1 ", "alice", "curl/8.0")
+	if len(recorder.attempts) != 1 {
+		t.Fatalf("recorded: %+v", recorder.attempts)
+	}
+	if got := recorder.attempts[0]; got.Outcome != "success" || got.ClientIP != "192.0.2.1" || got.Username != "alice" || got.UserAgent != "curl/8.0" {
+		t.Fatalf("recorded: %+v", recorder.attempts)
+	}
+}
+
+type failingLoginStore struct {
+	*MemoryLoginStore
+}
+
+func (failingLoginStore) Increment(context.Context, string, time.Duration, time.Time) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("database unavailable")
+}
+
+func TestLoginProtectionFailsClosedWhenStoreErrors(t *testing.T) {
+	protection := NewLoginProtection(LoginProtectionConfig{}, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))).
+		WithStore(failingLoginStore{NewMemoryLoginStore()})
+	decision := protection.Allow("192.0.2.1", "alice")
+	if decision.Allowed || decision.Reason != "store_error" {
+		t.Fatalf("unexpected decision: %#v", decision)
+	}
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"be-simpletracker/internal/core/auth/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required,max=72"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// ChangePassword counts wrong current passwords against the login limits, so a stolen
+// cookie cannot be used to guess the password.
+func ChangePassword(c *gin.Context, service *services.AuthService, cookie CookieConfig, protection *LoginProtection) {
+	setAuthResponseHeaders(c)
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	username := c.GetString("username")
+	decision := protection.Allow(clientIP, username)
+	if !decision.Allowed {
+		respondLoginRateLimited(c, decision.RetryAfter)
+		return
+	}
+
+	result, err := service.ChangePassword(services.ChangePasswordInput{
+		UserID:          c.GetUint("user_id"),
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+		Client:          clientInfo(c),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			protection.RecordFailure(clientIP, username)
+			c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		case errors.Is(err, services.ErrPasswordHash):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		case errors.Is(err, services.ErrTokenGeneration):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	protection.RecordSuccess(clientIP, username)
+	setAuthCookie(c, cookie, result.Token)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed; other sessions were signed out"})
+}
+
+type forgotPasswordRequest struct {
+	Identifier string `json:"identifier" binding:"required,max=254"`
+}
+
+// RequestPasswordReset always answers 202 so the response does not reveal which accounts exist.
+// The emailed link points at resetURL.
+func RequestPasswordReset(c *gin.Context, service *services.AuthService, protection *LoginProtection, resetURL string) {
+	setAuthResponseHeaders(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 16*1024)
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	identifier := strings.TrimSpace(req.Identifier)
+
+	decision := protection.Allow(c.ClientIP(), identifier)
+	if !decision.Allowed {
+		respondLoginRateLimited(c, decision.RetryAfter)
+		return
+	}
+
+	err := service.RequestPasswordReset(c.Request.Context(), services.PasswordResetRequest{
+		Identifier: identifier,
+		ResetURL:   resetURL,
+	})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "password reset request failed", slog.String("error", err.Error()))
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists, a reset link has been sent"})
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required,max=128"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+func ResetPassword(c *gin.Context, service *services.AuthService) {
+	setAuthResponseHeaders(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 16*1024)
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.ResetPassword(req.Token, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidResetToken):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Reset link is invalid or has expired"})
+		case errors.Is(err, services.ErrPasswordHash):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset; sign in with your new password"})
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"be-simpletracker/internal/core/auth/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type preferencesRequest struct {
+	Timezone     string `json:"timezone"`
+	WeekStart    int    `json:"week_start"`
+	DayStartHour int    `json:"day_start_hour"`
+}
+
+func GetPreferences(c *gin.Context, service *services.AuthService) {
+	prefs, err := service.Preferences(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// UpdatePreferences replaces all preferences; fields left out go back to their defaults.
+func UpdatePreferences(c *gin.Context, service *services.AuthService) {
+	var req preferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prefs, err := service.UpdatePreferences(c.GetUint("user_id"), services.PreferencesInput{
+		Timezone:     req.Timezone,
+		WeekStart:    req.WeekStart,
+		DayStartHour: req.DayStartHour,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPreferences) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
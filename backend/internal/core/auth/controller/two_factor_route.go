@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"be-simpletracker/internal/core/auth/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type twoFactorLoginRequest struct {
+	PendingToken string `json:"pending_token" binding:"required,max=128"`
+	Code         string `json:"code" binding:"required,max=32"`
+}
+
+// LoginTwoFactor is the second login step. Attempts count toward the same per-username
+// limits as passwords, so a leaked password does not buy unlimited code guesses.
+func LoginTwoFactor(c *gin.Context, service *services.AuthService, cookie CookieConfig, protection *LoginProtection) {
+	setAuthResponseHeaders(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 16*1024)
+	var req twoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login request"})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	username, err := service.PendingLoginUsername(req.PendingToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPendingLogin) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Login session expired, sign in again"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	decision := protection.Allow(clientIP, username)
+	if !decision.Allowed {
+		if decision.LogRejection {
+			protection.LogAttempt(c.Request.Context(), "rate_limited", clientIP, username, c.Request.UserAgent())
+		}
+		respondLoginRateLimited(c, decision.RetryAfter)
+		return
+	}
+
+	result, err := service.CompleteTwoFactorLogin(services.TwoFactorLoginInput{
+		PendingToken: req.PendingToken,
+		Code:         req.Code,
+		Client:       clientInfo(c),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			protection.LogAttempt(c.Request.Context(), "invalid_two_factor_code", clientIP, username, c.Request.UserAgent())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		case errors.Is(err, services.ErrInvalidPendingLogin):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Login session expired, sign in again"})
+		case errors.Is(err, services.ErrAccountDisabled):
+			protection.LogAttempt(c.Request.Context(), "account_disabled", clientIP, username, c.Request.UserAgent())
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		case errors.Is(err, services.ErrTokenGeneration):
+			protection.LogAttempt(c.Request.Context(), "server_error", clientIP, username, c.Request.UserAgent())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		default:
+			protection.LogAttempt(c.Request.Context(), "server_error", clientIP, username, c.Request.UserAgent())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	setAuthCookie(c, cookie, result.Token)
+	protection.RecordSuccess(clientIP, username)
+	protection.LogAttempt(c.Request.Context(), "success", clientIP, username, c.Request.UserAgent())
+	c.JSON(http.StatusOK, AuthResponse{
+		User:        result.User,
+		Username:    result.User.Username,
+		Environment: service.Environment(),
+	})
+}
+
+func SetupTOTP(c *gin.Context, service *services.AuthService) {
+	setAuthResponseHeaders(c)
+	enrollment, err := service.BeginTOTPEnrollment(c.GetUint("user_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           enrollment.Secret,
+		"provisioning_uri": enrollment.ProvisioningURI,
+	})
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+func VerifyTOTP(c *gin.Context, service *services.AuthService) {
+	setAuthResponseHeaders(c)
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := service.ConfirmTOTPEnrollment(c.GetUint("user_id"), req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid two-factor code"})
+		case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTwoFactorNotPending):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+type disableTOTPRequest struct {
+	Password string `json:"password" binding:"required,max=72"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+func DisableTOTP(c *gin.Context, service *services.AuthService) {
+	setAuthResponseHeaders(c)
+	var req disableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.DisableTOTP(c.GetUint("user_id"), req.Password, req.Code); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		case errors.Is(err, services.ErrTwoFactorNotEnabled):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
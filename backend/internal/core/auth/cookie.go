@@ -3,8 +3,6 @@ package auth
 import (
 	"net/http"
 	"strings"
-
-	"be-simpletracker/internal/env"
 )
 
 const AuthTokenCookieName = "auth_token"
@@ -12,11 +10,14 @@ const AuthTokenCookieName = "auth_token"
 const defaultCookieMaxAgeSec = 365 * 24 * 3600
 
 func CookieMaxAgeSeconds() int {
-	return env.IntOr("AUTH_COOKIE_MAX_AGE_SEC", defaultCookieMaxAgeSec)
+	if settings.CookieMaxAgeSec <= 0 {
+		return defaultCookieMaxAgeSec
+	}
+	return settings.CookieMaxAgeSec
 }
 
 func CookieSameSite() http.SameSite {
-	switch strings.ToLower(strings.TrimSpace(env.OptionalString("AUTH_COOKIE_SAMESITE"))) {
+	switch strings.ToLower(strings.TrimSpace(settings.CookieSameSite)) {
 	case "none":
 		return http.SameSiteNoneMode
 	case "strict":
@@ -27,9 +28,5 @@ func CookieSameSite() http.SameSite {
 }
 
 func CookieSecure() bool {
-	if env.IsProduction() || CookieSameSite() == http.SameSiteNoneMode {
-		return true
-	}
-	s := env.OptionalString("AUTH_COOKIE_SECURE")
-	return s == "true" || s == "1"
+	return settings.production || CookieSameSite() == http.SameSiteNoneMode || settings.CookieSecure
 }
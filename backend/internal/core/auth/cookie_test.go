@@ -1,45 +1,45 @@
 package auth
 
-import "testing"
+import (
+	"testing"
 
-func TestCookieMaxAgeSeconds_defaultWithoutEnv(t *testing.T) {
-	t.Setenv("AUTH_COOKIE_MAX_AGE_SEC", "")
+	"be-simpletracker/internal/config"
+)
+
+func TestCookieMaxAgeSeconds_default(t *testing.T) {
+	configure(t, func(cfg *config.Config) {})
 	if got := CookieMaxAgeSeconds(); got != defaultCookieMaxAgeSec {
 		t.Fatalf("default max age: got %d want %d", got, defaultCookieMaxAgeSec)
 	}
 }
 
-func TestCookieMaxAgeSeconds_fromEnv(t *testing.T) {
-	t.Setenv("AUTH_COOKIE_MAX_AGE_SEC", "3600")
+func TestCookieMaxAgeSeconds_fromConfig(t *testing.T) {
+	configure(t, func(cfg *config.Config) { cfg.Auth.CookieMaxAgeSec = 3600 })
 	if got := CookieMaxAgeSeconds(); got != 3600 {
 		t.Fatalf("got %d want 3600", got)
 	}
 }
 
-func TestCookieMaxAgeSeconds_invalidEnvUsesDefault(t *testing.T) {
-	t.Setenv("AUTH_COOKIE_MAX_AGE_SEC", "not-a-number")
-	if got := CookieMaxAgeSeconds(); got != defaultCookieMaxAgeSec {
-		t.Fatalf("invalid env: got %d want default %d", got, defaultCookieMaxAgeSec)
-	}
-	t.Setenv("AUTH_COOKIE_MAX_AGE_SEC", "0")
+func TestCookieMaxAgeSeconds_unsetUsesDefault(t *testing.T) {
+	configure(t, func(cfg *config.Config) { cfg.Auth.CookieMaxAgeSec = 0 })
 	if got := CookieMaxAgeSeconds(); got != defaultCookieMaxAgeSec {
-		t.Fatalf("zero env: got %d want default %d", got, defaultCookieMaxAgeSec)
+		t.Fatalf("zero: got %d want default %d", got, defaultCookieMaxAgeSec)
 	}
 }
 
 func TestCookieSecureAlwaysTrueInProduction(t *testing.T) {
-	t.Setenv("APP_ENV", "production")
-	t.Setenv("AUTH_COOKIE_SECURE", "false")
-	t.Setenv("AUTH_COOKIE_SAMESITE", "lax")
+	configure(t, func(cfg *config.Config) {
+		cfg.Environment = "production"
+		cfg.Auth.CookieSecure = false
+		cfg.Auth.CookieSameSite = "lax"
+	})
 	if !CookieSecure() {
 		t.Fatal("production cookie must be secure")
 	}
 }
 
 func TestCookieSecureDefaultsFalseInDevelopment(t *testing.T) {
-	t.Setenv("APP_ENV", "development")
-	t.Setenv("AUTH_COOKIE_SECURE", "")
-	t.Setenv("AUTH_COOKIE_SAMESITE", "lax")
+	configure(t, func(cfg *config.Config) { cfg.Auth.CookieSameSite = "lax" })
 	if CookieSecure() {
 		t.Fatal("development cookie should allow HTTP")
 	}
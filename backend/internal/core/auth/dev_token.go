@@ -1,16 +1,19 @@
 package auth
 
 import (
-	"be-simpletracker/internal/env"
+	"cmp"
 	"crypto/subtle"
 	"log"
+	"net/http"
 	"time"
 
+	authrepo "be-simpletracker/internal/core/auth/repository"
+
 	"github.com/gin-gonic/gin"
 )
 
 func devTokenMatches(value string) bool {
-	secret := env.OptionalString("DEV_AUTH_TOKEN")
+	secret := settings.DevAuthToken
 	if secret == "" || !bypassAllowed() {
 		return false
 	}
@@ -18,13 +21,19 @@ func devTokenMatches(value string) bool {
 }
 
 func applyDevAuthUser(c *gin.Context) {
-	user := env.StringOr("DEV_AUTH_USER", "dev")
-	log.Printf("[auth] DEV_AUTH_TOKEN cookie bypass active (user=%q) — DO NOT USE IN PRODUCTION", user)
-	c.Set("username", user)
-	c.Set("timestamp", time.Now().Unix())
+	username := cmp.Or(settings.DevAuthUser, "dev")
+	log.Printf("[auth] DEV_AUTH_TOKEN cookie bypass active (user=%q) — DO NOT USE IN PRODUCTION", username)
+	user, err := authrepo.FindOrCreateDevUser(username)
+	if err != nil {
+		log.Printf("[auth] dev user %q: %v", username, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		c.Abort()
+		return
+	}
+	setAuthenticatedUser(c, user.ID, username, time.Now().Unix())
 	c.Next()
 }
 
 func bypassAllowed() bool {
-	return !env.IsProduction() && env.StringOr("ALLOW_BYPASS", "false") == "true"
+	return !settings.production && settings.AllowBypass
 }
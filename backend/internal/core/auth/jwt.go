@@ -1,9 +1,7 @@
 package auth
 
 import (
-	"be-simpletracker/internal/env"
 	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -12,28 +10,41 @@ import (
 )
 
 type Claims struct {
+	ID        string `json:"jti,omitempty"`
 	Username  string `json:"username"`
 	Timestamp int64  `json:"timestamp"`
 	Iat       int64  `json:"iat,omitempty"`
 	Exp       int64  `json:"exp,omitempty"`
 }
 
-func GenerateToken(username string) (string, error) {
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// GenerateToken signs a token for username. tokenID becomes the jti claim, which ties the
+// token to its row in the sessions table.
+func GenerateToken(username, tokenID string) (string, error) {
+	ring, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+
 	now := time.Now().Unix()
 	ttl := int64(CookieMaxAgeSeconds())
 	claims := Claims{
+		ID:        tokenID,
 		Username:  username,
 		Timestamp: now,
 		Iat:       now,
 		Exp:       now + ttl,
 	}
+	return signToken(ring.Current, claims)
+}
 
-	header := map[string]string{
-		"alg": "HS256",
-		"typ": "JWT",
-	}
-
-	headerJSON, err := json.Marshal(header)
+func signToken(key SigningKey, claims Claims) (string, error) {
+	headerJSON, err := json.Marshal(header{Alg: "HS256", Typ: "JWT", Kid: key.ID})
 	if err != nil {
 		return "", err
 	}
@@ -45,16 +56,13 @@ func GenerateToken(username string) (string, error) {
 
 	headerEncoded := base64.RawURLEncoding.EncodeToString(headerJSON)
 	claimsEncoded := base64.RawURLEncoding.EncodeToString(claimsJSON)
-
-	signature, err := createSignature(headerEncoded + "." + claimsEncoded)
-	if err != nil {
-		return "", err
-	}
+	signature := key.sign(headerEncoded + "." + claimsEncoded)
 
 	token := fmt.Sprintf("%s.%s.%s", headerEncoded, claimsEncoded, signature)
 	return token, nil
 }
 
+// VerifyToken checks the signature with the key named by the token's kid header.
 func VerifyToken(token string) (*Claims, error) {
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
@@ -65,11 +73,28 @@ func VerifyToken(token string) (*Claims, error) {
 	claimsEncoded := parts[1]
 	signature := parts[2]
 
-	expectedSignature, err := createSignature(headerEncoded + "." + claimsEncoded)
+	headerJSON, err := base64.RawURLEncoding.DecodeString(headerEncoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token encoding: %v", err)
+	}
+	var h header
+	if err := json.Unmarshal(headerJSON, &h); err != nil {
+		return nil, fmt.Errorf("invalid token header: %v", err)
+	}
+	if h.Alg != "HS256" {
+		return nil, fmt.Errorf("unsupported token algorithm")
+	}
+
+	ring, err := currentKeyring()
 	if err != nil {
 		return nil, err
 	}
-	if signature != expectedSignature {
+	key, err := ring.Lookup(h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	expectedSignature := key.sign(headerEncoded + "." + claimsEncoded)
+	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
 		return nil, fmt.Errorf("invalid token signature")
 	}
 
@@ -89,16 +114,3 @@ func VerifyToken(token string) (*Claims, error) {
 
 	return &claims, nil
 }
-
-func createSignature(data string) (string, error) {
-	secret, err := env.String("JWT_SECRET")
-	
-	if err != nil {
-		return "", err
-	}
-	
-	h := hmac.New(sha256.New, []byte(secret))
-	h.Write([]byte(data))
-
-	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)), nil
-}
\ No newline at end of file
@@ -6,10 +6,12 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"be-simpletracker/internal/config"
 )
 
 func TestGenerateToken_verifyToken_roundTrip(t *testing.T) {
-	tok, err := GenerateToken("alice")
+	tok, err := GenerateToken("alice", "alice-session")
 	if err != nil {
 		t.Fatalf("GenerateToken: %v", err)
 	}
@@ -20,10 +22,13 @@ func TestGenerateToken_verifyToken_roundTrip(t *testing.T) {
 	if got.Username != "alice" {
 		t.Fatalf("username: got %q want alice", got.Username)
 	}
+	if got.ID != "alice-session" {
+		t.Fatalf("jti: got %q want alice-session", got.ID)
+	}
 }
 
 func TestVerifyToken_badSignature(t *testing.T) {
-	tok, err := GenerateToken("bob")
+	tok, err := GenerateToken("bob", "bob-session")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -56,27 +61,94 @@ func TestVerifyToken_expiredClaims(t *testing.T) {
 
 func mustSignedJWT(tb testing.TB, claims Claims) string {
 	tb.Helper()
-	header := map[string]string{"alg": "HS256", "typ": "JWT"}
-	hj, err := json.Marshal(header)
+	ring, err := currentKeyring()
 	if err != nil {
 		tb.Fatal(err)
 	}
-	cj, err := json.Marshal(claims)
+	tok, err := signToken(ring.Current, claims)
 	if err != nil {
 		tb.Fatal(err)
 	}
-	he := base64.RawURLEncoding.EncodeToString(hj)
-	ce := base64.RawURLEncoding.EncodeToString(cj)
-	sig, err := createSignature(he + "." + ce)
+	return tok
+}
+
+func TestGenerateToken_missingSecretReturnsError(t *testing.T) {
+	cfg := testConfig()
+	cfg.Auth.JWTSecret = ""
+	if err := Configure(cfg); err == nil {
+		t.Fatal("expected Configure to refuse an empty JWT_SECRET")
+	}
+	saved := settings
+	t.Cleanup(func() { settings = saved })
+	settings.keyring = nil
+	if _, err := GenerateToken("u", "u-session"); err == nil {
+		t.Fatal("expected error without signing keys")
+	}
+}
+
+func TestVerifyToken_acceptsPreviousKeyAfterRotation(t *testing.T) {
+	oldSecret := "old-jwt-secret-32-characters!!!!"
+	configure(t, func(cfg *config.Config) { cfg.Auth.JWTSecret = oldSecret })
+	tok, err := GenerateToken("carol", "carol-session")
 	if err != nil {
-		tb.Fatal(err)
+		t.Fatal(err)
+	}
+
+	newSecret := "new-jwt-secret-32-characters!!!!"
+	configure(t, func(cfg *config.Config) { cfg.Auth.JWTSecret = newSecret })
+	if _, err := VerifyToken(tok); err == nil {
+		t.Fatal("token signed with a dropped key should be rejected")
+	}
+	configure(t, func(cfg *config.Config) {
+		cfg.Auth.JWTSecret = newSecret
+		cfg.Auth.JWTPreviousSecrets = []string{oldSecret}
+	})
+	got, err := VerifyToken(tok)
+	if err != nil {
+		t.Fatalf("VerifyToken after rotation: %v", err)
+	}
+	if got.Username != "carol" {
+		t.Fatalf("username: got %q want carol", got.Username)
 	}
-	return he + "." + ce + "." + sig
 }
 
-func TestGenerateToken_missingSecretReturnsError(t *testing.T) {
-	t.Setenv("JWT_SECRET", "")
-	if _, err := GenerateToken("u"); err == nil {
-		t.Fatal("expected error when JWT_SECRET is empty")
+func TestGenerateToken_headerNamesCurrentKey(t *testing.T) {
+	tok, err := GenerateToken("dave", "dave-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hj, err := base64.RawURLEncoding.DecodeString(strings.Split(tok, ".")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h header
+	if err := json.Unmarshal(hj, &h); err != nil {
+		t.Fatal(err)
+	}
+	ring, err := currentKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Kid == "" || h.Kid != ring.Current.ID {
+		t.Fatalf("kid: got %q want %q", h.Kid, ring.Current.ID)
+	}
+}
+
+func TestVerifyToken_rejectsUnknownKeyID(t *testing.T) {
+	tok, err := signToken(newSigningKey("unrelated-secret-32-characters!!"), Claims{Username: "mallory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyToken(tok); err == nil {
+		t.Fatal("expected error for unknown kid")
+	}
+}
+
+func TestVerifyToken_rejectsOtherAlgorithms(t *testing.T) {
+	hj, _ := json.Marshal(header{Alg: "none", Typ: "JWT"})
+	cj, _ := json.Marshal(Claims{Username: "mallory"})
+	tok := base64.RawURLEncoding.EncodeToString(hj) + "." + base64.RawURLEncoding.EncodeToString(cj) + "."
+	if _, err := VerifyToken(tok); err == nil {
+		t.Fatal("expected error for alg none")
 	}
 }
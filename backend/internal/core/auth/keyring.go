@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"be-simpletracker/internal/config"
+)
+
+// SigningKey is one HMAC secret and the kid that names it in token headers.
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring holds the key new tokens are signed with and older keys that are still accepted
+// for verification. Rotate by moving JWT_SECRET into JWT_PREVIOUS_SECRETS and setting a new
+// JWT_SECRET; sessions signed with the old key keep working until it is dropped from the list.
+type Keyring struct {
+	Current  SigningKey
+	Previous []SigningKey
+}
+
+// KeyID derives a kid from a secret, so moving a secret between the env vars keeps its id.
+func KeyID(secret []byte) string {
+	sum := sha256.Sum256(append([]byte("simpletrack-jwt-kid:"), secret...))
+	return hex.EncodeToString(sum[:6])
+}
+
+func newSigningKey(secret string) SigningKey {
+	return SigningKey{ID: KeyID([]byte(secret)), Secret: []byte(secret)}
+}
+
+// LoadKeyring builds the keyring from JWT_SECRET and JWT_PREVIOUS_SECRETS.
+func LoadKeyring(cfg config.Auth) (*Keyring, error) {
+	if cfg.JWTSecret == "" {
+		return nil, errors.New("JWT_SECRET is not set")
+	}
+	ring := &Keyring{Current: newSigningKey(cfg.JWTSecret)}
+	for _, previous := range cfg.JWTPreviousSecrets {
+		key := newSigningKey(previous)
+		if key.ID == ring.Current.ID {
+			continue
+		}
+		ring.Previous = append(ring.Previous, key)
+	}
+	return ring, nil
+}
+
+// Keys returns the current key followed by the verification-only keys.
+func (k *Keyring) Keys() []SigningKey {
+	return append([]SigningKey{k.Current}, k.Previous...)
+}
+
+// Lookup finds the key for a token's kid. Tokens minted before key ids existed have no kid
+// and are checked against the current key only.
+func (k *Keyring) Lookup(kid string) (SigningKey, error) {
+	if kid == "" {
+		return k.Current, nil
+	}
+	for _, key := range k.Keys() {
+		if key.ID == kid {
+			return key, nil
+		}
+	}
+	return SigningKey{}, errors.New("unknown signing key")
+}
+
+func (key SigningKey) sign(data string) string {
+	h := hmac.New(sha256.New, key.Secret)
+	h.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invite is a single-use registration code minted by an existing user. Only the SHA-256 of
+// the code is stored.
+type Invite struct {
+	gorm.Model
+	CreatedByID uint       `json:"-" gorm:"not null;index"`
+	CodeHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	Prefix      string     `json:"prefix" gorm:"not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt      *time.Time `json:"used_at"`
+	UsedByID    *uint      `json:"used_by_id"`
+}
+
+func (Invite) TableName() string { return "invites" }
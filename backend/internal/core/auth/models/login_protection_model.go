@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// LoginRateWindow, LoginSprayUsername and LoginSprayBlock hold LoginProtection state when it
+// is shared through the database rather than kept in process.
+type LoginRateWindow struct {
+	RateKey string    `gorm:"primaryKey"`
+	Count   int       `gorm:"not null"`
+	ResetAt time.Time `gorm:"not null;index"`
+}
+
+func (LoginRateWindow) TableName() string { return "login_rate_windows" }
+
+type LoginSprayUsername struct {
+	IPKey     string    `gorm:"primaryKey"`
+	Username  string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+func (LoginSprayUsername) TableName() string { return "login_spray_usernames" }
+
+type LoginSprayBlock struct {
+	IPKey        string    `gorm:"primaryKey"`
+	BlockedUntil time.Time `gorm:"not null;index"`
+}
+
+func (LoginSprayBlock) TableName() string { return "login_spray_blocks" }
+
+// LoginAttempt is the persisted audit record of one LogAttempt call. NewIP and NewUserAgent
+// mark a successful login from an address or client the account had not logged in from before;
+// AcknowledgedAt is set once the owner has seen it.
+type LoginAttempt struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"not null;index"`
+	Outcome        string     `json:"outcome" gorm:"not null;index"`
+	Username       string     `json:"username" gorm:"index"`
+	ClientIP       string     `json:"client_ip"`
+	UserAgent      string     `json:"user_agent"`
+	NewIP          bool       `json:"new_ip" gorm:"not null;default:false"`
+	NewUserAgent   bool       `json:"new_user_agent" gorm:"not null;default:false"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
+
+func (LoginAttempt) TableName() string { return "login_attempts" }
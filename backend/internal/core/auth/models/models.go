@@ -0,0 +1,19 @@
+package models
+
+// All lists every table owned by the auth module, in migration order.
+func All() []any {
+	return []any{
+		&User{},
+		&Session{},
+		&AccessToken{},
+		&RecoveryCode{},
+		&LoginChallenge{},
+		&PasswordResetToken{},
+		&LoginRateWindow{},
+		&LoginSprayUsername{},
+		&LoginSprayBlock{},
+		&LoginAttempt{},
+		&Invite{},
+		&Preferences{},
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "gorm.io/gorm"
+
+// Preferences set how a user's days are counted. A user without a row counts days in the
+// server's zone, from midnight, with weeks starting on Sunday.
+type Preferences struct {
+	gorm.Model
+	UserID uint `json:"-" gorm:"not null;uniqueIndex"`
+	// Timezone is an IANA zone name such as "Europe/Oslo"; empty means the server's zone.
+	Timezone string `json:"timezone" gorm:"not null;default:''"`
+	// WeekStart is the first day of the week, 0 (Sunday) to 6 (Saturday).
+	WeekStart int `json:"week_start" gorm:"not null;default:0"`
+	// DayStartHour is how many hours past midnight still count as the previous day, so a
+	// late dinner lands on the day it belongs to.
+	DayStartHour int `json:"day_start_hour" gorm:"not null;default:0"`
+}
+
+func (Preferences) TableName() string { return "user_preferences" }
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is one signed-in device. The auth cookie carries TokenID as its jti claim; deleting
+// the row revokes the cookie even though its signature is still valid.
+type Session struct {
+	gorm.Model
+	UserID     uint      `json:"-" gorm:"not null;index"`
+	TokenID    string    `json:"-" gorm:"not null;uniqueIndex"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func (s Session) TableName() string { return "sessions" }
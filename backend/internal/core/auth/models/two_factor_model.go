@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a one-time fallback for a lost authenticator. Only its SHA-256 is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `json:"-" gorm:"not null;index"`
+	CodeHash string     `json:"-" gorm:"not null"`
+	UsedAt   *time.Time `json:"-"`
+}
+
+func (r RecoveryCode) TableName() string { return "recovery_codes" }
+
+// LoginChallenge is the pending-2FA state between a correct password and a correct code.
+type LoginChallenge struct {
+	gorm.Model
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	Attempts  int       `gorm:"not null;default:0"`
+}
+
+func (l LoginChallenge) TableName() string { return "login_challenges" }
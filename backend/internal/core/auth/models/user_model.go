@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // User represents a user account
 type User struct {
@@ -9,7 +13,14 @@ type User struct {
 	Password  string `json:"-" gorm:"not null"`
 	Email     string `json:"email" gorm:"uniqueIndex"`
 	BirthYear *int   `json:"birth_year"`
+	// TOTPSecret is set during enrollment and only used for login once TOTPEnabled is true.
+	TOTPSecret   string `json:"-"`
+	TOTPEnabled  bool   `json:"totp_enabled" gorm:"not null;default:false"`
+	TOTPLastStep int64  `json:"-" gorm:"not null;default:0"`
+	// DisabledAt is set by an administrator; a disabled account cannot sign in or use tokens.
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
 }
 
 func (u User) GetID() uint       { return u.ID }
 func (u User) TableName() string { return "users" }
+func (u User) Disabled() bool    { return u.DisabledAt != nil }
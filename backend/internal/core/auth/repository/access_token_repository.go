@@ -0,0 +1,44 @@
+package authrepo
+
+import (
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateAccessToken(token *models.AccessToken) error {
+	return conn().Create(token).Error
+}
+
+func FindAccessTokenByHash(tokenHash string) (models.AccessToken, error) {
+	var token models.AccessToken
+	if err := conn().Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return models.AccessToken{}, err
+	}
+	return token, nil
+}
+
+func ListAccessTokens(userID uint) ([]models.AccessToken, error) {
+	var tokens []models.AccessToken
+	err := conn().Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func TouchAccessToken(id uint, usedAt time.Time) error {
+	return conn().Model(&models.AccessToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+// RevokeAccessToken deletes one of userID's tokens, returning gorm.ErrRecordNotFound when
+// there is no such token for that user.
+func RevokeAccessToken(userID, id uint) error {
+	result := conn().Unscoped().Where("user_id = ? AND id = ?", userID, id).Delete(&models.AccessToken{}, &models.RecoveryCode{}, &models.LoginChallenge{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package authrepo
+
+import (
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateInvite(invite *models.Invite) error {
+	return conn().Create(invite).Error
+}
+
+func ListInvites(createdByID uint) ([]models.Invite, error) {
+	var invites []models.Invite
+	err := conn().Where("created_by_id = ?", createdByID).Order("created_at DESC").Find(&invites).Error
+	return invites, err
+}
+
+// RevokeInvite deletes an unused invite. It returns gorm.ErrRecordNotFound when the invite
+// does not exist, belongs to someone else or was already used.
+func RevokeInvite(createdByID, inviteID uint) error {
+	result := conn().Unscoped().
+		Where("id = ? AND created_by_id = ? AND used_at IS NULL", inviteID, createdByID).
+		Delete(&models.Invite{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateUserWithInvite creates user and claims the unexpired, unused invite with codeHash in
+// one transaction, so a failed registration leaves the invite usable and two registrations
+// cannot share a code. It returns gorm.ErrRecordNotFound when no such invite exists.
+func CreateUserWithInvite(user *models.User, codeHash string, now time.Time) error {
+	return conn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.Invite{}).
+			Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", codeHash, now).
+			Updates(map[string]any{"used_at": now, "used_by_id": user.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func FindUsableInvite(codeHash string, now time.Time) (models.Invite, error) {
+	var invite models.Invite
+	err := conn().Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", codeHash, now).First(&invite).Error
+	return invite, err
+}
@@ -0,0 +1,70 @@
+package authrepo
+
+import (
+	"context"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+
+	"gorm.io/gorm"
+)
+
+const loginOutcomeSuccess = "success"
+
+// LoginAttemptLog writes LoginProtection.LogAttempt outcomes to the login_attempts table.
+// A success is flagged as coming from a new IP or user agent when the username has logged in
+// before but never from that address or client.
+type LoginAttemptLog struct{}
+
+func (LoginAttemptLog) RecordLoginAttempt(ctx context.Context, attempt *models.LoginAttempt) error {
+	db := conn().WithContext(ctx)
+	if attempt.Outcome == loginOutcomeSuccess && attempt.Username != "" {
+		previous := db.Model(&models.LoginAttempt{}).
+			Where("outcome = ? AND LOWER(username) = LOWER(?)", loginOutcomeSuccess, attempt.Username)
+		var total int64
+		if err := previous.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return err
+		}
+		if total > 0 {
+			var sameIP, sameAgent int64
+			if err := previous.Session(&gorm.Session{}).Where("client_ip = ?", attempt.ClientIP).Count(&sameIP).Error; err != nil {
+				return err
+			}
+			if err := previous.Session(&gorm.Session{}).Where("user_agent = ?", attempt.UserAgent).Count(&sameAgent).Error; err != nil {
+				return err
+			}
+			attempt.NewIP = sameIP == 0
+			attempt.NewUserAgent = sameAgent == 0
+		}
+	}
+	return db.Create(attempt).Error
+}
+
+// ListLoginAttempts returns the most recent attempts against username, newest first.
+func ListLoginAttempts(username string, limit int) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := conn().Where("LOWER(username) = LOWER(?)", username).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&attempts).Error
+	return attempts, err
+}
+
+// ListNewDeviceLogins returns unacknowledged successful logins since the given time that came
+// from a new IP or user agent.
+func ListNewDeviceLogins(username string, since time.Time) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := conn().
+		Where("LOWER(username) = LOWER(?) AND outcome = ? AND created_at >= ?", username, loginOutcomeSuccess, since).
+		Where("(new_ip OR new_user_agent) AND acknowledged_at IS NULL").
+		Order("created_at DESC, id DESC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+func AcknowledgeNewDeviceLogins(username string, at time.Time) (int64, error) {
+	result := conn().Model(&models.LoginAttempt{}).
+		Where("LOWER(username) = LOWER(?) AND (new_ip OR new_user_agent) AND acknowledged_at IS NULL", username).
+		Update("acknowledged_at", at)
+	return result.RowsAffected, result.Error
+}
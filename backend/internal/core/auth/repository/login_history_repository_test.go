@@ -0,0 +1,63 @@
+package authrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+)
+
+func recordAttempt(t *testing.T, outcome, ip, userAgent string, at time.Time) models.LoginAttempt {
+	t.Helper()
+	attempt := models.LoginAttempt{CreatedAt: at, Outcome: outcome, Username: "alice", ClientIP: ip, UserAgent: userAgent}
+	if err := (LoginAttemptLog{}).RecordLoginAttempt(context.Background(), &attempt); err != nil {
+		t.Fatal(err)
+	}
+	return attempt
+}
+
+func TestLoginAttemptLogPersistsOutcome(t *testing.T) {
+	setupLoginStoreDB(t)
+	recordAttempt(t, "invalid_credentials", "192.0.2.1", "curl/8.0", time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC))
+	attempts, err := ListLoginAttempts("ALICE", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 || attempts[0].Outcome != "invalid_credentials" || attempts[0].UserAgent != "curl/8.0" {
+		t.Fatalf("attempts: %+v", attempts)
+	}
+}
+
+func TestLoginAttemptLogFlagsNewDevices(t *testing.T) {
+	setupLoginStoreDB(t)
+	start := time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC)
+
+	if first := recordAttempt(t, "success", "192.0.2.1", "firefox", start); first.NewIP || first.NewUserAgent {
+		t.Fatalf("first login flagged: %+v", first)
+	}
+	if same := recordAttempt(t, "success", "192.0.2.1", "firefox", start.Add(time.Hour)); same.NewIP || same.NewUserAgent {
+		t.Fatalf("known device flagged: %+v", same)
+	}
+	if failed := recordAttempt(t, "invalid_credentials", "198.51.100.7", "curl", start.Add(2*time.Hour)); failed.NewIP || failed.NewUserAgent {
+		t.Fatalf("failure flagged: %+v", failed)
+	}
+	newIP := recordAttempt(t, "success", "198.51.100.7", "firefox", start.Add(3*time.Hour))
+	if !newIP.NewIP || newIP.NewUserAgent {
+		t.Fatalf("new IP: %+v", newIP)
+	}
+
+	pending, err := ListNewDeviceLogins("alice", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != newIP.ID {
+		t.Fatalf("pending: %+v", pending)
+	}
+	if n, err := AcknowledgeNewDeviceLogins("alice", start.Add(4*time.Hour)); err != nil || n != 1 {
+		t.Fatalf("acknowledge: %d %v", n, err)
+	}
+	if pending, err := ListNewDeviceLogins("alice", start); err != nil || len(pending) != 0 {
+		t.Fatalf("pending after acknowledge: %+v %v", pending, err)
+	}
+}
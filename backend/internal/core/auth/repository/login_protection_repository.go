@@ -0,0 +1,83 @@
+package authrepo
+
+import (
+	"context"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+)
+
+// PostgresLoginStore shares LoginProtection state between replicas and across restarts.
+// Every counter change is a single INSERT ... ON CONFLICT statement, so concurrent attempts
+// on different instances cannot lose updates. The SQL also runs unchanged on SQLite.
+type PostgresLoginStore struct{}
+
+func (PostgresLoginStore) Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	var row models.LoginRateWindow
+	err := conn().WithContext(ctx).Raw(`
+		INSERT INTO login_rate_windows (rate_key, count, reset_at) VALUES (?, 1, ?)
+		ON CONFLICT (rate_key) DO UPDATE SET
+			count = CASE WHEN login_rate_windows.reset_at <= ? THEN 1 ELSE login_rate_windows.count + 1 END,
+			reset_at = CASE WHEN login_rate_windows.reset_at <= ? THEN excluded.reset_at ELSE login_rate_windows.reset_at END
+		RETURNING rate_key, count, reset_at`,
+		key, now.Add(window), now, now,
+	).Scan(&row).Error
+	return row.Count, row.ResetAt, err
+}
+
+func (PostgresLoginStore) Reset(ctx context.Context, key string) error {
+	return conn().WithContext(ctx).Where("rate_key = ?", key).Delete(&models.LoginRateWindow{}).Error
+}
+
+func (PostgresLoginStore) AddSprayUsername(ctx context.Context, ipKey, username string, window time.Duration, now time.Time) (int, error) {
+	db := conn().WithContext(ctx)
+	if err := db.Exec(`
+		INSERT INTO login_spray_usernames (ip_key, username, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (ip_key, username) DO UPDATE SET expires_at = excluded.expires_at`,
+		ipKey, username, now.Add(window),
+	).Error; err != nil {
+		return 0, err
+	}
+	var distinct int64
+	err := db.Model(&models.LoginSprayUsername{}).
+		Where("ip_key = ? AND expires_at > ?", ipKey, now).
+		Count(&distinct).Error
+	return int(distinct), err
+}
+
+func (PostgresLoginStore) RemoveSprayUsername(ctx context.Context, ipKey, username string) error {
+	return conn().WithContext(ctx).
+		Where("ip_key = ? AND username = ?", ipKey, username).
+		Delete(&models.LoginSprayUsername{}).Error
+}
+
+func (PostgresLoginStore) BlockIP(ctx context.Context, ipKey string, until time.Time) error {
+	return conn().WithContext(ctx).Exec(`
+		INSERT INTO login_spray_blocks (ip_key, blocked_until) VALUES (?, ?)
+		ON CONFLICT (ip_key) DO UPDATE SET blocked_until = excluded.blocked_until`,
+		ipKey, until,
+	).Error
+}
+
+func (PostgresLoginStore) BlockedUntil(ctx context.Context, ipKey string, now time.Time) (time.Time, error) {
+	var blocks []models.LoginSprayBlock
+	err := conn().WithContext(ctx).
+		Where("ip_key = ? AND blocked_until > ?", ipKey, now).
+		Limit(1).
+		Find(&blocks).Error
+	if err != nil || len(blocks) == 0 {
+		return time.Time{}, err
+	}
+	return blocks[0].BlockedUntil, nil
+}
+
+func (PostgresLoginStore) Prune(ctx context.Context, now time.Time) error {
+	db := conn().WithContext(ctx)
+	if err := db.Where("reset_at <= ?", now).Delete(&models.LoginRateWindow{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("expires_at <= ?", now).Delete(&models.LoginSprayUsername{}).Error; err != nil {
+		return err
+	}
+	return db.Where("blocked_until <= ?", now).Delete(&models.LoginSprayBlock{}).Error
+}
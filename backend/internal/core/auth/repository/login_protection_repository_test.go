@@ -0,0 +1,95 @@
+package authrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/dbtest"
+)
+
+func setupLoginStoreDB(t *testing.T) {
+	t.Helper()
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		t.Fatal(err)
+	}
+	database.SetDB(db)
+	t.Cleanup(func() {
+		database.SetDB(nil)
+	})
+}
+
+func TestPostgresLoginStoreIncrementResetsAfterWindow(t *testing.T) {
+	setupLoginStoreDB(t)
+	store := PostgresLoginStore{}
+	ctx := context.Background()
+	now := time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC)
+
+	for want := 1; want <= 3; want++ {
+		count, resetAt, err := store.Increment(ctx, "username:alice", time.Minute, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != want || !resetAt.Equal(now.Add(time.Minute)) {
+			t.Fatalf("increment %d: count=%d resetAt=%v", want, count, resetAt)
+		}
+	}
+
+	later := now.Add(time.Minute)
+	count, resetAt, err := store.Increment(ctx, "username:alice", time.Minute, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || !resetAt.Equal(later.Add(time.Minute)) {
+		t.Fatalf("after window: count=%d resetAt=%v", count, resetAt)
+	}
+
+	if err := store.Reset(ctx, "username:alice"); err != nil {
+		t.Fatal(err)
+	}
+	if count, _, _ := store.Increment(ctx, "username:alice", time.Minute, later); count != 1 {
+		t.Fatalf("after reset: count=%d", count)
+	}
+}
+
+func TestPostgresLoginStoreSprayBlock(t *testing.T) {
+	setupLoginStoreDB(t)
+	store := PostgresLoginStore{}
+	ctx := context.Background()
+	now := time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC)
+
+	for i, username := range []string{"alice", "bob", "alice"} {
+		if _, err := store.AddSprayUsername(ctx, "ip:192.0.2.1", username, 10*time.Minute, now); err != nil {
+			t.Fatalf("add %d: %v", i, err)
+		}
+	}
+	distinct, err := store.AddSprayUsername(ctx, "ip:192.0.2.1", "carol", 10*time.Minute, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if distinct != 3 {
+		t.Fatalf("distinct usernames: got %d want 3", distinct)
+	}
+
+	until := now.Add(24 * time.Hour)
+	if err := store.BlockIP(ctx, "ip:192.0.2.1", until); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.BlockedUntil(ctx, "ip:192.0.2.1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(until) {
+		t.Fatalf("blocked until: got %v want %v", got, until)
+	}
+
+	if err := store.Prune(ctx, until); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.BlockedUntil(ctx, "ip:192.0.2.1", until); err != nil || !got.IsZero() {
+		t.Fatalf("block after expiry: got %v err %v", got, err)
+	}
+}
@@ -0,0 +1,47 @@
+package authrepo
+
+import (
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+
+	"gorm.io/gorm"
+)
+
+func FindUserByEmail(email string) (models.User, error) {
+	var user models.User
+	if err := conn().Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// CreatePasswordResetToken replaces any outstanding reset tokens for the user, so only the
+// most recent email works.
+func CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	if err := conn().Unscoped().Where("user_id = ? AND used_at IS NULL", token.UserID).
+		Delete(&models.PasswordResetToken{}).Error; err != nil {
+		return err
+	}
+	return conn().Create(token).Error
+}
+
+// ConsumePasswordResetToken marks an unexpired, unused token as used and returns it.
+// It returns gorm.ErrRecordNotFound when no such token exists.
+func ConsumePasswordResetToken(tokenHash string, now time.Time) (models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := conn().Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		First(&token).Error; err != nil {
+		return models.PasswordResetToken{}, err
+	}
+	result := conn().Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return models.PasswordResetToken{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.PasswordResetToken{}, gorm.ErrRecordNotFound
+	}
+	return token, nil
+}
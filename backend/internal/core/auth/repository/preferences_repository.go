@@ -0,0 +1,16 @@
+package authrepo
+
+import (
+	"be-simpletracker/internal/core/auth/models"
+)
+
+// FindPreferences returns gorm.ErrRecordNotFound for a user who never saved any.
+func FindPreferences(userID uint) (models.Preferences, error) {
+	var prefs models.Preferences
+	err := conn().Where("user_id = ?", userID).Take(&prefs).Error
+	return prefs, err
+}
+
+func SavePreferences(prefs *models.Preferences) error {
+	return conn().Save(prefs).Error
+}
@@ -0,0 +1,57 @@
+package authrepo
+
+import (
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateSession(session *models.Session) error {
+	return conn().Create(session).Error
+}
+
+func FindSessionByTokenID(tokenID string) (models.Session, error) {
+	var session models.Session
+	if err := conn().Where("token_id = ?", tokenID).First(&session).Error; err != nil {
+		return models.Session{}, err
+	}
+	return session, nil
+}
+
+func TouchSession(id uint, ip, userAgent string, seenAt time.Time) error {
+	return conn().Model(&models.Session{}).Where("id = ?", id).Updates(map[string]any{
+		"last_seen_at": seenAt,
+		"ip":           ip,
+		"user_agent":   userAgent,
+	}).Error
+}
+
+func ListSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := conn().Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession deletes one of userID's sessions, returning gorm.ErrRecordNotFound when
+// there is no such session for that user.
+func RevokeSession(userID, id uint) error {
+	result := conn().Unscoped().Where("user_id = ? AND id = ?", userID, id).Delete(&models.Session{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func RevokeSessionByTokenID(tokenID string) error {
+	return conn().Unscoped().Where("token_id = ?", tokenID).Delete(&models.Session{}).Error
+}
+
+func RevokeAllSessions(userID uint) (int64, error) {
+	result := conn().Unscoped().Where("user_id = ?", userID).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
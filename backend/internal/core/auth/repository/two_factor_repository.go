@@ -0,0 +1,81 @@
+package authrepo
+
+import (
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+
+	"gorm.io/gorm"
+)
+
+func SetUserTOTP(userID uint, secret string, enabled bool) error {
+	return conn().Model(&models.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"totp_secret":    secret,
+		"totp_enabled":   enabled,
+		"totp_last_step": 0,
+	}).Error
+}
+
+// ClaimTOTPStep records step as used, returning false if it (or a later step) already was,
+// so a code cannot be replayed within its validity window.
+func ClaimTOTPStep(userID uint, step int64) (bool, error) {
+	result := conn().Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return conn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+		codes := make([]models.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, models.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode marks a matching unused code as spent, returning false if there was none.
+func UseRecoveryCode(userID uint, hash string) (bool, error) {
+	result := conn().Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func CountUnusedRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := conn().Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func CreateLoginChallenge(challenge *models.LoginChallenge) error {
+	return conn().Create(challenge).Error
+}
+
+func FindLoginChallenge(tokenHash string) (models.LoginChallenge, error) {
+	var challenge models.LoginChallenge
+	if err := conn().Where("token_hash = ?", tokenHash).First(&challenge).Error; err != nil {
+		return models.LoginChallenge{}, err
+	}
+	return challenge, nil
+}
+
+func IncrementLoginChallengeAttempts(id uint) error {
+	return conn().Model(&models.LoginChallenge{}).Where("id = ?", id).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+func DeleteLoginChallenge(id uint) error {
+	return conn().Unscoped().Delete(&models.LoginChallenge{}, id).Error
+}
+
+func DeleteExpiredLoginChallenges(now time.Time) error {
+	return conn().Unscoped().Where("expires_at < ?", now).Delete(&models.LoginChallenge{}).Error
+}
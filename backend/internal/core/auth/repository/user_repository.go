@@ -1,6 +1,12 @@
 package authrepo
 
-import "be-simpletracker/internal/core/auth/models"
+import (
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+
+	"gorm.io/gorm"
+)
 
 func FindUserByUsername(username string) (models.User, error) {
 	var user models.User
@@ -10,6 +16,14 @@ func FindUserByUsername(username string) (models.User, error) {
 	return user, nil
 }
 
+func FindUserByID(id uint) (models.User, error) {
+	var user models.User
+	if err := conn().First(&user, id).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
 func CreateUser(user *models.User) error {
 	return conn().Create(user).Error
 }
@@ -27,3 +41,38 @@ func UpdateUserBirthYear(username string, birthYear *int) (models.User, error) {
 	}
 	return user, nil
 }
+
+func UpdateUserPassword(userID uint, hashedPassword string) error {
+	return conn().Model(&models.User{}).Where("id = ?", userID).Update("password", hashedPassword).Error
+}
+
+// FindOrCreateDevUser returns the bypass user, creating it with an unusable password on first use.
+func FindOrCreateDevUser(username string) (models.User, error) {
+	user := models.User{
+		Username: username,
+		Password: "!",
+		Email:    username + "@localhost",
+	}
+	if err := conn().Where("username = ?", username).FirstOrCreate(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func ListUsers() ([]models.User, error) {
+	var users []models.User
+	err := conn().Order("id").Find(&users).Error
+	return users, err
+}
+
+// SetUserDisabledAt disables the account at the given time, or re-enables it when at is nil.
+func SetUserDisabledAt(userID uint, at *time.Time) error {
+	result := conn().Model(&models.User{}).Where("id = ?", userID).Update("disabled_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -5,16 +5,11 @@ import (
 
 	"be-simpletracker/internal/core/auth/models"
 	"be-simpletracker/internal/database"
-
-	"github.com/glebarez/sqlite"
-	"gorm.io/gorm"
+	"be-simpletracker/internal/database/dbtest"
 )
 
 func TestUserRepositoryFindsUserByUsername(t *testing.T) {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatal(err)
-	}
+	db := dbtest.Open(t)
 	if err := db.AutoMigrate(&models.User{}); err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
+
+	"gorm.io/gorm"
+)
+
+// AccessTokenPrefix marks personal access tokens so they are recognisable in logs and
+// secret scanners.
+const AccessTokenPrefix = "stp_"
+
+const accessTokenTouchInterval = time.Minute
+
+// ScopedModules are the route groups a personal access token can be granted. Each takes a
+// ":read" or ":write" suffix; write also grants read.
+var ScopedModules = []string{"diet", "workout", "tracking", "money"}
+
+var (
+	ErrInvalidScope       = errors.New("invalid scope")
+	ErrInvalidTokenInput  = errors.New("invalid access token request")
+	ErrInvalidAccessToken = errors.New("invalid access token")
+	ErrAccessTokenExpired = errors.New("access token expired")
+)
+
+type CreateAccessTokenInput struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type CreatedAccessToken struct {
+	Token       string
+	AccessToken models.AccessToken
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		module, access, ok := strings.Cut(scope, ":")
+		if !ok || !slices.Contains(ScopedModules, module) || (access != "read" && access != "write") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+		if !slices.Contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+// HasScope reports whether token grants required, treating module:write as covering module:read.
+func HasScope(token models.AccessToken, required string) bool {
+	granted := token.ScopeList()
+	if slices.Contains(granted, required) {
+		return true
+	}
+	module, access, _ := strings.Cut(required, ":")
+	return access == "read" && slices.Contains(granted, module+":write")
+}
+
+func (s *AuthService) CreateAccessToken(userID uint, input CreateAccessTokenInput) (CreatedAccessToken, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return CreatedAccessToken{}, fmt.Errorf("%w: name is required", ErrInvalidTokenInput)
+	}
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return CreatedAccessToken{}, err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return CreatedAccessToken{}, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidTokenInput)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return CreatedAccessToken{}, fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+	raw := AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	token := models.AccessToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:len(AccessTokenPrefix)+6],
+		TokenHash: hashSecret(raw),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: input.ExpiresAt,
+	}
+	if err := authrepo.CreateAccessToken(&token); err != nil {
+		return CreatedAccessToken{}, err
+	}
+	return CreatedAccessToken{Token: raw, AccessToken: token}, nil
+}
+
+func (s *AuthService) AccessTokens(userID uint) ([]models.AccessToken, error) {
+	return authrepo.ListAccessTokens(userID)
+}
+
+func (s *AuthService) RevokeAccessToken(userID, tokenID uint) error {
+	return authrepo.RevokeAccessToken(userID, tokenID)
+}
+
+// AuthenticateAccessToken resolves a Bearer credential to its token and owner.
+func AuthenticateAccessToken(raw string) (models.AccessToken, models.User, error) {
+	if !strings.HasPrefix(raw, AccessTokenPrefix) {
+		return models.AccessToken{}, models.User{}, ErrInvalidAccessToken
+	}
+	token, err := authrepo.FindAccessTokenByHash(hashSecret(raw))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.AccessToken{}, models.User{}, ErrInvalidAccessToken
+	}
+	if err != nil {
+		return models.AccessToken{}, models.User{}, err
+	}
+	now := time.Now()
+	if token.Expired(now) {
+		return models.AccessToken{}, models.User{}, ErrAccessTokenExpired
+	}
+	user, err := authrepo.FindUserByID(token.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user.Disabled()) {
+		return models.AccessToken{}, models.User{}, ErrInvalidAccessToken
+	}
+	if err != nil {
+		return models.AccessToken{}, models.User{}, err
+	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= accessTokenTouchInterval {
+		if err := authrepo.TouchAccessToken(token.ID, now); err != nil {
+			return models.AccessToken{}, models.User{}, err
+		}
+		token.LastUsedAt = &now
+	}
+	user.Password = ""
+	return token, user, nil
+}
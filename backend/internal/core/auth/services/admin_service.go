@@ -0,0 +1,42 @@
+package services
+
+import (
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
+)
+
+// The methods in this file back cmd/admin. They act on any account and have no HTTP routes.
+
+func (s *AuthService) Users() ([]models.User, error) {
+	return authrepo.ListUsers()
+}
+
+func (s *AuthService) UserByUsername(username string) (models.User, error) {
+	return s.CurrentUser(username)
+}
+
+// CreateUser adds an account without signing anyone in.
+func (s *AuthService) CreateUser(input RegisterInput) (models.User, error) {
+	user, err := createUser(input, authrepo.CreateUser)
+	if err != nil {
+		return models.User{}, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// DisableUser blocks sign-in and token use for the account and ends its sessions.
+func (s *AuthService) DisableUser(userID uint) error {
+	now := time.Now()
+	if err := authrepo.SetUserDisabledAt(userID, &now); err != nil {
+		return err
+	}
+	_, err := authrepo.RevokeAllSessions(userID)
+	return err
+}
+
+func (s *AuthService) EnableUser(userID uint) error {
+	return authrepo.SetUserDisabledAt(userID, nil)
+}
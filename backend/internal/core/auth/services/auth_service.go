@@ -7,6 +7,7 @@ import (
 
 	"be-simpletracker/internal/core/auth/models"
 	authrepo "be-simpletracker/internal/core/auth/repository"
+	"be-simpletracker/internal/mailer"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -18,6 +19,7 @@ var (
 	ErrPasswordHash       = errors.New("failed to hash password")
 	ErrUserCreation       = errors.New("failed to create user")
 	ErrTokenGeneration    = errors.New("failed to generate token")
+	ErrAccountDisabled    = errors.New("account is disabled")
 )
 
 var dummyPasswordHash = func() []byte {
@@ -28,26 +30,36 @@ var dummyPasswordHash = func() []byte {
 	return hash
 }()
 
-type TokenGenerator func(string) (string, error)
+// TokenGenerator signs a token for username carrying tokenID as its jti claim.
+type TokenGenerator func(username, tokenID string) (string, error)
 
 type AuthService struct {
 	generateToken TokenGenerator
+	mailer        mailer.Mailer
+	totpIssuer    string
+	environment   string
 }
 
 type RegisterInput struct {
 	Username string
 	Password string
 	Email    string
+	Client   ClientInfo
 }
 
 type LoginInput struct {
 	Username string
 	Password string
+	Client   ClientInfo
 }
 
+// AuthResult carries either a session token or, when the user has 2FA enabled, a
+// PendingToken to exchange for one via CompleteTwoFactorLogin.
 type AuthResult struct {
-	Token string
-	User  models.User
+	Token             string
+	User              models.User
+	TwoFactorRequired bool
+	PendingToken      string
 }
 
 func NewAuthService(generateToken TokenGenerator) *AuthService {
@@ -55,17 +67,38 @@ func NewAuthService(generateToken TokenGenerator) *AuthService {
 }
 
 func (s *AuthService) Register(input RegisterInput) (AuthResult, error) {
+	return s.register(input, authrepo.CreateUser)
+}
+
+// register creates the user and issues their first session.
+func (s *AuthService) register(input RegisterInput, create func(*models.User) error) (AuthResult, error) {
+	user, err := createUser(input, create)
+	if err != nil {
+		return AuthResult{}, err
+	}
+
+	token, err := s.issueToken(user, input.Client)
+	if err != nil {
+		return AuthResult{}, err
+	}
+
+	user.Password = ""
+	return AuthResult{Token: token, User: user}, nil
+}
+
+// createUser checks the username and hashes the password; create persists the user.
+func createUser(input RegisterInput, create func(*models.User) error) (models.User, error) {
 	_, err := authrepo.FindUserByUsername(input.Username)
 	if err == nil {
-		return AuthResult{}, ErrUsernameExists
+		return models.User{}, ErrUsernameExists
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return AuthResult{}, err
+		return models.User{}, err
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return AuthResult{}, fmt.Errorf("%w: %v", ErrPasswordHash, err)
+		return models.User{}, fmt.Errorf("%w: %v", ErrPasswordHash, err)
 	}
 
 	user := models.User{
@@ -73,17 +106,14 @@ func (s *AuthService) Register(input RegisterInput) (AuthResult, error) {
 		Password: string(hashedPassword),
 		Email:    input.Email,
 	}
-	if err := authrepo.CreateUser(&user); err != nil {
-		return AuthResult{}, fmt.Errorf("%w: %v", ErrUserCreation, err)
-	}
-
-	token, err := s.generateToken(user.Username)
-	if err != nil {
-		return AuthResult{}, fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	if err := create(&user); err != nil {
+		if errors.Is(err, ErrInvalidInvite) {
+			return models.User{}, err
+		}
+		return models.User{}, fmt.Errorf("%w: %v", ErrUserCreation, err)
 	}
 
-	user.Password = ""
-	return AuthResult{Token: token, User: user}, nil
+	return user, nil
 }
 
 func (s *AuthService) Login(input LoginInput) (AuthResult, error) {
@@ -98,10 +128,21 @@ func (s *AuthService) Login(input LoginInput) (AuthResult, error) {
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
 		return AuthResult{}, ErrInvalidCredentials
 	}
+	if user.Disabled() {
+		return AuthResult{}, ErrAccountDisabled
+	}
 
-	token, err := s.generateToken(user.Username)
+	if user.TOTPEnabled {
+		pendingToken, err := s.startLoginChallenge(user)
+		if err != nil {
+			return AuthResult{}, err
+		}
+		return AuthResult{TwoFactorRequired: true, PendingToken: pendingToken}, nil
+	}
+
+	token, err := s.issueToken(user, input.Client)
 	if err != nil {
-		return AuthResult{}, fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+		return AuthResult{}, err
 	}
 
 	user.Password = ""
@@ -1,11 +1,15 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"be-simpletracker/internal/core/auth/models"
 	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/mailer"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
@@ -17,7 +21,7 @@ func setupTestDB(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := db.AutoMigrate(&models.User{}); err != nil {
+	if err := db.AutoMigrate(models.All()...); err != nil {
 		t.Fatal(err)
 	}
 	database.SetDB(db)
@@ -28,7 +32,7 @@ func setupTestDB(t *testing.T) {
 
 func TestAuthServiceRegisterRedactsPassword(t *testing.T) {
 	setupTestDB(t)
-	service := NewAuthService(func(username string) (string, error) {
+	service := NewAuthService(func(username, _ string) (string, error) {
 		return "token-" + username, nil
 	})
 
@@ -61,7 +65,7 @@ func TestAuthServiceRegisterRejectsDuplicateUsername(t *testing.T) {
 	if err := database.GetDB().Create(&models.User{Username: "wanda", Password: "hash"}).Error; err != nil {
 		t.Fatal(err)
 	}
-	service := NewAuthService(func(string) (string, error) {
+	service := NewAuthService(func(string, string) (string, error) {
 		return "token", nil
 	})
 
@@ -73,7 +77,7 @@ func TestAuthServiceRegisterRejectsDuplicateUsername(t *testing.T) {
 
 func TestAuthServiceLoginRejectsInvalidCredentials(t *testing.T) {
 	setupTestDB(t)
-	service := NewAuthService(func(string) (string, error) {
+	service := NewAuthService(func(string, string) (string, error) {
 		return "token", nil
 	})
 	if _, err := service.Register(RegisterInput{Username: "wanda", Password: "secret"}); err != nil {
@@ -88,7 +92,7 @@ func TestAuthServiceLoginRejectsInvalidCredentials(t *testing.T) {
 
 func TestAuthServiceUpdatesBirthYear(t *testing.T) {
 	setupTestDB(t)
-	service := NewAuthService(func(string) (string, error) {
+	service := NewAuthService(func(string, string) (string, error) {
 		return "token", nil
 	})
 	if _, err := service.Register(RegisterInput{Username: "wanda", Password: "secret"}); err != nil {
@@ -102,3 +106,484 @@ func TestAuthServiceUpdatesBirthYear(t *testing.T) {
 		t.Fatalf("birth year = %v, want 2000", user.BirthYear)
 	}
 }
+
+func TestAuthServiceLoginRecordsSession(t *testing.T) {
+	setupTestDB(t)
+	var issuedTokenID string
+	service := NewAuthService(func(_, tokenID string) (string, error) {
+		issuedTokenID = tokenID
+		return "token", nil
+	})
+	if _, err := service.Register(RegisterInput{Username: "wanda", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := service.Login(LoginInput{
+		Username: "wanda",
+		Password: "secret",
+		Client:   ClientInfo{UserAgent: "curl/8.0", IP: "203.0.113.9"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := service.Sessions(result.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("sessions: got %d want 2 (register + login)", len(sessions))
+	}
+	var login models.Session
+	if err := database.GetDB().Where("token_id = ?", issuedTokenID).First(&login).Error; err != nil {
+		t.Fatal(err)
+	}
+	if login.UserAgent != "curl/8.0" || login.IP != "203.0.113.9" {
+		t.Fatalf("session client: got %q %q", login.UserAgent, login.IP)
+	}
+}
+
+func TestAuthServiceSetPasswordRevokesSessions(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	registered, err := service.Register(RegisterInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Login(LoginInput{Username: "wanda", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.SetPassword(registered.User.ID, "new-secret"); err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := service.Sessions(registered.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("sessions after password change: got %d want 0", len(sessions))
+	}
+	if _, err := service.Login(LoginInput{Username: "wanda", Password: "secret"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("old password: got %v want %v", err, ErrInvalidCredentials)
+	}
+	if _, err := service.Login(LoginInput{Username: "wanda", Password: "new-secret"}); err != nil {
+		t.Fatalf("new password: %v", err)
+	}
+}
+
+func TestAuthServiceRevokeSessionIsLimitedToOwner(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	wanda, err := service.Register(RegisterInput{Username: "wanda", Password: "secret", Email: "wanda@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vision, err := service.Register(RegisterInput{Username: "vision", Password: "secret", Email: "vision@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := service.Sessions(wanda.User.ID)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("sessions: %v %v", sessions, err)
+	}
+
+	if err := service.RevokeSession(vision.User.ID, sessions[0].ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("revoke other user's session: got %v want %v", err, gorm.ErrRecordNotFound)
+	}
+	if err := service.RevokeSession(wanda.User.ID, sessions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthServiceCreateAccessTokenStoresHashOnly(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	registered, err := service.Register(RegisterInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := service.CreateAccessToken(registered.User.ID, CreateAccessTokenInput{
+		Name:   "scale",
+		Scopes: []string{"tracking:write", "tracking:write"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored models.AccessToken
+	if err := database.GetDB().First(&stored, created.AccessToken.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.TokenHash == created.Token || stored.TokenHash == "" {
+		t.Fatal("access token was not stored hashed")
+	}
+	if stored.Scopes != "tracking:write" {
+		t.Fatalf("scopes: got %q", stored.Scopes)
+	}
+
+	token, user, err := AuthenticateAccessToken(created.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.ID != stored.ID || user.ID != registered.User.ID {
+		t.Fatalf("authenticated token %d user %d", token.ID, user.ID)
+	}
+}
+
+func TestAuthServiceCreateAccessTokenRejectsUnknownScope(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	for _, scopes := range [][]string{nil, {"auth:write"}, {"tracking:admin"}, {"tracking"}} {
+		_, err := service.CreateAccessToken(1, CreateAccessTokenInput{Name: "x", Scopes: scopes})
+		if !errors.Is(err, ErrInvalidScope) {
+			t.Fatalf("scopes %v: got %v want %v", scopes, err, ErrInvalidScope)
+		}
+	}
+}
+
+func currentTOTP(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totpCode(key, time.Now().Unix()/totpPeriod)
+}
+
+func TestAuthServiceTwoFactorLogin(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	registered, err := service.Register(RegisterInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enrollment, err := service.BeginTOTPEnrollment(registered.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/") {
+		t.Fatalf("provisioning uri: %s", enrollment.ProvisioningURI)
+	}
+	if _, err := service.ConfirmTOTPEnrollment(registered.User.ID, "abcdef"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("wrong code: got %v want %v", err, ErrInvalidTwoFactorCode)
+	}
+	enrollCode := currentTOTP(t, enrollment.Secret)
+	recoveryCodes, err := service.ConfirmTOTPEnrollment(registered.User.ID, enrollCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("recovery codes: got %d", len(recoveryCodes))
+	}
+
+	result, err := service.Login(LoginInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TwoFactorRequired || result.PendingToken == "" || result.Token != "" {
+		t.Fatalf("password step should only return a pending token: %+v", result)
+	}
+
+	// The code used to enroll cannot be replayed.
+	_, err = service.CompleteTwoFactorLogin(TwoFactorLoginInput{PendingToken: result.PendingToken, Code: enrollCode})
+	if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("replayed code: got %v want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	completed, err := service.CompleteTwoFactorLogin(TwoFactorLoginInput{PendingToken: result.PendingToken, Code: strings.ToUpper(recoveryCodes[0])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed.Token != "token" {
+		t.Fatalf("token: got %q", completed.Token)
+	}
+	if _, err := service.CompleteTwoFactorLogin(TwoFactorLoginInput{PendingToken: result.PendingToken, Code: recoveryCodes[1]}); !errors.Is(err, ErrInvalidPendingLogin) {
+		t.Fatalf("reused pending token: got %v want %v", err, ErrInvalidPendingLogin)
+	}
+
+	second, err := service.Login(LoginInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CompleteTwoFactorLogin(TwoFactorLoginInput{PendingToken: second.PendingToken, Code: recoveryCodes[0]}); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("reused recovery code: got %v want %v", err, ErrInvalidTwoFactorCode)
+	}
+}
+
+func TestAuthServicePendingLoginExpiresAfterTooManyAttempts(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	registered, err := service.Register(RegisterInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enrollment, err := service.BeginTOTPEnrollment(registered.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ConfirmTOTPEnrollment(registered.User.ID, currentTOTP(t, enrollment.Secret)); err != nil {
+		t.Fatal(err)
+	}
+	result, err := service.Login(LoginInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range maxPendingLoginAttempts {
+		if _, err := service.CompleteTwoFactorLogin(TwoFactorLoginInput{PendingToken: result.PendingToken, Code: "bad"}); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("bad code: got %v", err)
+		}
+	}
+	if _, err := service.PendingLoginUsername(result.PendingToken); !errors.Is(err, ErrInvalidPendingLogin) {
+		t.Fatalf("exhausted challenge: got %v want %v", err, ErrInvalidPendingLogin)
+	}
+}
+
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestAuthServiceChangePasswordRequiresCurrentPassword(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	registered, err := service.Register(RegisterInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.ChangePassword(ChangePasswordInput{UserID: registered.User.ID, CurrentPassword: "wrong", NewPassword: "new-secret"})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("wrong current password: got %v want %v", err, ErrInvalidCredentials)
+	}
+	result, err := service.ChangePassword(ChangePasswordInput{UserID: registered.User.ID, CurrentPassword: "secret", NewPassword: "new-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Token == "" {
+		t.Fatal("change password should issue a fresh session token")
+	}
+	sessions, err := service.Sessions(registered.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("sessions after change: got %d want only the new one", len(sessions))
+	}
+}
+
+func TestAuthServicePasswordResetFlow(t *testing.T) {
+	setupTestDB(t)
+	mail := &recordingMailer{}
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	}).WithMailer(mail)
+	if _, err := service.Register(RegisterInput{Username: "wanda", Password: "secret", Email: "wanda@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.RequestPasswordReset(context.Background(), PasswordResetRequest{Identifier: "nobody@example.com", ResetURL: "https://app.example.com/reset"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(mail.sent) != 0 {
+		t.Fatal("unknown account should not receive mail")
+	}
+	if err := service.RequestPasswordReset(context.Background(), PasswordResetRequest{Identifier: "Wanda@Example.com", ResetURL: "https://app.example.com/reset"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(mail.sent) != 1 || mail.sent[0].To != "wanda@example.com" {
+		t.Fatalf("sent: %+v", mail.sent)
+	}
+	_, after, ok := strings.Cut(mail.sent[0].Body, "https://app.example.com/reset?token=")
+	if !ok {
+		t.Fatalf("reset link missing from body: %s", mail.sent[0].Body)
+	}
+	token, _, _ := strings.Cut(after, "\n")
+
+	if err := service.ResetPassword("not-a-token", "new-secret"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("bad token: got %v want %v", err, ErrInvalidResetToken)
+	}
+	if err := service.ResetPassword(token, "new-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ResetPassword(token, "other-secret"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("reused token: got %v want %v", err, ErrInvalidResetToken)
+	}
+	if _, err := service.Login(LoginInput{Username: "wanda", Password: "new-secret"}); err != nil {
+		t.Fatalf("login with reset password: %v", err)
+	}
+}
+
+func TestAuthServicePasswordResetWithoutMailer(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	err := service.RequestPasswordReset(context.Background(), PasswordResetRequest{Identifier: "wanda", ResetURL: "https://app.example.com/reset"})
+	if !errors.Is(err, ErrMailerUnavailable) {
+		t.Fatalf("got %v want %v", err, ErrMailerUnavailable)
+	}
+}
+
+func TestAuthServiceRegisterWithInviteIsSingleUse(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(username, _ string) (string, error) {
+		return "token-" + username, nil
+	})
+	owner, err := service.Register(RegisterInput{Username: "owner", Password: "secret", Email: "owner@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := service.CreateInvite(owner.User.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(created.Code, InvitePrefix) || created.Invite.CodeHash == created.Code {
+		t.Fatalf("invite: %+v", created)
+	}
+
+	if _, err := service.RegisterWithInvite(RegisterInput{Username: "guest", Password: "secret"}, "inv_wrong"); !errors.Is(err, ErrInvalidInvite) {
+		t.Fatalf("wrong code: got %v", err)
+	}
+	// A registration that fails on the username leaves the invite usable.
+	if _, err := service.RegisterWithInvite(RegisterInput{Username: "owner", Password: "secret"}, created.Code); !errors.Is(err, ErrUsernameExists) {
+		t.Fatalf("taken username: got %v", err)
+	}
+	result, err := service.RegisterWithInvite(RegisterInput{Username: "guest", Password: "secret", Email: "guest@example.com"}, strings.ToUpper(created.Code))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.RegisterWithInvite(RegisterInput{Username: "second", Password: "secret"}, created.Code); !errors.Is(err, ErrInvalidInvite) {
+		t.Fatalf("reused code: got %v", err)
+	}
+
+	invites, err := service.Invites(owner.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invites) != 1 || invites[0].UsedByID == nil || *invites[0].UsedByID != result.User.ID {
+		t.Fatalf("invites: %+v", invites)
+	}
+	if err := service.RevokeInvite(owner.User.ID, invites[0].ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("revoking a used invite: got %v", err)
+	}
+}
+
+func TestAuthServiceRegisterWithInviteRejectsExpiredCode(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(username, _ string) (string, error) {
+		return "token-" + username, nil
+	})
+	created, err := service.CreateInvite(1, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.GetDB().Model(&models.Invite{}).Where("id = ?", created.Invite.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.RegisterWithInvite(RegisterInput{Username: "late", Password: "secret"}, created.Code); !errors.Is(err, ErrInvalidInvite) {
+		t.Fatalf("expired code: got %v", err)
+	}
+	if _, err := service.CreateInvite(1, 365*24*time.Hour); !errors.Is(err, ErrInvalidInviteInput) {
+		t.Fatalf("overlong ttl: got %v", err)
+	}
+}
+
+func TestAuthServiceDisableUserBlocksLoginAndTokens(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	user, err := service.CreateUser(RegisterInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Password != "" {
+		t.Fatal("created user contains password")
+	}
+	if sessions, err := service.Sessions(user.ID); err != nil || len(sessions) != 0 {
+		t.Fatalf("CreateUser signed in: %d sessions, err %v", len(sessions), err)
+	}
+	if _, err := service.Login(LoginInput{Username: "wanda", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	created, err := service.CreateAccessToken(user.ID, CreateAccessTokenInput{Name: "cli", Scopes: []string{"diet:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.DisableUser(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, err := service.Sessions(user.ID); err != nil || len(sessions) != 0 {
+		t.Fatalf("sessions after disable: %d, err %v", len(sessions), err)
+	}
+	if _, err := service.Login(LoginInput{Username: "wanda", Password: "secret"}); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("login while disabled: got %v want %v", err, ErrAccountDisabled)
+	}
+	if _, err := service.Login(LoginInput{Username: "wanda", Password: "wrong"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("wrong password while disabled: got %v want %v", err, ErrInvalidCredentials)
+	}
+	if _, _, err := AuthenticateAccessToken(created.Token); !errors.Is(err, ErrInvalidAccessToken) {
+		t.Fatalf("access token while disabled: got %v want %v", err, ErrInvalidAccessToken)
+	}
+
+	if err := service.EnableUser(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Login(LoginInput{Username: "wanda", Password: "secret"}); err != nil {
+		t.Fatalf("login after enable: %v", err)
+	}
+}
+
+func TestAuthServicePreferencesDriveCalendar(t *testing.T) {
+	setupTestDB(t)
+	service := NewAuthService(func(string, string) (string, error) {
+		return "token", nil
+	})
+	registered, err := service.Register(RegisterInput{Username: "wanda", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cal, err := CalendarFor(registered.User.ID); err != nil || cal.Location != time.Local || cal.WeekStart != time.Sunday {
+		t.Fatalf("calendar without preferences = %+v, err %v", cal, err)
+	}
+
+	for _, input := range []PreferencesInput{
+		{Timezone: "Mars/Olympus"},
+		{WeekStart: 7},
+		{DayStartHour: MaxDayStartHour + 1},
+	} {
+		if _, err := service.UpdatePreferences(registered.User.ID, input); !errors.Is(err, ErrInvalidPreferences) {
+			t.Fatalf("UpdatePreferences(%+v): got %v want %v", input, err, ErrInvalidPreferences)
+		}
+	}
+	if _, err := service.UpdatePreferences(registered.User.ID, PreferencesInput{Timezone: "Europe/Berlin", WeekStart: 1, DayStartHour: 3}); err != nil {
+		t.Fatal(err)
+	}
+	cal, err := CalendarFor(registered.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cal.Location.String() != "Europe/Berlin" || cal.WeekStart != time.Monday || cal.DayStartHour != 3 {
+		t.Fatalf("calendar = %+v", cal)
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	InvitePrefix     = "inv_"
+	DefaultInviteTTL = 7 * 24 * time.Hour
+	MaxInviteTTL     = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidInvite      = errors.New("invalid or expired invite code")
+	ErrInvalidInviteInput = errors.New("invalid invite")
+)
+
+type CreatedInvite struct {
+	Code   string
+	Invite models.Invite
+}
+
+func normalizeInviteCode(code string) string {
+	code = strings.TrimSpace(strings.ToLower(code))
+	return InvitePrefix + strings.TrimPrefix(normalizeRecoveryCode(code), InvitePrefix)
+}
+
+// CreateInvite mints a single-use registration code valid for ttl (DefaultInviteTTL when zero).
+// The code is only returned here.
+func (s *AuthService) CreateInvite(userID uint, ttl time.Duration) (CreatedInvite, error) {
+	if ttl == 0 {
+		ttl = DefaultInviteTTL
+	}
+	if ttl < time.Minute || ttl > MaxInviteTTL {
+		return CreatedInvite{}, fmt.Errorf("%w: expiry must be between one minute and %d days", ErrInvalidInviteInput, int(MaxInviteTTL.Hours()/24))
+	}
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return CreatedInvite{}, fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+	secret := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+	code := InvitePrefix + secret[:4] + "-" + secret[4:8] + "-" + secret[8:12] + "-" + secret[12:]
+
+	invite := models.Invite{
+		CreatedByID: userID,
+		CodeHash:    hashSecret(normalizeInviteCode(code)),
+		Prefix:      code[:len(InvitePrefix)+4],
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := authrepo.CreateInvite(&invite); err != nil {
+		return CreatedInvite{}, err
+	}
+	return CreatedInvite{Code: code, Invite: invite}, nil
+}
+
+func (s *AuthService) Invites(userID uint) ([]models.Invite, error) {
+	return authrepo.ListInvites(userID)
+}
+
+func (s *AuthService) RevokeInvite(userID, inviteID uint) error {
+	return authrepo.RevokeInvite(userID, inviteID)
+}
+
+// RegisterWithInvite registers like Register but consumes inviteCode in the same transaction
+// as the user insert. The code is checked first so callers without one learn nothing about
+// which usernames are taken.
+func (s *AuthService) RegisterWithInvite(input RegisterInput, inviteCode string) (AuthResult, error) {
+	codeHash := hashSecret(normalizeInviteCode(inviteCode))
+	if _, err := authrepo.FindUsableInvite(codeHash, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return AuthResult{}, ErrInvalidInvite
+		}
+		return AuthResult{}, err
+	}
+	return s.register(input, func(user *models.User) error {
+		err := authrepo.CreateUserWithInvite(user, codeHash, time.Now())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidInvite
+		}
+		return err
+	})
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
+)
+
+const (
+	DefaultLoginHistoryLimit = 50
+	MaxLoginHistoryLimit     = 200
+	// newDeviceLoginWindow bounds how long an unacknowledged new-device login stays on /auth/me.
+	newDeviceLoginWindow = 30 * 24 * time.Hour
+)
+
+func (s *AuthService) LoginHistory(username string, limit int) ([]models.LoginAttempt, error) {
+	if limit <= 0 {
+		limit = DefaultLoginHistoryLimit
+	}
+	limit = min(limit, MaxLoginHistoryLimit)
+	return authrepo.ListLoginAttempts(username, limit)
+}
+
+// NewDeviceLogins lists recent successful logins from an IP or user agent the account had
+// not used before, until the owner acknowledges them.
+func (s *AuthService) NewDeviceLogins(username string) ([]models.LoginAttempt, error) {
+	return authrepo.ListNewDeviceLogins(username, time.Now().Add(-newDeviceLoginWindow))
+}
+
+func (s *AuthService) AcknowledgeNewDeviceLogins(username string) (int64, error) {
+	return authrepo.AcknowledgeNewDeviceLogins(username, time.Now())
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
+	"be-simpletracker/internal/mailer"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const passwordResetTTL = time.Hour
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	ErrMailerUnavailable = errors.New("password reset email is not configured")
+)
+
+type ChangePasswordInput struct {
+	UserID          uint
+	CurrentPassword string
+	NewPassword     string
+	Client          ClientInfo
+}
+
+type PasswordResetRequest struct {
+	// Identifier is the account's email address or username.
+	Identifier string
+	// ResetURL is the frontend page that accepts the token as its "token" query parameter.
+	ResetURL string
+}
+
+// WithMailer sets the mailer used for password reset emails.
+func (s *AuthService) WithMailer(m mailer.Mailer) *AuthService {
+	s.mailer = m
+	return s
+}
+
+// WithTOTPIssuer sets the issuer authenticator apps show next to the account.
+func (s *AuthService) WithTOTPIssuer(issuer string) *AuthService {
+	s.totpIssuer = issuer
+	return s
+}
+
+// WithEnvironment sets the deployment name reported to the client after sign-in.
+func (s *AuthService) WithEnvironment(environment string) *AuthService {
+	s.environment = environment
+	return s
+}
+
+// Environment is the deployment name, "development" unless WithEnvironment set one.
+func (s *AuthService) Environment() string {
+	return cmp.Or(s.environment, "development")
+}
+
+// ChangePassword checks the current password, signs out every session, and issues a new one
+// for the device that made the change.
+func (s *AuthService) ChangePassword(input ChangePasswordInput) (AuthResult, error) {
+	user, err := authrepo.FindUserByID(input.UserID)
+	if err != nil {
+		return AuthResult{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+		return AuthResult{}, ErrInvalidCredentials
+	}
+	if err := s.SetPassword(user.ID, input.NewPassword); err != nil {
+		return AuthResult{}, err
+	}
+	token, err := s.issueToken(user, input.Client)
+	if err != nil {
+		return AuthResult{}, err
+	}
+	user.Password = ""
+	return AuthResult{Token: token, User: user}, nil
+}
+
+// RequestPasswordReset emails a reset link if the identifier matches an account with an email
+// address. Unknown identifiers are not an error, so callers cannot probe for accounts.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, input PasswordResetRequest) error {
+	if s.mailer == nil {
+		return ErrMailerUnavailable
+	}
+	user, err := authrepo.FindUserByEmail(input.Identifier)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = authrepo.FindUserByUsername(input.Identifier)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Email == "" || user.Disabled() {
+		return nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(secret)
+	if err := authrepo.CreatePasswordResetToken(&models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashSecret(raw),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}); err != nil {
+		return err
+	}
+
+	link, err := url.Parse(input.ResetURL)
+	if err != nil {
+		return fmt.Errorf("invalid reset url: %w", err)
+	}
+	query := link.Query()
+	query.Set("token", raw)
+	link.RawQuery = query.Encode()
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below within the next hour to choose a new password:\n\n%s\n\n"+
+			"If you did not ask for this, you can ignore this email.\n", user.Username, link.String()),
+	})
+}
+
+// ResetPassword spends a reset token and sets the new password, signing out every session.
+func (s *AuthService) ResetPassword(token, newPassword string) error {
+	reset, err := authrepo.ConsumePasswordResetToken(hashSecret(token), time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	return s.SetPassword(reset.UserID, newPassword)
+}
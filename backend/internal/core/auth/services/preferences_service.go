@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
+	"be-simpletracker/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// MaxDayStartHour keeps the start of a day before noon.
+const MaxDayStartHour = 11
+
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+type PreferencesInput struct {
+	Timezone     string
+	WeekStart    int
+	DayStartHour int
+}
+
+// Preferences returns the user's saved preferences, or the defaults when they have none.
+func (s *AuthService) Preferences(userID uint) (models.Preferences, error) {
+	prefs, err := authrepo.FindPreferences(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Preferences{UserID: userID}, nil
+	}
+	return prefs, err
+}
+
+func (s *AuthService) UpdatePreferences(userID uint, input PreferencesInput) (models.Preferences, error) {
+	timezone := strings.TrimSpace(input.Timezone)
+	if _, err := loadLocation(timezone); err != nil {
+		return models.Preferences{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidPreferences, timezone)
+	}
+	if input.WeekStart < int(time.Sunday) || input.WeekStart > int(time.Saturday) {
+		return models.Preferences{}, fmt.Errorf("%w: week_start must be between 0 (Sunday) and 6 (Saturday)", ErrInvalidPreferences)
+	}
+	if input.DayStartHour < 0 || input.DayStartHour > MaxDayStartHour {
+		return models.Preferences{}, fmt.Errorf("%w: day_start_hour must be between 0 and %d", ErrInvalidPreferences, MaxDayStartHour)
+	}
+	prefs, err := s.Preferences(userID)
+	if err != nil {
+		return models.Preferences{}, err
+	}
+	prefs.Timezone = timezone
+	prefs.WeekStart = input.WeekStart
+	prefs.DayStartHour = input.DayStartHour
+	if err := authrepo.SavePreferences(&prefs); err != nil {
+		return models.Preferences{}, err
+	}
+	return prefs, nil
+}
+
+// CalendarFor returns the calendar the user counts days on. The default calendar stands in
+// when they saved none, and alongside the error when they cannot be read.
+func CalendarFor(userID uint) (utils.Calendar, error) {
+	prefs, err := authrepo.FindPreferences(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.DefaultCalendar(), nil
+	}
+	if err != nil {
+		return utils.DefaultCalendar(), err
+	}
+	loc, err := loadLocation(prefs.Timezone)
+	if err != nil {
+		return utils.DefaultCalendar(), err
+	}
+	return utils.Calendar{
+		Location:     loc,
+		DayStartHour: prefs.DayStartHour,
+		WeekStart:    time.Weekday(prefs.WeekStart),
+	}, nil
+}
+
+// locations caches parsed zones; time.LoadLocation reads the zone database on every call.
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ClientInfo describes the device a session is issued to.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// sessionTouchInterval bounds how often an authenticated request writes last_seen_at.
+const sessionTouchInterval = time.Minute
+
+const maxUserAgentLength = 512
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) > maxUserAgentLength {
+		return userAgent[:maxUserAgentLength]
+	}
+	return userAgent
+}
+
+// issueToken records a session for user and signs a token bound to it.
+func (s *AuthService) issueToken(user models.User, client ClientInfo) (string, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+	token, err := s.generateToken(user.Username, tokenID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+	session := models.Session{
+		UserID:     user.ID,
+		TokenID:    tokenID,
+		UserAgent:  truncateUserAgent(client.UserAgent),
+		IP:         client.IP,
+		LastSeenAt: time.Now(),
+	}
+	if err := authrepo.CreateSession(&session); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// TouchSession refreshes a session's last-seen details, at most once per sessionTouchInterval.
+func TouchSession(session models.Session, client ClientInfo) error {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval {
+		return nil
+	}
+	return authrepo.TouchSession(session.ID, client.IP, truncateUserAgent(client.UserAgent), now)
+}
+
+func (s *AuthService) Sessions(userID uint) ([]models.Session, error) {
+	return authrepo.ListSessions(userID)
+}
+
+func (s *AuthService) RevokeSession(userID, sessionID uint) error {
+	return authrepo.RevokeSession(userID, sessionID)
+}
+
+func (s *AuthService) RevokeSessionByTokenID(tokenID string) error {
+	return authrepo.RevokeSessionByTokenID(tokenID)
+}
+
+func (s *AuthService) RevokeAllSessions(userID uint) (int64, error) {
+	return authrepo.RevokeAllSessions(userID)
+}
+
+// SetPassword replaces userID's password and signs out every device they were using.
+func (s *AuthService) SetPassword(userID uint, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPasswordHash, err)
+	}
+	if err := authrepo.UpdateUserPassword(userID, string(hashedPassword)); err != nil {
+		return err
+	}
+	_, err = authrepo.RevokeAllSessions(userID)
+	return err
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTOTPCodeMatchesRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		if got := totpCode(key, unix/totpPeriod); got != want {
+			t.Fatalf("t=%d: got %s want %s", unix, got, want)
+		}
+	}
+}
+
+func TestMatchTOTPAllowsOneStepOfDrift(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(59, 0)
+	if _, ok := matchTOTP(secret, "287082", now.Add(totpPeriod*time.Second)); !ok {
+		t.Fatal("previous step should match")
+	}
+	if _, ok := matchTOTP(secret, "287082", now.Add(3*totpPeriod*time.Second)); ok {
+		t.Fatal("code three steps old should not match")
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"cmp"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"be-simpletracker/internal/core/auth/models"
+	authrepo "be-simpletracker/internal/core/auth/repository"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	pendingLoginTTL         = 5 * time.Minute
+	maxPendingLoginAttempts = 5
+	recoveryCodeCount       = 10
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotPending     = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidPendingLogin     = errors.New("login session expired, sign in again")
+)
+
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+type TwoFactorLoginInput struct {
+	PendingToken string
+	Code         string
+	Client       ClientInfo
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// hashSecret digests high-entropy, server-generated secrets (access tokens, recovery codes,
+// pending-login tokens) for lookup by hash.
+func hashSecret(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// BeginTOTPEnrollment stores a fresh secret for userID without enabling it, so an abandoned
+// enrollment never locks the user out.
+func (s *AuthService) BeginTOTPEnrollment(userID uint) (TOTPEnrollment, error) {
+	user, err := authrepo.FindUserByID(userID)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if user.TOTPEnabled {
+		return TOTPEnrollment{}, ErrTwoFactorAlreadyEnabled
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if err := authrepo.SetUserTOTP(userID, secret, false); err != nil {
+		return TOTPEnrollment{}, err
+	}
+	issuer := cmp.Or(s.totpIssuer, "Simple Track")
+	return TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment enables 2FA once the user proves their authenticator works, and
+// returns the recovery codes. They are only ever shown here.
+func (s *AuthService) ConfirmTOTPEnrollment(userID uint, code string) ([]string, error) {
+	user, err := authrepo.FindUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotPending
+	}
+	step, ok := matchTOTP(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	codes, err := s.regenerateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authrepo.SetUserTOTP(userID, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+	if _, err := authrepo.ClaimTOTPStep(userID, step); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP turns 2FA off after re-checking both the password and a current code.
+func (s *AuthService) DisableTOTP(userID uint, password, code string) error {
+	user, err := authrepo.FindUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	if err := verifySecondFactor(user, code); err != nil {
+		return err
+	}
+	if err := authrepo.SetUserTOTP(userID, "", false); err != nil {
+		return err
+	}
+	return authrepo.ReplaceRecoveryCodes(userID, nil)
+}
+
+func (s *AuthService) regenerateRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashSecret(normalizeRecoveryCode(code)))
+	}
+	if err := authrepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifySecondFactor accepts a current TOTP code or an unused recovery code. Each is single-use.
+func verifySecondFactor(user models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if step, ok := matchTOTP(user.TOTPSecret, code, time.Now()); ok {
+		claimed, err := authrepo.ClaimTOTPStep(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+	used, err := authrepo.UseRecoveryCode(user.ID, hashSecret(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *AuthService) startLoginChallenge(user models.User) (string, error) {
+	now := time.Now()
+	if err := authrepo.DeleteExpiredLoginChallenges(now); err != nil {
+		return "", err
+	}
+	pendingToken, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	}
+	challenge := models.LoginChallenge{
+		UserID:    user.ID,
+		TokenHash: hashSecret(pendingToken),
+		ExpiresAt: now.Add(pendingLoginTTL),
+	}
+	if err := authrepo.CreateLoginChallenge(&challenge); err != nil {
+		return "", err
+	}
+	return pendingToken, nil
+}
+
+func findLoginChallenge(pendingToken string) (models.LoginChallenge, models.User, error) {
+	challenge, err := authrepo.FindLoginChallenge(hashSecret(pendingToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.LoginChallenge{}, models.User{}, ErrInvalidPendingLogin
+	}
+	if err != nil {
+		return models.LoginChallenge{}, models.User{}, err
+	}
+	if !time.Now().Before(challenge.ExpiresAt) || challenge.Attempts >= maxPendingLoginAttempts {
+		return models.LoginChallenge{}, models.User{}, ErrInvalidPendingLogin
+	}
+	user, err := authrepo.FindUserByID(challenge.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.LoginChallenge{}, models.User{}, ErrInvalidPendingLogin
+	}
+	if err != nil {
+		return models.LoginChallenge{}, models.User{}, err
+	}
+	return challenge, user, nil
+}
+
+// PendingLoginUsername resolves a pending-2FA token so the caller can rate limit by username
+// before a code is checked.
+func (s *AuthService) PendingLoginUsername(pendingToken string) (string, error) {
+	_, user, err := findLoginChallenge(pendingToken)
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+// CompleteTwoFactorLogin finishes a login started by Login that returned TwoFactorRequired.
+func (s *AuthService) CompleteTwoFactorLogin(input TwoFactorLoginInput) (AuthResult, error) {
+	challenge, user, err := findLoginChallenge(input.PendingToken)
+	if err != nil {
+		return AuthResult{}, err
+	}
+	if user.Disabled() {
+		return AuthResult{}, ErrAccountDisabled
+	}
+	if err := verifySecondFactor(user, input.Code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			if incErr := authrepo.IncrementLoginChallengeAttempts(challenge.ID); incErr != nil {
+				return AuthResult{}, incErr
+			}
+		}
+		return AuthResult{}, err
+	}
+	if err := authrepo.DeleteLoginChallenge(challenge.ID); err != nil {
+		return AuthResult{}, err
+	}
+
+	token, err := s.issueToken(user, input.Client)
+	if err != nil {
+		return AuthResult{}, err
+	}
+	user.Password = ""
+	return AuthResult{Token: token, User: user}, nil
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"errors"
+
+	"be-simpletracker/internal/config"
+)
+
+// settings is what Configure was given: the auth settings, whether this is a production
+// deployment, and the signing keys built from them.
+var settings struct {
+	config.Auth
+	production bool
+	keyring    *Keyring
+}
+
+// Configure sets the auth settings the middleware, cookies and tokens use. Commands call it
+// once at startup, before serving requests or signing tokens.
+func Configure(cfg *config.Config) error {
+	ring, err := LoadKeyring(cfg.Auth)
+	if err != nil {
+		return err
+	}
+	settings.Auth = cfg.Auth
+	settings.production = cfg.IsProduction()
+	settings.keyring = ring
+	return nil
+}
+
+func currentKeyring() (*Keyring, error) {
+	if settings.keyring == nil {
+		return nil, errors.New("auth: signing keys not configured")
+	}
+	return settings.keyring, nil
+}
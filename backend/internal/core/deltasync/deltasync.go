@@ -0,0 +1,131 @@
+// Package deltasync lets a client that keeps its own copy of the tracker work offline. Pull
+// returns what changed in an account since a cursor, by updated_at and deleted_at; Push
+// applies the client's queued writes one at a time and says which of them lost to a change
+// made elsewhere. Rows travel keyed by column name, as in an export.
+package deltasync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	dietmodels "be-simpletracker/internal/core/diet/models"
+	"be-simpletracker/internal/core/tracking/grocery"
+	"be-simpletracker/internal/core/tracking/profile"
+	"be-simpletracker/internal/core/tracking/steps"
+	"be-simpletracker/internal/core/tracking/water"
+	"be-simpletracker/internal/core/tracking/weight"
+	workoutmodels "be-simpletracker/internal/core/workout/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	DefaultLimit = 1000
+	MaxLimit     = 5000
+
+	// settleWindow holds back the newest changes from a pull. A transaction stamps updated_at
+	// before it commits, so a row can become visible with a time behind one already handed
+	// out; waiting this long before moving the cursor past a time lets those land first.
+	settleWindow = 2 * time.Second
+
+	ownerColumn = "user_id"
+)
+
+var ErrUnknownTable = errors.New("unknown table")
+
+// table is an entity clients keep a copy of. Only push tables accept writes: the rest are
+// edited through their own endpoints, which check more than a column can.
+type table struct {
+	name  string
+	model any
+	push  bool
+	// key is the column set one account holds at most one live row for. A create that
+	// collides on it conflicts with the row already there.
+	key []string
+}
+
+// tables are those with both timestamps. Workout plan days and exercises are replaced
+// wholesale and hard-deleted, so clients fetch a plan through /workout instead.
+var tables = []table{
+	{name: "plans", model: &dietmodels.Plan{}},
+	{name: "foods", model: &dietmodels.Food{}},
+	{name: "composite_foods", model: &dietmodels.CompositeFood{}},
+	{name: "composite_food_items", model: &dietmodels.CompositeFoodItem{}},
+	{name: "meals", model: &dietmodels.Meal{}, push: true},
+	{name: "meal_items", model: &dietmodels.MealItem{}, push: true},
+	{name: "saved_meals", model: &dietmodels.SavedMeal{}},
+	{name: "saved_meal_items", model: &dietmodels.SavedMealItem{}},
+	{name: "days", model: &dietmodels.DietDay{}},
+	{name: "planned_meals", model: &dietmodels.PlannedMeal{}},
+	{name: "day_logs", model: &dietmodels.DayLog{}, push: true},
+
+	{name: "exercises", model: &workoutmodels.Exercise{}},
+	{name: "workout_programs", model: &workoutmodels.WorkoutProgram{}},
+	{name: "workout_plans", model: &workoutmodels.WorkoutPlan{}},
+	{name: "workout_logs", model: &workoutmodels.WorkoutLog{}, push: true},
+	{name: "logged_exercises", model: &workoutmodels.LoggedExercise{}, push: true},
+	{name: "logged_sets", model: &workoutmodels.LoggedSet{}, push: true},
+	{name: "cardios", model: &workoutmodels.Cardio{}, push: true, key: []string{"workout_log_id"}},
+
+	{name: "grocery_items", model: &grocery.GroceryItem{}, push: true},
+	{name: "step_logs", model: &steps.StepLog{}, push: true, key: []string{"date"}},
+	{name: "body_weight_logs", model: &weight.BodyWeightLog{}, push: true, key: []string{"date"}},
+	{name: "drink_size_presets", model: &water.DrinkSizePreset{}},
+	{name: "water_logs", model: &water.WaterLog{}, push: true},
+	{name: "user_profiles", model: &profile.UserProfile{}},
+}
+
+// Tables names the synced tables of enabled modules and whether each takes pushed writes.
+func Tables(enabled func(table string) bool) map[string]bool {
+	out := map[string]bool{}
+	for _, t := range enabledTables(enabled) {
+		out[t.name] = t.push
+	}
+	return out
+}
+
+func enabledTables(enabled func(table string) bool) []table {
+	var out []table
+	for _, t := range tables {
+		if enabled(t.name) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func lookup(enabled func(table string) bool, name string) (table, error) {
+	for _, t := range enabledTables(enabled) {
+		if t.name == name {
+			return t, nil
+		}
+	}
+	return table{}, fmt.Errorf("%w %q", ErrUnknownTable, name)
+}
+
+// Row is one row keyed by column name, without its owner or deletion time.
+type Row map[string]any
+
+func parseSchema(db *gorm.DB, model any) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+func toRow(ctx context.Context, sch *schema.Schema, rv reflect.Value) Row {
+	rv = reflect.Indirect(rv)
+	row := Row{}
+	for _, name := range sch.DBNames {
+		if name == ownerColumn || name == "deleted_at" {
+			continue
+		}
+		row[name] = sch.FieldsByDBName[name].ReflectValueOf(ctx, rv).Interface()
+	}
+	return row
+}
@@ -0,0 +1,254 @@
+package deltasync
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"be-simpletracker/internal/core/module"
+	"be-simpletracker/internal/core/tracking/grocery"
+	"be-simpletracker/internal/core/tracking/steps"
+	workoutmodels "be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/dbtest"
+
+	"gorm.io/gorm"
+)
+
+const (
+	alice uint = 1
+	bob   uint = 2
+)
+
+// everyTable leaves every module enabled.
+func everyTable(string) bool { return true }
+
+func setupSyncDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := dbtest.Open(t)
+	modules, err := module.NewRegistry(module.All(db), module.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := modules.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func create(t *testing.T, db *gorm.DB, value any) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// backdate moves a column of every row of table to when, as if the write had happened then.
+func backdate(t *testing.T, db *gorm.DB, table, column string, when time.Time) {
+	t.Helper()
+	if err := db.Exec("UPDATE "+table+" SET "+column+" = ? WHERE "+column+" IS NOT NULL", when).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pull(t *testing.T, ctx context.Context, db *gorm.DB, since string, limit int) Changes {
+	t.Helper()
+	changes, err := Pull(ctx, db, everyTable, since, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return changes
+}
+
+func TestPullReturnsChangesSinceCursor(t *testing.T) {
+	db := setupSyncDB(t)
+	ctx := database.WithUserID(context.Background(), alice)
+	conn := db.WithContext(ctx)
+	milk := grocery.GroceryItem{Name: "Milk"}
+	create(t, conn, &milk)
+	create(t, conn, &grocery.GroceryItem{Name: "Eggs"})
+	walk := steps.StepLog{Date: database.Date{Year: 2026, Month: time.October, Day: 1}, Steps: 9000}
+	create(t, conn, &walk)
+	create(t, db.WithContext(database.WithUserID(context.Background(), bob)), &grocery.GroceryItem{Name: "Bread"})
+	long := time.Now().Add(-time.Hour)
+	for _, table := range []string{"grocery_items", "step_logs"} {
+		backdate(t, db, table, "created_at", long)
+		backdate(t, db, table, "updated_at", long)
+	}
+
+	first := pull(t, ctx, db, "", DefaultLimit)
+	if first.More || len(first.Tables["grocery_items"].Created) != 2 || len(first.Tables["step_logs"].Created) != 1 {
+		t.Fatalf("first pull = %+v", first.Tables)
+	}
+	for _, row := range first.Tables["grocery_items"].Created {
+		if row["name"] == "Bread" {
+			t.Fatal("pulled another user's row")
+		}
+		if _, ok := row[ownerColumn]; ok {
+			t.Fatal("row carries its owner")
+		}
+	}
+
+	edited := time.Now().Add(-time.Minute)
+	if err := conn.Model(&milk).Update("name", "Oat milk").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Delete(&walk).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("UPDATE grocery_items SET updated_at = ? WHERE id = ?", edited, milk.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, db, "step_logs", "deleted_at", edited)
+
+	// The edits land after a cursor from before them, as they would on a later pull.
+	second := pull(t, ctx, db, encodeCursor(long.Add(time.Minute)), DefaultLimit)
+	groceries := second.Tables["grocery_items"]
+	if groceries == nil || len(groceries.Created) != 0 || len(groceries.Updated) != 1 || groceries.Updated[0]["name"] != "Oat milk" {
+		t.Fatalf("second pull grocery_items = %+v", groceries)
+	}
+	if deleted := second.Tables["step_logs"]; deleted == nil || len(deleted.Deleted) != 1 || deleted.Deleted[0] != walk.ID {
+		t.Fatalf("second pull step_logs = %+v", deleted)
+	}
+	if third := pull(t, ctx, db, second.Cursor, DefaultLimit); len(third.Tables) != 0 {
+		t.Fatalf("third pull = %+v", third.Tables)
+	}
+
+	paged := pull(t, ctx, db, "", 1)
+	if !paged.More || len(paged.Tables) != 1 {
+		t.Fatalf("paged pull = %+v", paged)
+	}
+	rest := pull(t, ctx, db, paged.Cursor, DefaultLimit)
+	if rest.More || len(rest.Tables["grocery_items"].Updated) != 1 {
+		t.Fatalf("rest = %+v", rest.Tables)
+	}
+	if _, err := Pull(ctx, db, everyTable, "not a cursor!", DefaultLimit); err != ErrInvalidCursor {
+		t.Fatalf("bad cursor: %v", err)
+	}
+}
+
+func values(t *testing.T, v map[string]any) map[string]json.RawMessage {
+	t.Helper()
+	out := map[string]json.RawMessage{}
+	for k, x := range v {
+		raw, err := json.Marshal(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[k] = raw
+	}
+	return out
+}
+
+func TestPushAppliesMutationsAndReportsConflicts(t *testing.T) {
+	db := setupSyncDB(t)
+	ctx := database.WithUserID(context.Background(), alice)
+	conn := db.WithContext(ctx)
+	day := database.Date{Year: 2026, Month: time.October, Day: 2}
+	nextDay := day.AddDays(1)
+	create(t, conn, &steps.StepLog{Date: day, Steps: 4000})
+	milk := grocery.GroceryItem{Name: "Milk"}
+	create(t, conn, &milk)
+	seen := milk.UpdatedAt.Add(-time.Minute)
+	if err := conn.Model(&milk).Update("name", "Whole milk").Error; err != nil {
+		t.Fatal(err)
+	}
+	var other workoutmodels.WorkoutLog
+	create(t, db.WithContext(database.WithUserID(context.Background(), bob)), &other)
+
+	mutations := []Mutation{
+		{Op: OpCreate, Table: "workout_logs", ClientID: "w1", Values: values(t, map[string]any{"date": day})},
+		{Op: OpCreate, Table: "cardios", ClientID: "c1", Values: values(t, map[string]any{"workout_log_id": "w1", "minutes": 20, "type": "bike"})},
+		{Op: OpCreate, Table: "workout_logs", ClientID: "w1", Values: values(t, map[string]any{"date": day})},
+		{Op: OpUpdate, Table: "grocery_items", ID: milk.ID, BaseUpdatedAt: &seen, Values: values(t, map[string]any{"name": "Skim milk"})},
+		{Op: OpCreate, Table: "step_logs", ClientID: "s1", Values: values(t, map[string]any{"date": day, "steps": 6000})},
+		{Op: OpCreate, Table: "foods", ClientID: "f1", Values: values(t, map[string]any{"name": "Rice"})},
+		{Op: OpCreate, Table: "cardios", ClientID: "c2", Values: values(t, map[string]any{"workout_log_id": other.ID})},
+		{Op: OpUpdate, Table: "cardios", ClientID: "c1", Values: values(t, map[string]any{"user_id": bob})},
+		{Op: OpDelete, Table: "cardios", ClientID: "c1"},
+		// Logged, deleted and logged again offline: the second entry takes the deleted one's place.
+		{Op: OpCreate, Table: "step_logs", ClientID: "s2", Values: values(t, map[string]any{"date": nextDay, "steps": 3000})},
+		{Op: OpDelete, Table: "step_logs", ClientID: "s2"},
+		{Op: OpCreate, Table: "step_logs", ClientID: "s3", Values: values(t, map[string]any{"date": nextDay, "steps": 7000})},
+	}
+	results, err := Push(ctx, db, everyTable, mutations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		StatusApplied, StatusApplied, StatusApplied, StatusConflict, StatusConflict,
+		StatusRejected, StatusRejected, StatusRejected, StatusApplied,
+		StatusApplied, StatusApplied, StatusApplied,
+	}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Errorf("mutation %d: %s (%s), want %s", i, results[i].Status, results[i].Error, status)
+		}
+	}
+	if results[1].Row["workout_log_id"] != results[0].ID {
+		t.Errorf("cardio points at %v, want workout log %d", results[1].Row["workout_log_id"], results[0].ID)
+	}
+	if results[2].ID != results[0].ID {
+		t.Errorf("retried create made row %d, first was %d", results[2].ID, results[0].ID)
+	}
+	if results[3].Row["name"] != "Whole milk" {
+		t.Errorf("conflict row = %v", results[3].Row)
+	}
+	var logs int64
+	if err := conn.Model(&workoutmodels.WorkoutLog{}).Count(&logs).Error; err != nil || logs != 1 {
+		t.Fatalf("alice has %d workout logs, %v", logs, err)
+	}
+	var relogged steps.StepLog
+	if err := conn.Where("date = ?", nextDay).Take(&relogged).Error; err != nil {
+		t.Fatalf("step log logged again: %v", err)
+	}
+	if relogged.ID != results[9].ID || results[11].ID != relogged.ID || relogged.Steps != 7000 {
+		t.Fatalf("step log %+v, results %+v and %+v", relogged, results[9], results[11])
+	}
+
+	base := results[3].Row["updated_at"].(time.Time)
+	again, err := Push(ctx, db, everyTable, []Mutation{
+		{Op: OpUpdate, Table: "grocery_items", ID: milk.ID, BaseUpdatedAt: &base, Values: values(t, map[string]any{"name": "Skim milk"})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[0].Status != StatusApplied || again[0].Row["name"] != "Skim milk" {
+		t.Fatalf("update on fresh base = %+v", again[0])
+	}
+}
+
+func TestDisabledModuleIsLeftOutOfSync(t *testing.T) {
+	db := setupSyncDB(t)
+	ctx := database.WithUserID(context.Background(), alice)
+	create(t, db.WithContext(ctx), &grocery.GroceryItem{Name: "Milk"})
+	create(t, db.WithContext(ctx), &workoutmodels.WorkoutLog{})
+	long := time.Now().Add(-time.Hour)
+	for _, table := range []string{"grocery_items", "workout_logs"} {
+		backdate(t, db, table, "created_at", long)
+		backdate(t, db, table, "updated_at", long)
+	}
+	noWorkout := func(table string) bool { return table == "grocery_items" }
+
+	changes, err := Pull(ctx, db, noWorkout, "", DefaultLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := changes.Tables["workout_logs"]; ok || len(changes.Tables["grocery_items"].Created) != 1 {
+		t.Fatalf("pull = %+v", changes.Tables)
+	}
+	results, err := Push(ctx, db, noWorkout, []Mutation{
+		{Op: OpCreate, Table: "workout_logs", ClientID: "w1", Values: values(t, map[string]any{"date": "2026-10-01"})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != StatusRejected {
+		t.Fatalf("push to a disabled table = %+v", results[0])
+	}
+}
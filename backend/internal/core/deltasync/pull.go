@@ -0,0 +1,135 @@
+package deltasync
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Changes is one page of what changed in an account. Cursor goes back as since on the next
+// pull; More says the page was cut short and the client should pull again straight away.
+// Rows at the edge of a page can come round twice, so clients apply them as upserts.
+type Changes struct {
+	Cursor string                   `json:"cursor"`
+	More   bool                     `json:"more"`
+	Tables map[string]*TableChanges `json:"tables"`
+}
+
+// TableChanges splits a table's changed rows by what happened to them since the cursor. A
+// row created and then edited counts as created.
+type TableChanges struct {
+	Created []Row  `json:"created,omitempty"`
+	Updated []Row  `json:"updated,omitempty"`
+	Deleted []uint `json:"deleted,omitempty"`
+}
+
+// Cursors are opaque to clients; inside they are the last change time handed out.
+func encodeCursor(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 36)
+}
+
+func parseCursor(cursor string) (time.Time, error) {
+	n, err := strconv.ParseInt(cursor, 36, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return time.Unix(0, n), nil
+}
+
+// Pull returns about limit rows changed in the account in ctx after since, oldest changes
+// first, from the tables enabled reports as belonging to an enabled module. An empty since
+// starts a full copy, which leaves out deleted rows.
+func Pull(ctx context.Context, db *gorm.DB, enabled func(table string) bool, since string, limit int) (Changes, error) {
+	initial := since == ""
+	var from time.Time
+	if !initial {
+		var err error
+		if from, err = parseCursor(since); err != nil {
+			return Changes{}, err
+		}
+	}
+	horizon := time.Now().Add(-settleWindow)
+	changes := Changes{Cursor: since, Tables: map[string]*TableChanges{}}
+	if !initial && !horizon.After(from) {
+		return changes, nil
+	}
+
+	db = db.WithContext(ctx)
+	changed := func(t table, until time.Time) *gorm.DB {
+		q := db.Unscoped().Model(t.model).Where("COALESCE(deleted_at, updated_at) <= ?", until)
+		if initial {
+			return q.Where("deleted_at IS NULL")
+		}
+		return q.Where("COALESCE(deleted_at, updated_at) > ?", from)
+	}
+
+	// Cut the page at the limit-th oldest change across all tables. Rows sharing that time
+	// all go in this page, so the next one can start strictly after it.
+	type stamp struct {
+		UpdatedAt time.Time
+		DeletedAt gorm.DeletedAt
+	}
+	var stamps []time.Time
+	for _, t := range enabledTables(enabled) {
+		var page []stamp
+		err := changed(t, horizon).
+			Select("updated_at", "deleted_at").
+			Order("COALESCE(deleted_at, updated_at)").
+			Limit(limit + 1).
+			Find(&page).Error
+		if err != nil {
+			return Changes{}, err
+		}
+		for _, s := range page {
+			if s.DeletedAt.Valid {
+				stamps = append(stamps, s.DeletedAt.Time)
+			} else {
+				stamps = append(stamps, s.UpdatedAt)
+			}
+		}
+	}
+	until := horizon
+	if len(stamps) > limit {
+		sort.Slice(stamps, func(i, j int) bool { return stamps[i].Before(stamps[j]) })
+		until = stamps[limit-1]
+		changes.More = true
+	}
+
+	for _, t := range enabledTables(enabled) {
+		sch, err := parseSchema(db, t.model)
+		if err != nil {
+			return Changes{}, err
+		}
+		rows := reflect.New(reflect.SliceOf(sch.ModelType))
+		if err := changed(t, until).Order("id").Find(rows.Interface()).Error; err != nil {
+			return Changes{}, err
+		}
+		rows = rows.Elem()
+		if rows.Len() == 0 {
+			continue
+		}
+		tc := &TableChanges{}
+		for i := 0; i < rows.Len(); i++ {
+			rv := rows.Index(i)
+			created := sch.LookUpField("CreatedAt").ReflectValueOf(ctx, rv).Interface().(time.Time)
+			switch {
+			case deletedAt(ctx, sch, rv).Valid:
+				tc.Deleted = append(tc.Deleted, primaryKey(ctx, sch, rv))
+			case initial || created.After(from):
+				tc.Created = append(tc.Created, toRow(ctx, sch, rv))
+			default:
+				tc.Updated = append(tc.Updated, toRow(ctx, sch, rv))
+			}
+		}
+		changes.Tables[t.name] = tc
+	}
+	changes.Cursor = encodeCursor(until)
+	return changes, nil
+}
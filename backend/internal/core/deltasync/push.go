@@ -0,0 +1,422 @@
+package deltasync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"be-simpletracker/internal/core/archive"
+	"be-simpletracker/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+
+	StatusApplied  = "applied"
+	StatusConflict = "conflict"
+	StatusRejected = "rejected"
+
+	MaxMutations      = 500
+	maxClientIDLength = 128
+)
+
+var ErrTooManyMutations = fmt.Errorf("a push holds at most %d mutations", MaxMutations)
+
+// Mutation is one write the client made offline. Rows it created carry a ClientID of its own
+// choosing; later mutations name them by that ID, or by the server ID once a push has
+// returned it. Values are keyed by column name, and a reference column may hold a client ID
+// in place of a row ID. BaseUpdatedAt is the updated_at the client last saw: an update or
+// delete of a row that has changed since is a conflict and is not applied.
+type Mutation struct {
+	Op            string                     `json:"op"`
+	Table         string                     `json:"table"`
+	ID            uint                       `json:"id,omitempty"`
+	ClientID      string                     `json:"client_id,omitempty"`
+	BaseUpdatedAt *time.Time                 `json:"base_updated_at,omitempty"`
+	Values        map[string]json.RawMessage `json:"values,omitempty"`
+}
+
+// Result is what became of the mutation at Index. Row is the row as it now stands: after
+// the write when applied, the server's copy on a conflict, and absent once deleted.
+type Result struct {
+	Index    int    `json:"index"`
+	Table    string `json:"table"`
+	ClientID string `json:"client_id,omitempty"`
+	ID       uint   `json:"id,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Row      Row    `json:"row,omitempty"`
+}
+
+// clientMapping is the row a client ID became.
+type clientMapping struct {
+	UserID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	ClientID  string `gorm:"primaryKey"`
+	Entity    string
+	RowID     uint
+	CreatedAt time.Time
+}
+
+func (clientMapping) TableName() string { return "sync_client_ids" }
+
+// rejection is a mutation the server will not apply as sent, so sending it again is pointless.
+type rejection string
+
+func (r rejection) Error() string { return string(r) }
+
+func rejectf(format string, args ...any) error {
+	return rejection(fmt.Sprintf(format, args...))
+}
+
+// readOnly columns are kept by the server whatever a client sends.
+var readOnly = map[string]bool{
+	"id": true, ownerColumn: true, "created_at": true, "updated_at": true, "deleted_at": true, "version": true,
+}
+
+// Push applies mutations in order for the user in ctx, each in its own transaction, so a
+// conflict or rejection leaves the rest of the batch going through. Creating with a client ID
+// already seen applies nothing and returns the row made the first time, which makes a push
+// safe to retry. A mutation for a table enabled does not report as belonging to an enabled
+// module is rejected. A mutation the database refuses is rolled back and reported as rejected, so
+// the client still learns what became of the others; an error means ctx ended part way, and
+// the results so far are returned with it.
+func Push(ctx context.Context, db *gorm.DB, enabled func(table string) bool, mutations []Mutation) ([]Result, error) {
+	if len(mutations) > MaxMutations {
+		return nil, ErrTooManyMutations
+	}
+	userID, ok := database.UserIDFrom(ctx)
+	if !ok {
+		return nil, errors.New("push needs a user")
+	}
+	p := pusher{ctx: ctx, db: db.WithContext(ctx), enabled: enabled, userID: userID, refs: map[string]map[string]string{}}
+	for _, ref := range archive.References() {
+		if p.refs[ref.Table] == nil {
+			p.refs[ref.Table] = map[string]string{}
+		}
+		p.refs[ref.Table][ref.Column] = ref.Parent
+	}
+	results := make([]Result, 0, len(mutations))
+	for i, m := range mutations {
+		res := Result{Index: i, Table: m.Table, ClientID: m.ClientID, ID: m.ID}
+		err := p.apply(m, &res)
+		var rejected rejection
+		switch {
+		case errors.As(err, &rejected):
+			res.Status, res.Error, res.Row = StatusRejected, rejected.Error(), nil
+		case err != nil && ctx.Err() != nil:
+			return results, fmt.Errorf("mutation %d: %w", i, err)
+		case err != nil:
+			slog.Error("sync push mutation failed", "table", m.Table, "op", m.Op, "err", err)
+			res.Status, res.Error, res.Row = StatusRejected, "the database refused this change", nil
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+type pusher struct {
+	ctx     context.Context
+	db      *gorm.DB
+	enabled func(table string) bool
+	userID  uint
+	// refs maps a table's reference columns to the tables they point at.
+	refs map[string]map[string]string
+}
+
+func (p *pusher) apply(m Mutation, res *Result) error {
+	t, err := lookup(p.enabled, m.Table)
+	if err != nil {
+		return rejection(err.Error())
+	}
+	if !t.push {
+		return rejectf("%s does not take pushed writes", t.name)
+	}
+	sch, err := parseSchema(p.db, t.model)
+	if err != nil {
+		return err
+	}
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		switch m.Op {
+		case OpCreate:
+			return p.create(tx, t, sch, m, res)
+		case OpUpdate, OpDelete:
+			return p.change(tx, t, sch, m, res)
+		default:
+			return rejectf("unknown op %q", m.Op)
+		}
+	})
+}
+
+func (p *pusher) create(tx *gorm.DB, t table, sch *schema.Schema, m Mutation, res *Result) error {
+	if m.ClientID == "" || len(m.ClientID) > maxClientIDLength {
+		return rejectf("create needs a client_id of at most %d characters", maxClientIDLength)
+	}
+	if m.ID != 0 {
+		return rejectf("create takes no id; the server assigns it")
+	}
+	mapped, err := p.mapping(tx, m.ClientID)
+	if err == nil {
+		if mapped.Entity != t.name {
+			return rejectf("client_id %q already names a row in %s", m.ClientID, mapped.Entity)
+		}
+		res.ID, res.Status = mapped.RowID, StatusApplied
+		row, err := p.load(tx, sch, mapped.RowID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && !deletedAt(p.ctx, sch, row).Valid {
+			res.Row = toRow(p.ctx, sch, row)
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	row := reflect.New(sch.ModelType)
+	if _, err := p.assign(tx, t, sch, row, m.Values); err != nil {
+		return err
+	}
+	var id uint
+	if len(t.key) > 0 {
+		// The unique indexes cover deleted rows too, so look past the trash.
+		q := tx.Unscoped().Model(t.model)
+		for _, column := range t.key {
+			q = q.Where(column+" = ?", sch.FieldsByDBName[column].ReflectValueOf(p.ctx, row.Elem()).Interface())
+		}
+		existing := reflect.New(sch.ModelType)
+		err := q.Take(existing.Interface()).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && !deletedAt(p.ctx, sch, existing).Valid {
+			res.ID = primaryKey(p.ctx, sch, existing)
+			res.Status, res.Row = StatusConflict, toRow(p.ctx, sch, existing)
+			res.Error = "a row with the same " + strings.Join(t.key, ", ") + " already exists"
+			return nil
+		}
+		if err == nil {
+			id = primaryKey(p.ctx, sch, existing)
+		}
+	}
+	if id != 0 {
+		if err := p.revive(tx, t, sch, row, id); err != nil {
+			return err
+		}
+	} else {
+		if err := tx.Omit(clause.Associations).Create(row.Interface()).Error; err != nil {
+			return err
+		}
+		id = primaryKey(p.ctx, sch, row)
+	}
+	err = tx.Create(&clientMapping{UserID: p.userID, ClientID: m.ClientID, Entity: t.name, RowID: id}).Error
+	if err != nil {
+		return err
+	}
+	res.ID, res.Status, res.Row = id, StatusApplied, toRow(p.ctx, sch, row)
+	return nil
+}
+
+// revive puts row in the place of the deleted row id holding the same key, as a client does
+// when it deletes a day's entry and logs that day again. Every column takes row's value, so
+// the result is what creating it would have made; row is reloaded as stored.
+func (p *pusher) revive(tx *gorm.DB, t table, sch *schema.Schema, row reflect.Value, id uint) error {
+	sch.PrioritizedPrimaryField.ReflectValueOf(p.ctx, row.Elem()).SetUint(uint64(id))
+	err := tx.Unscoped().Model(row.Interface()).
+		Select("*").Omit("id", ownerColumn, "created_at", "version", clause.Associations).
+		Updates(row.Interface()).Error
+	if err != nil {
+		return err
+	}
+	if sch.LookUpField("Version") != nil {
+		if _, err := database.ClaimVersion(tx, t.model, id); err != nil {
+			return err
+		}
+	}
+	return tx.Where("id = ?", id).Take(row.Interface()).Error
+}
+
+// change updates or deletes an existing row, unless it has moved on since m.BaseUpdatedAt.
+func (p *pusher) change(tx *gorm.DB, t table, sch *schema.Schema, m Mutation, res *Result) error {
+	id := m.ID
+	if id == 0 {
+		if m.ClientID == "" {
+			return rejectf("%s needs an id or client_id", m.Op)
+		}
+		mapped, err := p.mapping(tx, m.ClientID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && mapped.Entity != t.name) {
+			return rejectf("unknown %s client_id %q", t.name, m.ClientID)
+		}
+		if err != nil {
+			return err
+		}
+		id = mapped.RowID
+	}
+	res.ID = id
+
+	row, err := p.load(tx, sch, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return rejectf("no %s row %d", t.name, id)
+	}
+	if err != nil {
+		return err
+	}
+	if deletedAt(p.ctx, sch, row).Valid {
+		if m.Op == OpDelete {
+			res.Status = StatusApplied
+			return nil
+		}
+		res.Status, res.Error = StatusConflict, "deleted since it was read"
+		return nil
+	}
+	if m.BaseUpdatedAt != nil {
+		updated := sch.LookUpField("UpdatedAt").ReflectValueOf(p.ctx, row.Elem()).Interface().(time.Time)
+		if updated.After(*m.BaseUpdatedAt) {
+			res.Status, res.Error, res.Row = StatusConflict, "modified since it was read", toRow(p.ctx, sch, row)
+			return nil
+		}
+	}
+
+	if m.Op == OpDelete {
+		if err := tx.Delete(t.model, id).Error; err != nil {
+			return err
+		}
+		res.Status = StatusApplied
+		return nil
+	}
+	if len(m.Values) == 0 {
+		return rejectf("update has no values")
+	}
+	columns, err := p.assign(tx, t, sch, row, m.Values)
+	if err != nil {
+		return err
+	}
+	if field := sch.LookUpField("Version"); field != nil {
+		version, err := database.ClaimVersion(tx, t.model, id)
+		if err != nil {
+			return err
+		}
+		if err := field.Set(p.ctx, row.Elem(), version); err != nil {
+			return err
+		}
+	}
+	columns = append(columns, "updated_at")
+	if err := tx.Model(row.Interface()).Select(columns).Updates(row.Interface()).Error; err != nil {
+		return err
+	}
+	res.Status, res.Row = StatusApplied, toRow(p.ctx, sch, row)
+	return nil
+}
+
+// assign sets the columns in values on row, decoding each the way the table's own endpoints
+// do, and returns the columns set.
+func (p *pusher) assign(tx *gorm.DB, t table, sch *schema.Schema, row reflect.Value, values map[string]json.RawMessage) ([]string, error) {
+	columns := make([]string, 0, len(values))
+	fields := make(map[string]json.RawMessage, len(values))
+	for column, raw := range values {
+		field := sch.FieldsByDBName[column]
+		name := jsonName(field)
+		if field == nil || readOnly[column] || name == "" {
+			return nil, rejectf("%s has no writable column %q", t.name, column)
+		}
+		if parent, ok := p.refs[t.name][column]; ok {
+			var err error
+			if raw, err = p.resolve(tx, parent, column, raw); err != nil {
+				return nil, err
+			}
+		}
+		fields[name] = raw
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	blob, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(blob, row.Interface()); err != nil {
+		return nil, rejectf("%s: %v", t.name, err)
+	}
+	return columns, nil
+}
+
+// resolve turns a client ID in a reference column into the row ID it became, and checks that
+// the row referenced belongs to the user.
+func (p *pusher) resolve(tx *gorm.DB, parent, column string, raw json.RawMessage) (json.RawMessage, error) {
+	var ref string
+	if json.Unmarshal(raw, &ref) == nil {
+		mapped, err := p.mapping(tx, ref)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && mapped.Entity != parent) {
+			return nil, rejectf("%s: unknown %s client_id %q", column, parent, ref)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(mapped.RowID)
+	}
+	var id *uint
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return nil, rejectf("%s must be an id or a client_id", column)
+	}
+	if id == nil || *id == 0 {
+		return raw, nil
+	}
+	var n int64
+	err := tx.Table(parent).Scopes(database.OwnedBy(p.ctx, parent)).
+		Where("id = ? AND deleted_at IS NULL", *id).
+		Count(&n).Error
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, rejectf("%s: no %s row %d", column, parent, *id)
+	}
+	return raw, nil
+}
+
+func (p *pusher) mapping(tx *gorm.DB, clientID string) (clientMapping, error) {
+	var mapped clientMapping
+	err := tx.Where("client_id = ?", clientID).Take(&mapped).Error
+	return mapped, err
+}
+
+// load reads row id of the user, deleted or not.
+func (p *pusher) load(tx *gorm.DB, sch *schema.Schema, id uint) (reflect.Value, error) {
+	row := reflect.New(sch.ModelType)
+	err := tx.Unscoped().Where("id = ?", id).Take(row.Interface()).Error
+	return row, err
+}
+
+func deletedAt(ctx context.Context, sch *schema.Schema, row reflect.Value) gorm.DeletedAt {
+	return sch.LookUpField("DeletedAt").ReflectValueOf(ctx, reflect.Indirect(row)).Interface().(gorm.DeletedAt)
+}
+
+func primaryKey(ctx context.Context, sch *schema.Schema, row reflect.Value) uint {
+	return sch.PrioritizedPrimaryField.ReflectValueOf(ctx, reflect.Indirect(row)).Interface().(uint)
+}
+
+// jsonName is the key a field goes by in the API, or "" for fields kept out of it.
+func jsonName(field *schema.Field) string {
+	if field == nil {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package deltasync
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"be-simpletracker/internal/utils/apierr"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type handler struct {
+	db      *gorm.DB
+	enabled func(table string) bool
+}
+
+// RegisterRoutes mounts sync for the tables enabled reports as belonging to an enabled module.
+func RegisterRoutes(router *gin.Engine, db *gorm.DB, enabled func(table string) bool, authMiddleware gin.HandlerFunc) {
+	h := handler{db: db, enabled: enabled}
+	group := router.Group("/sync", authMiddleware)
+	group.GET("", h.pull)
+	group.POST("", h.push)
+}
+
+// pull serves GET /sync?since=<cursor>&limit=1000. Leave since out for a full copy.
+func (h *handler) pull(c *gin.Context) {
+	limit := DefaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			apierr.BadRequest(c, "invalid limit")
+			return
+		}
+		limit = min(n, MaxLimit)
+	}
+	changes, err := Pull(c.Request.Context(), h.db, h.enabled, c.Query("since"), limit)
+	if errors.Is(err, ErrInvalidCursor) {
+		apierr.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		apierr.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+type pushBody struct {
+	Mutations []Mutation `json:"mutations"`
+}
+
+func (h *handler) push(c *gin.Context) {
+	var body pushBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apierr.BadRequest(c, "invalid request body")
+		return
+	}
+	results, err := Push(c.Request.Context(), h.db, h.enabled, body.Mutations)
+	if errors.Is(err, ErrTooManyMutations) {
+		apierr.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		apierr.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
@@ -5,7 +5,6 @@ import (
 	"be-simpletracker/internal/utils"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,7 +23,7 @@ func GetMealPlanToday(c *gin.Context) {
 		"totalFiber":    tot.Fiber,
 		"totalCarbs":    tot.Carbs,
 		"totalFat":      tot.Fat,
-		"today":         time.Now(),
+		"today":         utils.Today(c.Request.Context(), 0),
 	})
 }
 
@@ -36,7 +35,7 @@ func GetMealPlanWeek(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"days":  data,
-		"today": time.Now(),
+		"today": utils.Today(c.Request.Context(), 0),
 	})
 }
 
@@ -70,7 +69,7 @@ func GetMealPlanMonth(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"days":  days,
-		"today": time.Now(),
+		"today": utils.Today(c.Request.Context(), 0),
 		"range": gin.H{
 			"start": startOfMonth,
 			"end":   endOfMonth,
@@ -103,7 +102,7 @@ func GetMealPlanDay(c *gin.Context) {
 }
 
 func GetGoalsToday(c *gin.Context) {
-	goals, err := services.GoalsToday()
+	goals, err := services.GoalsToday(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"be-simpletracker/internal/core/diet/services"
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/utils"
 	"errors"
 	"net/http"
@@ -81,7 +82,16 @@ func PutPlanMacros(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "macro targets must be non-negative"})
 		return
 	}
-	plan, err := services.UpdatePlanMacros(uint(id64), req.Calories, req.Protein, req.Fiber, req.Carbs, req.Fat)
+	plan, err := services.UpdatePlanMacros(c.Request.Context(), uint(id64), req.Calories, req.Protein, req.Fiber, req.Carbs, req.Fat)
+	if errors.Is(err, database.ErrStaleVersion) {
+		current, err := services.PlanByID(c.Request.Context(), uint(id64))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		utils.PreconditionFailed(c, "plan", current, current.Version)
+		return
+	}
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "plan not found"})
@@ -90,5 +100,6 @@ func PutPlanMacros(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	utils.SetETag(c, plan.Version)
 	c.JSON(http.StatusOK, gin.H{"plan": plan})
 }
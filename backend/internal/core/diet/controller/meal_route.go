@@ -4,6 +4,7 @@ import (
 	"be-simpletracker/internal/core/diet/models"
 	dietrepo "be-simpletracker/internal/core/diet/repository"
 	"be-simpletracker/internal/core/diet/services"
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/utils"
 	"be-simpletracker/internal/utils/apierr"
 	"errors"
@@ -55,7 +56,7 @@ func PostQuickLog(c *gin.Context) {
 		apierr.BadRequest(c, "name is required")
 		return
 	}
-	result, err := services.QuickLogMeal(dietrepo.QuickLogParams{
+	result, err := services.QuickLogMeal(c.Request.Context(), dietrepo.QuickLogParams{
 		DisplayName:   displayName,
 		FoodRowName:   fmt.Sprintf("%s [ql-%d]", displayName, time.Now().UnixNano()),
 		Calories:      req.Calories,
@@ -79,7 +80,7 @@ func PostFood(c *gin.Context) {
 		apierr.BadRequest(c, err.Error())
 		return
 	}
-	createdFood, err := services.CreateFood(&req.Food, req.RelatedFoodID)
+	createdFood, err := services.CreateFood(c.Request.Context(), &req.Food, req.RelatedFoodID)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -143,12 +144,12 @@ func parseExcludeIDs(c *gin.Context) []uint {
 
 func GetAllFoods(c *gin.Context) {
 	excludeIDs := parseExcludeIDs(c)
-	foods, err := services.AllFoodsForPicker(excludeIDs)
+	foods, err := services.AllFoodsForPicker(c.Request.Context(), excludeIDs)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
 	}
-	composites, err := services.AllCompositeFoods()
+	composites, err := services.AllCompositeFoods(c.Request.Context())
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -174,12 +175,12 @@ func PostNewCompositeFood(c *gin.Context) {
 		apierr.BadRequest(c, "name and at least one item required")
 		return
 	}
-	id, err := services.CreateCompositeFood(&cf)
+	id, err := services.CreateCompositeFood(c.Request.Context(), &cf)
 	if err != nil {
-		apierr.Internal(c, err)
+		mealItemsError(c, err)
 		return
 	}
-	loaded, err := services.CompositeFoodByID(id)
+	loaded, err := services.CompositeFoodByID(c.Request.Context(), id)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -188,7 +189,7 @@ func PostNewCompositeFood(c *gin.Context) {
 }
 
 func GetAllMeals(c *gin.Context) {
-	meals, err := services.AllMeals(parseExcludeIDs(c))
+	meals, err := services.AllMeals(c.Request.Context(), parseExcludeIDs(c))
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -197,7 +198,7 @@ func GetAllMeals(c *gin.Context) {
 }
 
 func GetAllSavedMeals(c *gin.Context) {
-	saved, err := services.AllSavedMeals(parseExcludeIDs(c))
+	saved, err := services.AllSavedMeals(c.Request.Context(), parseExcludeIDs(c))
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -212,9 +213,9 @@ func PostNewSavedMeal(c *gin.Context) {
 		return
 	}
 	sm.ID = 0
-	id, err := services.CreateSavedMeal(&sm)
+	id, err := services.CreateSavedMeal(c.Request.Context(), &sm)
 	if err != nil {
-		apierr.Internal(c, err)
+		mealItemsError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"saved_meal_id": id})
@@ -229,7 +230,7 @@ func DeleteSavedMeal(c *gin.Context) {
 	}
 	id := uint(id64)
 	force := strings.ToLower(strings.TrimSpace(c.Query("force"))) == "true"
-	if _, err := services.SavedMealByID(id); err != nil {
+	if _, err := services.SavedMealByID(c.Request.Context(), id); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			apierr.NotFound(c, "saved meal not found")
 			return
@@ -238,7 +239,7 @@ func DeleteSavedMeal(c *gin.Context) {
 		return
 	}
 	if !force {
-		info, err := services.SavedMealPlannedUsageInfo(id)
+		info, err := services.SavedMealPlannedUsageInfo(c.Request.Context(), id)
 		if err != nil {
 			apierr.Internal(c, err)
 			return
@@ -248,7 +249,7 @@ func DeleteSavedMeal(c *gin.Context) {
 		})
 		return
 	}
-	if err := services.DeleteSavedMeal(id); err != nil {
+	if err := services.DeleteSavedMeal(c.Request.Context(), id); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			apierr.NotFound(c, "saved meal not found")
 			return
@@ -266,7 +267,7 @@ func GetSavedMeal(c *gin.Context) {
 		apierr.BadRequest(c, "invalid saved meal id")
 		return
 	}
-	sm, err := services.SavedMealByID(uint(id64))
+	sm, err := services.SavedMealByID(c.Request.Context(), uint(id64))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			apierr.NotFound(c, "saved meal not found")
@@ -278,6 +279,7 @@ func GetSavedMeal(c *gin.Context) {
 	for i := range sm.Items {
 		models.NormalizeQuickLogFoodNameForResponse(&sm.Items[i].Food)
 	}
+	utils.SetETag(c, sm.Version)
 	c.JSON(http.StatusOK, gin.H{"saved_meal": sm})
 }
 
@@ -298,15 +300,27 @@ func PutSavedMeal(c *gin.Context) {
 		apierr.BadRequest(c, "name and at least one item required")
 		return
 	}
-	if err := services.ReplaceSavedMeal(uint(id64), &body); err != nil {
+	if err := services.ReplaceSavedMeal(c.Request.Context(), uint(id64), &body); err != nil {
+		if errors.Is(err, database.ErrStaleVersion) {
+			current, err := services.SavedMealByID(c.Request.Context(), uint(id64))
+			if err != nil {
+				apierr.Internal(c, err)
+				return
+			}
+			for i := range current.Items {
+				models.NormalizeQuickLogFoodNameForResponse(&current.Items[i].Food)
+			}
+			utils.PreconditionFailed(c, "saved_meal", current, current.Version)
+			return
+		}
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			apierr.NotFound(c, "saved meal not found")
 			return
 		}
-		apierr.Internal(c, err)
+		mealItemsError(c, err)
 		return
 	}
-	updated, err := services.SavedMealByID(uint(id64))
+	updated, err := services.SavedMealByID(c.Request.Context(), uint(id64))
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -314,9 +328,20 @@ func PutSavedMeal(c *gin.Context) {
 	for i := range updated.Items {
 		models.NormalizeQuickLogFoodNameForResponse(&updated.Items[i].Food)
 	}
+	utils.SetETag(c, updated.Version)
 	c.JSON(http.StatusOK, gin.H{"saved_meal": updated})
 }
 
+// mealItemsError answers an error from saving meal items, which is the client's when an item
+// names a food the user does not have.
+func mealItemsError(c *gin.Context, err error) {
+	if errors.Is(err, dietrepo.ErrUnknownFood) {
+		apierr.BadRequest(c, err.Error())
+		return
+	}
+	apierr.Internal(c, err)
+}
+
 func savedMealFromMealTemplate(m *models.Meal) *models.SavedMeal {
 	s := &models.SavedMeal{Name: m.Name}
 	for _, it := range m.Items {
@@ -338,7 +363,7 @@ func GetMeal(c *gin.Context) {
 		apierr.BadRequest(c, "Invalid ID")
 		return
 	}
-	meal, err := services.MealByID(uint(id))
+	meal, err := services.MealByID(c.Request.Context(), uint(id))
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -359,13 +384,13 @@ func PostNewMeal(c *gin.Context) {
 		apierr.BadRequest(c, err.Error())
 		return
 	}
-	mealID, err := services.CreateMeal(&req.Meal)
+	mealID, err := services.CreateMeal(c.Request.Context(), &req.Meal)
 	if err != nil {
-		apierr.Internal(c, err)
+		mealItemsError(c, err)
 		return
 	}
 	if req.Log {
-		day, err := services.FindMealPlanDay(utils.ZerodTime(req.Offset))
+		day, err := services.FindMealPlanDay(c.Request.Context(), utils.Today(c.Request.Context(), req.Offset))
 		if err != nil {
 			apierr.Internal(c, err)
 			return
@@ -374,7 +399,7 @@ func PostNewMeal(c *gin.Context) {
 			apierr.NotFound(c, "Day not found")
 			return
 		}
-		if err := services.CreateDayMeal(&models.DayLog{
+		if err := services.CreateDayMeal(c.Request.Context(), &models.DayLog{
 			DayID:  day.ID,
 			MealID: mealID,
 		}); err != nil {
@@ -384,8 +409,8 @@ func PostNewMeal(c *gin.Context) {
 	}
 	if req.Log && req.SaveToLibrary {
 		sm := savedMealFromMealTemplate(&req.Meal)
-		if _, err := services.CreateSavedMeal(sm); err != nil {
-			apierr.Internal(c, err)
+		if _, err := services.CreateSavedMeal(c.Request.Context(), sm); err != nil {
+			mealItemsError(c, err)
 			return
 		}
 	}
@@ -402,7 +427,7 @@ func PostLogPlanned(c *gin.Context) {
 		apierr.BadRequest(c, err.Error())
 		return
 	}
-	day, err := services.FindMealPlanDay(utils.ZerodTime(0))
+	day, err := services.FindMealPlanDay(c.Request.Context(), utils.Today(c.Request.Context(), 0))
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -411,17 +436,17 @@ func PostLogPlanned(c *gin.Context) {
 		apierr.NotFound(c, "Day not found")
 		return
 	}
-	if err := services.SetPlannedMealLogged(day.ID, req.MealID); err != nil {
+	if err := services.SetPlannedMealLogged(c.Request.Context(), day.ID, req.MealID); err != nil {
 		apierr.Internal(c, err)
 		return
 	}
-	exists, err := services.DayLogExistsForMeal(day.ID, req.MealID)
+	exists, err := services.DayLogExistsForMeal(c.Request.Context(), day.ID, req.MealID)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
 	}
 	if !exists {
-		if err := services.CreateDayMeal(&models.DayLog{
+		if err := services.CreateDayMeal(c.Request.Context(), &models.DayLog{
 			DayID:  day.ID,
 			MealID: req.MealID,
 		}); err != nil {
@@ -429,7 +454,7 @@ func PostLogPlanned(c *gin.Context) {
 			return
 		}
 	}
-	result, err := services.ReloadDayWithTotals(day)
+	result, err := services.ReloadDayWithTotals(c.Request.Context(), day)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -450,12 +475,12 @@ func PostLogEdited(c *gin.Context) {
 		apierr.BadRequest(c, err.Error())
 		return
 	}
-	newMealID, err := services.CreateMeal(&req.Meal)
+	newMealID, err := services.CreateMeal(c.Request.Context(), &req.Meal)
 	if err != nil {
-		apierr.Internal(c, err)
+		mealItemsError(c, err)
 		return
 	}
-	day, err := services.FindMealPlanDayByRowIDOrToday(req.DayID)
+	day, err := services.FindMealPlanDayByRowIDOrToday(c.Request.Context(), req.DayID)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -464,7 +489,7 @@ func PostLogEdited(c *gin.Context) {
 		apierr.NotFound(c, "Day not found")
 		return
 	}
-	if err := services.CreateDayMeal(&models.DayLog{
+	if err := services.CreateDayMeal(c.Request.Context(), &models.DayLog{
 		DayID:  day.ID,
 		MealID: newMealID,
 	}); err != nil {
@@ -472,12 +497,12 @@ func PostLogEdited(c *gin.Context) {
 		return
 	}
 	if req.PlannedSourceMealID != 0 {
-		if err := services.SetPlannedMealLogged(day.ID, req.PlannedSourceMealID); err != nil {
+		if err := services.SetPlannedMealLogged(c.Request.Context(), day.ID, req.PlannedSourceMealID); err != nil {
 			apierr.Internal(c, err)
 			return
 		}
 	}
-	result, err := services.ReloadDayWithTotals(day)
+	result, err := services.ReloadDayWithTotals(c.Request.Context(), day)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -491,7 +516,7 @@ func PostEditLogged(c *gin.Context) {
 		apierr.BadRequest(c, err.Error())
 		return
 	}
-	day, err := services.FindMealPlanDayByRowIDOrToday(req.DayID)
+	day, err := services.FindMealPlanDayByRowIDOrToday(c.Request.Context(), req.DayID)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -500,11 +525,11 @@ func PostEditLogged(c *gin.Context) {
 		apierr.NotFound(c, "Day not found")
 		return
 	}
-	if err := services.EditLoggedMeal(day.ID, req.OldMealID, &req.Meal); err != nil {
-		apierr.Internal(c, err)
+	if err := services.EditLoggedMeal(c.Request.Context(), day.ID, req.OldMealID, &req.Meal); err != nil {
+		mealItemsError(c, err)
 		return
 	}
-	result, err := services.ReloadDayWithTotals(day)
+	result, err := services.ReloadDayWithTotals(c.Request.Context(), day)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -523,7 +548,7 @@ func DeleteLoggedMeal(c *gin.Context) {
 		apierr.BadRequest(c, err.Error())
 		return
 	}
-	day, err := services.FindMealPlanDayByRowIDOrToday(req.DayID)
+	day, err := services.FindMealPlanDayByRowIDOrToday(c.Request.Context(), req.DayID)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -532,11 +557,11 @@ func DeleteLoggedMeal(c *gin.Context) {
 		apierr.NotFound(c, "Day not found")
 		return
 	}
-	if err := services.DeleteLoggedMeal(day.ID, req.MealID); err != nil {
+	if err := services.DeleteLoggedMeal(c.Request.Context(), day.ID, req.MealID); err != nil {
 		apierr.Internal(c, err)
 		return
 	}
-	result, err := services.ReloadDayWithTotals(day)
+	result, err := services.ReloadDayWithTotals(c.Request.Context(), day)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -559,11 +584,11 @@ func PostPlannedFromSaved(c *gin.Context) {
 		apierr.BadRequest(c, "saved_meal_id is required")
 		return
 	}
-	if err := services.AddPlannedMealFromSavedMeal(req.Offset, req.SavedMealID); err != nil {
-		apierr.Internal(c, err)
+	if err := services.AddPlannedMealFromSavedMeal(c.Request.Context(), req.Offset, req.SavedMealID); err != nil {
+		mealItemsError(c, err)
 		return
 	}
-	day, err := services.FindMealPlanDay(utils.ZerodTime(req.Offset))
+	day, err := services.FindMealPlanDay(c.Request.Context(), utils.Today(c.Request.Context(), req.Offset))
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -572,7 +597,7 @@ func PostPlannedFromSaved(c *gin.Context) {
 		apierr.NotFound(c, "Day not found")
 		return
 	}
-	result, err := services.ReloadDayWithTotals(day)
+	result, err := services.ReloadDayWithTotals(c.Request.Context(), day)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -595,11 +620,11 @@ func PostPlannedReorder(c *gin.Context) {
 		apierr.BadRequest(c, "planned_meal_ids is required")
 		return
 	}
-	if err := services.ReorderPlannedMeals(req.Offset, req.PlannedMealIDs); err != nil {
+	if err := services.ReorderPlannedMeals(c.Request.Context(), req.Offset, req.PlannedMealIDs); err != nil {
 		apierr.BadRequest(c, err.Error())
 		return
 	}
-	day, err := services.FindMealPlanDay(utils.ZerodTime(req.Offset))
+	day, err := services.FindMealPlanDay(c.Request.Context(), utils.Today(c.Request.Context(), req.Offset))
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -608,7 +633,7 @@ func PostPlannedReorder(c *gin.Context) {
 		apierr.NotFound(c, "Day not found")
 		return
 	}
-	result, err := services.ReloadDayWithTotals(day)
+	result, err := services.ReloadDayWithTotals(c.Request.Context(), day)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -631,11 +656,11 @@ func DeletePlannedMeal(c *gin.Context) {
 		apierr.BadRequest(c, "planned_meal_id is required")
 		return
 	}
-	if err := services.DeletePlannedMeal(req.Offset, req.PlannedMealID); err != nil {
+	if err := services.DeletePlannedMeal(c.Request.Context(), req.Offset, req.PlannedMealID); err != nil {
 		apierr.Internal(c, err)
 		return
 	}
-	day, err := services.FindMealPlanDay(utils.ZerodTime(req.Offset))
+	day, err := services.FindMealPlanDay(c.Request.Context(), utils.Today(c.Request.Context(), req.Offset))
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -644,7 +669,7 @@ func DeletePlannedMeal(c *gin.Context) {
 		apierr.NotFound(c, "Day not found")
 		return
 	}
-	result, err := services.ReloadDayWithTotals(day)
+	result, err := services.ReloadDayWithTotals(c.Request.Context(), day)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -2,20 +2,23 @@ package diet
 
 import (
 	"be-simpletracker/internal/core/diet/controller"
+	"be-simpletracker/internal/idempotency"
 	"be-simpletracker/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+func RegisterRoutes(router *gin.Engine, db *gorm.DB, authMiddleware, ifMatch gin.HandlerFunc) {
 	dayOffsetMiddleware := utils.DayOffsetMiddleware()
+	idempotent := idempotency.Middleware(db)
 
 	group := router.Group("/diet", authMiddleware)
 	{
 		plans := group.Group("/plans")
 		{
 			plans.GET("/plan/all", controller.GetAllPlans)
-			plans.PUT("/plan/:id", controller.PutPlanMacros)
+			plans.PUT("/plan/:id", ifMatch, controller.PutPlanMacros)
 		}
 		logs := group.Group("/logs")
 		{
@@ -38,16 +41,16 @@ func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
 			meals.GET("/saved-meal/all", controller.GetAllSavedMeals)
 			meals.GET("/saved-meal/:id", controller.GetSavedMeal)
 			meals.POST("/saved-meal/new", controller.PostNewSavedMeal)
-			meals.PUT("/saved-meal/:id", controller.PutSavedMeal)
+			meals.PUT("/saved-meal/:id", ifMatch, controller.PutSavedMeal)
 			meals.DELETE("/saved-meal/:id", controller.DeleteSavedMeal)
 			meals.GET("/meal/:id", controller.GetMeal)
-			meals.POST("/quick-log", controller.PostQuickLog)
-			meals.POST("/meal/new", controller.PostNewMeal)
-			meals.POST("/meal/log-planned", controller.PostLogPlanned)
-			meals.POST("/meal/logedited", controller.PostLogEdited)
-			meals.POST("/meal/editlogged", controller.PostEditLogged)
+			meals.POST("/quick-log", idempotent, controller.PostQuickLog)
+			meals.POST("/meal/new", idempotent, controller.PostNewMeal)
+			meals.POST("/meal/log-planned", idempotent, controller.PostLogPlanned)
+			meals.POST("/meal/logedited", idempotent, controller.PostLogEdited)
+			meals.POST("/meal/editlogged", idempotent, controller.PostEditLogged)
 			meals.DELETE("/meal/logged", controller.DeleteLoggedMeal)
-			meals.POST("/planned/from-saved", controller.PostPlannedFromSaved)
+			meals.POST("/planned/from-saved", idempotent, controller.PostPlannedFromSaved)
 			meals.POST("/planned/reorder", controller.PostPlannedReorder)
 			meals.DELETE("/planned", controller.DeletePlannedMeal)
 		}
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "composite_food_items";
+DROP TABLE IF EXISTS "composite_foods";
+DROP TABLE IF EXISTS "day_logs";
+DROP TABLE IF EXISTS "planned_meals";
+DROP TABLE IF EXISTS "saved_meal_items";
+DROP TABLE IF EXISTS "saved_meals";
+DROP TABLE IF EXISTS "meal_items";
+DROP TABLE IF EXISTS "meals";
+DROP TABLE IF EXISTS "foods";
+DROP TABLE IF EXISTS "days";
+DROP TABLE IF EXISTS "plans";
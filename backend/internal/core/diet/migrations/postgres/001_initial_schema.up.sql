@@ -0,0 +1,189 @@
+-- Initial diet schema: its tables as created by the AutoMigrate setup this runner replaces.
+
+CREATE TABLE "plans" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text,
+    "calories" decimal,
+    "protein" decimal,
+    "fiber" decimal,
+    "carbs" decimal,
+    "fat" decimal,
+    "effective_from" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_plans_effective_from" ON "plans" ("effective_from");
+CREATE INDEX IF NOT EXISTS "idx_plans_user_id" ON "plans" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_plans_deleted_at" ON "plans" ("deleted_at");
+
+CREATE TABLE "days" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "date" timestamptz NOT NULL,
+    "plan_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_days_plan" FOREIGN KEY ("plan_id") REFERENCES "plans"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_days_user_date" ON "days" ("user_id","date");
+CREATE INDEX IF NOT EXISTS "idx_days_deleted_at" ON "days" ("deleted_at");
+
+CREATE TABLE "foods" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text NOT NULL,
+    "serving_type" text NOT NULL,
+    "serving_amount" decimal NOT NULL,
+    "calories" decimal NOT NULL,
+    "protein" decimal,
+    "fiber" decimal,
+    "carbs" decimal,
+    "fat" decimal,
+    "variant_group_id" bigint,
+    "quick_entry" boolean DEFAULT false,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_foods_quick_entry" ON "foods" ("quick_entry");
+CREATE INDEX IF NOT EXISTS "idx_foods_variant_group_id" ON "foods" ("variant_group_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_foods_user_name" ON "foods" ("user_id","name");
+CREATE INDEX IF NOT EXISTS "idx_foods_deleted_at" ON "foods" ("deleted_at");
+
+CREATE TABLE "meals" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_meals_user_id" ON "meals" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_meals_deleted_at" ON "meals" ("deleted_at");
+
+CREATE TABLE "meal_items" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "meal_id" bigint NOT NULL,
+    "food_id" bigint NOT NULL,
+    "amount" decimal,
+    "group_id" text,
+    "group_label" text,
+    "composite_food_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_meal_items_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id"),
+    CONSTRAINT "fk_meals_items" FOREIGN KEY ("meal_id") REFERENCES "meals"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_meal_items_group_id" ON "meal_items" ("group_id");
+CREATE INDEX IF NOT EXISTS "idx_meal_items_food_id" ON "meal_items" ("food_id");
+CREATE INDEX IF NOT EXISTS "idx_meal_items_meal_id" ON "meal_items" ("meal_id");
+CREATE INDEX IF NOT EXISTS "idx_meal_items_user_id" ON "meal_items" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_meal_items_deleted_at" ON "meal_items" ("deleted_at");
+
+CREATE TABLE "saved_meals" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_saved_meals_user_id" ON "saved_meals" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_saved_meals_deleted_at" ON "saved_meals" ("deleted_at");
+
+CREATE TABLE "saved_meal_items" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "saved_meal_id" bigint NOT NULL,
+    "food_id" bigint NOT NULL,
+    "amount" decimal,
+    "group_id" text,
+    "group_label" text,
+    "composite_food_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_saved_meals_items" FOREIGN KEY ("saved_meal_id") REFERENCES "saved_meals"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_saved_meal_items_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_saved_meal_items_group_id" ON "saved_meal_items" ("group_id");
+CREATE INDEX IF NOT EXISTS "idx_saved_meal_items_food_id" ON "saved_meal_items" ("food_id");
+CREATE INDEX IF NOT EXISTS "idx_saved_meal_items_saved_meal_id" ON "saved_meal_items" ("saved_meal_id");
+CREATE INDEX IF NOT EXISTS "idx_saved_meal_items_user_id" ON "saved_meal_items" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_saved_meal_items_deleted_at" ON "saved_meal_items" ("deleted_at");
+
+CREATE TABLE "planned_meals" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "day_id" bigint NOT NULL,
+    "meal_id" bigint NOT NULL,
+    "saved_meal_id" bigint,
+    "logged" boolean,
+    "display_order" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_planned_meals_meal" FOREIGN KEY ("meal_id") REFERENCES "meals"("id"),
+    CONSTRAINT "fk_days_planned_meals" FOREIGN KEY ("day_id") REFERENCES "days"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_planned_meals_saved_meal_id" ON "planned_meals" ("saved_meal_id");
+CREATE INDEX IF NOT EXISTS "idx_planned_meals_user_id" ON "planned_meals" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_planned_meals_deleted_at" ON "planned_meals" ("deleted_at");
+
+CREATE TABLE "day_logs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "day_id" bigint NOT NULL,
+    "meal_id" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_days_logs" FOREIGN KEY ("day_id") REFERENCES "days"("id"),
+    CONSTRAINT "fk_day_logs_meal" FOREIGN KEY ("meal_id") REFERENCES "meals"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_day_logs_user_id" ON "day_logs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_day_logs_deleted_at" ON "day_logs" ("deleted_at");
+
+CREATE TABLE "composite_foods" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_composite_foods_user_name" ON "composite_foods" ("user_id","name");
+CREATE INDEX IF NOT EXISTS "idx_composite_foods_deleted_at" ON "composite_foods" ("deleted_at");
+
+CREATE TABLE "composite_food_items" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "composite_food_id" bigint NOT NULL,
+    "food_id" bigint NOT NULL,
+    "amount" decimal,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_composite_food_items_food" FOREIGN KEY ("food_id") REFERENCES "foods"("id"),
+    CONSTRAINT "fk_composite_foods_items" FOREIGN KEY ("composite_food_id") REFERENCES "composite_foods"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_composite_food_items_food_id" ON "composite_food_items" ("food_id");
+CREATE INDEX IF NOT EXISTS "idx_composite_food_items_composite_food_id" ON "composite_food_items" ("composite_food_id");
+CREATE INDEX IF NOT EXISTS "idx_composite_food_items_user_id" ON "composite_food_items" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_composite_food_items_deleted_at" ON "composite_food_items" ("deleted_at");
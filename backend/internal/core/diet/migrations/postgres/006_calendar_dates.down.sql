@@ -0,0 +1,4 @@
+-- calendar_dates (postgres, down)
+
+-- The server's zone is not known here, so days go back as midnight UTC.
+ALTER TABLE "days" ALTER COLUMN "date" TYPE timestamptz USING "date"::timestamp AT TIME ZONE 'UTC';
@@ -0,0 +1,5 @@
+-- calendar_dates (postgres, up)
+
+-- Days were stored as midnight in the server's zone. Half a day either side of midnight UTC
+-- is still that day for any zone from UTC-12 to UTC+12, whatever the session's TimeZone.
+ALTER TABLE "days" ALTER COLUMN "date" TYPE date USING (("date" AT TIME ZONE 'UTC') + interval '12 hours')::date;
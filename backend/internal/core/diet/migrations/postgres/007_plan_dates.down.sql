@@ -0,0 +1,4 @@
+-- plan_dates (postgres, down)
+
+-- The server's zone is not known here, so plans go back to taking effect at midnight UTC.
+ALTER TABLE "plans" ALTER COLUMN "effective_from" TYPE timestamptz USING "effective_from"::timestamp AT TIME ZONE 'UTC';
@@ -0,0 +1,5 @@
+-- plan_dates (postgres, up)
+
+-- Plans took effect from midnight in the server's zone. Half a day either side of midnight
+-- UTC is still that day for any zone from UTC-12 to UTC+12, whatever the session's TimeZone.
+ALTER TABLE "plans" ALTER COLUMN "effective_from" TYPE date USING (("effective_from" AT TIME ZONE 'UTC') + interval '12 hours')::date;
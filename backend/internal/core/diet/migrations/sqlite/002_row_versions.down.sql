@@ -0,0 +1,4 @@
+-- row_versions (sqlite, down)
+
+ALTER TABLE "plans" DROP COLUMN "version";
+ALTER TABLE "saved_meals" DROP COLUMN "version";
@@ -0,0 +1,5 @@
+-- row_versions (sqlite, up)
+
+-- Updates send the version they read as If-Match; a row that has moved on refuses the write.
+ALTER TABLE "plans" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+ALTER TABLE "saved_meals" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
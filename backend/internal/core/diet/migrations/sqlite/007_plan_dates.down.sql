@@ -0,0 +1,4 @@
+-- plan_dates (sqlite, down)
+
+-- The server's zone is not known here, so plans go back to taking effect at midnight UTC.
+UPDATE "plans" SET "effective_from" = "effective_from" || ' 00:00:00+00:00' WHERE length("effective_from") = 10;
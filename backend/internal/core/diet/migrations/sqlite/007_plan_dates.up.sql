@@ -0,0 +1,4 @@
+-- plan_dates (sqlite, up)
+
+-- Plans took effect from a local-midnight timestamp whose first ten characters are the day.
+UPDATE "plans" SET "effective_from" = substr("effective_from", 1, 10) WHERE "effective_from" IS NOT NULL;
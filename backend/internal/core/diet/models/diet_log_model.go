@@ -1,7 +1,7 @@
 package models
 
 import (
-	"time"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
@@ -9,16 +9,17 @@ import (
 // DietDay represents a day in the diet plan
 type DietDay struct {
 	gorm.Model
-	Date         time.Time     `json:"date" gorm:"uniqueIndex;not null"`
+	UserID       uint          `json:"-" gorm:"uniqueIndex:idx_days_user_date"`
+	Date         database.Date `json:"date" gorm:"uniqueIndex:idx_days_user_date;not null"`
 	PlanID       uint          `json:"plan_id"`
 	Plan         Plan          `gorm:"foreignKey:PlanID" json:"plan"`
 	PlannedMeals []PlannedMeal `gorm:"foreignKey:DayID" json:"plannedMeals"`
 	Logs         []DayLog      `gorm:"foreignKey:DayID" json:"loggedMeals"`
 }
 
-func (d DietDay) GetID() uint        { return d.ID }
-func (d DietDay) TableName() string  { return "days" }
-func (d DietDay) GetDate() time.Time { return d.Date }
+func (d DietDay) GetID() uint            { return d.ID }
+func (d DietDay) TableName() string      { return "days" }
+func (d DietDay) GetDate() database.Date { return d.Date }
 func (d DietDay) Preloads() []string {
 	return []string{"PlannedMeals.Meal.Items.Food", "Plan", "Logs.Meal.Items.Food"}
 }
@@ -26,6 +27,7 @@ func (d DietDay) Preloads() []string {
 // DayLog represents a logged meal for a day
 type DayLog struct {
 	gorm.Model
+	UserID uint `json:"-" gorm:"index"`
 	DayID  uint `json:"day_id" gorm:"not null"`
 	MealID uint `json:"meal_id" gorm:"not null"`
 	Meal   Meal `json:"meal"`
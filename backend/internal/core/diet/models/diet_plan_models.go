@@ -1,7 +1,7 @@
 package models
 
 import (
-	"time"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
@@ -9,13 +9,15 @@ import (
 // Plan represents a diet plan
 type Plan struct {
 	gorm.Model
-	Name          string     `json:"name"`
-	Calories      float32    `json:"calories"`
-	Protein       float32    `json:"protein"`
-	Fiber         float32    `json:"fiber"`
-	Carbs         float32    `json:"carbs"`
-	Fat           float32    `json:"fat"`
-	EffectiveFrom *time.Time `json:"effective_from,omitempty" gorm:"index"`
+	UserID        uint           `json:"-" gorm:"index"`
+	Name          string         `json:"name"`
+	Calories      float32        `json:"calories"`
+	Protein       float32        `json:"protein"`
+	Fiber         float32        `json:"fiber"`
+	Carbs         float32        `json:"carbs"`
+	Fat           float32        `json:"fat"`
+	EffectiveFrom *database.Date `json:"effective_from,omitempty" gorm:"index"`
+	Version       uint           `json:"version" gorm:"not null;default:1"`
 }
 
 func (p Plan) GetID() uint        { return p.ID }
@@ -25,6 +27,7 @@ func (p Plan) Preloads() []string { return []string{} }
 // PlannedMeal represents a meal that is planned for a day
 type PlannedMeal struct {
 	gorm.Model
+	UserID       uint    `json:"-" gorm:"index"`
 	DayID        uint    `json:"day_id" gorm:"not null"`
 	DietDay      DietDay `gorm:"foreignKey:DayID" json:"day"`
 	MealID       uint    `json:"meal_id" gorm:"not null"`
@@ -10,8 +10,9 @@ import (
 // Meal represents a logged meal instance (foods eaten in one sitting); day_logs reference this.
 type Meal struct {
 	gorm.Model
-	Name  string     `json:"name" gorm:"not null"`
-	Items []MealItem `json:"items" gorm:"constraint:OnDelete:CASCADE;"`
+	UserID uint       `json:"-" gorm:"index"`
+	Name   string     `json:"name" gorm:"not null"`
+	Items  []MealItem `json:"items" gorm:"constraint:OnDelete:CASCADE;"`
 }
 
 func (m Meal) GetID() uint        { return m.ID }
@@ -21,14 +22,15 @@ func (m Meal) Preloads() []string { return []string{"Items.Food"} }
 // MealItem represents an item in a meal
 type MealItem struct {
 	gorm.Model
-	MealID            uint    `json:"meal_id" gorm:"not null;index"`
-	FoodID            uint    `json:"food_id" gorm:"not null;index"`
-	Amount            float32 `json:"amount"`
-	GroupID           string  `json:"group_id" gorm:"index"`
-	GroupLabel        string  `json:"group_label"`
-	CompositeFoodID   *uint   `json:"composite_food_id"`
-	Meal              Meal    `json:"meal"`
-	Food              Food    `json:"food"`
+	UserID          uint    `json:"-" gorm:"index"`
+	MealID          uint    `json:"meal_id" gorm:"not null;index"`
+	FoodID          uint    `json:"food_id" gorm:"not null;index"`
+	Amount          float32 `json:"amount"`
+	GroupID         string  `json:"group_id" gorm:"index"`
+	GroupLabel      string  `json:"group_label"`
+	CompositeFoodID *uint   `json:"composite_food_id"`
+	Meal            Meal    `json:"meal"`
+	Food            Food    `json:"food"`
 }
 
 func (m MealItem) GetID() uint        { return m.ID }
@@ -38,8 +40,10 @@ func (m MealItem) Preloads() []string { return []string{"Food"} }
 // SavedMeal is a reusable template (e.g. meals you eat often), not tied to a specific day log.
 type SavedMeal struct {
 	gorm.Model
-	Name  string          `json:"name" gorm:"not null"`
-	Items []SavedMealItem `json:"items" gorm:"constraint:OnDelete:CASCADE;"`
+	UserID  uint            `json:"-" gorm:"index"`
+	Name    string          `json:"name" gorm:"not null"`
+	Items   []SavedMealItem `json:"items" gorm:"constraint:OnDelete:CASCADE;"`
+	Version uint            `json:"version" gorm:"not null;default:1"`
 }
 
 func (s SavedMeal) GetID() uint        { return s.ID }
@@ -49,12 +53,13 @@ func (s SavedMeal) Preloads() []string { return []string{"Items.Food"} }
 // SavedMealItem represents an item in a saved meal
 type SavedMealItem struct {
 	gorm.Model
-	SavedMealID     uint    `json:"saved_meal_id" gorm:"not null;index"`
-	FoodID          uint    `json:"food_id" gorm:"not null;index"`
-	Amount          float64 `json:"amount"`
-	GroupID         string  `json:"group_id" gorm:"index"`
-	GroupLabel      string  `json:"group_label"`
-	CompositeFoodID *uint   `json:"composite_food_id"`
+	UserID          uint      `json:"-" gorm:"index"`
+	SavedMealID     uint      `json:"saved_meal_id" gorm:"not null;index"`
+	FoodID          uint      `json:"food_id" gorm:"not null;index"`
+	Amount          float64   `json:"amount"`
+	GroupID         string    `json:"group_id" gorm:"index"`
+	GroupLabel      string    `json:"group_label"`
+	CompositeFoodID *uint     `json:"composite_food_id"`
 	SavedMeal       SavedMeal `json:"-"`
 	Food            Food      `json:"food"`
 }
@@ -65,17 +70,18 @@ func (s SavedMealItem) TableName() string { return "saved_meal_items" }
 // Food represents a food item
 type Food struct {
 	gorm.Model
-	Name             string  `json:"name" gorm:"not null;uniqueIndex"`
-	ServingType      string  `json:"serving_type" gorm:"not null"`
-	ServingAmount    float32 `json:"serving_amount" gorm:"not null"`
-	Calories         float32 `json:"calories" gorm:"not null"`
-	Protein          float32 `json:"protein"`
-	Fiber            float32 `json:"fiber"`
-	Carbs            float32 `json:"carbs"`
-	Fat              float32 `json:"fat"`
-	VariantGroupID   *uint   `json:"variant_group_id" gorm:"index"`
-	QuickEntry       bool    `json:"quick_entry" gorm:"default:false;index"`
-	Variants         []Food  `json:"variants,omitempty" gorm:"-"`
+	UserID         uint    `json:"-" gorm:"uniqueIndex:idx_foods_user_name"`
+	Name           string  `json:"name" gorm:"not null;uniqueIndex:idx_foods_user_name"`
+	ServingType    string  `json:"serving_type" gorm:"not null"`
+	ServingAmount  float32 `json:"serving_amount" gorm:"not null"`
+	Calories       float32 `json:"calories" gorm:"not null"`
+	Protein        float32 `json:"protein"`
+	Fiber          float32 `json:"fiber"`
+	Carbs          float32 `json:"carbs"`
+	Fat            float32 `json:"fat"`
+	VariantGroupID *uint   `json:"variant_group_id" gorm:"index"`
+	QuickEntry     bool    `json:"quick_entry" gorm:"default:false;index"`
+	Variants       []Food  `json:"variants,omitempty" gorm:"-"`
 }
 
 func (f Food) GetID() uint       { return f.ID }
@@ -105,17 +111,19 @@ type FoodWithVariants struct {
 // CompositeFood is a reusable recipe (multiple foods + amounts); logging expands to grouped meal items.
 type CompositeFood struct {
 	gorm.Model
-	Name  string              `json:"name" gorm:"not null;uniqueIndex"`
-	Items []CompositeFoodItem `json:"items" gorm:"constraint:OnDelete:CASCADE;"`
+	UserID uint                `json:"-" gorm:"uniqueIndex:idx_composite_foods_user_name"`
+	Name   string              `json:"name" gorm:"not null;uniqueIndex:idx_composite_foods_user_name"`
+	Items  []CompositeFoodItem `json:"items" gorm:"constraint:OnDelete:CASCADE;"`
 }
 
 func (c CompositeFood) GetID() uint        { return c.ID }
-func (c CompositeFood) TableName() string { return "composite_foods" }
+func (c CompositeFood) TableName() string  { return "composite_foods" }
 func (c CompositeFood) Preloads() []string { return []string{"Items.Food"} }
 
 // CompositeFoodItem is one ingredient line in a composite food.
 type CompositeFoodItem struct {
 	gorm.Model
+	UserID          uint          `json:"-" gorm:"index"`
 	CompositeFoodID uint          `json:"composite_food_id" gorm:"not null;index"`
 	FoodID          uint          `json:"food_id" gorm:"not null;index"`
 	Amount          float32       `json:"amount"`
@@ -123,6 +131,6 @@ type CompositeFoodItem struct {
 	Food            Food          `json:"food"`
 }
 
-func (c CompositeFoodItem) GetID() uint       { return c.ID }
-func (c CompositeFoodItem) TableName() string { return "composite_food_items" }
+func (c CompositeFoodItem) GetID() uint        { return c.ID }
+func (c CompositeFoodItem) TableName() string  { return "composite_food_items" }
 func (c CompositeFoodItem) Preloads() []string { return []string{"Food"} }
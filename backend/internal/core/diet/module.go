@@ -0,0 +1,50 @@
+package diet
+
+import (
+	"context"
+	"io/fs"
+
+	"be-simpletracker/internal/core/diet/migrations"
+	"be-simpletracker/internal/core/diet/models"
+	"be-simpletracker/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ownedModels = []any{
+	&models.Plan{},
+	&models.DietDay{},
+	&models.Meal{},
+	&models.MealItem{},
+	&models.SavedMeal{},
+	&models.SavedMealItem{},
+	&models.PlannedMeal{},
+	&models.DayLog{},
+	&models.Food{},
+	&models.CompositeFood{},
+	&models.CompositeFoodItem{},
+}
+
+// Module is the diet feature: plans, foods, meals and the daily log.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Name() string { return "diet" }
+
+func (m *Module) Models() []any { return ownedModels }
+
+func (m *Module) Migrations() fs.FS { return migrations.FS }
+
+func (m *Module) RegisterRoutes(router *gin.Engine, authMiddleware, ifMatch gin.HandlerFunc) {
+	RegisterRoutes(router, m.db, authMiddleware, ifMatch)
+}
+
+func (m *Module) CheckHealth(ctx context.Context) error {
+	return database.RequireTables(m.db.WithContext(ctx), ownedModels...)
+}
@@ -4,17 +4,18 @@ import (
 	"be-simpletracker/internal/core/diet/models"
 	dietrepo "be-simpletracker/internal/core/diet/repository"
 	"be-simpletracker/internal/core/diet/testutil"
+	"be-simpletracker/internal/database"
 	"context"
+	"errors"
 	"strings"
 	"testing"
-	"time"
 
 	"be-simpletracker/internal/utils"
 )
 
 func TestDayMealPlanToday_createsDayWithDefaultPlan(t *testing.T) {
 	testutil.SetupTestDB(t)
-	day, err := dietrepo.DayMealPlanToday(0)
+	day, err := dietrepo.DayMealPlanToday(context.Background(), 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -34,14 +35,14 @@ func TestDayByID_andCalculateTotals(t *testing.T) {
 	meal := testutil.SeedMeal(t, db, "Dinner", food.ID, 2)
 	testutil.SeedDayLog(t, db, day.ID, meal.ID)
 
-	loaded, err := dietrepo.DayByID(int(day.ID))
+	loaded, err := dietrepo.DayByID(context.Background(), int(day.ID))
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(loaded.Logs) != 1 {
 		t.Fatalf("logs %+v", loaded.Logs)
 	}
-	tot := dietrepo.CalculateTotals(day.ID)
+	tot := dietrepo.CalculateTotals(context.Background(), day.ID)
 	if tot.Calories != 500 || tot.Protein != 52 {
 		t.Fatalf("totals %+v", tot)
 	}
@@ -51,7 +52,7 @@ func TestGoalsToday_returnsPlanForToday(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	plan := testutil.SeedPlan(t, db, "Maintain")
 	testutil.SeedDay(t, db, testutil.Today(), plan.ID)
-	got, err := dietrepo.GoalsToday()
+	got, err := dietrepo.GoalsToday(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -62,7 +63,7 @@ func TestGoalsToday_returnsPlanForToday(t *testing.T) {
 
 func TestFindDayByDate_createsIfMissing(t *testing.T) {
 	testutil.SetupTestDB(t)
-	day, err := dietrepo.FindDayByDate(testutil.Today())
+	day, err := dietrepo.FindDayByDate(context.Background(), testutil.Today())
 	if err != nil || day == nil || day.ID == 0 {
 		t.Fatalf("day %+v err %v", day, err)
 	}
@@ -73,9 +74,9 @@ func TestDaysByDateRange_andDayByIDGeneric(t *testing.T) {
 	plan := testutil.SeedPlan(t, db, "P")
 	today := testutil.Today()
 	testutil.SeedDay(t, db, today, plan.ID)
-	testutil.SeedDay(t, db, today.AddDate(0, 0, -1), plan.ID)
+	testutil.SeedDay(t, db, today.AddDays(-1), plan.ID)
 	ctx := context.Background()
-	days, err := dietrepo.DaysByDateRange(ctx, today.AddDate(0, 0, -2), today)
+	days, err := dietrepo.DaysByDateRange(ctx, today.AddDays(-2), today)
 	if err != nil || len(days) < 2 {
 		t.Fatalf("days %+v err %v", days, err)
 	}
@@ -99,13 +100,11 @@ func TestCountUnloggedPlannedMealsPerCalendarDay(t *testing.T) {
 	m2 := testutil.SeedMeal(t, db, "Snack", food.ID, 1)
 	testutil.SeedPlannedMeal(t, db, day.ID, m1.ID, 0, false)
 	testutil.SeedPlannedMeal(t, db, day.ID, m2.ID, 1, true)
-	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
-	end := start
-	counts, err := dietrepo.CountUnloggedPlannedMealsPerCalendarDay(start, end)
+	counts, err := dietrepo.CountUnloggedPlannedMealsPerCalendarDay(context.Background(), today, today)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if counts[start.Format("2006-01-02")] != 1 {
+	if counts[today] != 1 {
 		t.Fatalf("counts %+v", counts)
 	}
 }
@@ -114,7 +113,7 @@ func TestAllMealDays(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	plan := testutil.SeedPlan(t, db, "P")
 	testutil.SeedDay(t, db, testutil.Today(), plan.ID)
-	days, err := dietrepo.AllMealDays()
+	days, err := dietrepo.AllMealDays(context.Background())
 	if err != nil || len(days) != 1 {
 		t.Fatalf("days %+v err %v", days, err)
 	}
@@ -126,10 +125,10 @@ func TestCreateDayMeal_andDayLogExists(t *testing.T) {
 	day := testutil.SeedDay(t, db, testutil.Today(), plan.ID)
 	food := testutil.SeedFood(t, db, "Banana", testutil.DefaultMacros())
 	meal := testutil.SeedMeal(t, db, "B", food.ID, 1)
-	if err := dietrepo.CreateDayMeal(&models.DayLog{DayID: day.ID, MealID: meal.ID}); err != nil {
+	if err := dietrepo.CreateDayMeal(context.Background(), &models.DayLog{DayID: day.ID, MealID: meal.ID}); err != nil {
 		t.Fatal(err)
 	}
-	ok, err := dietrepo.DayLogExists(day.ID, meal.ID)
+	ok, err := dietrepo.DayLogExists(context.Background(), day.ID, meal.ID)
 	if err != nil || !ok {
 		t.Fatalf("exists %v err %v", ok, err)
 	}
@@ -143,17 +142,17 @@ func TestDeleteLoggedMeal_andUpdateDayLogMeal(t *testing.T) {
 	oldMeal := testutil.SeedMeal(t, db, "Old", food.ID, 1)
 	newMeal := testutil.SeedMeal(t, db, "New", food.ID, 1)
 	testutil.SeedDayLog(t, db, day.ID, oldMeal.ID)
-	if err := dietrepo.UpdateDayLogMeal(day.ID, oldMeal.ID, newMeal.ID); err != nil {
+	if err := dietrepo.UpdateDayLogMeal(context.Background(), day.ID, oldMeal.ID, newMeal.ID); err != nil {
 		t.Fatal(err)
 	}
-	ok, _ := dietrepo.DayLogExists(day.ID, newMeal.ID)
+	ok, _ := dietrepo.DayLogExists(context.Background(), day.ID, newMeal.ID)
 	if !ok {
 		t.Fatal("expected new meal logged")
 	}
-	if err := dietrepo.DeleteLoggedMeal(day.ID, newMeal.ID); err != nil {
+	if err := dietrepo.DeleteLoggedMeal(context.Background(), day.ID, newMeal.ID); err != nil {
 		t.Fatal(err)
 	}
-	ok, _ = dietrepo.DayLogExists(day.ID, newMeal.ID)
+	ok, _ = dietrepo.DayLogExists(context.Background(), day.ID, newMeal.ID)
 	if ok {
 		t.Fatal("expected deleted")
 	}
@@ -166,7 +165,7 @@ func TestSetPlannedMealLogged(t *testing.T) {
 	food := testutil.SeedFood(t, db, "Pasta", testutil.DefaultMacros())
 	meal := testutil.SeedMeal(t, db, "Dinner", food.ID, 1)
 	pm := testutil.SeedPlannedMeal(t, db, day.ID, meal.ID, 0, false)
-	if err := dietrepo.SetPlannedMealLogged(day.ID, meal.ID); err != nil {
+	if err := dietrepo.SetPlannedMealLogged(context.Background(), day.ID, meal.ID); err != nil {
 		t.Fatal(err)
 	}
 	var reloaded models.PlannedMeal
@@ -177,7 +176,7 @@ func TestSetPlannedMealLogged(t *testing.T) {
 
 func TestQuickLogMeal_createsLog(t *testing.T) {
 	testutil.SetupTestDB(t)
-	dayID, err := dietrepo.QuickLogMeal(dietrepo.QuickLogParams{
+	dayID, err := dietrepo.QuickLogMeal(context.Background(), dietrepo.QuickLogParams{
 		DisplayName: "Protein Shake",
 		FoodRowName: "Protein Shake [ql-99]",
 		Calories:    200,
@@ -186,7 +185,7 @@ func TestQuickLogMeal_createsLog(t *testing.T) {
 	if err != nil || dayID == 0 {
 		t.Fatalf("dayID %d err %v", dayID, err)
 	}
-	tot := dietrepo.CalculateTotals(dayID)
+	tot := dietrepo.CalculateTotals(context.Background(), dayID)
 	if tot.Calories != 200 {
 		t.Fatalf("totals %+v", tot)
 	}
@@ -199,7 +198,7 @@ func TestQuickLogMeal_replaceExistingLog(t *testing.T) {
 	food := testutil.SeedFood(t, db, "Bar", testutil.DefaultMacros())
 	oldMeal := testutil.SeedMeal(t, db, "Old Bar", food.ID, 1)
 	testutil.SeedDayLog(t, db, day.ID, oldMeal.ID)
-	_, err := dietrepo.QuickLogMeal(dietrepo.QuickLogParams{
+	_, err := dietrepo.QuickLogMeal(context.Background(), dietrepo.QuickLogParams{
 		DisplayName:   "New Bar",
 		FoodRowName:   "New Bar [ql-1]",
 		Calories:      150,
@@ -209,7 +208,7 @@ func TestQuickLogMeal_replaceExistingLog(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	ok, _ := dietrepo.DayLogExists(day.ID, oldMeal.ID)
+	ok, _ := dietrepo.DayLogExists(context.Background(), day.ID, oldMeal.ID)
 	if ok {
 		t.Fatal("old meal should be replaced")
 	}
@@ -217,7 +216,7 @@ func TestQuickLogMeal_replaceExistingLog(t *testing.T) {
 
 func TestQuickLogMeal_replaceInvalid(t *testing.T) {
 	testutil.SetupTestDB(t)
-	_, err := dietrepo.QuickLogMeal(dietrepo.QuickLogParams{
+	_, err := dietrepo.QuickLogMeal(context.Background(), dietrepo.QuickLogParams{
 		DisplayName:   "X",
 		FoodRowName:   "X [ql-1]",
 		ReplaceMealID: 9999,
@@ -241,10 +240,10 @@ func TestEditLoggedMeal(t *testing.T) {
 			Amount: 2,
 		}},
 	}
-	if err := dietrepo.EditLoggedMeal(day.ID, oldMeal.ID, newMeal); err != nil {
+	if err := dietrepo.EditLoggedMeal(context.Background(), day.ID, oldMeal.ID, newMeal); err != nil {
 		t.Fatal(err)
 	}
-	ok, _ := dietrepo.DayLogExists(day.ID, oldMeal.ID)
+	ok, _ := dietrepo.DayLogExists(context.Background(), day.ID, oldMeal.ID)
 	if ok {
 		t.Fatal("old meal should be unlinked")
 	}
@@ -272,3 +271,46 @@ func TestDayByIDGeneric_notFound(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestCalculateTotals_ignoresOtherUsersLogs(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	plan := testutil.SeedPlan(t, db, "P")
+	day := testutil.SeedDay(t, db, testutil.Today(), plan.ID)
+	alice := database.WithUserID(context.Background(), 1)
+	food := testutil.SeedFood(t, db.WithContext(alice), "Egg", testutil.DefaultMacros())
+	meal := models.Meal{Name: "Breakfast", Items: []models.MealItem{{FoodID: food.ID, Amount: 1}}}
+	if err := db.WithContext(alice).Create(&meal).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dietrepo.CreateDayMeal(alice, &models.DayLog{DayID: day.ID, MealID: meal.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := dietrepo.CalculateTotals(alice, day.ID); got.Calories == 0 {
+		t.Fatalf("alice totals: %+v", got)
+	}
+	bob := database.WithUserID(context.Background(), 2)
+	if got := dietrepo.CalculateTotals(bob, day.ID); got.Calories != 0 {
+		t.Fatalf("bob totals should be empty: %+v", got)
+	}
+}
+
+func TestMealCreate_rejectsOtherUsersFoods(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	alice := database.WithUserID(context.Background(), 1)
+	bob := database.WithUserID(context.Background(), 2)
+	food := testutil.SeedFood(t, db.WithContext(alice), "Egg", testutil.DefaultMacros())
+
+	meal := models.Meal{Name: "Breakfast", Items: []models.MealItem{{FoodID: food.ID, Amount: 1}}}
+	if _, err := dietrepo.MealCreate(bob, &meal); !errors.Is(err, dietrepo.ErrUnknownFood) {
+		t.Fatalf("bob using alice's food: err = %v, want %v", err, dietrepo.ErrUnknownFood)
+	}
+	saved := models.SavedMeal{Name: "Breakfast", Items: []models.SavedMealItem{{FoodID: food.ID, Amount: 1}}}
+	if _, err := dietrepo.SavedMealCreate(bob, &saved); !errors.Is(err, dietrepo.ErrUnknownFood) {
+		t.Fatalf("bob saving alice's food: err = %v, want %v", err, dietrepo.ErrUnknownFood)
+	}
+	meal = models.Meal{Name: "Breakfast", Items: []models.MealItem{{FoodID: food.ID, Amount: 1}}}
+	if _, err := dietrepo.MealCreate(alice, &meal); err != nil {
+		t.Fatal(err)
+	}
+}
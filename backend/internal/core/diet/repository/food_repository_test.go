@@ -4,12 +4,14 @@ import (
 	"be-simpletracker/internal/core/diet/models"
 	dietrepo "be-simpletracker/internal/core/diet/repository"
 	"be-simpletracker/internal/core/diet/testutil"
+	"be-simpletracker/internal/database"
+	"context"
 	"testing"
 )
 
 func TestEnrichFoodVariants_nilSafe(t *testing.T) {
 	testutil.SetupTestDB(t)
-	dietrepo.EnrichFoodVariants(nil)
+	dietrepo.EnrichFoodVariants(context.Background(), nil)
 }
 
 func TestFoodCreateAndFoodsAll_excludesQuickEntry(t *testing.T) {
@@ -21,7 +23,7 @@ func TestFoodCreateAndFoodsAll_excludesQuickEntry(t *testing.T) {
 	q.QuickEntry = true
 	testutil.SeedFood(t, db, "Quick Snack [ql-1]", q)
 
-	foods, err := dietrepo.FoodsAll(nil)
+	foods, err := dietrepo.FoodsAll(context.Background(), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -41,13 +43,13 @@ func TestFoodCreateWithOptionalRelated_linksVariantGroup(t *testing.T) {
 		Calories:      110,
 		Protein:       3,
 	}
-	if err := dietrepo.FoodCreateWithOptionalRelated(newFood, &related); err != nil {
+	if err := dietrepo.FoodCreateWithOptionalRelated(context.Background(), newFood, &related); err != nil {
 		t.Fatal(err)
 	}
 	if newFood.VariantGroupID == nil {
 		t.Fatal("expected variant group")
 	}
-	dietrepo.EnrichFoodVariants(newFood)
+	dietrepo.EnrichFoodVariants(context.Background(), newFood)
 	if len(newFood.Variants) != 1 || newFood.Variants[0].ID != base.ID {
 		t.Fatalf("variants %+v", newFood.Variants)
 	}
@@ -61,7 +63,7 @@ func TestFoodCreateWithOptionalRelated_noRelated(t *testing.T) {
 		ServingAmount: 40,
 		Calories:      150,
 	}
-	if err := dietrepo.FoodCreateWithOptionalRelated(f, nil); err != nil {
+	if err := dietrepo.FoodCreateWithOptionalRelated(context.Background(), f, nil); err != nil {
 		t.Fatal(err)
 	}
 	if f.ID == 0 {
@@ -80,7 +82,7 @@ func TestFoodsAllWithVariantSiblings_andExclude(t *testing.T) {
 	if err := db.Model(&b).Update("variant_group_id", gid).Error; err != nil {
 		t.Fatal(err)
 	}
-	rows, err := dietrepo.FoodsAllWithVariantSiblings([]uint{a.ID})
+	rows, err := dietrepo.FoodsAllWithVariantSiblings(context.Background(), []uint{a.ID})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -102,15 +104,15 @@ func TestCompositeFoodCreateAndByID(t *testing.T) {
 			Amount: 2,
 		}},
 	}
-	id, err := dietrepo.CompositeFoodCreate(cf)
+	id, err := dietrepo.CompositeFoodCreate(context.Background(), cf)
 	if err != nil {
 		t.Fatal(err)
 	}
-	all, err := dietrepo.CompositeFoodsAll()
+	all, err := dietrepo.CompositeFoodsAll(context.Background())
 	if err != nil || len(all) != 1 {
 		t.Fatalf("all %+v err %v", all, err)
 	}
-	loaded, err := dietrepo.CompositeFoodByID(id)
+	loaded, err := dietrepo.CompositeFoodByID(context.Background(), id)
 	if err != nil || loaded.Name != "Scramble" {
 		t.Fatalf("loaded %+v err %v", loaded, err)
 	}
@@ -119,7 +121,7 @@ func TestCompositeFoodCreateAndByID(t *testing.T) {
 func TestUpdatePlanMacros(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	p := testutil.SeedPlan(t, db, "Cut")
-	updated, err := dietrepo.UpdatePlanMacros(p.ID, 1800, 140, 25, 150, 55)
+	updated, err := dietrepo.UpdatePlanMacros(context.Background(), p.ID, 1800, 140, 25, 150, 55)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -130,8 +132,27 @@ func TestUpdatePlanMacros(t *testing.T) {
 
 func TestUpdatePlanMacros_notFound(t *testing.T) {
 	testutil.SetupTestDB(t)
-	_, err := dietrepo.UpdatePlanMacros(9999, 1, 1, 1, 1, 1)
+	_, err := dietrepo.UpdatePlanMacros(context.Background(), 9999, 1, 1, 1, 1, 1)
 	if err == nil {
 		t.Fatal("expected error")
 	}
 }
+
+func TestFoodsAll_scopedToContextUser(t *testing.T) {
+	testutil.SetupTestDB(t)
+	alice := database.WithUserID(context.Background(), 1)
+	bob := database.WithUserID(context.Background(), 2)
+	for _, ctx := range []context.Context{alice, bob} {
+		f := &models.Food{Name: "Oats", ServingType: "g", ServingAmount: 40, Calories: 150}
+		if err := dietrepo.FoodCreate(ctx, f); err != nil {
+			t.Fatalf("same name for a second user should be allowed: %v", err)
+		}
+	}
+	foods, err := dietrepo.FoodsAll(bob, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(foods) != 1 || foods[0].UserID != 2 {
+		t.Fatalf("bob foods: %+v", foods)
+	}
+}
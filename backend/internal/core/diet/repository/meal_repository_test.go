@@ -4,6 +4,7 @@ import (
 	"be-simpletracker/internal/core/diet/models"
 	dietrepo "be-simpletracker/internal/core/diet/repository"
 	"be-simpletracker/internal/core/diet/testutil"
+	"context"
 	"strings"
 	"testing"
 )
@@ -11,14 +12,14 @@ import (
 func TestMealCreateAndMealByID(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	food := testutil.SeedFood(t, db, "Yogurt", testutil.DefaultMacros())
-	id, err := dietrepo.MealCreate(&models.Meal{
+	id, err := dietrepo.MealCreate(context.Background(), &models.Meal{
 		Name:  "Breakfast",
 		Items: []models.MealItem{{FoodID: food.ID, Amount: 1}},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	meal, err := dietrepo.MealByID(id)
+	meal, err := dietrepo.MealByID(context.Background(), id)
 	if err != nil || meal.Name != "Breakfast" {
 		t.Fatalf("meal %+v err %v", meal, err)
 	}
@@ -29,7 +30,7 @@ func TestMealsAll_excludesIDs(t *testing.T) {
 	food := testutil.SeedFood(t, db, "Milk", testutil.DefaultMacros())
 	a := testutil.SeedMeal(t, db, "A", food.ID, 1)
 	testutil.SeedMeal(t, db, "B", food.ID, 1)
-	meals, err := dietrepo.MealsAll([]uint{a.ID})
+	meals, err := dietrepo.MealsAll(context.Background(), []uint{a.ID})
 	if err != nil || len(meals) != 1 || meals[0].Name != "B" {
 		t.Fatalf("meals %+v err %v", meals, err)
 	}
@@ -38,32 +39,32 @@ func TestMealsAll_excludesIDs(t *testing.T) {
 func TestSavedMealCRUD(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	food := testutil.SeedFood(t, db, "Cheese", testutil.DefaultMacros())
-	id, err := dietrepo.SavedMealCreate(&models.SavedMeal{
+	id, err := dietrepo.SavedMealCreate(context.Background(), &models.SavedMeal{
 		Name:  "Snack Plate",
 		Items: []models.SavedMealItem{{FoodID: food.ID, Amount: 1}},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	sm, err := dietrepo.SavedMealByID(id)
+	sm, err := dietrepo.SavedMealByID(context.Background(), id)
 	if err != nil || sm.Name != "Snack Plate" {
 		t.Fatalf("sm %+v err %v", sm, err)
 	}
-	all, err := dietrepo.SavedMealsAll(nil)
+	all, err := dietrepo.SavedMealsAll(context.Background(), nil)
 	if err != nil || len(all) != 1 {
 		t.Fatalf("all %+v err %v", all, err)
 	}
-	if err := dietrepo.SavedMealReplace(id, &models.SavedMeal{
+	if err := dietrepo.SavedMealReplace(context.Background(), id, &models.SavedMeal{
 		Name:  "Updated Plate",
 		Items: []models.SavedMealItem{{FoodID: food.ID, Amount: 2}},
 	}); err != nil {
 		t.Fatal(err)
 	}
-	updated, err := dietrepo.SavedMealByID(id)
+	updated, err := dietrepo.SavedMealByID(context.Background(), id)
 	if err != nil || updated.Name != "Updated Plate" {
 		t.Fatalf("updated %+v", updated)
 	}
-	if err := dietrepo.SavedMealDelete(id); err != nil {
+	if err := dietrepo.SavedMealDelete(context.Background(), id); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -82,10 +83,10 @@ func TestDeleteSavedMeal_clearsUnloggedPlanned(t *testing.T) {
 	if err := db.Create(&pm).Error; err != nil {
 		t.Fatal(err)
 	}
-	if err := dietrepo.DeleteSavedMeal(sm.ID); err != nil {
+	if err := dietrepo.DeleteSavedMeal(context.Background(), sm.ID); err != nil {
 		t.Fatal(err)
 	}
-	count, err := dietrepo.CountUnloggedPlannedBySavedMealID(sm.ID)
+	count, err := dietrepo.CountUnloggedPlannedBySavedMealID(context.Background(), sm.ID)
 	if err != nil || count != 0 {
 		t.Fatalf("count %d err %v", count, err)
 	}
@@ -104,14 +105,14 @@ func TestPlannedMealLifecycle(t *testing.T) {
 	m2 := testutil.SeedMeal(t, db, "Salad 2", food.ID, 1)
 	pm1 := testutil.SeedPlannedMeal(t, db, day.ID, m1.ID, 0, false)
 	pm2 := testutil.SeedPlannedMeal(t, db, day.ID, m2.ID, 1, false)
-	next, err := dietrepo.NextPlannedMealDisplayOrder(day.ID)
+	next, err := dietrepo.NextPlannedMealDisplayOrder(context.Background(), day.ID)
 	if err != nil || next != 2 {
 		t.Fatalf("next %d err %v", next, err)
 	}
-	if err := dietrepo.PlannedMealReorder(day.ID, []uint{pm2.ID, pm1.ID}); err != nil {
+	if err := dietrepo.PlannedMealReorder(context.Background(), day.ID, []uint{pm2.ID, pm1.ID}); err != nil {
 		t.Fatal(err)
 	}
-	if err := dietrepo.PlannedMealDelete(pm1.ID, day.ID); err != nil {
+	if err := dietrepo.PlannedMealDelete(context.Background(), pm1.ID, day.ID); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -125,11 +126,11 @@ func TestPlannedMealReorder_mismatch(t *testing.T) {
 	m2 := testutil.SeedMeal(t, db, "Soup B", food.ID, 1)
 	pm1 := testutil.SeedPlannedMeal(t, db, day.ID, m1.ID, 0, false)
 	pm2 := testutil.SeedPlannedMeal(t, db, day.ID, m2.ID, 1, false)
-	err := dietrepo.PlannedMealReorder(day.ID, []uint{pm1.ID, pm1.ID})
+	err := dietrepo.PlannedMealReorder(context.Background(), day.ID, []uint{pm1.ID, pm1.ID})
 	if err == nil || !strings.Contains(err.Error(), "duplicate") {
 		t.Fatalf("expected duplicate error, got %v", err)
 	}
-	err = dietrepo.PlannedMealReorder(day.ID, []uint{pm1.ID, pm2.ID, pm1.ID})
+	err = dietrepo.PlannedMealReorder(context.Background(), day.ID, []uint{pm1.ID, pm2.ID, pm1.ID})
 	if err == nil || !strings.Contains(err.Error(), "mismatch") {
 		t.Fatalf("expected mismatch error, got %v", err)
 	}
@@ -148,10 +149,10 @@ func TestAddPlannedMealFromSavedMeal(t *testing.T) {
 			Amount: 1,
 		}},
 	}
-	if err := dietrepo.AddPlannedMealFromSavedMeal(0, sm.ID, meal); err != nil {
+	if err := dietrepo.AddPlannedMealFromSavedMeal(context.Background(), 0, sm.ID, meal); err != nil {
 		t.Fatal(err)
 	}
-	count, err := dietrepo.CountUnloggedPlannedBySavedMealID(sm.ID)
+	count, err := dietrepo.CountUnloggedPlannedBySavedMealID(context.Background(), sm.ID)
 	if err != nil || count != 1 {
 		t.Fatalf("count %d err %v", count, err)
 	}
@@ -170,10 +171,10 @@ func TestDeleteUnloggedPlannedBySavedMealID(t *testing.T) {
 	}).Error; err != nil {
 		t.Fatal(err)
 	}
-	if err := dietrepo.DeleteUnloggedPlannedBySavedMealID(sm.ID); err != nil {
+	if err := dietrepo.DeleteUnloggedPlannedBySavedMealID(context.Background(), sm.ID); err != nil {
 		t.Fatal(err)
 	}
-	count, _ := dietrepo.CountUnloggedPlannedBySavedMealID(sm.ID)
+	count, _ := dietrepo.CountUnloggedPlannedBySavedMealID(context.Background(), sm.ID)
 	if count != 0 {
 		t.Fatalf("count %d", count)
 	}
@@ -184,7 +185,7 @@ func TestFoodCreate(t *testing.T) {
 	f := &models.Food{
 		Name: "Direct", ServingType: "g", ServingAmount: 1, Calories: 1,
 	}
-	if err := dietrepo.FoodCreate(f); err != nil {
+	if err := dietrepo.FoodCreate(context.Background(), f); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -4,17 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
-	"time"
 
 	"be-simpletracker/internal/core/diet/models"
+	"be-simpletracker/internal/database"
 	dbrepo "be-simpletracker/internal/database/repository"
 	"be-simpletracker/internal/utils"
 
 	"gorm.io/gorm"
 )
 
-func EnrichFoodVariants(f *models.Food) {
+func EnrichFoodVariants(ctx context.Context, f *models.Food) {
 	if f == nil {
 		return
 	}
@@ -23,7 +24,7 @@ func EnrichFoodVariants(f *models.Food) {
 		return
 	}
 	var sibs []models.Food
-	if err := conn().Where("variant_group_id = ? AND id != ?", *f.VariantGroupID, f.ID).Order("name ASC").Find(&sibs).Error; err != nil {
+	if err := conn(ctx).Where("variant_group_id = ? AND id != ?", *f.VariantGroupID, f.ID).Order("name ASC").Find(&sibs).Error; err != nil {
 		return
 	}
 	for i := range sibs {
@@ -32,47 +33,47 @@ func EnrichFoodVariants(f *models.Food) {
 	f.Variants = sibs
 }
 
-func enrichMealFoodVariants(m *models.Meal) {
+func enrichMealFoodVariants(ctx context.Context, m *models.Meal) {
 	if m == nil {
 		return
 	}
 	for i := range m.Items {
 		if m.Items[i].Food.ID != 0 {
-			EnrichFoodVariants(&m.Items[i].Food)
+			EnrichFoodVariants(ctx, &m.Items[i].Food)
 		}
 	}
 }
 
-func enrichSavedMealFoodVariants(sm *models.SavedMeal) {
+func enrichSavedMealFoodVariants(ctx context.Context, sm *models.SavedMeal) {
 	if sm == nil {
 		return
 	}
 	for i := range sm.Items {
 		if sm.Items[i].Food.ID != 0 {
-			EnrichFoodVariants(&sm.Items[i].Food)
+			EnrichFoodVariants(ctx, &sm.Items[i].Food)
 		}
 	}
 }
 
-func enrichDietDayFoodVariants(d *models.DietDay) {
+func enrichDietDayFoodVariants(ctx context.Context, d *models.DietDay) {
 	if d == nil {
 		return
 	}
 	for i := range d.PlannedMeals {
 		if d.PlannedMeals[i].Meal.ID != 0 {
-			enrichMealFoodVariants(&d.PlannedMeals[i].Meal)
+			enrichMealFoodVariants(ctx, &d.PlannedMeals[i].Meal)
 		}
 	}
 	for i := range d.Logs {
 		if d.Logs[i].Meal.ID != 0 {
-			enrichMealFoodVariants(&d.Logs[i].Meal)
+			enrichMealFoodVariants(ctx, &d.Logs[i].Meal)
 		}
 	}
 }
 
-func FoodsAll(excludeIDs []uint) ([]models.Food, error) {
+func FoodsAll(ctx context.Context, excludeIDs []uint) ([]models.Food, error) {
 	var foods []models.Food
-	query := conn().Model(&models.Food{}).Where("quick_entry = ? OR quick_entry IS NULL", false).Order("name ASC")
+	query := conn(ctx).Model(&models.Food{}).Where("quick_entry = ? OR quick_entry IS NULL", false).Order("name ASC")
 	if len(excludeIDs) > 0 {
 		query = query.Where("id NOT IN ?", excludeIDs)
 	}
@@ -82,13 +83,13 @@ func FoodsAll(excludeIDs []uint) ([]models.Food, error) {
 	return foods, nil
 }
 
-func FoodCreate(food *models.Food) error {
-	return conn().Create(food).Error
+func FoodCreate(ctx context.Context, food *models.Food) error {
+	return conn(ctx).Create(food).Error
 }
 
 // FoodCreateWithOptionalRelated inserts a food and, if set, links it to a related food's variant group.
-func FoodCreateWithOptionalRelated(food *models.Food, relatedFoodID *uint) error {
-	return conn().Transaction(func(tx *gorm.DB) error {
+func FoodCreateWithOptionalRelated(ctx context.Context, food *models.Food, relatedFoodID *uint) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
 		food.ID = 0
 		if err := tx.Create(food).Error; err != nil {
 			return err
@@ -131,9 +132,9 @@ func linkNewFoodToRelatedInTx(tx *gorm.DB, food *models.Food, relatedFoodID uint
 	return nil
 }
 
-func foodsByVariantGroup() (map[uint][]models.Food, error) {
+func foodsByVariantGroup(ctx context.Context) (map[uint][]models.Food, error) {
 	var list []models.Food
-	if err := conn().Model(&models.Food{}).Where("variant_group_id IS NOT NULL").Order("name ASC").Find(&list).Error; err != nil {
+	if err := conn(ctx).Model(&models.Food{}).Where("variant_group_id IS NOT NULL").Order("name ASC").Find(&list).Error; err != nil {
 		return nil, err
 	}
 	m := make(map[uint][]models.Food)
@@ -148,12 +149,12 @@ func foodsByVariantGroup() (map[uint][]models.Food, error) {
 }
 
 // FoodsAllWithVariantSiblings returns all foods (with excludes) and attaches sibling rows per variant group.
-func FoodsAllWithVariantSiblings(excludeIDs []uint) ([]models.FoodWithVariants, error) {
-	foods, err := FoodsAll(excludeIDs)
+func FoodsAllWithVariantSiblings(ctx context.Context, excludeIDs []uint) ([]models.FoodWithVariants, error) {
+	foods, err := FoodsAll(ctx, excludeIDs)
 	if err != nil {
 		return nil, err
 	}
-	byGroup, err := foodsByVariantGroup()
+	byGroup, err := foodsByVariantGroup(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -175,9 +176,9 @@ func FoodsAllWithVariantSiblings(excludeIDs []uint) ([]models.FoodWithVariants,
 	return out, nil
 }
 
-func CompositeFoodsAll() ([]models.CompositeFood, error) {
+func CompositeFoodsAll(ctx context.Context) ([]models.CompositeFood, error) {
 	var list []models.CompositeFood
-	if err := conn().Preload("Items.Food").Order("name ASC").Find(&list).Error; err != nil {
+	if err := conn(ctx).Preload("Items.Food").Order("name ASC").Find(&list).Error; err != nil {
 		return nil, err
 	}
 	for i := range list {
@@ -188,20 +189,74 @@ func CompositeFoodsAll() ([]models.CompositeFood, error) {
 	return list, nil
 }
 
-func CompositeFoodCreate(cf *models.CompositeFood) (uint, error) {
+// ErrUnknownFood is returned when an item names a food or composite food the user does not
+// have, whether it never existed or belongs to someone else.
+var ErrUnknownFood = errors.New("food not found")
+
+// requireOwnFoods checks that the user has every food and composite food named. The owner
+// scope hides other users' rows, so one of theirs counts as missing.
+func requireOwnFoods(tx *gorm.DB, foodIDs, compositeFoodIDs []uint) error {
+	for _, refs := range []struct {
+		model any
+		ids   []uint
+	}{{&models.Food{}, foodIDs}, {&models.CompositeFood{}, compositeFoodIDs}} {
+		slices.Sort(refs.ids)
+		ids := slices.Compact(refs.ids)
+		if len(ids) == 0 {
+			continue
+		}
+		var found int64
+		if err := tx.Model(refs.model).Where("id IN ?", ids).Count(&found).Error; err != nil {
+			return err
+		}
+		if found != int64(len(ids)) {
+			return ErrUnknownFood
+		}
+	}
+	return nil
+}
+
+func requireOwnMealItemFoods(tx *gorm.DB, items []models.MealItem) error {
+	var foods, composites []uint
+	for _, it := range items {
+		foods = append(foods, it.FoodID)
+		if it.CompositeFoodID != nil {
+			composites = append(composites, *it.CompositeFoodID)
+		}
+	}
+	return requireOwnFoods(tx, foods, composites)
+}
+
+func requireOwnSavedMealItemFoods(tx *gorm.DB, items []models.SavedMealItem) error {
+	var foods, composites []uint
+	for _, it := range items {
+		foods = append(foods, it.FoodID)
+		if it.CompositeFoodID != nil {
+			composites = append(composites, *it.CompositeFoodID)
+		}
+	}
+	return requireOwnFoods(tx, foods, composites)
+}
+
+func CompositeFoodCreate(ctx context.Context, cf *models.CompositeFood) (uint, error) {
 	cf.ID = 0
+	foods := make([]uint, 0, len(cf.Items))
 	for i := range cf.Items {
 		cf.Items[i].ID = 0
+		foods = append(foods, cf.Items[i].FoodID)
+	}
+	if err := requireOwnFoods(conn(ctx), foods, nil); err != nil {
+		return 0, err
 	}
-	if err := conn().Create(cf).Error; err != nil {
+	if err := conn(ctx).Create(cf).Error; err != nil {
 		return 0, err
 	}
 	return cf.ID, nil
 }
 
-func MealsAll(excludeIDs []uint) ([]models.Meal, error) {
+func MealsAll(ctx context.Context, excludeIDs []uint) ([]models.Meal, error) {
 	var meals []models.Meal
-	query := conn().Model(&models.Meal{}).Order("name ASC")
+	query := conn(ctx).Model(&models.Meal{}).Order("name ASC")
 	if len(excludeIDs) > 0 {
 		query = query.Where("id NOT IN ?", excludeIDs)
 	}
@@ -211,9 +266,9 @@ func MealsAll(excludeIDs []uint) ([]models.Meal, error) {
 	return meals, nil
 }
 
-func SavedMealsAll(excludeIDs []uint) ([]models.SavedMeal, error) {
+func SavedMealsAll(ctx context.Context, excludeIDs []uint) ([]models.SavedMeal, error) {
 	var meals []models.SavedMeal
-	query := conn().Model(&models.SavedMeal{}).Order("name ASC")
+	query := conn(ctx).Model(&models.SavedMeal{}).Order("name ASC")
 	if len(excludeIDs) > 0 {
 		query = query.Where("id NOT IN ?", excludeIDs)
 	}
@@ -222,58 +277,67 @@ func SavedMealsAll(excludeIDs []uint) ([]models.SavedMeal, error) {
 	}
 	for i := range meals {
 		if meals[i].Items != nil {
-			enrichSavedMealFoodVariants(&meals[i])
+			enrichSavedMealFoodVariants(ctx, &meals[i])
 		}
 	}
 	return meals, nil
 }
 
-func SavedMealCreate(sm *models.SavedMeal) (uint, error) {
+func SavedMealCreate(ctx context.Context, sm *models.SavedMeal) (uint, error) {
 	for i := range sm.Items {
 		sm.Items[i].ID = 0
 	}
-	if err := conn().Create(sm).Error; err != nil {
+	if err := requireOwnSavedMealItemFoods(conn(ctx), sm.Items); err != nil {
+		return 0, err
+	}
+	if err := conn(ctx).Create(sm).Error; err != nil {
 		return 0, err
 	}
 	return sm.ID, nil
 }
 
-func SavedMealByID(id uint) (*models.SavedMeal, error) {
+func SavedMealByID(ctx context.Context, id uint) (*models.SavedMeal, error) {
 	var sm models.SavedMeal
-	if err := conn().Preload("Items.Food").First(&sm, id).Error; err != nil {
+	if err := conn(ctx).Preload("Items.Food").First(&sm, id).Error; err != nil {
 		return nil, err
 	}
-	enrichSavedMealFoodVariants(&sm)
+	enrichSavedMealFoodVariants(ctx, &sm)
 	return &sm, nil
 }
 
-func SavedMealDelete(id uint) error {
+func SavedMealDelete(ctx context.Context, id uint) error {
 	var sm models.SavedMeal
-	if err := conn().First(&sm, id).Error; err != nil {
+	if err := conn(ctx).First(&sm, id).Error; err != nil {
 		return err
 	}
-	return conn().Delete(&sm).Error
+	return conn(ctx).Delete(&sm).Error
 }
 
-func CountUnloggedPlannedBySavedMealID(savedMealID uint) (int64, error) {
+func CountUnloggedPlannedBySavedMealID(ctx context.Context, savedMealID uint) (int64, error) {
 	var count int64
-	err := conn().Model(&models.PlannedMeal{}).
+	err := conn(ctx).Model(&models.PlannedMeal{}).
 		Where("saved_meal_id = ? AND logged = ?", savedMealID, false).
 		Count(&count).Error
 	return count, err
 }
 
-func DeleteUnloggedPlannedBySavedMealID(savedMealID uint) error {
-	return conn().Where("saved_meal_id = ? AND logged = ?", savedMealID, false).Delete(&models.PlannedMeal{}).Error
+func DeleteUnloggedPlannedBySavedMealID(ctx context.Context, savedMealID uint) error {
+	return conn(ctx).Where("saved_meal_id = ? AND logged = ?", savedMealID, false).Delete(&models.PlannedMeal{}).Error
 }
 
 // SavedMealReplace updates the template name and replaces all items in one transaction.
-func SavedMealReplace(id uint, incoming *models.SavedMeal) error {
-	return conn().Transaction(func(tx *gorm.DB) error {
+func SavedMealReplace(ctx context.Context, id uint, incoming *models.SavedMeal) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
 		var existing models.SavedMeal
 		if err := tx.First(&existing, id).Error; err != nil {
 			return err
 		}
+		if err := requireOwnSavedMealItemFoods(tx, incoming.Items); err != nil {
+			return err
+		}
+		if _, err := database.ClaimVersion(tx, &models.SavedMeal{}, id); err != nil {
+			return err
+		}
 		if err := tx.Where("saved_meal_id = ?", id).Delete(&models.SavedMealItem{}).Error; err != nil {
 			return err
 		}
@@ -292,30 +356,30 @@ func SavedMealReplace(id uint, incoming *models.SavedMeal) error {
 	})
 }
 
-func PlannedMealCreate(pm *models.PlannedMeal) error {
+func PlannedMealCreate(ctx context.Context, pm *models.PlannedMeal) error {
 	pm.ID = 0
-	return conn().Create(pm).Error
+	return conn(ctx).Create(pm).Error
 }
 
-func PlannedMealDelete(plannedMealID uint, dayID uint) error {
+func PlannedMealDelete(ctx context.Context, plannedMealID uint, dayID uint) error {
 	var pm models.PlannedMeal
-	if err := conn().Where("id = ? AND day_id = ?", plannedMealID, dayID).First(&pm).Error; err != nil {
+	if err := conn(ctx).Where("id = ? AND day_id = ?", plannedMealID, dayID).First(&pm).Error; err != nil {
 		return err
 	}
-	return conn().Delete(&pm).Error
+	return conn(ctx).Delete(&pm).Error
 }
 
-func NextPlannedMealDisplayOrder(dayID uint) (int, error) {
+func NextPlannedMealDisplayOrder(ctx context.Context, dayID uint) (int, error) {
 	var next int
-	err := conn().Model(&models.PlannedMeal{}).
+	err := conn(ctx).Model(&models.PlannedMeal{}).
 		Where("day_id = ? AND logged = ?", dayID, false).
 		Select("COALESCE(MAX(display_order), -1) + 1").
 		Scan(&next).Error
 	return next, err
 }
 
-func PlannedMealReorder(dayID uint, orderedIDs []uint) error {
-	return conn().Transaction(func(tx *gorm.DB) error {
+func PlannedMealReorder(ctx context.Context, dayID uint, orderedIDs []uint) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
 		var count int64
 		if err := tx.Model(&models.PlannedMeal{}).
 			Where("day_id = ? AND logged = ?", dayID, false).
@@ -353,32 +417,28 @@ func PlannedMealReorder(dayID uint, orderedIDs []uint) error {
 	})
 }
 
-func MealByID(id uint) (*models.Meal, error) {
+func MealByID(ctx context.Context, id uint) (*models.Meal, error) {
 	var meal models.Meal
-	if err := conn().Preload("Items.Food").First(&meal, id).Error; err != nil {
+	if err := conn(ctx).Preload("Items.Food").First(&meal, id).Error; err != nil {
 		return nil, err
 	}
-	enrichMealFoodVariants(&meal)
+	enrichMealFoodVariants(ctx, &meal)
 	return &meal, nil
 }
 
-func MealCreate(meal *models.Meal) (uint, error) {
+func MealCreate(ctx context.Context, meal *models.Meal) (uint, error) {
 	for i := range meal.Items {
 		meal.Items[i].ID = 0
 	}
-	if err := conn().Create(meal).Error; err != nil {
+	if err := requireOwnMealItemFoods(conn(ctx), meal.Items); err != nil {
+		return 0, err
+	}
+	if err := conn(ctx).Create(meal).Error; err != nil {
 		return 0, err
 	}
 	return meal.ID, nil
 }
 
-func calendarDayRange(t time.Time) (start, end time.Time) {
-	loc := t.Location()
-	start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
-	end = start.Add(24 * time.Hour)
-	return start, end
-}
-
 func createDefaultPlan(db *gorm.DB) (models.Plan, error) {
 	p := models.Plan{
 		Name:     "Default",
@@ -394,11 +454,11 @@ func createDefaultPlan(db *gorm.DB) (models.Plan, error) {
 	return p, nil
 }
 
-func planForDate(db *gorm.DB, t time.Time) (models.Plan, error) {
-	start, _ := calendarDayRange(t)
+// planForDate returns the plan in effect on date. Plans take effect from local midnight.
+func planForDate(db *gorm.DB, date database.Date) (models.Plan, error) {
 	var plan models.Plan
 	err := db.
-		Where("effective_from IS NOT NULL AND effective_from <= ?", start).
+		Where("effective_from IS NOT NULL AND effective_from <= ?", date).
 		Order("effective_from DESC, id DESC").
 		First(&plan).Error
 	if err == nil {
@@ -417,39 +477,10 @@ func planForDate(db *gorm.DB, t time.Time) (models.Plan, error) {
 	return plan, nil
 }
 
-// findOrCreateDietDayForCalendarDate returns a day row for the wall-clock calendar day of t (location from t).
-func findOrCreateDietDayForCalendarDate(t time.Time) (models.DietDay, error) {
-	start, end := calendarDayRange(t)
-	var day models.DietDay
-	err := conn().Where("date >= ? AND date < ?", start, end).First(&day).Error
-	if err == nil {
-		return day, nil
-	}
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return models.DietDay{}, err
-	}
-
-	plan, err := planForDate(conn(), t)
-	if err != nil {
-		return models.DietDay{}, err
-	}
-
-	loc := t.Location()
-	atMidnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
-	day = models.DietDay{
-		Date:   atMidnight,
-		PlanID: plan.ID,
-	}
-	if err := conn().Create(&day).Error; err != nil {
-		if isUniqueConstraintError(err) {
-			if err := conn().Where("date >= ? AND date < ?", start, end).First(&day).Error; err != nil {
-				return models.DietDay{}, err
-			}
-			return day, nil
-		}
-		return models.DietDay{}, err
-	}
-	return day, nil
+// findOrCreateDietDayForCalendarDate returns the day row for date, creating it on the plan in
+// effect that day.
+func findOrCreateDietDayForCalendarDate(ctx context.Context, date database.Date) (models.DietDay, error) {
+	return findOrCreateDietDayForCalendarDateInTx(conn(ctx), date)
 }
 
 func isUniqueConstraintError(err error) bool {
@@ -462,9 +493,9 @@ func isUniqueConstraintError(err error) bool {
 		strings.Contains(s, "unique constraint failed")
 }
 
-func loadDietDayWithPreloads(id uint) (models.DietDay, error) {
+func loadDietDayWithPreloads(ctx context.Context, id uint) (models.DietDay, error) {
 	var day models.DietDay
-	if err := conn().
+	if err := conn(ctx).
 		Preload("PlannedMeals", func(db *gorm.DB) *gorm.DB {
 			return db.Where("logged = ?", false).Order("display_order ASC, id ASC")
 		}).
@@ -474,22 +505,23 @@ func loadDietDayWithPreloads(id uint) (models.DietDay, error) {
 		First(&day, id).Error; err != nil {
 		return models.DietDay{}, err
 	}
-	enrichDietDayFoodVariants(&day)
+	enrichDietDayFoodVariants(ctx, &day)
 	return day, nil
 }
 
-func DayMealPlanToday(offset int) (models.DietDay, error) {
-	d, err := findOrCreateDietDayForCalendarDate(utils.ZerodTime(offset))
+func DayMealPlanToday(ctx context.Context, offset int) (models.DietDay, error) {
+	d, err := findOrCreateDietDayForCalendarDate(ctx, utils.Today(ctx, offset))
 	if err != nil {
 		return models.DietDay{}, err
 	}
-	return loadDietDayWithPreloads(d.ID)
+	return loadDietDayWithPreloads(ctx, d.ID)
 }
 
-// CountUnloggedPlannedMealsPerCalendarDay returns YYYY-MM-DD (loc) → count for that calendar day.
-func CountUnloggedPlannedMealsPerCalendarDay(start, end time.Time) (map[string]int, error) {
-	out := make(map[string]int)
-	rows, err := conn().Model(&models.PlannedMeal{}).
+// CountUnloggedPlannedMealsPerCalendarDay returns how many planned meals are still unlogged on
+// each day from start to end.
+func CountUnloggedPlannedMealsPerCalendarDay(ctx context.Context, start, end database.Date) (map[database.Date]int, error) {
+	out := make(map[database.Date]int)
+	rows, err := conn(ctx).Model(&models.PlannedMeal{}).
 		Joins("JOIN days ON days.id = planned_meals.day_id").
 		Where("planned_meals.logged = ?", false).
 		Where("days.date >= ? AND days.date <= ?", start, end).
@@ -502,32 +534,30 @@ func CountUnloggedPlannedMealsPerCalendarDay(start, end time.Time) (map[string]i
 	}
 	defer rows.Close()
 	for rows.Next() {
-		var d time.Time
+		var d database.Date
 		if err := rows.Scan(&d); err != nil {
 			return nil, err
 		}
-		loc := d.Location()
-		k := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc).Format("2006-01-02")
-		out[k]++
+		out[d]++
 	}
 	return out, rows.Err()
 }
 
-func DayByID(id int) (*models.DietDay, error) {
-	day, err := loadDietDayWithPreloads(uint(id))
+func DayByID(ctx context.Context, id int) (*models.DietDay, error) {
+	day, err := loadDietDayWithPreloads(ctx, uint(id))
 	if err != nil {
 		return nil, err
 	}
 	return &day, nil
 }
 
-func DaysByDateRange(ctx context.Context, start, end time.Time) ([]models.DietDay, error) {
-	repo := dbrepo.NewGormRepository[models.DietDay](conn())
+func DaysByDateRange(ctx context.Context, start, end database.Date) ([]models.DietDay, error) {
+	repo := dbrepo.NewGormRepository[models.DietDay](conn(ctx))
 	return repo.GetByDateRange(ctx, start, end, dbrepo.WithDefaultPreloads())
 }
 
 func DayByIDGeneric(ctx context.Context, id uint) (models.DietDay, error) {
-	repo := dbrepo.NewGormRepository[models.DietDay](conn())
+	repo := dbrepo.NewGormRepository[models.DietDay](conn(ctx))
 	return repo.GetByID(ctx, id, dbrepo.WithDefaultPreloads())
 }
 
@@ -540,60 +570,59 @@ type MealDayTotals struct {
 	Fat      float32
 }
 
-func CalculateTotals(dayID uint) MealDayTotals {
+func CalculateTotals(ctx context.Context, dayID uint) MealDayTotals {
 	var totals MealDayTotals
-	conn().Raw(`
-        SELECT
+	conn(ctx).Table("day_logs dl").
+		Select(`
             SUM(f.calories * mi.amount) AS calories,
             SUM(f.protein  * mi.amount) AS protein,
             SUM(f.fiber    * mi.amount) AS fiber,
             SUM(f.carbs    * mi.amount) AS carbs,
-            SUM(COALESCE(f.fat, 0) * mi.amount) AS fat
-        FROM day_logs dl
-        JOIN meals m       ON dl.meal_id = m.id
-        JOIN meal_items mi ON mi.meal_id = m.id
-        JOIN foods f       ON f.id = mi.food_id
-        WHERE dl.day_id = ?
-        AND dl.deleted_at IS NULL
-    `, dayID).Scan(&totals)
+            SUM(COALESCE(f.fat, 0) * mi.amount) AS fat`).
+		Joins("JOIN meals m ON dl.meal_id = m.id").
+		Joins("JOIN meal_items mi ON mi.meal_id = m.id").
+		Joins("JOIN foods f ON f.id = mi.food_id").
+		Where("dl.day_id = ? AND dl.deleted_at IS NULL", dayID).
+		Scopes(database.OwnedBy(ctx, "dl"), database.OwnedBy(ctx, "m"), database.OwnedBy(ctx, "mi"), database.OwnedBy(ctx, "f")).
+		Scan(&totals)
 	return totals
 }
 
-func AllMealDays() ([]models.DietDay, error) {
+func AllMealDays(ctx context.Context) ([]models.DietDay, error) {
 	var days []models.DietDay
-	if err := conn().Find(&days).Error; err != nil {
+	if err := conn(ctx).Find(&days).Error; err != nil {
 		return nil, err
 	}
 	return days, nil
 }
 
-func GoalsToday() (*models.Plan, error) {
-	todayDay, err := findOrCreateDietDayForCalendarDate(utils.ZerodTime(0))
+func GoalsToday(ctx context.Context) (*models.Plan, error) {
+	todayDay, err := findOrCreateDietDayForCalendarDate(ctx, utils.Today(ctx, 0))
 	if err != nil {
 		return nil, err
 	}
 	var plan models.Plan
-	if err := conn().First(&plan, todayDay.PlanID).Error; err != nil {
+	if err := conn(ctx).First(&plan, todayDay.PlanID).Error; err != nil {
 		return nil, err
 	}
 	return &plan, nil
 }
 
-func FindDayByDate(date time.Time) (*models.DietDay, error) {
-	day, err := findOrCreateDietDayForCalendarDate(date)
+func FindDayByDate(ctx context.Context, date database.Date) (*models.DietDay, error) {
+	day, err := findOrCreateDietDayForCalendarDate(ctx, date)
 	if err != nil {
 		return nil, err
 	}
 	return &day, nil
 }
 
-func CreateDayMeal(dayMeal *models.DayLog) error {
-	return conn().Create(dayMeal).Error
+func CreateDayMeal(ctx context.Context, dayMeal *models.DayLog) error {
+	return conn(ctx).Create(dayMeal).Error
 }
 
-func DayLogExists(dayID uint, mealID uint) (bool, error) {
+func DayLogExists(ctx context.Context, dayID uint, mealID uint) (bool, error) {
 	var count int64
-	err := conn().Model(&models.DayLog{}).
+	err := conn(ctx).Model(&models.DayLog{}).
 		Where("day_id = ? AND meal_id = ?", dayID, mealID).
 		Count(&count).Error
 	if err != nil {
@@ -602,34 +631,34 @@ func DayLogExists(dayID uint, mealID uint) (bool, error) {
 	return count > 0, nil
 }
 
-func SetPlannedMealLogged(dayID uint, mealID uint) error {
+func SetPlannedMealLogged(ctx context.Context, dayID uint, mealID uint) error {
 	var pm models.PlannedMeal
-	if err := conn().Where("day_id = ? AND meal_id = ?", dayID, mealID).First(&pm).Error; err != nil {
+	if err := conn(ctx).Where("day_id = ? AND meal_id = ?", dayID, mealID).First(&pm).Error; err != nil {
 		return err
 	}
 	pm.Logged = true
-	return conn().Save(&pm).Error
+	return conn(ctx).Save(&pm).Error
 }
 
-func DeleteLoggedMeal(dayID uint, mealID uint) error {
+func DeleteLoggedMeal(ctx context.Context, dayID uint, mealID uint) error {
 	var log models.DayLog
-	if err := conn().Where("day_id = ? AND meal_id = ?", dayID, mealID).First(&log).Error; err != nil {
+	if err := conn(ctx).Where("day_id = ? AND meal_id = ?", dayID, mealID).First(&log).Error; err != nil {
 		return err
 	}
-	return conn().Delete(&log).Error
+	return conn(ctx).Delete(&log).Error
 }
 
-func UpdateDayLogMeal(dayID uint, oldMealID uint, newMealID uint) error {
+func UpdateDayLogMeal(ctx context.Context, dayID uint, oldMealID uint, newMealID uint) error {
 	var log models.DayLog
-	if err := conn().Where("day_id = ? AND meal_id = ?", dayID, oldMealID).First(&log).Error; err != nil {
+	if err := conn(ctx).Where("day_id = ? AND meal_id = ?", dayID, oldMealID).First(&log).Error; err != nil {
 		return err
 	}
 	log.MealID = newMealID
-	return conn().Save(&log).Error
+	return conn(ctx).Save(&log).Error
 }
 
 func PlansGetAll(ctx context.Context, params utils.QueryParams) (*utils.GetAllResult[models.Plan], error) {
-	repo := dbrepo.NewGormRepository[models.Plan](conn())
+	repo := dbrepo.NewGormRepository[models.Plan](conn(ctx))
 	opts := utils.BuildQueryOptions(params, "id", true)
 	if params.Page > 0 && params.PageSize > 0 {
 		result, err := repo.GetAllPaginated(ctx, params.Page, params.PageSize, opts...)
@@ -651,9 +680,9 @@ func PlansGetAll(ctx context.Context, params utils.QueryParams) (*utils.GetAllRe
 	}, nil
 }
 
-func CompositeFoodByID(id uint) (*models.CompositeFood, error) {
+func CompositeFoodByID(ctx context.Context, id uint) (*models.CompositeFood, error) {
 	var cf models.CompositeFood
-	if err := conn().Preload("Items.Food").First(&cf, id).Error; err != nil {
+	if err := conn(ctx).Preload("Items.Food").First(&cf, id).Error; err != nil {
 		return nil, err
 	}
 	for i := range cf.Items {
@@ -662,15 +691,26 @@ func CompositeFoodByID(id uint) (*models.CompositeFood, error) {
 	return &cf, nil
 }
 
-func UpdatePlanMacros(id uint, calories, protein, fiber, carbs, fat float32) (*models.Plan, error) {
-	effectiveStart, _ := calendarDayRange(utils.ZerodTime(0))
+func PlanByID(ctx context.Context, id uint) (*models.Plan, error) {
+	var plan models.Plan
+	if err := conn(ctx).First(&plan, id).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func UpdatePlanMacros(ctx context.Context, id uint, calories, protein, fiber, carbs, fat float32) (*models.Plan, error) {
+	today := utils.Today(ctx, 0)
 	var plan models.Plan
 
-	if err := conn().Transaction(func(tx *gorm.DB) error {
+	if err := conn(ctx).Transaction(func(tx *gorm.DB) error {
 		var basePlan models.Plan
 		if err := tx.First(&basePlan, id).Error; err != nil {
 			return err
 		}
+		if _, err := database.ClaimVersion(tx, &models.Plan{}, id); err != nil {
+			return err
+		}
 
 		plan = models.Plan{
 			Name:          basePlan.Name,
@@ -679,14 +719,14 @@ func UpdatePlanMacros(id uint, calories, protein, fiber, carbs, fat float32) (*m
 			Fiber:         fiber,
 			Carbs:         carbs,
 			Fat:           fat,
-			EffectiveFrom: &effectiveStart,
+			EffectiveFrom: &today,
 		}
 		if err := tx.Create(&plan).Error; err != nil {
 			return err
 		}
 
 		return tx.Model(&models.DietDay{}).
-			Where("date >= ?", effectiveStart).
+			Where("date >= ?", today).
 			Update("plan_id", plan.ID).Error
 	}); err != nil {
 		return nil, err
@@ -694,8 +734,8 @@ func UpdatePlanMacros(id uint, calories, protein, fiber, carbs, fat float32) (*m
 	return &plan, nil
 }
 
-func DeleteSavedMeal(id uint) error {
-	return conn().Transaction(func(tx *gorm.DB) error {
+func DeleteSavedMeal(ctx context.Context, id uint) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("saved_meal_id = ? AND logged = ?", id, false).Delete(&models.PlannedMeal{}).Error; err != nil {
 			return err
 		}
@@ -707,15 +747,18 @@ func DeleteSavedMeal(id uint) error {
 	})
 }
 
-func AddPlannedMealFromSavedMeal(offset int, savedMealID uint, meal *models.Meal) error {
-	return conn().Transaction(func(tx *gorm.DB) error {
-		day, err := findOrCreateDietDayForCalendarDateInTx(tx, utils.ZerodTime(offset))
+func AddPlannedMealFromSavedMeal(ctx context.Context, offset int, savedMealID uint, meal *models.Meal) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
+		day, err := findOrCreateDietDayForCalendarDateInTx(tx, utils.Today(ctx, offset))
 		if err != nil {
 			return err
 		}
 		for i := range meal.Items {
 			meal.Items[i].ID = 0
 		}
+		if err := requireOwnMealItemFoods(tx, meal.Items); err != nil {
+			return err
+		}
 		if err := tx.Create(meal).Error; err != nil {
 			return err
 		}
@@ -738,29 +781,26 @@ func AddPlannedMealFromSavedMeal(offset int, savedMealID uint, meal *models.Meal
 	})
 }
 
-func findOrCreateDietDayForCalendarDateInTx(tx *gorm.DB, t time.Time) (models.DietDay, error) {
-	start, end := calendarDayRange(t)
+func findOrCreateDietDayForCalendarDateInTx(tx *gorm.DB, date database.Date) (models.DietDay, error) {
 	var day models.DietDay
-	err := tx.Where("date >= ? AND date < ?", start, end).First(&day).Error
+	err := tx.Where("date = ?", date).First(&day).Error
 	if err == nil {
 		return day, nil
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return models.DietDay{}, err
 	}
-	plan, err := planForDate(tx, t)
+	plan, err := planForDate(tx, date)
 	if err != nil {
 		return models.DietDay{}, err
 	}
-	loc := t.Location()
-	atMidnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
 	day = models.DietDay{
-		Date:   atMidnight,
+		Date:   date,
 		PlanID: plan.ID,
 	}
 	if err := tx.Create(&day).Error; err != nil {
 		if isUniqueConstraintError(err) {
-			if err := tx.Where("date >= ? AND date < ?", start, end).First(&day).Error; err != nil {
+			if err := tx.Where("date = ?", date).First(&day).Error; err != nil {
 				return models.DietDay{}, err
 			}
 			return day, nil
@@ -782,9 +822,9 @@ type QuickLogParams struct {
 	ReplaceMealID uint
 }
 
-func QuickLogMeal(params QuickLogParams) (dayID uint, err error) {
-	err = conn().Transaction(func(tx *gorm.DB) error {
-		day, derr := findOrCreateDietDayForCalendarDateInTx(tx, utils.ZerodTime(params.Offset))
+func QuickLogMeal(ctx context.Context, params QuickLogParams) (dayID uint, err error) {
+	err = conn(ctx).Transaction(func(tx *gorm.DB) error {
+		day, derr := findOrCreateDietDayForCalendarDateInTx(tx, utils.Today(ctx, params.Offset))
 		if derr != nil {
 			return derr
 		}
@@ -838,12 +878,15 @@ func QuickLogMeal(params QuickLogParams) (dayID uint, err error) {
 	return dayID, err
 }
 
-func EditLoggedMeal(dayID uint, oldMealID uint, meal *models.Meal) error {
-	return conn().Transaction(func(tx *gorm.DB) error {
+func EditLoggedMeal(ctx context.Context, dayID uint, oldMealID uint, meal *models.Meal) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
 		meal.ID = 0
 		for i := range meal.Items {
 			meal.Items[i].ID = 0
 		}
+		if err := requireOwnMealItemFoods(tx, meal.Items); err != nil {
+			return err
+		}
 		if err := tx.Create(meal).Error; err != nil {
 			return err
 		}
@@ -6,6 +6,7 @@ import (
 
 	"be-simpletracker/internal/core/diet/models"
 	dietrepo "be-simpletracker/internal/core/diet/repository"
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/utils"
 )
 
@@ -19,48 +20,41 @@ type DayWithTotals struct {
 }
 
 func MealPlanToday(ctx context.Context, offset int) (models.DietDay, dietrepo.MealDayTotals, error) {
-	day, err := dietrepo.DayMealPlanToday(offset)
+	day, err := dietrepo.DayMealPlanToday(ctx, offset)
 	if err != nil {
 		return models.DietDay{}, dietrepo.MealDayTotals{}, err
 	}
-	tot := dietrepo.CalculateTotals(day.ID)
+	tot := dietrepo.CalculateTotals(ctx, day.ID)
 	return day, tot, nil
 }
 
 func MealPlanWeek(ctx context.Context) ([]models.DietDay, error) {
-	today := time.Now()
-	start := today.AddDate(0, 0, -3)
-	end := today.AddDate(0, 0, 3)
-	return dietrepo.DaysByDateRange(ctx, start, end)
+	today := utils.Today(ctx, 0)
+	return dietrepo.DaysByDateRange(ctx, today.AddDays(-3), today.AddDays(3))
 }
 
-func MealPlanMonth(ctx context.Context, offset int) (days []models.DietDay, startOfMonth, endOfMonth time.Time, month time.Month, err error) {
-	today := time.Now()
-	target := today.AddDate(0, offset, 0)
-	startOfMonth = time.Date(target.Year(), target.Month(), 1, 0, 0, 0, 0, target.Location())
-	endOfMonth = startOfMonth.AddDate(0, 1, -1)
+func MealPlanMonth(ctx context.Context, offset int) (days []models.DietDay, startOfMonth, endOfMonth database.Date, month time.Month, err error) {
+	target := utils.Today(ctx, 0).AddMonths(offset)
+	startOfMonth = database.Date{Year: target.Year, Month: target.Month, Day: 1}
+	endOfMonth = startOfMonth.AddMonths(1).AddDays(-1)
 	days, err = dietrepo.DaysByDateRange(ctx, startOfMonth, endOfMonth)
 	if err != nil {
 		return nil, startOfMonth, endOfMonth, 0, err
 	}
-	return days, startOfMonth, endOfMonth, target.Month(), nil
+	return days, startOfMonth, endOfMonth, target.Month, nil
 }
 
-func MonthPlannedSummary(_ context.Context, monthOffset int) ([]int, error) {
-	today := time.Now()
-	target := today.AddDate(0, monthOffset, 0)
-	loc := target.Location()
-	startOfMonth := time.Date(target.Year(), target.Month(), 1, 0, 0, 0, 0, loc)
-	endOfMonth := startOfMonth.AddDate(0, 1, -1)
-	dim := endOfMonth.Day()
-	byDate, err := dietrepo.CountUnloggedPlannedMealsPerCalendarDay(startOfMonth, endOfMonth)
+func MonthPlannedSummary(ctx context.Context, monthOffset int) ([]int, error) {
+	target := utils.Today(ctx, 0).AddMonths(monthOffset)
+	startOfMonth := database.Date{Year: target.Year, Month: target.Month, Day: 1}
+	endOfMonth := startOfMonth.AddMonths(1).AddDays(-1)
+	byDate, err := dietrepo.CountUnloggedPlannedMealsPerCalendarDay(ctx, startOfMonth, endOfMonth)
 	if err != nil {
 		return nil, err
 	}
-	out := make([]int, dim)
-	for day := 1; day <= dim; day++ {
-		d := time.Date(target.Year(), target.Month(), day, 0, 0, 0, 0, loc)
-		out[day-1] = byDate[d.Format("2006-01-02")]
+	out := make([]int, endOfMonth.Day)
+	for day := range out {
+		out[day] = byDate[startOfMonth.AddDays(day)]
 	}
 	return out, nil
 }
@@ -70,103 +64,103 @@ func MealPlanDay(ctx context.Context, id uint) (models.DietDay, dietrepo.MealDay
 	if err != nil {
 		return models.DietDay{}, dietrepo.MealDayTotals{}, err
 	}
-	tot := dietrepo.CalculateTotals(day.ID)
+	tot := dietrepo.CalculateTotals(ctx, day.ID)
 	return day, tot, nil
 }
 
-func GoalsToday() (*models.Plan, error) {
-	return dietrepo.GoalsToday()
+func GoalsToday(ctx context.Context) (*models.Plan, error) {
+	return dietrepo.GoalsToday(ctx)
 }
 
-func MealPlanDayByID(id int) (*models.DietDay, error) {
-	return dietrepo.DayByID(id)
+func MealPlanDayByID(ctx context.Context, id int) (*models.DietDay, error) {
+	return dietrepo.DayByID(ctx, id)
 }
 
-func CalculateTotals(dayID uint) dietrepo.MealDayTotals {
-	return dietrepo.CalculateTotals(dayID)
+func CalculateTotals(ctx context.Context, dayID uint) dietrepo.MealDayTotals {
+	return dietrepo.CalculateTotals(ctx, dayID)
 }
 
-func DayWithTotalsByID(id int) (DayWithTotals, error) {
-	day, err := dietrepo.DayByID(id)
+func DayWithTotalsByID(ctx context.Context, id int) (DayWithTotals, error) {
+	day, err := dietrepo.DayByID(ctx, id)
 	if err != nil {
 		return DayWithTotals{}, err
 	}
-	return DayWithTotals{Day: day, Totals: dietrepo.CalculateTotals(day.ID)}, nil
+	return DayWithTotals{Day: day, Totals: dietrepo.CalculateTotals(ctx, day.ID)}, nil
 }
 
-func AllMealDays() ([]models.DietDay, error) {
-	return dietrepo.AllMealDays()
+func AllMealDays(ctx context.Context) ([]models.DietDay, error) {
+	return dietrepo.AllMealDays(ctx)
 }
 
-func AllFoods(excludeIDs []uint) ([]models.Food, error) {
-	return dietrepo.FoodsAll(excludeIDs)
+func AllFoods(ctx context.Context, excludeIDs []uint) ([]models.Food, error) {
+	return dietrepo.FoodsAll(ctx, excludeIDs)
 }
 
-func AllFoodsForPicker(excludeIDs []uint) ([]models.FoodWithVariants, error) {
-	return dietrepo.FoodsAllWithVariantSiblings(excludeIDs)
+func AllFoodsForPicker(ctx context.Context, excludeIDs []uint) ([]models.FoodWithVariants, error) {
+	return dietrepo.FoodsAllWithVariantSiblings(ctx, excludeIDs)
 }
 
-func CreateFood(food *models.Food, relatedFoodID *uint) (*models.Food, error) {
+func CreateFood(ctx context.Context, food *models.Food, relatedFoodID *uint) (*models.Food, error) {
 	food.ID = 0
 	food.VariantGroupID = nil
-	if err := dietrepo.FoodCreateWithOptionalRelated(food, relatedFoodID); err != nil {
+	if err := dietrepo.FoodCreateWithOptionalRelated(ctx, food, relatedFoodID); err != nil {
 		return nil, err
 	}
-	dietrepo.EnrichFoodVariants(food)
+	dietrepo.EnrichFoodVariants(ctx, food)
 	return food, nil
 }
 
-func AllCompositeFoods() ([]models.CompositeFood, error) {
-	return dietrepo.CompositeFoodsAll()
+func AllCompositeFoods(ctx context.Context) ([]models.CompositeFood, error) {
+	return dietrepo.CompositeFoodsAll(ctx)
 }
 
-func CreateCompositeFood(cf *models.CompositeFood) (uint, error) {
-	return dietrepo.CompositeFoodCreate(cf)
+func CreateCompositeFood(ctx context.Context, cf *models.CompositeFood) (uint, error) {
+	return dietrepo.CompositeFoodCreate(ctx, cf)
 }
 
-func CompositeFoodByID(id uint) (*models.CompositeFood, error) {
-	return dietrepo.CompositeFoodByID(id)
+func CompositeFoodByID(ctx context.Context, id uint) (*models.CompositeFood, error) {
+	return dietrepo.CompositeFoodByID(ctx, id)
 }
 
-func AllMeals(excludeIDs []uint) ([]models.Meal, error) {
-	return dietrepo.MealsAll(excludeIDs)
+func AllMeals(ctx context.Context, excludeIDs []uint) ([]models.Meal, error) {
+	return dietrepo.MealsAll(ctx, excludeIDs)
 }
 
-func MealByID(id uint) (*models.Meal, error) {
-	return dietrepo.MealByID(id)
+func MealByID(ctx context.Context, id uint) (*models.Meal, error) {
+	return dietrepo.MealByID(ctx, id)
 }
 
-func FindMealPlanDay(date time.Time) (*models.DietDay, error) {
-	return dietrepo.FindDayByDate(date)
+func FindMealPlanDay(ctx context.Context, date database.Date) (*models.DietDay, error) {
+	return dietrepo.FindDayByDate(ctx, date)
 }
 
-func CreateDayMeal(dayMeal *models.DayLog) error {
-	return dietrepo.CreateDayMeal(dayMeal)
+func CreateDayMeal(ctx context.Context, dayMeal *models.DayLog) error {
+	return dietrepo.CreateDayMeal(ctx, dayMeal)
 }
 
-func DayLogExistsForMeal(dayID uint, mealID uint) (bool, error) {
-	return dietrepo.DayLogExists(dayID, mealID)
+func DayLogExistsForMeal(ctx context.Context, dayID uint, mealID uint) (bool, error) {
+	return dietrepo.DayLogExists(ctx, dayID, mealID)
 }
 
-func CreateMeal(meal *models.Meal) (uint, error) {
-	return dietrepo.MealCreate(meal)
+func CreateMeal(ctx context.Context, meal *models.Meal) (uint, error) {
+	return dietrepo.MealCreate(ctx, meal)
 }
 
-func AllSavedMeals(excludeIDs []uint) ([]models.SavedMeal, error) {
-	return dietrepo.SavedMealsAll(excludeIDs)
+func AllSavedMeals(ctx context.Context, excludeIDs []uint) ([]models.SavedMeal, error) {
+	return dietrepo.SavedMealsAll(ctx, excludeIDs)
 }
 
-func CreateSavedMeal(sm *models.SavedMeal) (uint, error) {
-	return dietrepo.SavedMealCreate(sm)
+func CreateSavedMeal(ctx context.Context, sm *models.SavedMeal) (uint, error) {
+	return dietrepo.SavedMealCreate(ctx, sm)
 }
 
-func SavedMealByID(id uint) (*models.SavedMeal, error) {
-	return dietrepo.SavedMealByID(id)
+func SavedMealByID(ctx context.Context, id uint) (*models.SavedMeal, error) {
+	return dietrepo.SavedMealByID(ctx, id)
 }
 
-func SavedMealPlannedUsageInfo(savedMealID uint) (SavedMealPlannedUsage, error) {
+func SavedMealPlannedUsageInfo(ctx context.Context, savedMealID uint) (SavedMealPlannedUsage, error) {
 	var out SavedMealPlannedUsage
-	count, err := dietrepo.CountUnloggedPlannedBySavedMealID(savedMealID)
+	count, err := dietrepo.CountUnloggedPlannedBySavedMealID(ctx, savedMealID)
 	if err != nil {
 		return out, err
 	}
@@ -174,16 +168,16 @@ func SavedMealPlannedUsageInfo(savedMealID uint) (SavedMealPlannedUsage, error)
 	return out, nil
 }
 
-func DeleteSavedMeal(id uint) error {
-	return dietrepo.DeleteSavedMeal(id)
+func DeleteSavedMeal(ctx context.Context, id uint) error {
+	return dietrepo.DeleteSavedMeal(ctx, id)
 }
 
-func ReplaceSavedMeal(id uint, incoming *models.SavedMeal) error {
-	return dietrepo.SavedMealReplace(id, incoming)
+func ReplaceSavedMeal(ctx context.Context, id uint, incoming *models.SavedMeal) error {
+	return dietrepo.SavedMealReplace(ctx, id, incoming)
 }
 
-func SetPlannedMealLogged(dayID uint, mealID uint) error {
-	return dietrepo.SetPlannedMealLogged(dayID, mealID)
+func SetPlannedMealLogged(ctx context.Context, dayID uint, mealID uint) error {
+	return dietrepo.SetPlannedMealLogged(ctx, dayID, mealID)
 }
 
 func mealFromSaved(sm *models.SavedMeal) *models.Meal {
@@ -200,69 +194,73 @@ func mealFromSaved(sm *models.SavedMeal) *models.Meal {
 	return m
 }
 
-func AddPlannedMealFromSavedMeal(offset int, savedMealID uint) error {
-	sm, err := dietrepo.SavedMealByID(savedMealID)
+func AddPlannedMealFromSavedMeal(ctx context.Context, offset int, savedMealID uint) error {
+	sm, err := dietrepo.SavedMealByID(ctx, savedMealID)
 	if err != nil {
 		return err
 	}
-	return dietrepo.AddPlannedMealFromSavedMeal(offset, savedMealID, mealFromSaved(sm))
+	return dietrepo.AddPlannedMealFromSavedMeal(ctx, offset, savedMealID, mealFromSaved(sm))
 }
 
-func ReorderPlannedMeals(offset int, orderedIDs []uint) error {
-	day, err := dietrepo.FindDayByDate(utils.ZerodTime(offset))
+func ReorderPlannedMeals(ctx context.Context, offset int, orderedIDs []uint) error {
+	day, err := dietrepo.FindDayByDate(ctx, utils.Today(ctx, offset))
 	if err != nil {
 		return err
 	}
-	return dietrepo.PlannedMealReorder(day.ID, orderedIDs)
+	return dietrepo.PlannedMealReorder(ctx, day.ID, orderedIDs)
 }
 
-func DeletePlannedMeal(offset int, plannedMealID uint) error {
-	day, err := dietrepo.FindDayByDate(utils.ZerodTime(offset))
+func DeletePlannedMeal(ctx context.Context, offset int, plannedMealID uint) error {
+	day, err := dietrepo.FindDayByDate(ctx, utils.Today(ctx, offset))
 	if err != nil {
 		return err
 	}
-	return dietrepo.PlannedMealDelete(plannedMealID, day.ID)
+	return dietrepo.PlannedMealDelete(ctx, plannedMealID, day.ID)
 }
 
-func DeleteLoggedMeal(dayID uint, mealID uint) error {
-	return dietrepo.DeleteLoggedMeal(dayID, mealID)
+func DeleteLoggedMeal(ctx context.Context, dayID uint, mealID uint) error {
+	return dietrepo.DeleteLoggedMeal(ctx, dayID, mealID)
 }
 
-func UpdateDayLogMeal(dayID uint, oldMealID uint, newMealID uint) error {
-	return dietrepo.UpdateDayLogMeal(dayID, oldMealID, newMealID)
+func UpdateDayLogMeal(ctx context.Context, dayID uint, oldMealID uint, newMealID uint) error {
+	return dietrepo.UpdateDayLogMeal(ctx, dayID, oldMealID, newMealID)
 }
 
-func EditLoggedMeal(dayID uint, oldMealID uint, meal *models.Meal) error {
-	return dietrepo.EditLoggedMeal(dayID, oldMealID, meal)
+func EditLoggedMeal(ctx context.Context, dayID uint, oldMealID uint, meal *models.Meal) error {
+	return dietrepo.EditLoggedMeal(ctx, dayID, oldMealID, meal)
 }
 
-func QuickLogMeal(params dietrepo.QuickLogParams) (DayWithTotals, error) {
-	dayID, err := dietrepo.QuickLogMeal(params)
+func QuickLogMeal(ctx context.Context, params dietrepo.QuickLogParams) (DayWithTotals, error) {
+	dayID, err := dietrepo.QuickLogMeal(ctx, params)
 	if err != nil {
 		return DayWithTotals{}, err
 	}
-	return DayWithTotalsByID(int(dayID))
+	return DayWithTotalsByID(ctx, int(dayID))
 }
 
 func GetAllPlans(ctx context.Context, params utils.QueryParams) (*utils.GetAllResult[models.Plan], error) {
 	return dietrepo.PlansGetAll(ctx, params)
 }
 
-func UpdatePlanMacros(id uint, calories, protein, fiber, carbs, fat float32) (*models.Plan, error) {
-	return dietrepo.UpdatePlanMacros(id, calories, protein, fiber, carbs, fat)
+func PlanByID(ctx context.Context, id uint) (*models.Plan, error) {
+	return dietrepo.PlanByID(ctx, id)
 }
 
-func FindMealPlanDayByRowIDOrToday(dayID uint) (*models.DietDay, error) {
+func UpdatePlanMacros(ctx context.Context, id uint, calories, protein, fiber, carbs, fat float32) (*models.Plan, error) {
+	return dietrepo.UpdatePlanMacros(ctx, id, calories, protein, fiber, carbs, fat)
+}
+
+func FindMealPlanDayByRowIDOrToday(ctx context.Context, dayID uint) (*models.DietDay, error) {
 	if dayID != 0 {
-		return MealPlanDayByID(int(dayID))
+		return MealPlanDayByID(ctx, int(dayID))
 	}
-	return FindMealPlanDay(utils.ZerodTime(0))
+	return FindMealPlanDay(ctx, utils.Today(ctx, 0))
 }
 
-func DayWithTotalsForDay(day *models.DietDay) DayWithTotals {
-	return DayWithTotals{Day: day, Totals: CalculateTotals(day.ID)}
+func DayWithTotalsForDay(ctx context.Context, day *models.DietDay) DayWithTotals {
+	return DayWithTotals{Day: day, Totals: CalculateTotals(ctx, day.ID)}
 }
 
-func ReloadDayWithTotals(day *models.DietDay) (DayWithTotals, error) {
-	return DayWithTotalsByID(int(day.ID))
+func ReloadDayWithTotals(ctx context.Context, day *models.DietDay) (DayWithTotals, error) {
+	return DayWithTotalsByID(ctx, int(day.ID))
 }
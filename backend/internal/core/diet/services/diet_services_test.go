@@ -76,7 +76,7 @@ func TestMealPlanDay_andGoalsToday(t *testing.T) {
 	if tot.Calories != 0 {
 		t.Fatalf("expected zero totals, got %+v", tot)
 	}
-	goals, err := services.GoalsToday()
+	goals, err := services.GoalsToday(context.Background())
 	if err != nil || goals.Name != "Goals" {
 		t.Fatalf("goals %+v err %v", goals, err)
 	}
@@ -90,19 +90,19 @@ func TestDayWithTotalsHelpers(t *testing.T) {
 	meal := testutil.SeedMeal(t, db, "Snack", food.ID, 1)
 	testutil.SeedDayLog(t, db, day.ID, meal.ID)
 
-	byID, err := services.DayWithTotalsByID(int(day.ID))
+	byID, err := services.DayWithTotalsByID(context.Background(), int(day.ID))
 	if err != nil || byID.Totals.Calories != 100 {
 		t.Fatalf("byID %+v err %v", byID, err)
 	}
-	loaded, err := services.MealPlanDayByID(int(day.ID))
+	loaded, err := services.MealPlanDayByID(context.Background(), int(day.ID))
 	if err != nil {
 		t.Fatal(err)
 	}
-	wrap := services.DayWithTotalsForDay(loaded)
+	wrap := services.DayWithTotalsForDay(context.Background(), loaded)
 	if wrap.Totals.Calories != 100 {
 		t.Fatalf("wrap %+v", wrap)
 	}
-	reloaded, err := services.ReloadDayWithTotals(loaded)
+	reloaded, err := services.ReloadDayWithTotals(context.Background(), loaded)
 	if err != nil || reloaded.Totals.Calories != 100 {
 		t.Fatalf("reloaded %+v err %v", reloaded, err)
 	}
@@ -112,11 +112,11 @@ func TestFindMealPlanDayByRowIDOrToday(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	plan := testutil.SeedPlan(t, db, "P")
 	day := testutil.SeedDay(t, db, testutil.Today(), plan.ID)
-	byID, err := services.FindMealPlanDayByRowIDOrToday(day.ID)
+	byID, err := services.FindMealPlanDayByRowIDOrToday(context.Background(), day.ID)
 	if err != nil || byID.ID != day.ID {
 		t.Fatalf("byID %+v err %v", byID, err)
 	}
-	today, err := services.FindMealPlanDayByRowIDOrToday(0)
+	today, err := services.FindMealPlanDayByRowIDOrToday(context.Background(), 0)
 	if err != nil || today.ID == 0 {
 		t.Fatalf("today %+v err %v", today, err)
 	}
@@ -124,21 +124,21 @@ func TestFindMealPlanDayByRowIDOrToday(t *testing.T) {
 
 func TestFoodAndCompositeServices(t *testing.T) {
 	testutil.SetupTestDB(t)
-	created, err := services.CreateFood(&models.Food{
+	created, err := services.CreateFood(context.Background(), &models.Food{
 		Name: "Broccoli", ServingType: "g", ServingAmount: 100, Calories: 34,
 	}, nil)
 	if err != nil || created.ID == 0 {
 		t.Fatalf("food %+v err %v", created, err)
 	}
-	all, err := services.AllFoods(nil)
+	all, err := services.AllFoods(context.Background(), nil)
 	if err != nil || len(all) != 1 {
 		t.Fatalf("all %+v err %v", all, err)
 	}
-	picker, err := services.AllFoodsForPicker(nil)
+	picker, err := services.AllFoodsForPicker(context.Background(), nil)
 	if err != nil || len(picker) != 1 {
 		t.Fatalf("picker %+v err %v", picker, err)
 	}
-	cfID, err := services.CreateCompositeFood(&models.CompositeFood{
+	cfID, err := services.CreateCompositeFood(context.Background(), &models.CompositeFood{
 		Name: "Mix",
 		Items: []models.CompositeFoodItem{{
 			FoodID: created.ID,
@@ -148,11 +148,11 @@ func TestFoodAndCompositeServices(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	cf, err := services.CompositeFoodByID(cfID)
+	cf, err := services.CompositeFoodByID(context.Background(), cfID)
 	if err != nil || cf.Name != "Mix" {
 		t.Fatalf("cf %+v err %v", cf, err)
 	}
-	composites, err := services.AllCompositeFoods()
+	composites, err := services.AllCompositeFoods(context.Background())
 	if err != nil || len(composites) != 1 {
 		t.Fatalf("composites %+v err %v", composites, err)
 	}
@@ -163,29 +163,29 @@ func TestMealAndLoggingServices(t *testing.T) {
 	plan := testutil.SeedPlan(t, db, "P")
 	day := testutil.SeedDay(t, db, testutil.Today(), plan.ID)
 	food := testutil.SeedFood(t, db, "Potato", testutil.DefaultMacros())
-	mealID, err := services.CreateMeal(&models.Meal{
+	mealID, err := services.CreateMeal(context.Background(), &models.Meal{
 		Name:  "Baked",
 		Items: []models.MealItem{{FoodID: food.ID, Amount: 1}},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := services.CreateDayMeal(&models.DayLog{DayID: day.ID, MealID: mealID}); err != nil {
+	if err := services.CreateDayMeal(context.Background(), &models.DayLog{DayID: day.ID, MealID: mealID}); err != nil {
 		t.Fatal(err)
 	}
-	ok, err := services.DayLogExistsForMeal(day.ID, mealID)
+	ok, err := services.DayLogExistsForMeal(context.Background(), day.ID, mealID)
 	if err != nil || !ok {
 		t.Fatalf("exists %v err %v", ok, err)
 	}
-	meal, err := services.MealByID(mealID)
+	meal, err := services.MealByID(context.Background(), mealID)
 	if err != nil || meal.Name != "Baked" {
 		t.Fatalf("meal %+v err %v", meal, err)
 	}
-	meals, err := services.AllMeals(nil)
+	meals, err := services.AllMeals(context.Background(), nil)
 	if err != nil || len(meals) != 1 {
 		t.Fatalf("meals %+v err %v", meals, err)
 	}
-	tot := services.CalculateTotals(day.ID)
+	tot := services.CalculateTotals(context.Background(), day.ID)
 	if tot.Calories != 100 {
 		t.Fatalf("tot %+v", tot)
 	}
@@ -196,7 +196,7 @@ func TestSavedMealServices(t *testing.T) {
 	plan := testutil.SeedPlan(t, db, "P")
 	day := testutil.SeedDay(t, db, testutil.Today(), plan.ID)
 	food := testutil.SeedFood(t, db, "Avocado", testutil.DefaultMacros())
-	smID, err := services.CreateSavedMeal(&models.SavedMeal{
+	smID, err := services.CreateSavedMeal(context.Background(), &models.SavedMeal{
 		Name:  "Avocado Toast",
 		Items: []models.SavedMealItem{{FoodID: food.ID, Amount: 1}},
 	})
@@ -210,21 +210,21 @@ func TestSavedMealServices(t *testing.T) {
 	}).Error; err != nil {
 		t.Fatal(err)
 	}
-	info, err := services.SavedMealPlannedUsageInfo(smID)
+	info, err := services.SavedMealPlannedUsageInfo(context.Background(), smID)
 	if err != nil || info.ReferenceCount != 1 {
 		t.Fatalf("info %+v err %v", info, err)
 	}
-	all, err := services.AllSavedMeals(nil)
+	all, err := services.AllSavedMeals(context.Background(), nil)
 	if err != nil || len(all) != 1 {
 		t.Fatalf("all %+v err %v", all, err)
 	}
-	if err := services.ReplaceSavedMeal(smID, &models.SavedMeal{
+	if err := services.ReplaceSavedMeal(context.Background(), smID, &models.SavedMeal{
 		Name:  "Updated Toast",
 		Items: []models.SavedMealItem{{FoodID: food.ID, Amount: 2}},
 	}); err != nil {
 		t.Fatal(err)
 	}
-	if err := services.DeleteSavedMeal(smID); err != nil {
+	if err := services.DeleteSavedMeal(context.Background(), smID); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -232,14 +232,14 @@ func TestSavedMealServices(t *testing.T) {
 func TestSavedMealByID(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	food := testutil.SeedFood(t, db, "Cheddar", testutil.DefaultMacros())
-	smID, err := services.CreateSavedMeal(&models.SavedMeal{
+	smID, err := services.CreateSavedMeal(context.Background(), &models.SavedMeal{
 		Name:  "Cheese Snack",
 		Items: []models.SavedMealItem{{FoodID: food.ID, Amount: 1}},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	sm, err := services.SavedMealByID(smID)
+	sm, err := services.SavedMealByID(context.Background(), smID)
 	if err != nil || sm.Name != "Cheese Snack" {
 		t.Fatalf("sm %+v err %v", sm, err)
 	}
@@ -251,15 +251,15 @@ func TestPlannedMealServices(t *testing.T) {
 	testutil.SeedDay(t, db, testutil.Today(), plan.ID)
 	food := testutil.SeedFood(t, db, "Chicken Breast", testutil.DefaultMacros())
 	sm := testutil.SeedSavedMeal(t, db, "Meal Prep", food.ID)
-	if err := services.AddPlannedMealFromSavedMeal(0, sm.ID); err != nil {
+	if err := services.AddPlannedMealFromSavedMeal(context.Background(), 0, sm.ID); err != nil {
 		t.Fatal(err)
 	}
-	day, err := services.FindMealPlanDay(testutil.Today())
+	day, err := services.FindMealPlanDay(context.Background(), testutil.Today())
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(day.PlannedMeals) == 0 {
-		loaded, err := services.MealPlanDayByID(int(day.ID))
+		loaded, err := services.MealPlanDayByID(context.Background(), int(day.ID))
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -270,10 +270,10 @@ func TestPlannedMealServices(t *testing.T) {
 		for i, pm := range loaded.PlannedMeals {
 			ids[i] = pm.ID
 		}
-		if err := services.ReorderPlannedMeals(0, ids); err != nil {
+		if err := services.ReorderPlannedMeals(context.Background(), 0, ids); err != nil {
 			t.Fatal(err)
 		}
-		if err := services.DeletePlannedMeal(0, ids[0]); err != nil {
+		if err := services.DeletePlannedMeal(context.Background(), 0, ids[0]); err != nil {
 			t.Fatal(err)
 		}
 	}
@@ -287,10 +287,10 @@ func TestSetPlannedMealLogged_andDeleteLoggedMeal(t *testing.T) {
 	meal := testutil.SeedMeal(t, db, "Snack", food.ID, 1)
 	testutil.SeedPlannedMeal(t, db, day.ID, meal.ID, 0, false)
 	testutil.SeedDayLog(t, db, day.ID, meal.ID)
-	if err := services.SetPlannedMealLogged(day.ID, meal.ID); err != nil {
+	if err := services.SetPlannedMealLogged(context.Background(), day.ID, meal.ID); err != nil {
 		t.Fatal(err)
 	}
-	if err := services.DeleteLoggedMeal(day.ID, meal.ID); err != nil {
+	if err := services.DeleteLoggedMeal(context.Background(), day.ID, meal.ID); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -302,13 +302,13 @@ func TestEditAndQuickLogServices(t *testing.T) {
 	food := testutil.SeedFood(t, db, "Protein Bar", testutil.DefaultMacros())
 	oldMeal := testutil.SeedMeal(t, db, "Old", food.ID, 1)
 	testutil.SeedDayLog(t, db, day.ID, oldMeal.ID)
-	if err := services.EditLoggedMeal(day.ID, oldMeal.ID, &models.Meal{
+	if err := services.EditLoggedMeal(context.Background(), day.ID, oldMeal.ID, &models.Meal{
 		Name:  "Edited",
 		Items: []models.MealItem{{FoodID: food.ID, Amount: 2}},
 	}); err != nil {
 		t.Fatal(err)
 	}
-	result, err := services.QuickLogMeal(dietrepo.QuickLogParams{
+	result, err := services.QuickLogMeal(context.Background(), dietrepo.QuickLogParams{
 		DisplayName: "Shake",
 		FoodRowName: "Shake [ql-42]",
 		Calories:    180,
@@ -327,10 +327,10 @@ func TestUpdateDayLogMeal(t *testing.T) {
 	oldMeal := testutil.SeedMeal(t, db, "Old", food.ID, 1)
 	newMeal := testutil.SeedMeal(t, db, "New", food.ID, 1)
 	testutil.SeedDayLog(t, db, day.ID, oldMeal.ID)
-	if err := services.UpdateDayLogMeal(day.ID, oldMeal.ID, newMeal.ID); err != nil {
+	if err := services.UpdateDayLogMeal(context.Background(), day.ID, oldMeal.ID, newMeal.ID); err != nil {
 		t.Fatal(err)
 	}
-	ok, _ := services.DayLogExistsForMeal(day.ID, newMeal.ID)
+	ok, _ := services.DayLogExistsForMeal(context.Background(), day.ID, newMeal.ID)
 	if !ok {
 		t.Fatal("expected updated log")
 	}
@@ -344,7 +344,7 @@ func TestGetAllPlans_andUpdatePlanMacros(t *testing.T) {
 	if err != nil || len(all.Data) != 2 {
 		t.Fatalf("all %+v err %v", all, err)
 	}
-	updated, err := services.UpdatePlanMacros(all.Data[0].ID, 2100, 160, 35, 180, 70)
+	updated, err := services.UpdatePlanMacros(context.Background(), all.Data[0].ID, 2100, 160, 35, 180, 70)
 	if err != nil || updated.Calories != 2100 {
 		t.Fatalf("updated %+v err %v", updated, err)
 	}
@@ -354,11 +354,11 @@ func TestUpdatePlanMacrosCreatesPlanSnapshotForTodayForward(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	plan := testutil.SeedPlan(t, db, "P")
 	today := testutil.Today()
-	yesterday := testutil.SeedDay(t, db, today.AddDate(0, 0, -1), plan.ID)
+	yesterday := testutil.SeedDay(t, db, today.AddDays(-1), plan.ID)
 	todayDay := testutil.SeedDay(t, db, today, plan.ID)
-	tomorrow := testutil.SeedDay(t, db, today.AddDate(0, 0, 1), plan.ID)
+	tomorrow := testutil.SeedDay(t, db, today.AddDays(1), plan.ID)
 
-	updated, err := services.UpdatePlanMacros(plan.ID, 2100, 160, 35, 180, 70)
+	updated, err := services.UpdatePlanMacros(context.Background(), plan.ID, 2100, 160, 35, 180, 70)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -396,12 +396,12 @@ func TestFindMealPlanDayUsesPlanEffectiveForDate(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	plan := testutil.SeedPlan(t, db, "P")
 
-	updated, err := services.UpdatePlanMacros(plan.ID, 2100, 160, 35, 180, 70)
+	updated, err := services.UpdatePlanMacros(context.Background(), plan.ID, 2100, 160, 35, 180, 70)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	yesterday, err := services.FindMealPlanDay(testutil.Today().AddDate(0, 0, -1))
+	yesterday, err := services.FindMealPlanDay(context.Background(), testutil.Today().AddDays(-1))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -409,7 +409,7 @@ func TestFindMealPlanDayUsesPlanEffectiveForDate(t *testing.T) {
 		t.Fatalf("missing past day should use old plan, got %d want %d", yesterday.PlanID, plan.ID)
 	}
 
-	tomorrow, err := services.FindMealPlanDay(testutil.Today().AddDate(0, 0, 1))
+	tomorrow, err := services.FindMealPlanDay(context.Background(), testutil.Today().AddDays(1))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -422,11 +422,11 @@ func TestAllMealDays_andFindMealPlanDay(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	plan := testutil.SeedPlan(t, db, "P")
 	testutil.SeedDay(t, db, testutil.Today(), plan.ID)
-	days, err := services.AllMealDays()
+	days, err := services.AllMealDays(context.Background())
 	if err != nil || len(days) != 1 {
 		t.Fatalf("days %+v err %v", days, err)
 	}
-	day, err := services.FindMealPlanDay(testutil.Today())
+	day, err := services.FindMealPlanDay(context.Background(), testutil.Today())
 	if err != nil || day.ID == 0 {
 		t.Fatalf("day %+v err %v", day, err)
 	}
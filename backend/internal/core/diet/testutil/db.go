@@ -3,20 +3,17 @@ package testutil
 import (
 	"be-simpletracker/internal/core/diet/models"
 	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/dbtest"
 	"be-simpletracker/internal/utils"
+	"context"
 	"testing"
-	"time"
 
-	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
 func SetupTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatal(err)
-	}
+	db := dbtest.Open(t)
 	if err := db.AutoMigrate(
 		&models.Plan{},
 		&models.DietDay{},
@@ -64,7 +61,7 @@ func SeedFood(t *testing.T, db *gorm.DB, name string, macros models.Food) models
 	return f
 }
 
-func SeedDay(t *testing.T, db *gorm.DB, date time.Time, planID uint) models.DietDay {
+func SeedDay(t *testing.T, db *gorm.DB, date database.Date, planID uint) models.DietDay {
 	t.Helper()
 	d := models.DietDay{
 		Date:   date,
@@ -129,8 +126,8 @@ func SeedPlannedMeal(t *testing.T, db *gorm.DB, dayID, mealID uint, order int, l
 	return pm
 }
 
-func Today() time.Time {
-	return utils.ZerodTime(0)
+func Today() database.Date {
+	return utils.Today(context.Background(), 0)
 }
 
 func DefaultMacros() models.Food {
@@ -0,0 +1,180 @@
+// Package module assembles the server's feature areas. Each module owns its tables, the
+// migrations that make them and its routes, and may report on its own health. A deployment
+// switches whole modules off with DISABLED_MODULES: a disabled module's routes are not
+// mounted, its migrations are not applied, and export, import, the trash, sync and the
+// background jobs leave its tables alone. Auth and the cross-cutting endpoints are not modules
+// and always run.
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	diet "be-simpletracker/internal/core/diet"
+	money "be-simpletracker/internal/core/money"
+	tracking "be-simpletracker/internal/core/tracking"
+	workout "be-simpletracker/internal/core/workout"
+	"be-simpletracker/internal/database/migrate"
+	"be-simpletracker/internal/database/migrations"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Module is one feature area of the API.
+type Module interface {
+	Name() string
+	// Models are the module's tables.
+	Models() []any
+	// Migrations holds the SQL that creates and changes those tables, one subdirectory per
+	// driver, numbered in the version sequence of the core schema.
+	Migrations() fs.FS
+	// RegisterRoutes mounts the module's routes behind authMiddleware, with ifMatch guarding
+	// the updates of versioned resources.
+	RegisterRoutes(router *gin.Engine, authMiddleware, ifMatch gin.HandlerFunc)
+}
+
+// HealthChecker is implemented by modules that can tell whether they are able to serve.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// BackgroundJobs is implemented by modules with work to do for as long as the server runs. It
+// returns once ctx is done.
+type BackgroundJobs interface {
+	BackgroundJobs(ctx context.Context)
+}
+
+// Job is background work that spans modules, such as the trash purge. It runs until ctx is
+// done and leaves alone every table for which enabled is false.
+type Job func(ctx context.Context, enabled func(table string) bool)
+
+var ErrUnknownModule = errors.New("unknown module")
+
+// All returns every module in the order their routes are mounted.
+func All(db *gorm.DB) []Module {
+	return []Module{
+		diet.NewModule(db),
+		workout.NewModule(db),
+		tracking.NewModule(db),
+		money.NewModule(db),
+	}
+}
+
+// Config names the modules a deployment switches off.
+type Config struct {
+	Disabled []string
+}
+
+// Registry holds the modules a deployment runs.
+type Registry struct {
+	all     []Module
+	modules []Module
+	// tables are the enabled modules' tables.
+	tables map[string]bool
+}
+
+// NewRegistry keeps the modules of all that config does not disable, in order. Disabling a
+// name that is not in all is an error, so a typo cannot leave a module running.
+func NewRegistry(all []Module, config Config) (*Registry, error) {
+	disabled := make(map[string]bool, len(config.Disabled))
+	for _, name := range config.Disabled {
+		disabled[strings.ToLower(name)] = true
+	}
+	r := &Registry{all: all, tables: map[string]bool{}}
+	cache := &sync.Map{}
+	for _, m := range all {
+		if disabled[m.Name()] {
+			delete(disabled, m.Name())
+			continue
+		}
+		r.modules = append(r.modules, m)
+		for _, model := range m.Models() {
+			sch, err := schema.Parse(model, cache, schema.NamingStrategy{})
+			if err != nil {
+				return nil, fmt.Errorf("module %s: %w", m.Name(), err)
+			}
+			r.tables[sch.Table] = true
+		}
+	}
+	for name := range disabled {
+		return nil, fmt.Errorf("%w %q", ErrUnknownModule, name)
+	}
+	return r, nil
+}
+
+// Modules returns the enabled modules.
+func (r *Registry) Modules() []Module {
+	return r.modules
+}
+
+// HasTable reports whether table belongs to an enabled module.
+func (r *Registry) HasTable(table string) bool {
+	return r.tables[table]
+}
+
+// Migrations returns every module's migration set, the disabled ones marked so the runner
+// leaves their tables alone.
+func (r *Registry) Migrations() []migrate.Set {
+	sets := make([]migrate.Set, 0, len(r.all))
+	for _, m := range r.all {
+		sets = append(sets, migrate.Set{Module: m.Name(), FS: m.Migrations(), Disabled: !r.enabled(m)})
+	}
+	return sets
+}
+
+// Runner returns the migration runner for the core schema and the enabled modules.
+func (r *Registry) Runner(db *gorm.DB) (*migrate.Runner, error) {
+	return migrate.New(db, migrations.FS, r.Migrations()...)
+}
+
+func (r *Registry) enabled(m Module) bool {
+	for _, e := range r.modules {
+		if e == m {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterRoutes mounts every enabled module. Disabled modules' routes answer 404.
+func (r *Registry) RegisterRoutes(router *gin.Engine, authMiddleware, ifMatch gin.HandlerFunc) {
+	for _, m := range r.modules {
+		m.RegisterRoutes(router, authMiddleware, ifMatch)
+	}
+}
+
+// StartBackgroundJobs starts the background jobs of the enabled modules, and each of jobs over
+// their tables; nil jobs are skipped. A disabled module's jobs never run and its tables are
+// left alone.
+func (r *Registry) StartBackgroundJobs(ctx context.Context, jobs ...Job) {
+	for _, m := range r.modules {
+		if worker, ok := m.(BackgroundJobs); ok {
+			go worker.BackgroundJobs(ctx)
+		}
+	}
+	for _, job := range jobs {
+		if job != nil {
+			go job(ctx, r.HasTable)
+		}
+	}
+}
+
+// CheckHealth asks each enabled module that can report on itself, and returns the problems by
+// module name. An empty map means every module is healthy.
+func (r *Registry) CheckHealth(ctx context.Context) map[string]string {
+	problems := map[string]string{}
+	for _, m := range r.modules {
+		if checker, ok := m.(HealthChecker); ok {
+			if err := checker.CheckHealth(ctx); err != nil {
+				problems[m.Name()] = err.Error()
+			}
+		}
+	}
+	return problems
+}
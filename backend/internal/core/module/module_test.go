@@ -0,0 +1,284 @@
+package module
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"be-simpletracker/internal/database/dbtest"
+	"be-simpletracker/internal/database/migrate"
+	"be-simpletracker/internal/database/migrations"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDisabledModuleHasNoRoutes(t *testing.T) {
+	db := dbtest.Open(t)
+	registry, err := NewRegistry(All(db), Config{Disabled: []string{"Money"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range registry.Modules() {
+		if m.Name() == "money" {
+			t.Fatal("money is still enabled")
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	registry.RegisterRoutes(router, func(c *gin.Context) {}, func(c *gin.Context) {})
+	for _, route := range router.Routes() {
+		if strings.HasPrefix(route.Path, "/money") {
+			t.Fatalf("disabled module mounted %s %s", route.Method, route.Path)
+		}
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/money/investments", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("GET /money/investments = %d", w.Code)
+	}
+}
+
+func TestUnknownModuleIsRejected(t *testing.T) {
+	_, err := NewRegistry(All(nil), Config{Disabled: []string{"mony"}})
+	if !errors.Is(err, ErrUnknownModule) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestHealthReportsMissingTables(t *testing.T) {
+	db := dbtest.Open(t)
+	registry, err := NewRegistry(All(db), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := registry.CheckHealth(context.Background()); len(problems) != len(All(db)) {
+		t.Fatalf("problems before migrating = %v", problems)
+	}
+	runner, err := registry.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if problems := registry.CheckHealth(context.Background()); len(problems) != 0 {
+		t.Fatalf("problems after migrating = %v", problems)
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	registry, err := NewRegistry(All(nil), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every set has the same versions for both drivers, and together the sets leave no gap in
+	// the version sequence they share.
+	all := map[int]bool{}
+	for _, set := range append([]migrate.Set{{FS: migrations.FS}}, registry.Migrations()...) {
+		versions := map[string][]int{}
+		for _, driver := range []string{"postgres", "sqlite"} {
+			sub, err := fs.Sub(set.FS, driver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := migrate.Load(sub)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", cmp.Or(set.Module, "core"), driver, err)
+			}
+			for _, m := range loaded {
+				if m.Down == "" {
+					t.Fatalf("%s/%s: %03d_%s has no down file", cmp.Or(set.Module, "core"), driver, m.Version, m.Name)
+				}
+				versions[driver] = append(versions[driver], m.Version)
+				all[m.Version] = true
+			}
+		}
+		if !slices.Equal(versions["postgres"], versions["sqlite"]) {
+			t.Fatalf("%s: postgres has %v, sqlite has %v", cmp.Or(set.Module, "core"), versions["postgres"], versions["sqlite"])
+		}
+	}
+	for v := 1; v <= len(all); v++ {
+		if !all[v] {
+			t.Fatalf("no set has version %03d", v)
+		}
+	}
+}
+
+func TestEmbeddedSQLiteMigrationsApplyAndRollBack(t *testing.T) {
+	db := dbtest.Open(t)
+	registry, err := NewRegistry(All(db), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := registry.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	ran, err := runner.Up(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !db.Migrator().HasTable("investment_deposits") {
+		t.Fatal("investment_deposits not created")
+	}
+	if _, err := runner.Down(ctx, len(ran)); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Fatal("users still exists after rolling back")
+	}
+}
+
+func TestEmbeddedSQLiteMigrationsTurnDayTimestampsIntoDates(t *testing.T) {
+	db := dbtest.Open(t)
+	registry, err := NewRegistry(All(db), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := registry.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := runner.Up(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	// Local midnight east of UTC is still the previous day in UTC.
+	if err := db.Exec(`INSERT INTO step_logs (user_id, date, steps) VALUES (1, '2026-03-29 00:00:00+02:00', 9000)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(ctx, 6); err != nil {
+		t.Fatal(err)
+	}
+	var date string
+	if err := db.Raw(`SELECT CAST(date AS text) FROM step_logs`).Scan(&date).Error; err != nil || date != "2026-03-29" {
+		t.Fatalf("date = %q, %v", date, err)
+	}
+}
+
+func TestEmbeddedSQLiteMigrationsTurnPlanAndDepositTimestampsIntoDates(t *testing.T) {
+	db := dbtest.Open(t)
+	registry, err := NewRegistry(All(db), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := registry.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := runner.Up(ctx, 6); err != nil {
+		t.Fatal(err)
+	}
+	for _, insert := range []string{
+		`INSERT INTO plans (user_id, name, effective_from) VALUES (1, 'Cut', '2026-03-29 00:00:00+02:00')`,
+		`INSERT INTO investment_accounts (user_id, name) VALUES (1, 'Brokerage')`,
+		`INSERT INTO investment_deposits (user_id, account_id, amount, date) VALUES (1, 1, 100, '2026-01-01 00:00:00-08:00')`,
+	} {
+		if err := db.Exec(insert).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := runner.Up(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	var from, deposited string
+	if err := db.Raw(`SELECT CAST(effective_from AS text) FROM plans`).Scan(&from).Error; err != nil || from != "2026-03-29" {
+		t.Fatalf("effective_from = %q, %v", from, err)
+	}
+	if err := db.Raw(`SELECT CAST(date AS text) FROM investment_deposits`).Scan(&deposited).Error; err != nil || deposited != "2026-01-01" {
+		t.Fatalf("date = %q, %v", deposited, err)
+	}
+}
+
+func TestDisabledModuleGetsNoTablesUntilEnabled(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	disabled, err := NewRegistry(All(db), Config{Disabled: []string{"money"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := disabled.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("investment_accounts") || disabled.HasTable("investment_accounts") {
+		t.Fatal("disabled module has its tables")
+	}
+	if !db.Migrator().HasTable("foods") || !disabled.HasTable("foods") {
+		t.Fatal("enabled module has no tables")
+	}
+	if version, err := runner.Version(ctx); err != nil || version != runner.Latest() {
+		t.Fatalf("version %d of %d, err %v", version, runner.Latest(), err)
+	}
+
+	enabled, err := NewRegistry(All(db), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runner, err = enabled.Runner(db); err != nil {
+		t.Fatal(err)
+	}
+	ran, err := runner.Up(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ran {
+		if m.Module != "money" {
+			t.Fatalf("enabling money applied %s", m)
+		}
+	}
+	if len(ran) == 0 || !db.Migrator().HasTable("investment_accounts") {
+		t.Fatalf("enabling money applied %v", ran)
+	}
+}
+
+type jobModule struct {
+	Module
+	name    string
+	started chan string
+}
+
+func (m jobModule) Name() string { return m.name }
+
+func (m jobModule) Models() []any { return nil }
+
+func (m jobModule) BackgroundJobs(ctx context.Context) { m.started <- m.name }
+
+func TestBackgroundJobsRunForEnabledModulesOnly(t *testing.T) {
+	started := make(chan string, 2)
+	registry, err := NewRegistry([]Module{
+		jobModule{name: "on", started: started},
+		jobModule{name: "off", started: started},
+	}, Config{Disabled: []string{"off"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sawTables := make(chan bool, 1)
+	registry.StartBackgroundJobs(context.Background(), nil, func(ctx context.Context, enabled func(string) bool) {
+		sawTables <- enabled("investment_accounts")
+	})
+	if name := <-started; name != "on" {
+		t.Fatalf("started %s", name)
+	}
+	if <-sawTables {
+		t.Fatal("job was given a table no enabled module owns")
+	}
+	select {
+	case name := <-started:
+		t.Fatalf("started %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
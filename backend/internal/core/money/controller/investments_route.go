@@ -6,8 +6,12 @@ import (
 	"strconv"
 
 	authmodels "be-simpletracker/internal/core/auth/models"
+	"be-simpletracker/internal/core/money/models"
 	"be-simpletracker/internal/core/money/service"
 	"be-simpletracker/internal/core/tracking/common"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/idempotency"
+	"be-simpletracker/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,24 +21,28 @@ type InvestmentHandler struct {
 	db *gorm.DB
 }
 
-func RegisterInvestmentRoutes(group *gin.RouterGroup, db *gorm.DB) {
+func RegisterInvestmentRoutes(group *gin.RouterGroup, db *gorm.DB, ifMatch gin.HandlerFunc) {
 	h := InvestmentHandler{db: db}
 	group.GET("", h.listAccounts)
 	group.POST("", h.createAccount)
-	group.PATCH("/:id", h.updateAccount)
+	group.PATCH("/:id", ifMatch, h.updateAccount)
 	group.DELETE("/:id", h.deleteAccount)
 	group.GET("/:id/deposits", h.listDeposits)
-	group.POST("/:id/deposits", h.createDeposit)
+	group.POST("/:id/deposits", idempotency.Middleware(db), h.createDeposit)
 	group.DELETE("/:id/deposits/:deposit_id", h.deleteDeposit)
 	accountTypes := group.Group("/account-types")
 	accountTypes.GET("", h.listAccountTypes)
 	accountTypes.POST("", h.createAccountType)
-	accountTypes.PATCH("/:id", h.updateAccountType)
+	accountTypes.PATCH("/:id", ifMatch, h.updateAccountType)
 	accountTypes.DELETE("/:id", h.deleteAccountType)
 	accountTypes.PUT("/:id/contribution-rules/:year", h.upsertContributionRule)
 	accountTypes.DELETE("/:id/contribution-rules/:year", h.deleteContributionRule)
 }
 
+func (h *InvestmentHandler) conn(c *gin.Context) *gorm.DB {
+	return h.db.WithContext(c.Request.Context())
+}
+
 type accountBody struct {
 	Name                    *string  `json:"name"`
 	InvestmentAccountTypeID *uint    `json:"investment_account_type_id"`
@@ -46,7 +54,7 @@ func (h *InvestmentHandler) listAccounts(c *gin.Context) {
 	if !ok {
 		return
 	}
-	accounts, err := service.ListInvestmentAccounts(h.db, birthYear)
+	accounts, err := service.ListInvestmentAccounts(h.conn(c), birthYear)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -64,7 +72,7 @@ func (h *InvestmentHandler) createAccount(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "name and current_balance are required"})
 		return
 	}
-	account, err := service.CreateInvestmentAccount(h.db, *body.Name, body.InvestmentAccountTypeID, *body.CurrentBalance)
+	account, err := service.CreateInvestmentAccount(h.conn(c), *body.Name, body.InvestmentAccountTypeID, *body.CurrentBalance)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -82,11 +90,21 @@ func (h *InvestmentHandler) updateAccount(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	account, err := service.UpdateInvestmentAccount(h.db, id, body.Name, body.InvestmentAccountTypeID, body.CurrentBalance)
+	account, err := service.UpdateInvestmentAccount(h.conn(c), id, body.Name, body.InvestmentAccountTypeID, body.CurrentBalance)
+	if errors.Is(err, database.ErrStaleVersion) {
+		var current models.InvestmentAccount
+		if err := h.conn(c).First(&current, id).Error; err != nil {
+			respondAccountError(c, err)
+			return
+		}
+		utils.PreconditionFailed(c, "account", current, current.Version)
+		return
+	}
 	if err != nil {
 		respondAccountError(c, err)
 		return
 	}
+	utils.SetETag(c, account.Version)
 	c.JSON(http.StatusOK, gin.H{"account": account})
 }
 
@@ -95,7 +113,7 @@ func (h *InvestmentHandler) deleteAccount(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := service.DeleteInvestmentAccount(h.db, id); err != nil {
+	if err := service.DeleteInvestmentAccount(h.conn(c), id); err != nil {
 		respondAccountError(c, err)
 		return
 	}
@@ -112,7 +130,7 @@ func (h *InvestmentHandler) listAccountTypes(c *gin.Context) {
 	if !ok {
 		return
 	}
-	accountTypes, err := service.ListInvestmentAccountTypes(h.db, birthYear)
+	accountTypes, err := service.ListInvestmentAccountTypes(h.conn(c), birthYear)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -126,7 +144,7 @@ func (h *InvestmentHandler) createAccountType(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	accountType, err := service.CreateInvestmentAccountType(h.db, body.Name, body.ContributionStartYear)
+	accountType, err := service.CreateInvestmentAccountType(h.conn(c), body.Name, body.ContributionStartYear)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -144,11 +162,21 @@ func (h *InvestmentHandler) updateAccountType(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	accountType, err := service.UpdateInvestmentAccountType(h.db, id, body.Name, body.ContributionStartYear)
+	accountType, err := service.UpdateInvestmentAccountType(h.conn(c), id, body.Name, body.ContributionStartYear)
+	if errors.Is(err, database.ErrStaleVersion) {
+		var current models.InvestmentAccountType
+		if err := h.conn(c).First(&current, id).Error; err != nil {
+			respondAccountError(c, err)
+			return
+		}
+		utils.PreconditionFailed(c, "account_type", current, current.Version)
+		return
+	}
 	if err != nil {
 		respondAccountError(c, err)
 		return
 	}
+	utils.SetETag(c, accountType.Version)
 	c.JSON(http.StatusOK, gin.H{"account_type": accountType})
 }
 
@@ -157,7 +185,7 @@ func (h *InvestmentHandler) deleteAccountType(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := service.DeleteInvestmentAccountType(h.db, id); err != nil {
+	if err := service.DeleteInvestmentAccountType(h.conn(c), id); err != nil {
 		respondAccountError(c, err)
 		return
 	}
@@ -174,7 +202,7 @@ func (h *InvestmentHandler) listDeposits(c *gin.Context) {
 	if !ok {
 		return
 	}
-	deposits, err := service.ListInvestmentDeposits(h.db, accountID)
+	deposits, err := service.ListInvestmentDeposits(h.conn(c), accountID)
 	if err != nil {
 		respondAccountError(c, err)
 		return
@@ -192,12 +220,12 @@ func (h *InvestmentHandler) createDeposit(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	date, err := common.ParseDateString(body.Date)
+	date, err := common.ParseDateString(c.Request.Context(), body.Date)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
 		return
 	}
-	deposit, err := service.CreateInvestmentDeposit(h.db, accountID, body.Amount, date)
+	deposit, err := service.CreateInvestmentDeposit(h.conn(c), accountID, body.Amount, date)
 	if err != nil {
 		respondAccountError(c, err)
 		return
@@ -214,7 +242,7 @@ func (h *InvestmentHandler) deleteDeposit(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := service.DeleteInvestmentDeposit(h.db, accountID, depositID); err != nil {
+	if err := service.DeleteInvestmentDeposit(h.conn(c), accountID, depositID); err != nil {
 		respondAccountError(c, err)
 		return
 	}
@@ -243,7 +271,7 @@ func (h *InvestmentHandler) upsertContributionRule(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "annual_limit is required"})
 		return
 	}
-	rule, err := service.UpsertContributionRule(h.db, accountTypeID, year, *body.AnnualLimit)
+	rule, err := service.UpsertContributionRule(h.conn(c), accountTypeID, year, *body.AnnualLimit)
 	if err != nil {
 		respondAccountError(c, err)
 		return
@@ -260,7 +288,7 @@ func (h *InvestmentHandler) deleteContributionRule(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := service.DeleteContributionRule(h.db, accountTypeID, year); err != nil {
+	if err := service.DeleteContributionRule(h.conn(c), accountTypeID, year); err != nil {
 		respondAccountError(c, err)
 		return
 	}
@@ -300,7 +328,7 @@ func (h *InvestmentHandler) currentUserBirthYear(c *gin.Context) (*int, bool) {
 		return nil, false
 	}
 	var user authmodels.User
-	if err := h.db.Select("birth_year").Where("username = ?", username).First(&user).Error; err != nil {
+	if err := h.conn(c).Select("birth_year").Where("username = ?", username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, true
 		}
@@ -0,0 +1,8 @@
+// Package migrations embeds the money module's schema, one directory per database driver.
+// Add files with `go run ./cmd/migration create -module money <name>`.
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "investment_contribution_rules";
+DROP TABLE IF EXISTS "investment_deposits";
+DROP TABLE IF EXISTS "investment_accounts";
+DROP TABLE IF EXISTS "investment_account_types";
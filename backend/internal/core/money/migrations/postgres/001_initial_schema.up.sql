@@ -0,0 +1,62 @@
+-- Initial money schema: its tables as created by the AutoMigrate setup this runner replaces.
+
+CREATE TABLE "investment_account_types" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text NOT NULL,
+    "contribution_start_year" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_investment_account_types_user_name" ON "investment_account_types" ("user_id","name");
+CREATE INDEX IF NOT EXISTS "idx_investment_account_types_deleted_at" ON "investment_account_types" ("deleted_at");
+
+CREATE TABLE "investment_accounts" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text NOT NULL,
+    "investment_account_type_id" bigint,
+    "current_balance" numeric(14,2) NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_investment_accounts_investment_account_type" FOREIGN KEY ("investment_account_type_id") REFERENCES "investment_account_types"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_investment_accounts_user_id" ON "investment_accounts" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_investment_accounts_deleted_at" ON "investment_accounts" ("deleted_at");
+
+CREATE TABLE "investment_deposits" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "account_id" bigint NOT NULL,
+    "amount" numeric(14,2) NOT NULL,
+    "date" timestamptz NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_investment_accounts_deposits" FOREIGN KEY ("account_id") REFERENCES "investment_accounts"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_investment_deposits_date" ON "investment_deposits" ("date");
+CREATE INDEX IF NOT EXISTS "idx_investment_deposits_account_id" ON "investment_deposits" ("account_id");
+CREATE INDEX IF NOT EXISTS "idx_investment_deposits_user_id" ON "investment_deposits" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_investment_deposits_deleted_at" ON "investment_deposits" ("deleted_at");
+
+CREATE TABLE "investment_contribution_rules" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "investment_account_type_id" bigint NOT NULL,
+    "year" bigint NOT NULL,
+    "annual_limit" numeric(14,2) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_investment_account_types_rules" FOREIGN KEY ("investment_account_type_id") REFERENCES "investment_account_types"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_investment_account_type_contribution_rule" ON "investment_contribution_rules" ("investment_account_type_id","year");
+CREATE INDEX IF NOT EXISTS "idx_investment_contribution_rules_user_id" ON "investment_contribution_rules" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_investment_contribution_rules_deleted_at" ON "investment_contribution_rules" ("deleted_at");
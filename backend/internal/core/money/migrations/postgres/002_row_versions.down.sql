@@ -0,0 +1,4 @@
+-- row_versions (postgres, down)
+
+ALTER TABLE "investment_account_types" DROP COLUMN "version";
+ALTER TABLE "investment_accounts" DROP COLUMN "version";
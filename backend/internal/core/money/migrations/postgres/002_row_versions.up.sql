@@ -0,0 +1,5 @@
+-- row_versions (postgres, up)
+
+-- Updates send the version they read as If-Match; a row that has moved on refuses the write.
+ALTER TABLE "investment_account_types" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "investment_accounts" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- deposit_dates (postgres, down)
+
+-- The server's zone is not known here, so deposits go back as midnight UTC.
+ALTER TABLE "investment_deposits" ALTER COLUMN "date" TYPE timestamptz USING "date"::timestamp AT TIME ZONE 'UTC';
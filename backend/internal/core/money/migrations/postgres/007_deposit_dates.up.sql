@@ -0,0 +1,5 @@
+-- deposit_dates (postgres, up)
+
+-- Deposits were stored as midnight in the server's zone. Half a day either side of midnight
+-- UTC is still that day for any zone from UTC-12 to UTC+12, whatever the session's TimeZone.
+ALTER TABLE "investment_deposits" ALTER COLUMN "date" TYPE date USING (("date" AT TIME ZONE 'UTC') + interval '12 hours')::date;
@@ -0,0 +1,58 @@
+-- Initial money schema: its tables as created by the AutoMigrate setup this runner replaces.
+
+CREATE TABLE "investment_account_types" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "name" text NOT NULL,
+    "contribution_start_year" integer
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_investment_account_types_user_name" ON "investment_account_types" ("user_id","name");
+CREATE INDEX IF NOT EXISTS "idx_investment_account_types_deleted_at" ON "investment_account_types" ("deleted_at");
+
+CREATE TABLE "investment_accounts" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "name" text NOT NULL,
+    "investment_account_type_id" integer,
+    "current_balance" numeric NOT NULL DEFAULT 0,
+    CONSTRAINT "fk_investment_accounts_investment_account_type" FOREIGN KEY ("investment_account_type_id") REFERENCES "investment_account_types"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_investment_accounts_user_id" ON "investment_accounts" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_investment_accounts_deleted_at" ON "investment_accounts" ("deleted_at");
+
+CREATE TABLE "investment_deposits" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "account_id" integer NOT NULL,
+    "amount" numeric NOT NULL,
+    "date" datetime NOT NULL,
+    CONSTRAINT "fk_investment_accounts_deposits" FOREIGN KEY ("account_id") REFERENCES "investment_accounts"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_investment_deposits_date" ON "investment_deposits" ("date");
+CREATE INDEX IF NOT EXISTS "idx_investment_deposits_account_id" ON "investment_deposits" ("account_id");
+CREATE INDEX IF NOT EXISTS "idx_investment_deposits_user_id" ON "investment_deposits" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_investment_deposits_deleted_at" ON "investment_deposits" ("deleted_at");
+
+CREATE TABLE "investment_contribution_rules" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "investment_account_type_id" integer NOT NULL,
+    "year" integer NOT NULL,
+    "annual_limit" numeric NOT NULL,
+    CONSTRAINT "fk_investment_account_types_rules" FOREIGN KEY ("investment_account_type_id") REFERENCES "investment_account_types"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_investment_account_type_contribution_rule" ON "investment_contribution_rules" ("investment_account_type_id","year");
+CREATE INDEX IF NOT EXISTS "idx_investment_contribution_rules_user_id" ON "investment_contribution_rules" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_investment_contribution_rules_deleted_at" ON "investment_contribution_rules" ("deleted_at");
@@ -0,0 +1,4 @@
+-- deposit_dates (sqlite, down)
+
+-- The server's zone is not known here, so deposits go back as midnight UTC.
+UPDATE "investment_deposits" SET "date" = "date" || ' 00:00:00+00:00' WHERE length("date") = 10;
@@ -0,0 +1,4 @@
+-- deposit_dates (sqlite, up)
+
+-- Deposits were stored as local-midnight timestamps whose first ten characters are the day.
+UPDATE "investment_deposits" SET "date" = substr("date", 1, 10);
@@ -1,45 +1,51 @@
 package models
 
 import (
-	"time"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
 type InvestmentAccount struct {
 	gorm.Model
+	UserID                  uint                   `json:"-" gorm:"index"`
 	Name                    string                 `json:"name" gorm:"not null"`
 	InvestmentAccountTypeID *uint                  `json:"investment_account_type_id"`
 	InvestmentAccountType   *InvestmentAccountType `json:"investment_account_type,omitempty" gorm:"foreignKey:InvestmentAccountTypeID"`
-	CurrentBalance          float64                `json:"current_balance" gorm:"type:numeric(14,2);not null;default:0"`
+	CurrentBalance          database.Money         `json:"current_balance" gorm:"not null;default:0"`
 	Deposits                []InvestmentDeposit    `json:"-" gorm:"foreignKey:AccountID;constraint:OnDelete:CASCADE"`
+	Version                 uint                   `json:"version" gorm:"not null;default:1"`
 }
 
 func (InvestmentAccount) TableName() string { return "investment_accounts" }
 
 type InvestmentAccountType struct {
 	gorm.Model
-	Name                  string             `json:"name" gorm:"not null;uniqueIndex"`
+	UserID                uint               `json:"-" gorm:"uniqueIndex:idx_investment_account_types_user_name"`
+	Name                  string             `json:"name" gorm:"not null;uniqueIndex:idx_investment_account_types_user_name"`
 	ContributionStartYear *int               `json:"contribution_start_year,omitempty"`
 	Rules                 []ContributionRule `json:"rules,omitempty" gorm:"foreignKey:InvestmentAccountTypeID;constraint:OnDelete:CASCADE"`
+	Version               uint               `json:"version" gorm:"not null;default:1"`
 }
 
 func (InvestmentAccountType) TableName() string { return "investment_account_types" }
 
 type InvestmentDeposit struct {
 	gorm.Model
-	AccountID uint      `json:"account_id" gorm:"not null;index"`
-	Amount    float64   `json:"amount" gorm:"type:numeric(14,2);not null"`
-	Date      time.Time `json:"date" gorm:"not null;index"`
+	UserID    uint           `json:"-" gorm:"index"`
+	AccountID uint           `json:"account_id" gorm:"not null;index"`
+	Amount    database.Money `json:"amount" gorm:"not null"`
+	Date      database.Date  `json:"date" gorm:"not null;index"`
 }
 
 func (InvestmentDeposit) TableName() string { return "investment_deposits" }
 
 type ContributionRule struct {
 	gorm.Model
-	InvestmentAccountTypeID uint    `json:"investment_account_type_id" gorm:"not null;uniqueIndex:idx_investment_account_type_contribution_rule,priority:1"`
-	Year                    int     `json:"year" gorm:"not null;uniqueIndex:idx_investment_account_type_contribution_rule,priority:2"`
-	AnnualLimit             float64 `json:"annual_limit" gorm:"type:numeric(14,2);not null"`
+	UserID                  uint           `json:"-" gorm:"index"`
+	InvestmentAccountTypeID uint           `json:"investment_account_type_id" gorm:"not null;uniqueIndex:idx_investment_account_type_contribution_rule,priority:1"`
+	Year                    int            `json:"year" gorm:"not null;uniqueIndex:idx_investment_account_type_contribution_rule,priority:2"`
+	AnnualLimit             database.Money `json:"annual_limit" gorm:"not null"`
 }
 
 func (ContributionRule) TableName() string { return "investment_contribution_rules" }
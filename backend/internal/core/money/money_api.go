@@ -1,31 +1,45 @@
 package money
 
 import (
+	"context"
+	"io/fs"
+
 	"be-simpletracker/internal/core/money/controller"
+	"be-simpletracker/internal/core/money/migrations"
 	"be-simpletracker/internal/core/money/models"
+	"be-simpletracker/internal/database"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-type Handler struct {
-	db *gorm.DB
+var ownedModels = []any{
+	&models.InvestmentAccountType{},
+	&models.InvestmentAccount{},
+	&models.InvestmentDeposit{},
+	&models.ContributionRule{},
 }
 
-func NewHandler(db *gorm.DB) *Handler {
-	return &Handler{db: db}
+// Module is the money feature: investment accounts and their deposits.
+type Module struct {
+	db *gorm.DB
 }
 
-func (h *Handler) Migrate() error {
-	return h.db.AutoMigrate(
-		&models.InvestmentAccountType{},
-		&models.InvestmentAccount{},
-		&models.InvestmentDeposit{},
-		&models.ContributionRule{},
-	)
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
 }
 
-func (h *Handler) RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+func (m *Module) Name() string { return "money" }
+
+func (m *Module) Models() []any { return ownedModels }
+
+func (m *Module) Migrations() fs.FS { return migrations.FS }
+
+func (m *Module) RegisterRoutes(router *gin.Engine, authMiddleware, ifMatch gin.HandlerFunc) {
 	group := router.Group("/money", authMiddleware)
-	controller.RegisterInvestmentRoutes(group.Group("/investments"), h.db)
+	controller.RegisterInvestmentRoutes(group.Group("/investments"), m.db, ifMatch)
+}
+
+func (m *Module) CheckHealth(ctx context.Context) error {
+	return database.RequireTables(m.db.WithContext(ctx), ownedModels...)
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"be-simpletracker/internal/core/money/models"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
@@ -110,7 +111,7 @@ func GetInvestmentAccountSummary(db *gorm.DB, id uint, birthYear *int) (*Investm
 	return &InvestmentAccountSummary{
 		InvestmentAccount:  account,
 		TotalDeposits:      totalDeposits,
-		Profit:             account.CurrentBalance - totalDeposits,
+		Profit:             float64(account.CurrentBalance) - totalDeposits,
 		ContributionStatus: status,
 		ContributionRoom:   room,
 	}, nil
@@ -120,7 +121,7 @@ func CreateInvestmentAccount(db *gorm.DB, name string, accountTypeID *uint, curr
 	account := models.InvestmentAccount{
 		Name:                    strings.TrimSpace(name),
 		InvestmentAccountTypeID: accountTypeID,
-		CurrentBalance:          currentBalance,
+		CurrentBalance:          database.Money(currentBalance),
 	}
 	if err := validateAccount(db, account); err != nil {
 		return nil, err
@@ -133,22 +134,30 @@ func CreateInvestmentAccount(db *gorm.DB, name string, accountTypeID *uint, curr
 
 func UpdateInvestmentAccount(db *gorm.DB, id uint, name *string, accountTypeID *uint, currentBalance *float64) (*models.InvestmentAccount, error) {
 	var account models.InvestmentAccount
-	if err := db.First(&account, id).Error; err != nil {
-		return nil, err
-	}
-	if name != nil {
-		account.Name = strings.TrimSpace(*name)
-	}
-	if accountTypeID != nil {
-		account.InvestmentAccountTypeID = accountTypeID
-	}
-	if currentBalance != nil {
-		account.CurrentBalance = *currentBalance
-	}
-	if err := validateAccount(db, account); err != nil {
-		return nil, err
-	}
-	if err := db.Save(&account).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&account, id).Error; err != nil {
+			return err
+		}
+		version, err := database.ClaimVersion(tx, &models.InvestmentAccount{}, id)
+		if err != nil {
+			return err
+		}
+		account.Version = version
+		if name != nil {
+			account.Name = strings.TrimSpace(*name)
+		}
+		if accountTypeID != nil {
+			account.InvestmentAccountTypeID = accountTypeID
+		}
+		if currentBalance != nil {
+			account.CurrentBalance = database.Money(*currentBalance)
+		}
+		if err := validateAccount(tx, account); err != nil {
+			return err
+		}
+		return tx.Save(&account).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &account, nil
@@ -176,14 +185,14 @@ func ListInvestmentDeposits(db *gorm.DB, accountID uint) ([]models.InvestmentDep
 	return deposits, nil
 }
 
-func CreateInvestmentDeposit(db *gorm.DB, accountID uint, amount float64, date time.Time) (*models.InvestmentDeposit, error) {
+func CreateInvestmentDeposit(db *gorm.DB, accountID uint, amount float64, date database.Date) (*models.InvestmentDeposit, error) {
 	if amount <= 0 {
 		return nil, errors.New("amount must be positive")
 	}
 	if err := accountExists(db, accountID); err != nil {
 		return nil, err
 	}
-	deposit := models.InvestmentDeposit{AccountID: accountID, Amount: amount, Date: date}
+	deposit := models.InvestmentDeposit{AccountID: accountID, Amount: database.Money(amount), Date: date}
 	if err := db.Create(&deposit).Error; err != nil {
 		return nil, err
 	}
@@ -214,17 +223,25 @@ func CreateInvestmentAccountType(db *gorm.DB, name string, contributionStartYear
 
 func UpdateInvestmentAccountType(db *gorm.DB, id uint, name string, contributionStartYear *int) (*models.InvestmentAccountType, error) {
 	var accountType models.InvestmentAccountType
-	if err := db.First(&accountType, id).Error; err != nil {
-		return nil, err
-	}
-	accountType.Name = strings.TrimSpace(name)
-	if contributionStartYear != nil {
-		accountType.ContributionStartYear = contributionStartYear
-	}
-	if err := validateAccountType(accountType); err != nil {
-		return nil, err
-	}
-	if err := db.Save(&accountType).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&accountType, id).Error; err != nil {
+			return err
+		}
+		version, err := database.ClaimVersion(tx, &models.InvestmentAccountType{}, id)
+		if err != nil {
+			return err
+		}
+		accountType.Version = version
+		accountType.Name = strings.TrimSpace(name)
+		if contributionStartYear != nil {
+			accountType.ContributionStartYear = contributionStartYear
+		}
+		if err := validateAccountType(accountType); err != nil {
+			return err
+		}
+		return tx.Save(&accountType).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &accountType, nil
@@ -261,7 +278,7 @@ func UpsertContributionRule(db *gorm.DB, accountTypeID uint, year int, annualLim
 	var rule models.ContributionRule
 	err := db.Where("investment_account_type_id = ? AND year = ?", accountTypeID, year).First(&rule).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		rule = models.ContributionRule{InvestmentAccountTypeID: accountTypeID, Year: year, AnnualLimit: annualLimit}
+		rule = models.ContributionRule{InvestmentAccountTypeID: accountTypeID, Year: year, AnnualLimit: database.Money(annualLimit)}
 		if err := db.Create(&rule).Error; err != nil {
 			return nil, err
 		}
@@ -270,7 +287,7 @@ func UpsertContributionRule(db *gorm.DB, accountTypeID uint, year int, annualLim
 	if err != nil {
 		return nil, err
 	}
-	rule.AnnualLimit = annualLimit
+	rule.AnnualLimit = database.Money(annualLimit)
 	if err := db.Save(&rule).Error; err != nil {
 		return nil, err
 	}
@@ -342,15 +359,15 @@ func contributionRoom(db *gorm.DB, accountTypeID uint, startYear *int, rules []m
 func annualLimitForYear(rules []models.ContributionRule, year int) (float64, bool) {
 	for _, rule := range rules {
 		if rule.Year <= year {
-			return rule.AnnualLimit, true
+			return float64(rule.AnnualLimit), true
 		}
 	}
 	return 0, false
 }
 
 func contributionTotal(db *gorm.DB, accountTypeID uint, year int) (float64, error) {
-	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
-	end := start.AddDate(1, 0, 0)
+	start := database.Date{Year: year, Month: time.January, Day: 1}
+	end := database.Date{Year: year + 1, Month: time.January, Day: 1}
 	var total float64
 	err := db.Model(&models.InvestmentDeposit{}).
 		Joins("JOIN investment_accounts ON investment_accounts.id = investment_deposits.account_id").
@@ -361,8 +378,8 @@ func contributionTotal(db *gorm.DB, accountTypeID uint, year int) (float64, erro
 }
 
 func contributionTotalThroughYear(db *gorm.DB, accountTypeID uint, startYear, endYear int) (float64, error) {
-	start := time.Date(startYear, time.January, 1, 0, 0, 0, 0, time.Local)
-	end := time.Date(endYear+1, time.January, 1, 0, 0, 0, 0, time.Local)
+	start := database.Date{Year: startYear, Month: time.January, Day: 1}
+	end := database.Date{Year: endYear + 1, Month: time.January, Day: 1}
 	var total float64
 	err := db.Model(&models.InvestmentDeposit{}).
 		Joins("JOIN investment_accounts ON investment_accounts.id = investment_deposits.account_id").
@@ -1,21 +1,21 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"be-simpletracker/internal/core/money/models"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/dbtest"
 
-	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatal(err)
-	}
+	db := dbtest.Open(t)
 	if err := db.AutoMigrate(
 		&models.InvestmentAccountType{},
 		&models.InvestmentAccount{},
@@ -45,17 +45,17 @@ func TestInvestmentAccountSummaryTracksProfitAndContributionRoom(t *testing.T) {
 	}
 	for _, deposit := range []struct {
 		amount float64
-		date   time.Time
+		date   database.Date
 	}{
-		{amount: 500, date: time.Date(2026, time.January, 15, 0, 0, 0, 0, time.Local)},
-		{amount: 300, date: time.Date(2026, time.March, 10, 0, 0, 0, 0, time.Local)},
-		{amount: 100, date: time.Date(2025, time.December, 31, 0, 0, 0, 0, time.Local)},
+		{amount: 500, date: database.Date{Year: 2026, Month: time.January, Day: 15}},
+		{amount: 300, date: database.Date{Year: 2026, Month: time.March, Day: 10}},
+		{amount: 100, date: database.Date{Year: 2025, Month: time.December, Day: 31}},
 	} {
 		if _, err := CreateInvestmentDeposit(db, account.ID, deposit.amount, deposit.date); err != nil {
 			t.Fatal(err)
 		}
 	}
-	if _, err := CreateInvestmentDeposit(db, secondAccount.ID, 200, time.Date(2026, time.April, 8, 0, 0, 0, 0, time.Local)); err != nil {
+	if _, err := CreateInvestmentDeposit(db, secondAccount.ID, 200, database.Date{Year: 2026, Month: time.April, Day: 8}); err != nil {
 		t.Fatal(err)
 	}
 	if _, err := UpsertContributionRule(db, accountType.ID, 2026, 7_000); err != nil {
@@ -131,3 +131,55 @@ func TestInvestmentAccountValidation(t *testing.T) {
 		t.Fatal("expected negative balance to be rejected")
 	}
 }
+
+func TestInvestmentAmountsAreStoredToTheCent(t *testing.T) {
+	db := setupTestDB(t)
+	account, err := CreateInvestmentAccount(db, "Brokerage", nil, 100.004)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateInvestmentDeposit(db, account.ID, 19.999, database.Date{Year: 2026, Month: time.May, Day: 1}); err != nil {
+		t.Fatal(err)
+	}
+	var reloaded models.InvestmentAccount
+	if err := db.First(&reloaded, account.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var deposit models.InvestmentDeposit
+	if err := db.Where("account_id = ?", account.ID).First(&deposit).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.CurrentBalance != 100 || deposit.Amount != 20 {
+		t.Fatalf("balance %v deposit %v, want 100 and 20", reloaded.CurrentBalance, deposit.Amount)
+	}
+}
+
+func TestUpdateInvestmentAccountRejectsStaleVersion(t *testing.T) {
+	db := setupTestDB(t)
+	account, err := CreateInvestmentAccount(db, "Brokerage", nil, 1_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := account.Version
+	name := "Phone edit"
+	phone, err := UpdateInvestmentAccount(db.WithContext(database.WithExpectedVersion(context.Background(), read)), account.ID, &name, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phone.Version != read+1 {
+		t.Fatalf("version %d after one update, read %d", phone.Version, read)
+	}
+
+	balance := 2_000.0
+	_, err = UpdateInvestmentAccount(db.WithContext(database.WithExpectedVersion(context.Background(), read)), account.ID, nil, nil, &balance)
+	if !errors.Is(err, database.ErrStaleVersion) {
+		t.Fatalf("laptop edit: got %v, want ErrStaleVersion", err)
+	}
+	var stored models.InvestmentAccount
+	if err := db.First(&stored, account.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != name || stored.CurrentBalance != 1_000 || stored.Version != phone.Version {
+		t.Fatalf("stored %+v", stored)
+	}
+}
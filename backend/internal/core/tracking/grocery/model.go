@@ -8,6 +8,7 @@ import (
 
 type GroceryItem struct {
 	gorm.Model
+	UserID      uint       `json:"-" gorm:"index"`
 	Name        string     `json:"name" gorm:"not null;index"`
 	CompletedAt *time.Time `json:"completed_at"`
 }
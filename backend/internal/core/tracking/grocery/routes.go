@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"be-simpletracker/internal/idempotency"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -16,14 +18,18 @@ type handler struct {
 func RegisterGroceryRoutes(group *gin.RouterGroup, db *gorm.DB) {
 	h := handler{db: db}
 	group.GET("/items", h.getItems)
-	group.POST("/items", h.postItem)
+	group.POST("/items", idempotency.Middleware(db), h.postItem)
 	group.PATCH("/items/:id/complete", h.completeItem)
 	group.DELETE("/items/:id", h.deleteItem)
 	group.GET("/suggestions", h.getSuggestions)
 }
 
+func (h *handler) conn(c *gin.Context) *gorm.DB {
+	return h.db.WithContext(c.Request.Context())
+}
+
 func (h *handler) getItems(c *gin.Context) {
-	rows, err := ListActiveItems(h.db)
+	rows, err := ListActiveItems(h.conn(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -41,7 +47,7 @@ func (h *handler) postItem(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	row, err := CreateItem(h.db, body.Name)
+	row, err := CreateItem(h.conn(c), body.Name)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -63,7 +69,7 @@ func (h *handler) completeItem(c *gin.Context) {
 	if !ok {
 		return
 	}
-	row, err := CompleteItem(h.db, id)
+	row, err := CompleteItem(h.conn(c), id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
@@ -80,7 +86,7 @@ func (h *handler) deleteItem(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := DeleteItem(h.db, id); err != nil {
+	if err := DeleteItem(h.conn(c), id); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 			return
@@ -92,7 +98,7 @@ func (h *handler) deleteItem(c *gin.Context) {
 }
 
 func (h *handler) getSuggestions(c *gin.Context) {
-	rows, err := ListSuggestions(h.db, c.Query("q"))
+	rows, err := ListSuggestions(h.conn(c), c.Query("q"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,85 @@
+-- Initial tracking schema: its tables as created by the AutoMigrate setup this runner replaces.
+
+CREATE TABLE "grocery_items" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text NOT NULL,
+    "completed_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_grocery_items_name" ON "grocery_items" ("name");
+CREATE INDEX IF NOT EXISTS "idx_grocery_items_user_id" ON "grocery_items" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_grocery_items_deleted_at" ON "grocery_items" ("deleted_at");
+
+CREATE TABLE "step_logs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "date" timestamptz NOT NULL,
+    "steps" bigint NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_step_logs_user_date" ON "step_logs" ("user_id","date");
+CREATE INDEX IF NOT EXISTS "idx_step_logs_deleted_at" ON "step_logs" ("deleted_at");
+
+CREATE TABLE "body_weight_logs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "date" timestamptz NOT NULL,
+    "weight_lbs" decimal NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_body_weight_logs_user_date" ON "body_weight_logs" ("user_id","date");
+CREATE INDEX IF NOT EXISTS "idx_body_weight_logs_deleted_at" ON "body_weight_logs" ("deleted_at");
+
+CREATE TABLE "drink_size_presets" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text NOT NULL,
+    "amount_oz" decimal NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_drink_size_presets_user_id" ON "drink_size_presets" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_drink_size_presets_deleted_at" ON "drink_size_presets" ("deleted_at");
+
+CREATE TABLE "water_logs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "date" timestamptz NOT NULL,
+    "amount_oz" decimal NOT NULL,
+    "preset_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_water_logs_preset" FOREIGN KEY ("preset_id") REFERENCES "drink_size_presets"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_water_logs_date" ON "water_logs" ("date");
+CREATE INDEX IF NOT EXISTS "idx_water_logs_user_id" ON "water_logs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_water_logs_deleted_at" ON "water_logs" ("deleted_at");
+
+CREATE TABLE "user_profiles" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "height_in" decimal NOT NULL,
+    "age" bigint NOT NULL,
+    "sex" text NOT NULL,
+    "activity_level" text NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_profiles_user_id" ON "user_profiles" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_user_profiles_deleted_at" ON "user_profiles" ("deleted_at");
@@ -0,0 +1,6 @@
+-- calendar_dates (postgres, down)
+
+-- The server's zone is not known here, so days go back as midnight UTC.
+ALTER TABLE "step_logs" ALTER COLUMN "date" TYPE timestamptz USING "date"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "body_weight_logs" ALTER COLUMN "date" TYPE timestamptz USING "date"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "water_logs" ALTER COLUMN "date" TYPE timestamptz USING "date"::timestamp AT TIME ZONE 'UTC';
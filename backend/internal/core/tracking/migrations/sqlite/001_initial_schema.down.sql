@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "user_profiles";
+DROP TABLE IF EXISTS "water_logs";
+DROP TABLE IF EXISTS "drink_size_presets";
+DROP TABLE IF EXISTS "body_weight_logs";
+DROP TABLE IF EXISTS "step_logs";
+DROP TABLE IF EXISTS "grocery_items";
@@ -0,0 +1,79 @@
+-- Initial tracking schema: its tables as created by the AutoMigrate setup this runner replaces.
+
+CREATE TABLE "grocery_items" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "name" text NOT NULL,
+    "completed_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_grocery_items_name" ON "grocery_items" ("name");
+CREATE INDEX IF NOT EXISTS "idx_grocery_items_user_id" ON "grocery_items" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_grocery_items_deleted_at" ON "grocery_items" ("deleted_at");
+
+CREATE TABLE "step_logs" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "date" datetime NOT NULL,
+    "steps" integer NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_step_logs_user_date" ON "step_logs" ("user_id","date");
+CREATE INDEX IF NOT EXISTS "idx_step_logs_deleted_at" ON "step_logs" ("deleted_at");
+
+CREATE TABLE "body_weight_logs" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "date" datetime NOT NULL,
+    "weight_lbs" real NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_body_weight_logs_user_date" ON "body_weight_logs" ("user_id","date");
+CREATE INDEX IF NOT EXISTS "idx_body_weight_logs_deleted_at" ON "body_weight_logs" ("deleted_at");
+
+CREATE TABLE "drink_size_presets" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "name" text NOT NULL,
+    "amount_oz" real NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_drink_size_presets_user_id" ON "drink_size_presets" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_drink_size_presets_deleted_at" ON "drink_size_presets" ("deleted_at");
+
+CREATE TABLE "water_logs" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "date" datetime NOT NULL,
+    "amount_oz" real NOT NULL,
+    "preset_id" integer,
+    CONSTRAINT "fk_water_logs_preset" FOREIGN KEY ("preset_id") REFERENCES "drink_size_presets"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_water_logs_date" ON "water_logs" ("date");
+CREATE INDEX IF NOT EXISTS "idx_water_logs_user_id" ON "water_logs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_water_logs_deleted_at" ON "water_logs" ("deleted_at");
+
+CREATE TABLE "user_profiles" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "height_in" real NOT NULL,
+    "age" integer NOT NULL,
+    "sex" text NOT NULL,
+    "activity_level" text NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_user_profiles_user_id" ON "user_profiles" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_user_profiles_deleted_at" ON "user_profiles" ("deleted_at");
@@ -0,0 +1,6 @@
+-- calendar_dates (sqlite, down)
+
+-- The server's zone is not known here, so days go back as midnight UTC.
+UPDATE "step_logs" SET "date" = "date" || ' 00:00:00+00:00' WHERE length("date") = 10;
+UPDATE "body_weight_logs" SET "date" = "date" || ' 00:00:00+00:00' WHERE length("date") = 10;
+UPDATE "water_logs" SET "date" = "date" || ' 00:00:00+00:00' WHERE length("date") = 10;
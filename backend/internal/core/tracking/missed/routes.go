@@ -9,13 +9,13 @@ import (
 
 func RegisterMissedRoutes(group *gin.RouterGroup, db *gorm.DB) {
 	group.GET("/missed", func(c *gin.Context) {
-		date, missingWeight, missingSteps, err := GetMissedYesterday(db)
+		date, missingWeight, missingSteps, err := GetMissedYesterday(db.WithContext(c.Request.Context()))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{
-			"date":   date.Format("2006-01-02"),
+			"date":   date,
 			"weight": missingWeight,
 			"steps":  missingSteps,
 		})
@@ -4,6 +4,7 @@ import "gorm.io/gorm"
 
 type UserProfile struct {
 	gorm.Model
+	UserID        uint    `json:"-" gorm:"index"`
 	HeightIn      float64 `json:"height_in" gorm:"not null"`
 	Age           int     `json:"age" gorm:"not null"`
 	Sex           string  `json:"sex" gorm:"not null"`
@@ -17,8 +17,12 @@ func RegisterProfileRoutes(group *gin.RouterGroup, db *gorm.DB) {
 	group.PUT("", h.putProfile)
 }
 
+func (h *handler) conn(c *gin.Context) *gorm.DB {
+	return h.db.WithContext(c.Request.Context())
+}
+
 func (h *handler) getProfile(c *gin.Context) {
-	row, err := GetProfile(h.db)
+	row, err := GetProfile(h.conn(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -43,7 +47,7 @@ func (h *handler) putProfile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	row, err := UpsertProfile(h.db, body.HeightIn, body.Age, body.Sex, body.ActivityLevel)
+	row, err := UpsertProfile(h.conn(c), body.HeightIn, body.Age, body.Sex, body.ActivityLevel)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -1,15 +1,16 @@
 package steps
 
 import (
-	"time"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
 type StepLog struct {
 	gorm.Model
-	Date  time.Time `json:"date" gorm:"uniqueIndex;not null"`
-	Steps int       `json:"steps" gorm:"not null"`
+	UserID uint          `json:"-" gorm:"uniqueIndex:idx_step_logs_user_date"`
+	Date   database.Date `json:"date" gorm:"uniqueIndex:idx_step_logs_user_date;not null"`
+	Steps  int           `json:"steps" gorm:"not null"`
 }
 
 func (StepLog) TableName() string { return "step_logs" }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"be-simpletracker/internal/core/tracking/common"
+	"be-simpletracker/internal/idempotency"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,12 +17,16 @@ type handler struct {
 func RegisterStepsRoutes(group *gin.RouterGroup, db *gorm.DB) {
 	h := handler{db: db}
 	group.GET("", h.getSteps)
-	group.POST("", h.postSteps)
+	group.POST("", idempotency.Middleware(db), h.postSteps)
+}
+
+func (h *handler) conn(c *gin.Context) *gorm.DB {
+	return h.db.WithContext(c.Request.Context())
 }
 
 func (h *handler) getSteps(c *gin.Context) {
 	limit := common.ParseLimitQuery(c)
-	rows, err := ListSteps(h.db, limit)
+	rows, err := ListSteps(h.conn(c), limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -40,12 +45,12 @@ func (h *handler) postSteps(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	date, err := common.ParseDateString(body.Date)
+	date, err := common.ParseDateString(c.Request.Context(), body.Date)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
 		return
 	}
-	row, err := UpsertSteps(h.db, date, body.Steps)
+	row, err := UpsertSteps(h.conn(c), date, body.Steps)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
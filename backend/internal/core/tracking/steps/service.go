@@ -2,14 +2,15 @@ package steps
 
 import (
 	"errors"
-	"time"
+
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
 const defaultListLimit = 365
 
-func UpsertSteps(db *gorm.DB, date time.Time, stepsVal int) (*StepLog, error) {
+func UpsertSteps(db *gorm.DB, date database.Date, stepsVal int) (*StepLog, error) {
 	if stepsVal < 0 {
 		return nil, errors.New("steps must be non-negative")
 	}
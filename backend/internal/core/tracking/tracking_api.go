@@ -1,42 +1,56 @@
 package tracking
 
 import (
+	"context"
+	"io/fs"
+
 	"be-simpletracker/internal/core/tracking/grocery"
+	"be-simpletracker/internal/core/tracking/migrations"
 	"be-simpletracker/internal/core/tracking/missed"
 	"be-simpletracker/internal/core/tracking/profile"
 	"be-simpletracker/internal/core/tracking/steps"
 	"be-simpletracker/internal/core/tracking/water"
 	"be-simpletracker/internal/core/tracking/weight"
+	"be-simpletracker/internal/database"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-type Handler struct {
-	db *gorm.DB
+var ownedModels = []any{
+	&grocery.GroceryItem{},
+	&steps.StepLog{},
+	&weight.BodyWeightLog{},
+	&water.WaterLog{},
+	&water.DrinkSizePreset{},
+	&profile.UserProfile{},
 }
 
-func NewHandler(db *gorm.DB) *Handler {
-	return &Handler{db: db}
+// Module is the tracking feature: weight, steps, water, groceries and the profile.
+type Module struct {
+	db *gorm.DB
 }
 
-func (h *Handler) Migrate() error {
-	return h.db.AutoMigrate(
-		&grocery.GroceryItem{},
-		&steps.StepLog{},
-		&weight.BodyWeightLog{},
-		&water.WaterLog{},
-		&water.DrinkSizePreset{},
-		&profile.UserProfile{},
-	)
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
 }
 
-func (h *Handler) RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+func (m *Module) Name() string { return "tracking" }
+
+func (m *Module) Models() []any { return ownedModels }
+
+func (m *Module) Migrations() fs.FS { return migrations.FS }
+
+func (m *Module) RegisterRoutes(router *gin.Engine, authMiddleware, ifMatch gin.HandlerFunc) {
 	group := router.Group("/tracking", authMiddleware)
-	missed.RegisterMissedRoutes(group, h.db)
-	profile.RegisterProfileRoutes(group.Group("/profile"), h.db)
-	grocery.RegisterGroceryRoutes(group.Group("/grocery"), h.db)
-	weight.RegisterWeightRoutes(group.Group("/weight"), h.db)
-	steps.RegisterStepsRoutes(group.Group("/steps"), h.db)
-	water.RegisterWaterRoutes(group.Group("/water"), h.db)
+	missed.RegisterMissedRoutes(group, m.db)
+	profile.RegisterProfileRoutes(group.Group("/profile"), m.db)
+	grocery.RegisterGroceryRoutes(group.Group("/grocery"), m.db)
+	weight.RegisterWeightRoutes(group.Group("/weight"), m.db)
+	steps.RegisterStepsRoutes(group.Group("/steps"), m.db)
+	water.RegisterWaterRoutes(group.Group("/water"), m.db)
+}
+
+func (m *Module) CheckHealth(ctx context.Context) error {
+	return database.RequireTables(m.db.WithContext(ctx), ownedModels...)
 }
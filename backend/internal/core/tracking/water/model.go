@@ -1,13 +1,14 @@
 package water
 
 import (
-	"time"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
 type DrinkSizePreset struct {
 	gorm.Model
+	UserID   uint    `json:"-" gorm:"index"`
 	Name     string  `json:"name" gorm:"not null"`
 	AmountOz float64 `json:"amount_oz" gorm:"not null"`
 }
@@ -16,7 +17,8 @@ func (DrinkSizePreset) TableName() string { return "drink_size_presets" }
 
 type WaterLog struct {
 	gorm.Model
-	Date     time.Time        `json:"date" gorm:"index;not null"`
+	UserID   uint             `json:"-" gorm:"index"`
+	Date     database.Date    `json:"date" gorm:"index;not null"`
 	AmountOz float64          `json:"amount_oz" gorm:"not null"`
 	PresetID *uint            `json:"preset_id"`
 	Preset   *DrinkSizePreset `json:"preset,omitempty" gorm:"foreignKey:PresetID"`
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"be-simpletracker/internal/core/tracking/common"
+	"be-simpletracker/internal/idempotency"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -18,7 +19,7 @@ type handler struct {
 func RegisterWaterRoutes(group *gin.RouterGroup, db *gorm.DB) {
 	h := handler{db: db}
 	group.GET("", h.getWater)
-	group.POST("", h.postWater)
+	group.POST("", idempotency.Middleware(db), h.postWater)
 	group.DELETE("/:id", h.deleteWater)
 	p := group.Group("/presets")
 	{
@@ -29,14 +30,18 @@ func RegisterWaterRoutes(group *gin.RouterGroup, db *gorm.DB) {
 	}
 }
 
+func (h *handler) conn(c *gin.Context) *gorm.DB {
+	return h.db.WithContext(c.Request.Context())
+}
+
 func (h *handler) getWater(c *gin.Context) {
 	dateStr := c.Query("date")
-	date, err := common.ParseDateString(dateStr)
+	date, err := common.ParseDateString(c.Request.Context(), dateStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
 		return
 	}
-	rows, err := ListWaterLogsForDate(h.db, date)
+	rows, err := ListWaterLogsForDate(h.conn(c), date)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -56,12 +61,12 @@ func (h *handler) postWater(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	date, err := common.ParseDateString(body.Date)
+	date, err := common.ParseDateString(c.Request.Context(), body.Date)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
 		return
 	}
-	row, err := CreateWaterLog(h.db, date, body.AmountOz, body.PresetID)
+	row, err := CreateWaterLog(h.conn(c), date, body.AmountOz, body.PresetID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -75,7 +80,7 @@ func (h *handler) deleteWater(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
-	err = DeleteWaterLog(h.db, uint(id64))
+	err = DeleteWaterLog(h.conn(c), uint(id64))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
@@ -88,7 +93,7 @@ func (h *handler) deleteWater(c *gin.Context) {
 }
 
 func (h *handler) getPresets(c *gin.Context) {
-	rows, err := ListDrinkSizePresets(h.db)
+	rows, err := ListDrinkSizePresets(h.conn(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -107,7 +112,7 @@ func (h *handler) postPreset(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	row, err := CreateDrinkSizePreset(h.db, body.Name, body.AmountOz)
+	row, err := CreateDrinkSizePreset(h.conn(c), body.Name, body.AmountOz)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -126,7 +131,7 @@ func (h *handler) putPreset(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	row, err := UpdateDrinkSizePreset(h.db, uint(id64), body.Name, body.AmountOz)
+	row, err := UpdateDrinkSizePreset(h.conn(c), uint(id64), body.Name, body.AmountOz)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
@@ -144,7 +149,7 @@ func (h *handler) deletePreset(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
-	err = DeleteDrinkSizePreset(h.db, uint(id64))
+	err = DeleteDrinkSizePreset(h.conn(c), uint(id64))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
@@ -2,12 +2,13 @@ package water
 
 import (
 	"errors"
-	"time"
+
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
-func CreateWaterLog(db *gorm.DB, date time.Time, amountOz float64, presetID *uint) (*WaterLog, error) {
+func CreateWaterLog(db *gorm.DB, date database.Date, amountOz float64, presetID *uint) (*WaterLog, error) {
 	if amountOz <= 0 {
 		return nil, errors.New("amount_oz must be positive")
 	}
@@ -34,7 +35,7 @@ func CreateWaterLog(db *gorm.DB, date time.Time, amountOz float64, presetID *uin
 	return &row, nil
 }
 
-func ListWaterLogsForDate(db *gorm.DB, date time.Time) ([]WaterLog, error) {
+func ListWaterLogsForDate(db *gorm.DB, date database.Date) ([]WaterLog, error) {
 	var rows []WaterLog
 	err := db.Where("date = ?", date).Preload("Preset").Order("created_at DESC").Find(&rows).Error
 	return rows, err
@@ -1,15 +1,16 @@
 package weight
 
 import (
-	"time"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
 type BodyWeightLog struct {
 	gorm.Model
-	Date      time.Time `json:"date" gorm:"uniqueIndex;not null"`
-	WeightLbs float64   `json:"weight_lbs" gorm:"not null"`
+	UserID    uint          `json:"-" gorm:"uniqueIndex:idx_body_weight_logs_user_date"`
+	Date      database.Date `json:"date" gorm:"uniqueIndex:idx_body_weight_logs_user_date;not null"`
+	WeightLbs float64       `json:"weight_lbs" gorm:"not null"`
 }
 
 func (BodyWeightLog) TableName() string { return "body_weight_logs" }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"be-simpletracker/internal/core/tracking/common"
+	"be-simpletracker/internal/idempotency"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,12 +17,16 @@ type handler struct {
 func RegisterWeightRoutes(group *gin.RouterGroup, db *gorm.DB) {
 	h := handler{db: db}
 	group.GET("", h.getWeights)
-	group.POST("", h.postWeight)
+	group.POST("", idempotency.Middleware(db), h.postWeight)
+}
+
+func (h *handler) conn(c *gin.Context) *gorm.DB {
+	return h.db.WithContext(c.Request.Context())
 }
 
 func (h *handler) getWeights(c *gin.Context) {
 	limit := common.ParseLimitQuery(c)
-	rows, err := ListBodyWeights(h.db, limit)
+	rows, err := ListBodyWeights(h.conn(c), limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -40,12 +45,12 @@ func (h *handler) postWeight(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	date, err := common.ParseDateString(body.Date)
+	date, err := common.ParseDateString(c.Request.Context(), body.Date)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
 		return
 	}
-	row, err := UpsertBodyWeight(h.db, date, body.WeightLbs)
+	row, err := UpsertBodyWeight(h.conn(c), date, body.WeightLbs)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -2,14 +2,15 @@ package weight
 
 import (
 	"errors"
-	"time"
+
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
 const defaultListLimit = 365
 
-func UpsertBodyWeight(db *gorm.DB, date time.Time, weightLbs float64) (*BodyWeightLog, error) {
+func UpsertBodyWeight(db *gorm.DB, date database.Date, weightLbs float64) (*BodyWeightLog, error) {
 	if weightLbs <= 0 {
 		return nil, errors.New("weight_lbs must be positive")
 	}
@@ -0,0 +1,168 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"be-simpletracker/internal/config"
+	"be-simpletracker/internal/core/archive"
+	"be-simpletracker/internal/core/module"
+
+	"gorm.io/gorm"
+)
+
+// Config is the server's purge schedule.
+type Config struct {
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// ConfigFrom reads TRASH_RETENTION_DAYS (default 30). Zero keeps deleted rows forever.
+func ConfigFrom(cfg config.Trash) (Config, bool, error) {
+	days := cfg.RetentionDays
+	if days < 0 {
+		return Config{}, false, fmt.Errorf("TRASH_RETENTION_DAYS must not be negative")
+	}
+	if days == 0 {
+		return Config{}, false, nil
+	}
+	return Config{Retention: time.Duration(days) * 24 * time.Hour, Interval: 24 * time.Hour}, true, nil
+}
+
+// Job is the purge of rows past TRASH_RETENTION_DAYS as a background job, or nil when deleted
+// rows are kept forever.
+func Job(db *gorm.DB, cfg config.Trash) (module.Job, error) {
+	schedule, enabled, err := ConfigFrom(cfg)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	return func(ctx context.Context, enabled func(string) bool) { Run(ctx, db, enabled, schedule) }, nil
+}
+
+// Run purges rows older than config.Retention now and then every config.Interval until ctx
+// is done.
+func Run(ctx context.Context, db *gorm.DB, enabled func(table string) bool, config Config) {
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+	for {
+		purged, err := Purge(ctx, db, enabled, time.Now().Add(-config.Retention))
+		for _, table := range purgeTables(enabled) {
+			if n := purged[table.table]; n > 0 {
+				log.Printf("trash: purged %d from %s", n, table.table)
+			}
+		}
+		if err != nil {
+			log.Printf("trash: purge failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge hard-deletes, for every account, the rows deleted before cutoff along with the rows
+// that belong to them, and returns how many went per table. A row that others still point at
+// stays until they are gone too. Only the tables of enabled modules are touched.
+func Purge(ctx context.Context, db *gorm.DB, enabled func(table string) bool, cutoff time.Time) (map[string]int64, error) {
+	db = db.WithContext(ctx)
+	purged := map[string]int64{}
+	refs := archive.References()
+	for _, t := range purgeTables(enabled) {
+		if !softDeletes(db, t.table) {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			expired := func() *gorm.DB {
+				q := tx.Table(t.table).Select("id").Where("deleted_at < ?", cutoff)
+				for _, ref := range refs {
+					if ref.Parent != t.table || t.owns(ref) || !enabled(ref.Table) {
+						continue
+					}
+					q = q.Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s r WHERE r.%s = %s.id)", ref.Table, ref.Column, t.table))
+				}
+				return q
+			}
+			if err := purgeChildren(tx, t.children, expired, purged); err != nil {
+				return err
+			}
+			result := tx.Exec("DELETE FROM "+t.table+" WHERE id IN (?)", expired())
+			purged[t.table] += result.RowsAffected
+			return result.Error
+		})
+		if err != nil {
+			return purged, fmt.Errorf("%s: %w", t.table, err)
+		}
+	}
+	return purged, nil
+}
+
+// purgeChildren deletes the rows below the parents selected by parents, deepest first.
+func purgeChildren(tx *gorm.DB, children []child, parents func() *gorm.DB, purged map[string]int64) error {
+	for _, c := range children {
+		if c.detach {
+			err := tx.Exec("UPDATE "+c.table+" SET "+c.column+" = NULL WHERE "+c.column+" IN (?)", parents()).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		rows := func() *gorm.DB {
+			return tx.Table(c.table).Select("id").Where(c.column+" IN (?)", parents())
+		}
+		if len(c.children) > 0 {
+			if err := purgeChildren(tx, c.children, rows, purged); err != nil {
+				return err
+			}
+		}
+		result := tx.Exec("DELETE FROM "+c.table+" WHERE "+c.column+" IN (?)", parents())
+		if result.Error != nil {
+			return result.Error
+		}
+		purged[c.table] += result.RowsAffected
+	}
+	return nil
+}
+
+// purgeTable is a soft-deleting table with the children that go with its rows.
+type purgeTable struct {
+	table    string
+	children []child
+}
+
+func (t purgeTable) owns(ref archive.Reference) bool {
+	for _, c := range t.children {
+		if c.table == ref.Table && c.column == ref.Column {
+			return true
+		}
+	}
+	return false
+}
+
+// purgeTables lists every soft-deleting table the enabled kinds reach, children before the
+// tables they belong to so a parent is not held back by rows purged in the same run.
+func purgeTables(enabled func(table string) bool) []purgeTable {
+	var order []purgeTable
+	seen := map[string]bool{}
+	var visit func(table string, children []child)
+	visit = func(table string, children []child) {
+		if seen[table] {
+			return
+		}
+		seen[table] = true
+		for _, c := range children {
+			if !c.detach {
+				visit(c.table, c.children)
+			}
+		}
+		order = append(order, purgeTable{table: table, children: children})
+	}
+	selected := enabledKinds(enabled)
+	for i := len(selected) - 1; i >= 0; i-- {
+		visit(selected[i].table, selected[i].children)
+	}
+	return order
+}
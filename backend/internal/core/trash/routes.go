@@ -0,0 +1,73 @@
+package trash
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"be-simpletracker/internal/utils/apierr"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type handler struct {
+	db      *gorm.DB
+	enabled func(table string) bool
+}
+
+// RegisterRoutes mounts the trash for the tables enabled reports as belonging to an enabled
+// module.
+func RegisterRoutes(router *gin.Engine, db *gorm.DB, enabled func(table string) bool, authMiddleware gin.HandlerFunc) {
+	h := handler{db: db, enabled: enabled}
+	group := router.Group("/trash", authMiddleware)
+	group.GET("", h.list)
+	group.POST("/:type/:id/restore", h.restore)
+}
+
+// list serves GET /trash?type=meals&limit=50.
+func (h *handler) list(c *gin.Context) {
+	limit := DefaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			apierr.BadRequest(c, "invalid limit")
+			return
+		}
+		limit = min(n, MaxLimit)
+	}
+	items, err := List(c.Request.Context(), h.db, h.enabled, c.Query("type"), limit)
+	if errors.Is(err, ErrUnknownType) {
+		apierr.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		apierr.Internal(c, err)
+		return
+	}
+	if items == nil {
+		items = []Item{}
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "types": Types(h.enabled)})
+}
+
+func (h *handler) restore(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierr.BadRequest(c, "invalid id")
+		return
+	}
+	restored, err := Restore(c.Request.Context(), h.db, h.enabled, c.Param("type"), uint(id))
+	switch {
+	case errors.Is(err, ErrUnknownType):
+		apierr.BadRequest(c, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		apierr.NotFound(c, "not in trash")
+	case errors.Is(err, ErrOwnerDeleted), errors.Is(err, ErrKeyTaken):
+		apierr.Conflict(c, err.Error())
+	case err != nil:
+		apierr.Internal(c, err)
+	default:
+		c.JSON(http.StatusOK, gin.H{"restored": restored})
+	}
+}
@@ -0,0 +1,387 @@
+// Package trash lists and restores soft-deleted rows and purges them for good once they have
+// been deleted for longer than the retention period.
+//
+// Deleting a row only stamps its deleted_at; the rows that belong to it are left alone or, where
+// a service deletes them itself, stamped a moment later. Restoring a row therefore also restores
+// the rows below it that were deleted within cascadeWindow of it, and leaves the ones deleted on
+// their own before or after.
+package trash
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"be-simpletracker/internal/database"
+
+	"gorm.io/gorm"
+)
+
+const (
+	cascadeWindow = 2 * time.Second
+
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+var (
+	ErrUnknownType = errors.New("unknown trash type")
+	// ErrOwnerDeleted refuses a row whose owning row is still in the trash.
+	ErrOwnerDeleted = errors.New("belongs to a row that is still in the trash")
+	// ErrKeyTaken refuses a row whose unique key a live row has taken since.
+	ErrKeyTaken = errors.New("a row with the same key already exists")
+)
+
+// child is a table whose rows belong to a row of its parent through column.
+type child struct {
+	table  string
+	column string
+	// detach children outlive their parent: purging it clears the reference instead of
+	// deleting them.
+	detach   bool
+	children []child
+}
+
+// kind is a table whose rows can be listed and restored.
+type kind struct {
+	table string
+	// preview are the SQL expressions shown for a deleted row.
+	preview  []string
+	children []child
+}
+
+var kinds = []kind{
+	{table: "plans", preview: []string{"name", "calories", "effective_from"}},
+	{table: "foods", preview: []string{"name", "calories"}},
+	{table: "composite_foods", preview: []string{"name"}, children: []child{
+		{table: "composite_food_items", column: "composite_food_id"},
+	}},
+	{table: "meals", preview: []string{"name"}, children: []child{
+		{table: "meal_items", column: "meal_id"},
+		{table: "planned_meals", column: "meal_id"},
+		{table: "day_logs", column: "meal_id"},
+	}},
+	{table: "saved_meals", preview: []string{"name"}, children: []child{
+		{table: "saved_meal_items", column: "saved_meal_id"},
+		{table: "planned_meals", column: "saved_meal_id", detach: true},
+	}},
+	{table: "planned_meals", preview: []string{
+		"(SELECT name FROM meals WHERE meals.id = planned_meals.meal_id) AS meal",
+		"(SELECT date FROM days WHERE days.id = planned_meals.day_id) AS date",
+	}},
+	{table: "day_logs", preview: []string{
+		"(SELECT name FROM meals WHERE meals.id = day_logs.meal_id) AS meal",
+		"(SELECT date FROM days WHERE days.id = day_logs.day_id) AS date",
+	}},
+
+	{table: "exercises", preview: []string{"name"}},
+	{table: "workout_programs", preview: []string{"name"}, children: []child{
+		{table: "workout_plans", column: "workout_program_id", detach: true},
+	}},
+	{table: "workout_plans", preview: []string{"name"}, children: []child{
+		{table: "workout_plan_days", column: "workout_plan_id"},
+		{table: "workout_plan_exercises", column: "workout_plan_id"},
+	}},
+	{table: "workout_logs", preview: []string{"date"}, children: []child{
+		{table: "logged_exercises", column: "workout_log_id", children: []child{
+			{table: "logged_sets", column: "logged_exercise_id"},
+		}},
+		{table: "cardios", column: "workout_log_id"},
+	}},
+	{table: "logged_exercises", preview: []string{
+		"(SELECT name FROM exercises WHERE exercises.id = logged_exercises.exercise_id) AS exercise",
+		"(SELECT date FROM workout_logs WHERE workout_logs.id = logged_exercises.workout_log_id) AS date",
+	}, children: []child{
+		{table: "logged_sets", column: "logged_exercise_id"},
+	}},
+
+	{table: "grocery_items", preview: []string{"name", "completed_at"}},
+	{table: "step_logs", preview: []string{"date", "steps"}},
+	{table: "body_weight_logs", preview: []string{"date", "weight_lbs"}},
+	{table: "drink_size_presets", preview: []string{"name", "amount_oz"}, children: []child{
+		{table: "water_logs", column: "preset_id", detach: true},
+	}},
+	{table: "water_logs", preview: []string{"date", "amount_oz"}},
+
+	{table: "investment_account_types", preview: []string{"name"}, children: []child{
+		{table: "investment_contribution_rules", column: "investment_account_type_id"},
+	}},
+	{table: "investment_contribution_rules", preview: []string{
+		"(SELECT name FROM investment_account_types t WHERE t.id = investment_contribution_rules.investment_account_type_id) AS account_type",
+		"year", "annual_limit",
+	}},
+	{table: "investment_accounts", preview: []string{"name", "current_balance"}, children: []child{
+		{table: "investment_deposits", column: "account_id"},
+	}},
+	{table: "investment_deposits", preview: []string{
+		"(SELECT name FROM investment_accounts a WHERE a.id = investment_deposits.account_id) AS account",
+		"date", "amount",
+	}},
+}
+
+// Types lists the type names List and Restore accept: the kinds whose table enabled reports
+// as belonging to an enabled module.
+func Types(enabled func(table string) bool) []string {
+	var out []string
+	for _, k := range enabledKinds(enabled) {
+		out = append(out, k.table)
+	}
+	return out
+}
+
+func enabledKinds(enabled func(table string) bool) []kind {
+	var out []kind
+	for _, k := range kinds {
+		if enabled(k.table) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func lookup(enabled func(table string) bool, name string) (kind, error) {
+	for _, k := range enabledKinds(enabled) {
+		if k.table == name {
+			return k, nil
+		}
+	}
+	return kind{}, ErrUnknownType
+}
+
+// Item is one deleted row.
+type Item struct {
+	Type      string         `json:"type"`
+	ID        uint           `json:"id"`
+	DeletedAt time.Time      `json:"deleted_at"`
+	Preview   map[string]any `json:"preview"`
+	// Children counts, by table, the rows deleted along with this one.
+	Children map[string]int64 `json:"children,omitempty"`
+}
+
+type deletedRow struct {
+	ID        uint
+	DeletedAt time.Time
+}
+
+// List returns the most recently deleted rows of the user in ctx, newest first. An empty
+// typeName lists every type.
+func List(ctx context.Context, db *gorm.DB, enabled func(table string) bool, typeName string, limit int) ([]Item, error) {
+	db = db.WithContext(ctx)
+	selected := enabledKinds(enabled)
+	if typeName != "" {
+		k, err := lookup(enabled, typeName)
+		if err != nil {
+			return nil, err
+		}
+		selected = []kind{k}
+	}
+
+	var items []Item
+	for _, k := range selected {
+		var rows []deletedRow
+		err := db.Table(k.table).Scopes(database.OwnedBy(ctx, k.table)).
+			Select("id", "deleted_at").
+			Where("deleted_at IS NOT NULL").
+			Order("deleted_at DESC, id DESC").
+			Limit(limit).
+			Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			items = append(items, Item{Type: k.table, ID: row.ID, DeletedAt: row.DeletedAt})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	for i := range items {
+		k, _ := lookup(enabled, items[i].Type)
+		if err := describe(db, k, &items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func describe(db *gorm.DB, k kind, item *Item) error {
+	item.Preview = map[string]any{}
+	if err := db.Table(k.table).Select(k.preview).Where("id = ?", item.ID).Take(&item.Preview).Error; err != nil {
+		return err
+	}
+	from, to := window(item.DeletedAt)
+	for _, c := range k.children {
+		if !softDeletes(db, c.table) {
+			continue
+		}
+		var n int64
+		err := db.Table(c.table).
+			Where(c.column+" = ?", item.ID).
+			Where("deleted_at BETWEEN ? AND ?", from, to).
+			Count(&n).Error
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			if item.Children == nil {
+				item.Children = map[string]int64{}
+			}
+			item.Children[c.table] += n
+		}
+	}
+	return nil
+}
+
+// Restore undeletes one row of the user in ctx along with the rows deleted with it, and
+// returns how many rows came back per table. A row that is not in the trash is
+// gorm.ErrRecordNotFound. A row whose owner is still deleted is ErrOwnerDeleted, and one that
+// would clash with a live row is ErrKeyTaken; nothing is restored then.
+func Restore(ctx context.Context, db *gorm.DB, enabled func(table string) bool, typeName string, id uint) (map[string]int64, error) {
+	k, err := lookup(enabled, typeName)
+	if err != nil {
+		return nil, err
+	}
+	restored := map[string]int64{}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var row deletedRow
+		err := tx.Table(k.table).Scopes(database.OwnedBy(ctx, k.table)).
+			Select("id", "deleted_at").
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Take(&row).Error
+		if err != nil {
+			return err
+		}
+		if err := requireLiveOwners(tx, k.table, id); err != nil {
+			return err
+		}
+		if err := tx.Table(k.table).Where("id = ?", id).Updates(undelete(tx, k.table)).Error; err != nil {
+			return err
+		}
+		restored[k.table]++
+		return restoreChildren(tx, k.children, []uint{id}, row.DeletedAt, restored)
+	})
+	if isUniqueViolation(err) {
+		return nil, ErrKeyTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// owner is a table whose rows own rows of another through column.
+type owner struct {
+	table  string
+	column string
+}
+
+// owners returns the tables that own rows of table. Detached children outlive their parent,
+// so they have no owner to wait for.
+func owners(table string) []owner {
+	var out []owner
+	for _, k := range kinds {
+		for _, c := range k.children {
+			if c.table == table && !c.detach {
+				out = append(out, owner{table: k.table, column: c.column})
+			}
+		}
+	}
+	return out
+}
+
+// requireLiveOwners refuses to bring back a row whose owner is still in the trash, where it
+// would point at a row the user cannot see. Restoring the owner brings the row back with it
+// when they were deleted together.
+func requireLiveOwners(tx *gorm.DB, table string, id uint) error {
+	for _, o := range owners(table) {
+		var ref sql.NullInt64
+		if err := tx.Table(table).Select(o.column).Where("id = ?", id).Scan(&ref).Error; err != nil {
+			return err
+		}
+		if !ref.Valid {
+			continue
+		}
+		var deleted int64
+		if err := tx.Table(o.table).Where("id = ? AND deleted_at IS NOT NULL", ref.Int64).Count(&deleted).Error; err != nil {
+			return err
+		}
+		if deleted > 0 {
+			return fmt.Errorf("%w: restore %s %d first", ErrOwnerDeleted, o.table, ref.Int64)
+		}
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	s := strings.ToLower(err.Error())
+	return strings.Contains(s, "duplicate key") || strings.Contains(s, "unique constraint")
+}
+
+// restoreChildren walks down through live children as well, since a service may have left a
+// row in place and deleted only the rows below it.
+func restoreChildren(tx *gorm.DB, children []child, parentIDs []uint, deletedAt time.Time, restored map[string]int64) error {
+	if len(parentIDs) == 0 {
+		return nil
+	}
+	from, to := window(deletedAt)
+	for _, c := range children {
+		if !softDeletes(tx, c.table) {
+			continue
+		}
+		var ids []uint
+		err := tx.Table(c.table).
+			Where(c.column+" IN ?", parentIDs).
+			Where("deleted_at IS NULL OR deleted_at BETWEEN ? AND ?", from, to).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		result := tx.Table(c.table).Where("id IN ? AND deleted_at IS NOT NULL", ids).Updates(undelete(tx, c.table))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			restored[c.table] += result.RowsAffected
+		}
+		if err := restoreChildren(tx, c.children, ids, deletedAt, restored); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// undelete clears deleted_at and, where the table keeps them, moves updated_at on so delta sync
+// hands the row back to clients that saw it deleted, and bumps the version so a write based
+// on the row as it was before the delete is refused.
+func undelete(tx *gorm.DB, table string) map[string]any {
+	values := map[string]any{"deleted_at": nil}
+	if tx.Migrator().HasColumn(table, "updated_at") {
+		values["updated_at"] = time.Now()
+	}
+	if tx.Migrator().HasColumn(table, "version") {
+		values["version"] = gorm.Expr("version + 1")
+	}
+	return values
+}
+
+func window(deletedAt time.Time) (time.Time, time.Time) {
+	return deletedAt.Add(-cascadeWindow), deletedAt.Add(cascadeWindow)
+}
+
+// softDeletes reports whether table has a deleted_at column. Join tables such as
+// workout_plan_days do not and are only ever deleted for good.
+func softDeletes(db *gorm.DB, table string) bool {
+	return db.Migrator().HasColumn(table, "deleted_at")
+}
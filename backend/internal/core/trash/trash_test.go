@@ -0,0 +1,223 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dietmodels "be-simpletracker/internal/core/diet/models"
+	"be-simpletracker/internal/core/module"
+	moneymodels "be-simpletracker/internal/core/money/models"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/dbtest"
+
+	"gorm.io/gorm"
+)
+
+const (
+	alice uint = 1
+	bob   uint = 2
+)
+
+// everyTable leaves every module enabled.
+func everyTable(string) bool { return true }
+
+func setupTrashDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := dbtest.Open(t)
+	modules, err := module.NewRegistry(module.All(db), module.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := modules.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func create(t *testing.T, db *gorm.DB, value any) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// deleteAt soft-deletes rows as if it had happened at when.
+func deleteAt(t *testing.T, db *gorm.DB, table string, when time.Time, where string, args ...any) {
+	t.Helper()
+	if err := db.Table(table).Where(where, args...).Update("deleted_at", when).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func count(t *testing.T, db *gorm.DB, table, where string, args ...any) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Table(table).Where(where, args...).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRestoreBringsBackRowsDeletedWithIt(t *testing.T) {
+	db := setupTrashDB(t)
+	ctx := database.WithUserID(context.Background(), alice)
+	oats := dietmodels.Food{Name: "Oats", ServingType: "g", ServingAmount: 40}
+	create(t, db.WithContext(ctx), &oats)
+	meal := dietmodels.Meal{Name: "Breakfast", Items: []dietmodels.MealItem{
+		{FoodID: oats.ID, Amount: 1}, {FoodID: oats.ID, Amount: 2}, {FoodID: oats.ID, Amount: 3},
+	}}
+	create(t, db.WithContext(ctx), &meal)
+
+	now := time.Now()
+	deleteAt(t, db, "meal_items", now.Add(-time.Hour), "id = ?", meal.Items[0].ID)
+	deleteAt(t, db, "meals", now, "id = ?", meal.ID)
+	deleteAt(t, db, "meal_items", now.Add(50*time.Millisecond), "meal_id = ? AND deleted_at IS NULL", meal.ID)
+
+	items, err := List(ctx, db, everyTable, "", DefaultLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Type != "meals" || items[0].Preview["name"] != "Breakfast" || items[0].Children["meal_items"] != 2 {
+		t.Fatalf("list = %+v", items)
+	}
+	if others, err := List(database.WithUserID(context.Background(), bob), db, everyTable, "", DefaultLimit); err != nil || len(others) != 0 {
+		t.Fatalf("bob sees %+v, %v", others, err)
+	}
+	if _, err := Restore(database.WithUserID(context.Background(), bob), db, everyTable, "meals", meal.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("bob restore: %v", err)
+	}
+
+	restored, err := Restore(ctx, db, everyTable, "meals", meal.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored["meals"] != 1 || restored["meal_items"] != 2 {
+		t.Fatalf("restored %v", restored)
+	}
+	if n := count(t, db, "meal_items", "meal_id = ? AND deleted_at IS NOT NULL", meal.ID); n != 1 {
+		t.Fatalf("%d items still deleted, want the one removed beforehand", n)
+	}
+	if _, err := Restore(ctx, db, everyTable, "meals", meal.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("second restore: %v", err)
+	}
+	if _, err := Restore(ctx, db, everyTable, "users", 1); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("restore users: %v", err)
+	}
+}
+
+func TestRestoreWaitsForOwnerAndBumpsVersion(t *testing.T) {
+	db := setupTrashDB(t)
+	ctx := database.WithUserID(context.Background(), alice)
+	conn := db.WithContext(ctx)
+	now := time.Now()
+	account := moneymodels.InvestmentAccount{Name: "Brokerage"}
+	create(t, conn, &account)
+	deposit := moneymodels.InvestmentDeposit{AccountID: account.ID, Amount: 100, Date: database.DateOf(now)}
+	create(t, conn, &deposit)
+	deleteAt(t, db, "investment_deposits", now, "id = ?", deposit.ID)
+	deleteAt(t, db, "investment_accounts", now, "id = ?", account.ID)
+
+	if _, err := Restore(ctx, db, everyTable, "investment_deposits", deposit.ID); !errors.Is(err, ErrOwnerDeleted) {
+		t.Fatalf("restoring the deposit alone: %v", err)
+	}
+	if n := count(t, db, "investment_deposits", "id = ? AND deleted_at IS NULL", deposit.ID); n != 0 {
+		t.Fatal("deposit came back without its account")
+	}
+	restored, err := Restore(ctx, db, everyTable, "investment_accounts", account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored["investment_accounts"] != 1 || restored["investment_deposits"] != 1 {
+		t.Fatalf("restored %v", restored)
+	}
+	var version uint
+	if err := db.Table("investment_accounts").Where("id = ?", account.ID).Pluck("version", &version).Error; err != nil {
+		t.Fatal(err)
+	}
+	if version != account.Version+1 {
+		t.Fatalf("version %d after restore, was %d", version, account.Version)
+	}
+}
+
+func TestPurgeDeletesExpiredRowsAndWhatBelongsToThem(t *testing.T) {
+	db := setupTrashDB(t)
+	ctx := database.WithUserID(context.Background(), alice)
+	conn := db.WithContext(ctx)
+	now := time.Now()
+	old := now.AddDate(0, 0, -40)
+
+	account := moneymodels.InvestmentAccount{Name: "Brokerage"}
+	create(t, conn, &account)
+	for range 3 {
+		create(t, conn, &moneymodels.InvestmentDeposit{AccountID: account.ID, Amount: 100, Date: database.DateOf(now)})
+	}
+	deleteAt(t, db, "investment_accounts", old, "id = ?", account.ID)
+
+	// A deleted food still used by a live meal stays.
+	rice := dietmodels.Food{Name: "Rice", ServingType: "g", ServingAmount: 100}
+	create(t, conn, &rice)
+	create(t, conn, &dietmodels.Meal{Name: "Lunch", Items: []dietmodels.MealItem{{FoodID: rice.ID, Amount: 1}}})
+	deleteAt(t, db, "foods", old, "id = ?", rice.ID)
+
+	// A deleted saved meal lets go of the logged meals planned from it.
+	saved := dietmodels.SavedMeal{Name: "Usual"}
+	create(t, conn, &saved)
+	dinner := dietmodels.Meal{Name: "Dinner"}
+	create(t, conn, &dinner)
+	day := dietmodels.DietDay{Date: database.DateOf(now)}
+	create(t, conn, &day)
+	planned := dietmodels.PlannedMeal{DayID: day.ID, MealID: dinner.ID, SavedMealID: &saved.ID, Logged: true}
+	create(t, conn, &planned)
+	deleteAt(t, db, "saved_meals", old, "id = ?", saved.ID)
+
+	recent := dietmodels.Meal{Name: "Snack"}
+	create(t, conn, &recent)
+	deleteAt(t, db, "meals", now, "id = ?", recent.ID)
+
+	purged, err := Purge(context.Background(), db, everyTable, now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged["investment_accounts"] != 1 || purged["investment_deposits"] != 3 || purged["saved_meals"] != 1 {
+		t.Fatalf("purged %v", purged)
+	}
+	if n := count(t, db, "foods", "id = ?", rice.ID); n != 1 {
+		t.Fatal("purged a food a meal still uses")
+	}
+	if n := count(t, db, "meals", "id = ?", recent.ID); n != 1 {
+		t.Fatal("purged a meal deleted inside the retention period")
+	}
+	var left dietmodels.PlannedMeal
+	if err := db.First(&left, planned.ID).Error; err != nil || left.SavedMealID != nil {
+		t.Fatalf("planned meal %+v, %v", left, err)
+	}
+}
+
+func TestDisabledModuleIsLeftOutOfTrash(t *testing.T) {
+	db := setupTrashDB(t)
+	ctx := database.WithUserID(context.Background(), alice)
+	account := moneymodels.InvestmentAccount{Name: "Brokerage"}
+	create(t, db.WithContext(ctx), &account)
+	old := time.Now().AddDate(0, 0, -40)
+	deleteAt(t, db, "investment_accounts", old, "id = ?", account.ID)
+	noMoney := func(table string) bool { return table != "investment_accounts" && table != "investment_deposits" }
+
+	if items, err := List(ctx, db, noMoney, "", DefaultLimit); err != nil || len(items) != 0 {
+		t.Fatalf("listed %+v, %v", items, err)
+	}
+	if _, err := Restore(ctx, db, noMoney, "investment_accounts", account.ID); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := Purge(context.Background(), db, noMoney, time.Now().AddDate(0, 0, -30)); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, db, "investment_accounts", "id = ?", account.ID); n != 1 {
+		t.Fatal("purged a disabled module's row")
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"be-simpletracker/internal/core/workout/models"
 	"be-simpletracker/internal/core/workout/services"
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/utils"
 	"be-simpletracker/internal/utils/apierr"
 	"errors"
@@ -34,7 +35,7 @@ func GetAllExercises(c *gin.Context) {
 	}
 	search := c.Query("search")
 
-	result, err := services.ListExercises(page, pageSize, search)
+	result, err := services.ListExercises(c.Request.Context(), page, pageSize, search)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -73,13 +74,26 @@ func LogExercise(c *gin.Context) {
 			request.Log.Sets[i].LoggedExerciseID = 0
 			request.Log.Sets[i].ID = 0
 		}
-		err := services.LogExercise(&request.Log)
+		err := services.LogExercise(c.Request.Context(), &request.Log)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 	case "logged":
-		err := services.UpdateLoggedExercise(request.Log)
+		err := services.UpdateLoggedExercise(c.Request.Context(), request.Log)
+		if errors.Is(err, database.ErrStaleVersion) {
+			current, err := services.LoadLoggedExercise(c.Request.Context(), request.Log.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			utils.PreconditionFailed(c, "exercise", current, current.Version)
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Logged exercise not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -89,12 +103,13 @@ func LogExercise(c *gin.Context) {
 		return
 	}
 
-	savedExercise, err := services.LoadLoggedExercise(request.Log.ID)
+	savedExercise, err := services.LoadLoggedExercise(c.Request.Context(), request.Log.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload exercise: " + err.Error()})
 		return
 	}
 
+	utils.SetETag(c, savedExercise.Version)
 	c.JSON(http.StatusOK, gin.H{"exercise": savedExercise})
 }
 
@@ -133,12 +148,12 @@ func AddExerciseToWorkout(c *gin.Context) {
 		Sets:         []models.LoggedSet{},
 		Notes:        "",
 	}
-	err := services.LogExercise(&newExercise)
+	err := services.LogExercise(c.Request.Context(), &newExercise)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
 	}
-	createdExercise, err := services.LoadLoggedExercise(newExercise.ID)
+	createdExercise, err := services.LoadLoggedExercise(c.Request.Context(), newExercise.ID)
 	if err != nil {
 		apierr.Internal(c, err)
 		return
@@ -176,7 +191,7 @@ func DeleteLoggedSet(c *gin.Context) {
 		return
 	}
 
-	err = services.DeleteLoggedSet(uint(setID))
+	err = services.DeleteLoggedSet(c.Request.Context(), uint(setID))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Set not found"})
@@ -197,7 +212,7 @@ func GetExerciseProgression(c *gin.Context) {
 		return
 	}
 
-	progression, err := services.GetExerciseProgression(uint(exerciseID))
+	progression, err := services.GetExerciseProgression(c.Request.Context(), uint(exerciseID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -233,7 +248,7 @@ func CreateExercise(c *gin.Context) {
 		request.RepRollover = 10
 	}
 
-	exercise, err := services.CreateExercise(request.Name, request.RepRollover, request.Cues, request.LoadType)
+	exercise, err := services.CreateExercise(c.Request.Context(), request.Name, request.RepRollover, request.Cues, request.LoadType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -268,7 +283,11 @@ func UpdateExercise(c *gin.Context) {
 	if req.RepRollover == 0 {
 		req.RepRollover = 10
 	}
-	exercise, err := services.UpdateExercise(uint(id64), req.Name, req.RepRollover, req.Cues, req.LoadType)
+	exercise, err := services.UpdateExercise(c.Request.Context(), uint(id64), req.Name, req.RepRollover, req.Cues, req.LoadType)
+	if errors.Is(err, database.ErrStaleVersion) {
+		respondStaleExercise(c, uint(id64))
+		return
+	}
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
@@ -277,9 +296,19 @@ func UpdateExercise(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	utils.SetETag(c, exercise.Version)
 	c.JSON(http.StatusOK, gin.H{"exercise": exercise})
 }
 
+func respondStaleExercise(c *gin.Context, id uint) {
+	current, err := services.GetExercise(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	utils.PreconditionFailed(c, "exercise", current, current.Version)
+}
+
 type updateExerciseCuesRequest struct {
 	Cues string `json:"cues"`
 }
@@ -296,7 +325,11 @@ func UpdateExerciseCues(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	exercise, err := services.UpdateExerciseCues(uint(id64), req.Cues)
+	exercise, err := services.UpdateExerciseCues(c.Request.Context(), uint(id64), req.Cues)
+	if errors.Is(err, database.ErrStaleVersion) {
+		respondStaleExercise(c, uint(id64))
+		return
+	}
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not found"})
@@ -305,5 +338,6 @@ func UpdateExerciseCues(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	utils.SetETag(c, exercise.Version)
 	c.JSON(http.StatusOK, gin.H{"exercise": exercise})
 }
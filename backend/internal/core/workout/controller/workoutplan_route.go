@@ -3,6 +3,9 @@ package controller
 import (
 	"be-simpletracker/internal/core/workout/models"
 	"be-simpletracker/internal/core/workout/services"
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/utils"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -21,7 +24,7 @@ type createWorkoutPlanRequest struct {
 }
 
 func GetAllWorkoutPrograms(c *gin.Context) {
-	programs, err := services.GetAllWorkoutPrograms()
+	programs, err := services.GetAllWorkoutPrograms(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -35,7 +38,7 @@ func CreateWorkoutProgram(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
 		return
 	}
-	program, err := services.CreateWorkoutProgram(body.Name)
+	program, err := services.CreateWorkoutProgram(c.Request.Context(), body.Name)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -54,7 +57,7 @@ func CreateWorkoutPlan(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	plan, err := services.CreateWorkoutPlan(uint(programID), body.Name, body.DayOfWeek)
+	plan, err := services.CreateWorkoutPlan(c.Request.Context(), uint(programID), body.Name, body.DayOfWeek)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -73,11 +76,25 @@ func RenameWorkoutProgram(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
 		return
 	}
-	program, err := services.RenameWorkoutProgram(uint(id), body.Name)
+	program, err := services.RenameWorkoutProgram(c.Request.Context(), uint(id), body.Name)
+	if errors.Is(err, database.ErrStaleVersion) {
+		current, err := services.GetWorkoutProgram(c.Request.Context(), uint(id))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		utils.PreconditionFailed(c, "program", current, current.Version)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	utils.SetETag(c, program.Version)
 	c.JSON(http.StatusOK, gin.H{"program": program})
 }
 
@@ -96,7 +113,7 @@ func ActivateWorkoutProgram(c *gin.Context) {
 }
 
 func GetAllWorkoutPlans(c *gin.Context) {
-	workoutPlans, err := services.GetAllWorkoutPlans()
+	workoutPlans, err := services.GetAllWorkoutPlans(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -123,7 +140,7 @@ func AddExerciseToPlan(c *gin.Context) {
 		return
 	}
 
-	plan, err := services.LoadPlanWithOrderedExercises(uint(planID))
+	plan, err := services.LoadPlanWithOrderedExercises(c.Request.Context(), uint(planID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Plan not found"})
 		return
@@ -135,12 +152,12 @@ func AddExerciseToPlan(c *gin.Context) {
 		}
 	}
 
-	if err := services.AddExerciseToPlan(uint(planID), request.ExerciseID); err != nil {
+	if err := services.AddExerciseToPlan(c.Request.Context(), uint(planID), request.ExerciseID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	plan, err = services.LoadPlanWithOrderedExercises(uint(planID))
+	plan, err = services.LoadPlanWithOrderedExercises(c.Request.Context(), uint(planID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -163,7 +180,7 @@ func RemoveExerciseFromPlan(c *gin.Context) {
 		return
 	}
 
-	if err := services.RemoveExerciseFromPlan(uint(planID), request.ExerciseID); err != nil {
+	if err := services.RemoveExerciseFromPlan(c.Request.Context(), uint(planID), request.ExerciseID); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Exercise not in plan"})
 			return
@@ -172,7 +189,7 @@ func RemoveExerciseFromPlan(c *gin.Context) {
 		return
 	}
 
-	plan, err := services.LoadPlanWithOrderedExercises(uint(planID))
+	plan, err := services.LoadPlanWithOrderedExercises(c.Request.Context(), uint(planID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -197,11 +214,11 @@ func ReorderPlanExercises(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := services.ReorderPlanExercises(uint(planID), body.ExerciseIDs); err != nil {
+	if err := services.ReorderPlanExercises(c.Request.Context(), uint(planID), body.ExerciseIDs); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	plan, err := services.LoadPlanWithOrderedExercises(uint(planID))
+	plan, err := services.LoadPlanWithOrderedExercises(c.Request.Context(), uint(planID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -231,7 +248,7 @@ func AssignPlanToDay(c *gin.Context) {
 		return
 	}
 
-	plan, err := services.AssignPlanToDay(uint(planID), *request.DayOfWeek)
+	plan, err := services.AssignPlanToDay(c.Request.Context(), uint(planID), *request.DayOfWeek)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -257,7 +274,7 @@ func SetPlannedCardio(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	plan, err := services.SetPlannedCardio(uint(planID), body.Type, body.Minutes)
+	plan, err := services.SetPlannedCardio(c.Request.Context(), uint(planID), body.Type, body.Minutes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -281,7 +298,7 @@ func SetPlannedMobility(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	plan, err := services.SetPlannedMobility(uint(planID), body.PreMobilityItems, body.PostMobilityItems)
+	plan, err := services.SetPlannedMobility(c.Request.Context(), uint(planID), body.PreMobilityItems, body.PostMobilityItems)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -304,9 +321,9 @@ func UnassignPlanFromDay(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid day_of_week"})
 			return
 		}
-		plan, err = services.UnassignPlanFromSpecificDay(uint(planID), day)
+		plan, err = services.UnassignPlanFromSpecificDay(c.Request.Context(), uint(planID), day)
 	} else {
-		plan, err = services.UnassignPlanFromDay(uint(planID))
+		plan, err = services.UnassignPlanFromDay(c.Request.Context(), uint(planID))
 	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
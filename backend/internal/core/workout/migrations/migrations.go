@@ -0,0 +1,8 @@
+// Package migrations embeds the workout module's schema, one directory per database driver.
+// Add files with `go run ./cmd/migration create -module workout <name>`.
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
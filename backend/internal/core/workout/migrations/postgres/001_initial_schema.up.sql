@@ -0,0 +1,130 @@
+-- Initial workout schema: its tables as created by the AutoMigrate setup this runner replaces.
+
+CREATE TABLE "workout_programs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text,
+    "is_active" boolean,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_programs_user_id" ON "workout_programs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_workout_programs_deleted_at" ON "workout_programs" ("deleted_at");
+
+CREATE TABLE "exercises" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text NOT NULL,
+    "rep_rollover" bigint,
+    "cues" text,
+    "load_type" text NOT NULL DEFAULT 'plate_loaded_with_bar',
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_exercises_user_name" ON "exercises" ("user_id","name");
+CREATE INDEX IF NOT EXISTS "idx_exercises_deleted_at" ON "exercises" ("deleted_at");
+
+CREATE TABLE "workout_plans" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "name" text,
+    "workout_program_id" bigint,
+    "day_of_week" bigint,
+    "planned_cardio_type" text,
+    "planned_cardio_minutes" bigint,
+    "pre_mobility_items" jsonb,
+    "post_mobility_items" jsonb,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_workout_programs_plans" FOREIGN KEY ("workout_program_id") REFERENCES "workout_programs"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_plans_user_id" ON "workout_plans" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_workout_plans_deleted_at" ON "workout_plans" ("deleted_at");
+
+CREATE TABLE "workout_plan_days" (
+    "user_id" bigint,
+    "workout_plan_id" bigint,
+    "day_of_week" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("workout_plan_id","day_of_week")
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_plan_days_user_id" ON "workout_plan_days" ("user_id");
+
+CREATE TABLE "workout_plan_exercises" (
+    "user_id" bigint,
+    "workout_plan_id" bigint,
+    "exercise_id" bigint,
+    "display_order" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("workout_plan_id","exercise_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_plan_exercises_user_id" ON "workout_plan_exercises" ("user_id");
+
+CREATE TABLE "logged_exercises" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "workout_log_id" bigint,
+    "exercise_id" bigint,
+    "notes" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_logged_exercises_exercise" FOREIGN KEY ("exercise_id") REFERENCES "exercises"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_logged_exercises_user_id" ON "logged_exercises" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_logged_exercises_deleted_at" ON "logged_exercises" ("deleted_at");
+
+CREATE TABLE "logged_sets" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "logged_exercise_id" bigint,
+    "reps" bigint,
+    "weight" decimal,
+    "weight_setup" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_logged_exercises_sets" FOREIGN KEY ("logged_exercise_id") REFERENCES "logged_exercises"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_logged_sets_user_id" ON "logged_sets" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_logged_sets_deleted_at" ON "logged_sets" ("deleted_at");
+
+CREATE TABLE "workout_logs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "date" timestamptz,
+    "workout_plan_id" bigint,
+    "pre_mobility_checked" jsonb,
+    "post_mobility_checked" jsonb,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_workout_logs_workout_plan" FOREIGN KEY ("workout_plan_id") REFERENCES "workout_plans"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_logs_user_id" ON "workout_logs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_workout_logs_deleted_at" ON "workout_logs" ("deleted_at");
+
+CREATE TABLE "cardios" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "workout_log_id" bigint NOT NULL,
+    "minutes" bigint,
+    "type" text,
+    "notes" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_workout_logs_cardio" FOREIGN KEY ("workout_log_id") REFERENCES "workout_logs"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_cardios_workout_log_id" ON "cardios" ("workout_log_id");
+CREATE INDEX IF NOT EXISTS "idx_cardios_user_id" ON "cardios" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_cardios_deleted_at" ON "cardios" ("deleted_at");
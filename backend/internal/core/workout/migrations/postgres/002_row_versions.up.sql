@@ -0,0 +1,6 @@
+-- row_versions (postgres, up)
+
+-- Updates send the version they read as If-Match; a row that has moved on refuses the write.
+ALTER TABLE "exercises" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "logged_exercises" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "workout_programs" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "cardios";
+DROP TABLE IF EXISTS "workout_logs";
+DROP TABLE IF EXISTS "logged_sets";
+DROP TABLE IF EXISTS "logged_exercises";
+DROP TABLE IF EXISTS "workout_plan_days";
+DROP TABLE IF EXISTS "workout_plans";
+DROP TABLE IF EXISTS "workout_plan_exercises";
+DROP TABLE IF EXISTS "exercises";
+DROP TABLE IF EXISTS "workout_programs";
@@ -0,0 +1,124 @@
+-- Initial workout schema: its tables as created by the AutoMigrate setup this runner replaces.
+
+CREATE TABLE "workout_programs" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "name" text,
+    "is_active" numeric
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_programs_user_id" ON "workout_programs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_workout_programs_deleted_at" ON "workout_programs" ("deleted_at");
+
+CREATE TABLE "exercises" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "name" text NOT NULL,
+    "rep_rollover" integer,
+    "cues" text,
+    "load_type" text NOT NULL DEFAULT "plate_loaded_with_bar"
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_exercises_user_name" ON "exercises" ("user_id","name");
+CREATE INDEX IF NOT EXISTS "idx_exercises_deleted_at" ON "exercises" ("deleted_at");
+
+CREATE TABLE "workout_plan_exercises" (
+    "user_id" integer,
+    "workout_plan_id" integer,
+    "exercise_id" integer,
+    "display_order" integer NOT NULL DEFAULT 0,
+    PRIMARY KEY ("workout_plan_id","exercise_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_plan_exercises_user_id" ON "workout_plan_exercises" ("user_id");
+
+CREATE TABLE "workout_plans" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "name" text,
+    "workout_program_id" integer,
+    "day_of_week" integer,
+    "planned_cardio_type" text,
+    "planned_cardio_minutes" integer,
+    "pre_mobility_items" text,
+    "post_mobility_items" text,
+    CONSTRAINT "fk_workout_programs_plans" FOREIGN KEY ("workout_program_id") REFERENCES "workout_programs"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_plans_user_id" ON "workout_plans" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_workout_plans_deleted_at" ON "workout_plans" ("deleted_at");
+
+CREATE TABLE "workout_plan_days" (
+    "user_id" integer,
+    "workout_plan_id" integer,
+    "day_of_week" integer,
+    "created_at" datetime,
+    PRIMARY KEY ("workout_plan_id","day_of_week")
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_plan_days_user_id" ON "workout_plan_days" ("user_id");
+
+CREATE TABLE "logged_exercises" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "workout_log_id" integer,
+    "exercise_id" integer,
+    "notes" text,
+    CONSTRAINT "fk_workout_logs_exercises" FOREIGN KEY ("workout_log_id") REFERENCES "workout_logs"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_logged_exercises_exercise" FOREIGN KEY ("exercise_id") REFERENCES "exercises"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_logged_exercises_user_id" ON "logged_exercises" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_logged_exercises_deleted_at" ON "logged_exercises" ("deleted_at");
+
+CREATE TABLE "logged_sets" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "logged_exercise_id" integer,
+    "reps" integer,
+    "weight" real,
+    "weight_setup" text,
+    CONSTRAINT "fk_logged_exercises_sets" FOREIGN KEY ("logged_exercise_id") REFERENCES "logged_exercises"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_logged_sets_user_id" ON "logged_sets" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_logged_sets_deleted_at" ON "logged_sets" ("deleted_at");
+
+CREATE TABLE "workout_logs" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "date" datetime,
+    "workout_plan_id" integer,
+    "pre_mobility_checked" text,
+    "post_mobility_checked" text,
+    CONSTRAINT "fk_workout_logs_workout_plan" FOREIGN KEY ("workout_plan_id") REFERENCES "workout_plans"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workout_logs_user_id" ON "workout_logs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_workout_logs_deleted_at" ON "workout_logs" ("deleted_at");
+
+CREATE TABLE "cardios" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer,
+    "workout_log_id" integer NOT NULL,
+    "minutes" integer,
+    "type" text,
+    "notes" text,
+    CONSTRAINT "fk_workout_logs_cardio" FOREIGN KEY ("workout_log_id") REFERENCES "workout_logs"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_cardios_workout_log_id" ON "cardios" ("workout_log_id");
+CREATE INDEX IF NOT EXISTS "idx_cardios_user_id" ON "cardios" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_cardios_deleted_at" ON "cardios" ("deleted_at");
@@ -0,0 +1,5 @@
+-- row_versions (sqlite, down)
+
+ALTER TABLE "exercises" DROP COLUMN "version";
+ALTER TABLE "logged_exercises" DROP COLUMN "version";
+ALTER TABLE "workout_programs" DROP COLUMN "version";
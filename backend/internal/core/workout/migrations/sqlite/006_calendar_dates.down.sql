@@ -0,0 +1,4 @@
+-- calendar_dates (sqlite, down)
+
+-- The server's zone is not known here, so days go back as midnight UTC.
+UPDATE "workout_logs" SET "date" = "date" || ' 00:00:00+00:00' WHERE length("date") = 10;
@@ -0,0 +1,6 @@
+-- calendar_dates (sqlite, up)
+
+-- Days were stored as local-midnight timestamps such as "2026-10-01 00:00:00-07:00"; the
+-- first ten characters are already the day. SQLite keeps a DATE as that text, so the
+-- columns keep their declared type.
+UPDATE "workout_logs" SET "date" = substr("date", 1, 10) WHERE "date" IS NOT NULL;
@@ -1,8 +1,8 @@
 package models
 
 import (
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/database/repository"
-	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,13 +11,15 @@ import (
 // It links an exercise to a workout log and contains the sets performed
 type LoggedExercise struct {
 	gorm.Model
-	WorkoutLogID  uint        `json:"workout_log_id"`
-	ExerciseID    uint        `json:"exercise_id"`
-	Exercise      *Exercise   `json:"exercise"`
-	Sets          []LoggedSet `json:"sets" gorm:"constraint:OnDelete:CASCADE;"`
-	Notes         string      `json:"notes"`
-	PercentChange float32     `json:"percent_change" gorm:"-"`
-	LogDate       time.Time   `json:"log_date,omitempty" gorm:"-"`
+	UserID        uint          `json:"-" gorm:"index"`
+	WorkoutLogID  uint          `json:"workout_log_id"`
+	ExerciseID    uint          `json:"exercise_id"`
+	Exercise      *Exercise     `json:"exercise"`
+	Sets          []LoggedSet   `json:"sets" gorm:"constraint:OnDelete:CASCADE;"`
+	Notes         string        `json:"notes"`
+	PercentChange float32       `json:"percent_change" gorm:"-"`
+	LogDate       database.Date `json:"log_date" gorm:"-"`
+	Version       uint          `json:"version" gorm:"not null;default:1"`
 }
 
 func (l LoggedExercise) GetID() uint        { return l.ID }
@@ -28,6 +30,7 @@ func (l LoggedExercise) Preloads() []string { return []string{"Exercise", "Sets"
 // Contains reps, weight, and weight setup information for tracking progression
 type LoggedSet struct {
 	gorm.Model
+	UserID           uint    `json:"-" gorm:"index"`
 	LoggedExerciseID uint    `json:"logged_exercise_id"`
 	Reps             uint    `json:"reps"`
 	Weight           float32 `json:"weight"`
@@ -60,11 +63,13 @@ func NormalizeExerciseLoadType(loadType ExerciseLoadType) ExerciseLoadType {
 // Can be associated with multiple workout plans via many-to-many relationship
 type Exercise struct {
 	gorm.Model
-	Name         string           `gorm:"uniqueIndex;not null" json:"name"`
+	UserID       uint             `json:"-" gorm:"uniqueIndex:idx_exercises_user_name"`
+	Name         string           `gorm:"uniqueIndex:idx_exercises_user_name;not null" json:"name"`
 	RepRollover  uint             `json:"rep_rollover"`
 	Cues         string           `json:"cues"`
 	LoadType     ExerciseLoadType `gorm:"type:text;not null;default:plate_loaded_with_bar" json:"load_type"`
 	WorkoutPlans []WorkoutPlan    `gorm:"many2many:workout_plan_exercises;" json:"workout_plans"`
+	Version      uint             `json:"version" gorm:"not null;default:1"`
 }
 
 func (e Exercise) GetID() uint        { return e.ID }
@@ -75,6 +80,7 @@ func (e Exercise) Preloads() []string { return []string{} }
 // Stores duration and type of cardio activity performed
 type Cardio struct {
 	gorm.Model
+	UserID       uint   `json:"-" gorm:"index"`
 	WorkoutLogID uint   `json:"workout_log_id" gorm:"uniqueIndex;not null"`
 	Minutes      int    `json:"minutes"`
 	Type         string `json:"type"`
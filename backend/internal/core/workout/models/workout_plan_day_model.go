@@ -3,6 +3,7 @@ package models
 import "time"
 
 type WorkoutPlanDay struct {
+	UserID        uint      `json:"-" gorm:"index"`
 	WorkoutPlanID uint      `json:"workout_plan_id" gorm:"primaryKey"`
 	DayOfWeek     int       `json:"day_of_week" gorm:"primaryKey"`
 	CreatedAt     time.Time `json:"created_at"`
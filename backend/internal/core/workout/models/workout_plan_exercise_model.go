@@ -2,6 +2,7 @@ package models
 
 // WorkoutPlanExercise is the join row for plan ↔ exercise with display order.
 type WorkoutPlanExercise struct {
+	UserID        uint `json:"-" gorm:"index"`
 	WorkoutPlanID uint `gorm:"primaryKey" json:"workout_plan_id"`
 	ExerciseID    uint `gorm:"primaryKey" json:"exercise_id"`
 	DisplayOrder  int  `gorm:"not null;default:0" json:"display_order"`
@@ -1,8 +1,8 @@
 package models
 
 import (
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/database/repository"
-	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,18 +11,19 @@ import (
 // Contains the exercises performed, sets logged, and optional cardio activity
 type WorkoutLog struct {
 	gorm.Model
-	Date                time.Time        `json:"date"`
-	WorkoutPlanID       *uint            `json:"workout_plan_id"`
-	WorkoutPlan         *WorkoutPlan     `json:"workout_plan" gorm:"foreignKey:WorkoutPlanID"`
-	Exercises           []LoggedExercise `json:"exercises" gorm:"constraint:OnDelete:CASCADE;"`
-	Cardio              *Cardio          `json:"cardio" gorm:"constraint:OnDelete:CASCADE;"`
-	PreMobilityChecked  []string         `json:"pre_mobility_checked,omitempty" gorm:"type:jsonb;serializer:json"`
-	PostMobilityChecked []string         `json:"post_mobility_checked,omitempty" gorm:"type:jsonb;serializer:json"`
+	UserID              uint                `json:"-" gorm:"index"`
+	Date                database.Date       `json:"date"`
+	WorkoutPlanID       *uint               `json:"workout_plan_id"`
+	WorkoutPlan         *WorkoutPlan        `json:"workout_plan" gorm:"foreignKey:WorkoutPlanID"`
+	Exercises           []LoggedExercise    `json:"exercises" gorm:"constraint:OnDelete:CASCADE;"`
+	Cardio              *Cardio             `json:"cardio" gorm:"constraint:OnDelete:CASCADE;"`
+	PreMobilityChecked  database.StringList `json:"pre_mobility_checked,omitempty" gorm:"serializer:json"`
+	PostMobilityChecked database.StringList `json:"post_mobility_checked,omitempty" gorm:"serializer:json"`
 }
 
-func (w WorkoutLog) GetID() uint        { return w.ID }
-func (w WorkoutLog) TableName() string  { return "workout_logs" }
-func (w WorkoutLog) GetDate() time.Time { return w.Date }
+func (w WorkoutLog) GetID() uint            { return w.ID }
+func (w WorkoutLog) TableName() string      { return "workout_logs" }
+func (w WorkoutLog) GetDate() database.Date { return w.Date }
 func (w WorkoutLog) Preloads() []string {
 	// WorkoutPlan preload handled separately since it's in different package
 	return []string{"Cardio", "Exercises.Sets", "Exercises.Exercise"}
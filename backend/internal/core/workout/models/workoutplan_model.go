@@ -1,6 +1,7 @@
 package models
 
 import (
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/database/repository"
 
 	"gorm.io/gorm"
@@ -10,16 +11,17 @@ import (
 // that can be assigned to workout logs for tracking training sessions
 type WorkoutPlan struct {
 	gorm.Model
-	Name                 string          `json:"name"`
-	WorkoutProgramID     *uint           `json:"workout_program_id"`
-	WorkoutProgram       *WorkoutProgram `json:"workout_program,omitempty" gorm:"foreignKey:WorkoutProgramID"`
-	DayOfWeek            *int            `json:"day_of_week,omitempty"`            // Legacy primary assignment; use AssignedDays for schedules.
-	PlannedCardioType    string          `json:"planned_cardio_type,omitempty"`    // e.g. Run, Bike; empty means no planned cardio
-	PlannedCardioMinutes int             `json:"planned_cardio_minutes,omitempty"` // Defaults the cardio log time for this plan.
-	PreMobilityItems     []string        `json:"pre_mobility_items,omitempty" gorm:"type:jsonb;serializer:json"`
-	PostMobilityItems    []string        `json:"post_mobility_items,omitempty" gorm:"type:jsonb;serializer:json"`
-	Exercises            []Exercise      `gorm:"many2many:workout_plan_exercises;" json:"exercises"`
-	AssignedDays         []int           `json:"assigned_days" gorm:"-"`
+	UserID               uint                `json:"-" gorm:"index"`
+	Name                 string              `json:"name"`
+	WorkoutProgramID     *uint               `json:"workout_program_id"`
+	WorkoutProgram       *WorkoutProgram     `json:"workout_program,omitempty" gorm:"foreignKey:WorkoutProgramID"`
+	DayOfWeek            *int                `json:"day_of_week,omitempty"`            // Legacy primary assignment; use AssignedDays for schedules.
+	PlannedCardioType    string              `json:"planned_cardio_type,omitempty"`    // e.g. Run, Bike; empty means no planned cardio
+	PlannedCardioMinutes int                 `json:"planned_cardio_minutes,omitempty"` // Defaults the cardio log time for this plan.
+	PreMobilityItems     database.StringList `json:"pre_mobility_items,omitempty" gorm:"serializer:json"`
+	PostMobilityItems    database.StringList `json:"post_mobility_items,omitempty" gorm:"serializer:json"`
+	Exercises            []Exercise          `gorm:"many2many:workout_plan_exercises;" json:"exercises"`
+	AssignedDays         []int               `json:"assigned_days" gorm:"-"`
 }
 
 // GetID implements repository.Entity interface
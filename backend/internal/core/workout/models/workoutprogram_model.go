@@ -8,9 +8,11 @@ import (
 
 type WorkoutProgram struct {
 	gorm.Model
+	UserID   uint          `json:"-" gorm:"index"`
 	Name     string        `json:"name"`
 	IsActive bool          `json:"is_active"`
 	Plans    []WorkoutPlan `json:"plans" gorm:"foreignKey:WorkoutProgramID"`
+	Version  uint          `json:"version" gorm:"not null;default:1"`
 }
 
 func (w WorkoutProgram) GetID() uint       { return w.ID }
@@ -0,0 +1,48 @@
+package workout
+
+import (
+	"context"
+	"io/fs"
+
+	"be-simpletracker/internal/core/workout/migrations"
+	"be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ownedModels = []any{
+	&models.WorkoutProgram{},
+	&models.Exercise{},
+	&models.WorkoutPlan{},
+	&models.WorkoutPlanDay{},
+	&models.WorkoutPlanExercise{},
+	&models.LoggedExercise{},
+	&models.LoggedSet{},
+	&models.WorkoutLog{},
+	&models.Cardio{},
+}
+
+// Module is the workout feature: programs, exercises and the training log.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Name() string { return "workout" }
+
+func (m *Module) Models() []any { return ownedModels }
+
+func (m *Module) Migrations() fs.FS { return migrations.FS }
+
+func (m *Module) RegisterRoutes(router *gin.Engine, authMiddleware, ifMatch gin.HandlerFunc) {
+	RegisterRoutes(router, m.db, authMiddleware, ifMatch)
+}
+
+func (m *Module) CheckHealth(ctx context.Context) error {
+	return database.RequireTables(m.db.WithContext(ctx), ownedModels...)
+}
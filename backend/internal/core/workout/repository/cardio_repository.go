@@ -8,14 +8,14 @@ import (
 
 func FirstCardioByWorkoutLogID(ctx context.Context, workoutLogID uint) (models.Cardio, error) {
 	var existing models.Cardio
-	err := conn().WithContext(ctx).Where("workout_log_id = ?", workoutLogID).First(&existing).Error
+	err := conn(ctx).Where("workout_log_id = ?", workoutLogID).First(&existing).Error
 	return existing, err
 }
 
 func CreateCardio(ctx context.Context, row *models.Cardio) error {
-	return conn().WithContext(ctx).Create(row).Error
+	return conn(ctx).Create(row).Error
 }
 
 func SaveCardio(ctx context.Context, row *models.Cardio) error {
-	return conn().WithContext(ctx).Save(row).Error
+	return conn(ctx).Save(row).Error
 }
@@ -1,11 +1,13 @@
 package workoutrepo
 
 import (
+	"context"
+
 	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
-func conn() *gorm.DB {
-	return database.GetDB()
+func conn(ctx context.Context) *gorm.DB {
+	return database.GetDB().WithContext(ctx)
 }
@@ -1,9 +1,13 @@
 package workoutrepo
 
 import (
-	"time"
+	"context"
+	"strings"
 
 	"be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database"
+
+	"gorm.io/gorm"
 )
 
 type ExerciseListResult struct {
@@ -12,14 +16,14 @@ type ExerciseListResult struct {
 }
 
 type ExerciseProgressionEntry struct {
-	Date   time.Time `json:"date"`
-	Weight float32   `json:"weight"`
-	Reps   uint      `json:"reps"`
+	Date   database.Date `json:"date"`
+	Weight float32       `json:"weight"`
+	Reps   uint          `json:"reps"`
 }
 
-func FindAllExercises(excludeIDs []uint) ([]models.Exercise, error) {
+func FindAllExercises(ctx context.Context, excludeIDs []uint) ([]models.Exercise, error) {
 	var exercises []models.Exercise
-	query := conn().Model(&models.Exercise{})
+	query := conn(ctx).Model(&models.Exercise{})
 	if len(excludeIDs) > 0 {
 		query = query.Where("id NOT IN ?", excludeIDs)
 	}
@@ -30,10 +34,10 @@ func FindAllExercises(excludeIDs []uint) ([]models.Exercise, error) {
 	return exercises, nil
 }
 
-func ListExercises(page, pageSize int, search string) (ExerciseListResult, error) {
-	query := conn().Model(&models.Exercise{})
+func ListExercises(ctx context.Context, page, pageSize int, search string) (ExerciseListResult, error) {
+	query := conn(ctx).Model(&models.Exercise{})
 	if search != "" {
-		query = query.Where("name ILIKE ?", "%"+search+"%")
+		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(search)+"%")
 	}
 	query = query.Order("name ASC")
 
@@ -53,16 +57,17 @@ func ListExercises(page, pageSize int, search string) (ExerciseListResult, error
 	return ExerciseListResult{Exercises: exercises, Total: total}, nil
 }
 
-func GetExerciseProgression(exerciseID uint) ([]ExerciseProgressionEntry, error) {
+func GetExerciseProgression(ctx context.Context, exerciseID uint) ([]ExerciseProgressionEntry, error) {
 	var entries []ExerciseProgressionEntry
 
-	err := conn().
+	err := conn(ctx).
 		Table("logged_exercises").
 		Select("workout_logs.date, logged_sets.weight, logged_sets.reps").
 		Joins("JOIN workout_logs ON workout_logs.id = logged_exercises.workout_log_id").
 		Joins("JOIN logged_sets ON logged_sets.logged_exercise_id = logged_exercises.id").
 		Where("logged_exercises.exercise_id = ?", exerciseID).
 		Where("logged_sets.weight > 0 AND logged_sets.reps > 0").
+		Scopes(database.OwnedBy(ctx, "logged_exercises")).
 		Order("workout_logs.date ASC").
 		Scan(&entries).Error
 
@@ -72,43 +77,62 @@ func GetExerciseProgression(exerciseID uint) ([]ExerciseProgressionEntry, error)
 	return entries, nil
 }
 
-func ExerciseExists(id uint) error {
-	return conn().First(&models.Exercise{}, id).Error
+func ExerciseExists(ctx context.Context, id uint) error {
+	return conn(ctx).First(&models.Exercise{}, id).Error
+}
+
+func FindExerciseByID(ctx context.Context, id uint) (models.Exercise, error) {
+	var exercise models.Exercise
+	return exercise, conn(ctx).First(&exercise, id).Error
 }
 
-func CreateExercise(exercise *models.Exercise) error {
-	return conn().Create(exercise).Error
+func CreateExercise(ctx context.Context, exercise *models.Exercise) error {
+	return conn(ctx).Create(exercise).Error
 }
 
-func UpdateExercise(id uint, name string, repRollover uint, cues string, loadTypes ...models.ExerciseLoadType) (*models.Exercise, error) {
+func UpdateExercise(ctx context.Context, id uint, name string, repRollover uint, cues string, loadTypes ...models.ExerciseLoadType) (*models.Exercise, error) {
 	var exercise models.Exercise
-	if err := conn().First(&exercise, id).Error; err != nil {
+	if err := conn(ctx).First(&exercise, id).Error; err != nil {
 		return nil, err
 	}
 	loadType := models.NormalizeExerciseLoadType(exercise.LoadType)
 	if len(loadTypes) > 0 {
 		loadType = models.NormalizeExerciseLoadType(loadTypes[0])
 	}
-	if err := conn().Model(&exercise).Updates(map[string]interface{}{
-		"name":         name,
-		"rep_rollover": repRollover,
-		"cues":         cues,
-		"load_type":    loadType,
-	}).Error; err != nil {
+	err := conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := database.ClaimVersion(tx, &models.Exercise{}, id); err != nil {
+			return err
+		}
+		return tx.Model(&exercise).Updates(map[string]interface{}{
+			"name":         name,
+			"rep_rollover": repRollover,
+			"cues":         cues,
+			"load_type":    loadType,
+		}).Error
+	})
+	if err != nil {
 		return nil, err
 	}
-	if err := conn().First(&exercise, id).Error; err != nil {
+	if err := conn(ctx).First(&exercise, id).Error; err != nil {
 		return nil, err
 	}
 	return &exercise, nil
 }
 
-func UpdateExerciseCues(exerciseID uint, cues string) (*models.Exercise, error) {
+func UpdateExerciseCues(ctx context.Context, exerciseID uint, cues string) (*models.Exercise, error) {
 	var exercise models.Exercise
-	if err := conn().First(&exercise, exerciseID).Error; err != nil {
+	if err := conn(ctx).First(&exercise, exerciseID).Error; err != nil {
 		return nil, err
 	}
-	if err := conn().Model(&exercise).Update("cues", cues).Error; err != nil {
+	err := conn(ctx).Transaction(func(tx *gorm.DB) error {
+		version, err := database.ClaimVersion(tx, &models.Exercise{}, exerciseID)
+		if err != nil {
+			return err
+		}
+		exercise.Version = version
+		return tx.Model(&exercise).Update("cues", cues).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &exercise, nil
@@ -4,6 +4,7 @@ import (
 	"be-simpletracker/internal/core/workout/models"
 	workoutrepo "be-simpletracker/internal/core/workout/repository"
 	"be-simpletracker/internal/core/workout/testutil"
+	"context"
 	"testing"
 
 	"gorm.io/gorm"
@@ -15,17 +16,17 @@ func TestExerciseExists(t *testing.T) {
 	if err := db.Create(&ex).Error; err != nil {
 		t.Fatal(err)
 	}
-	if err := workoutrepo.ExerciseExists(ex.ID); err != nil {
+	if err := workoutrepo.ExerciseExists(context.Background(), ex.ID); err != nil {
 		t.Fatal(err)
 	}
-	if err := workoutrepo.ExerciseExists(9999); err != gorm.ErrRecordNotFound {
+	if err := workoutrepo.ExerciseExists(context.Background(), 9999); err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected not found, got %v", err)
 	}
 }
 
 func TestUpdateExercise_notFound(t *testing.T) {
 	testutil.SetupTestDB(t)
-	_, err := workoutrepo.UpdateExercise(9999, "Missing", 10, "")
+	_, err := workoutrepo.UpdateExercise(context.Background(), 9999, "Missing", 10, "")
 	if err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected not found, got %v", err)
 	}
@@ -33,7 +34,7 @@ func TestUpdateExercise_notFound(t *testing.T) {
 
 func TestUpdateExerciseCues_notFound(t *testing.T) {
 	testutil.SetupTestDB(t)
-	_, err := workoutrepo.UpdateExerciseCues(9999, "cue")
+	_, err := workoutrepo.UpdateExerciseCues(context.Background(), 9999, "cue")
 	if err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected not found, got %v", err)
 	}
@@ -50,7 +51,7 @@ func TestFindAllExercises_withExclude(t *testing.T) {
 	if err := db.Where("name = ?", "Two").First(&second).Error; err != nil {
 		t.Fatal(err)
 	}
-	all, err := workoutrepo.FindAllExercises([]uint{second.ID})
+	all, err := workoutrepo.FindAllExercises(context.Background(), []uint{second.ID})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2,19 +2,22 @@ package workoutrepo
 
 import (
 	"context"
-	"time"
 
 	"be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
-func CreateLoggedExercise(exercise *models.LoggedExercise) error {
-	return conn().Omit("Exercise").Create(exercise).Error
+func CreateLoggedExercise(ctx context.Context, exercise *models.LoggedExercise) error {
+	return conn(ctx).Omit("Exercise").Create(exercise).Error
 }
 
-func UpdateLoggedExerciseWithSets(exercise models.LoggedExercise) error {
-	return conn().Transaction(func(tx *gorm.DB) error {
+func UpdateLoggedExerciseWithSets(ctx context.Context, exercise models.LoggedExercise) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := database.ClaimVersion(tx, &models.LoggedExercise{}, exercise.ID); err != nil {
+			return err
+		}
 		if err := tx.Model(&models.LoggedExercise{}).
 			Where("id = ?", exercise.ID).
 			Updates(map[string]any{
@@ -78,12 +81,12 @@ func UpdateLoggedExerciseWithSets(exercise models.LoggedExercise) error {
 	})
 }
 
-func RemoveLoggedExerciseForDay(ctx context.Context, day time.Time, exerciseID uint) error {
-	res := conn().WithContext(ctx).Unscoped().
+func RemoveLoggedExerciseForDay(ctx context.Context, day database.Date, exerciseID uint) error {
+	res := conn(ctx).Unscoped().
 		Where(
 			"exercise_id = ? AND workout_log_id IN (?)",
 			exerciseID,
-			conn().Model(&models.WorkoutLog{}).Select("id").Where("date = ?", day),
+			conn(ctx).Model(&models.WorkoutLog{}).Select("id").Where("date = ?", day),
 		).
 		Delete(&models.LoggedExercise{})
 	if res.Error != nil {
@@ -95,24 +98,24 @@ func RemoveLoggedExerciseForDay(ctx context.Context, day time.Time, exerciseID u
 	return nil
 }
 
-func LoadLoggedExercise(id uint) (models.LoggedExercise, error) {
+func LoadLoggedExercise(ctx context.Context, id uint) (models.LoggedExercise, error) {
 	var exercise models.LoggedExercise
-	err := conn().Preload("Exercise").Preload("Sets").Where("id = ?", id).First(&exercise).Error
+	err := conn(ctx).Preload("Exercise").Preload("Sets").Where("id = ?", id).First(&exercise).Error
 	if err != nil {
 		return models.LoggedExercise{}, err
 	}
-	if err := attachWorkoutLogDate(&exercise); err != nil {
+	if err := attachWorkoutLogDate(ctx, &exercise); err != nil {
 		return models.LoggedExercise{}, err
 	}
 	return exercise, nil
 }
 
-func attachWorkoutLogDate(exerciseLog *models.LoggedExercise) error {
+func attachWorkoutLogDate(ctx context.Context, exerciseLog *models.LoggedExercise) error {
 	if exerciseLog == nil || exerciseLog.ID == 0 {
 		return nil
 	}
 	var workoutLog models.WorkoutLog
-	err := conn().Select("date").First(&workoutLog, exerciseLog.WorkoutLogID).Error
+	err := conn(ctx).Select("date").First(&workoutLog, exerciseLog.WorkoutLogID).Error
 	if err != nil {
 		return err
 	}
@@ -120,9 +123,9 @@ func attachWorkoutLogDate(exerciseLog *models.LoggedExercise) error {
 	return nil
 }
 
-func GetPreviousExerciseLog(ctx context.Context, day time.Time, exercise string, offset int) (models.LoggedExercise, error) {
+func GetPreviousExerciseLog(ctx context.Context, day database.Date, exercise string, offset int) (models.LoggedExercise, error) {
 	var exerciseLog models.LoggedExercise
-	err := conn().WithContext(ctx).
+	err := conn(ctx).
 		Joins("JOIN workout_logs ON workout_logs.id = logged_exercises.workout_log_id").
 		Joins("JOIN exercises ON exercises.id = logged_exercises.exercise_id").
 		Where("exercises.name = ?", exercise).
@@ -140,15 +143,15 @@ func GetPreviousExerciseLog(ctx context.Context, day time.Time, exercise string,
 	if exerciseLog.ID == 0 {
 		return exerciseLog, nil
 	}
-	if err := attachWorkoutLogDate(&exerciseLog); err != nil {
+	if err := attachWorkoutLogDate(ctx, &exerciseLog); err != nil {
 		return models.LoggedExercise{}, err
 	}
 	return exerciseLog, nil
 }
 
-func GetMaxExerciseLog(ctx context.Context, day time.Time, exercise string) (models.LoggedExercise, error) {
+func GetMaxExerciseLog(ctx context.Context, day database.Date, exercise string) (models.LoggedExercise, error) {
 	var exerciseLog models.LoggedExercise
-	err := conn().WithContext(ctx).
+	err := conn(ctx).
 		Joins("JOIN workout_logs ON workout_logs.id = logged_exercises.workout_log_id").
 		Joins("JOIN exercises ON exercises.id = logged_exercises.exercise_id").
 		Joins("JOIN logged_sets ON logged_sets.logged_exercise_id = logged_exercises.id").
@@ -171,7 +174,7 @@ func GetMaxExerciseLog(ctx context.Context, day time.Time, exercise string) (mod
 	if exerciseLog.ID == 0 {
 		return exerciseLog, nil
 	}
-	if err := attachWorkoutLogDate(&exerciseLog); err != nil {
+	if err := attachWorkoutLogDate(ctx, &exerciseLog); err != nil {
 		return models.LoggedExercise{}, err
 	}
 	return exerciseLog, nil
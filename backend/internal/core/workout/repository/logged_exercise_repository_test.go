@@ -13,7 +13,7 @@ import (
 
 func TestUpdateLoggedExerciseWithSets_addUpdateDelete(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	ex := models.Exercise{Name: "Press"}
 	if err := db.Create(&ex).Error; err != nil {
 		t.Fatal(err)
@@ -30,7 +30,7 @@ func TestUpdateLoggedExerciseWithSets_addUpdateDelete(t *testing.T) {
 			{Reps: 8, Weight: 60},
 		},
 	}
-	if err := workoutrepo.CreateLoggedExercise(&le); err != nil {
+	if err := workoutrepo.CreateLoggedExercise(context.Background(), &le); err != nil {
 		t.Fatal(err)
 	}
 	var sets []models.LoggedSet
@@ -42,7 +42,7 @@ func TestUpdateLoggedExerciseWithSets_addUpdateDelete(t *testing.T) {
 	}
 	sets[0].Reps = 10
 	le.Sets = []models.LoggedSet{sets[0], {Reps: 6, Weight: 65}}
-	if err := workoutrepo.UpdateLoggedExerciseWithSets(le); err != nil {
+	if err := workoutrepo.UpdateLoggedExerciseWithSets(context.Background(), le); err != nil {
 		t.Fatal(err)
 	}
 	var after []models.LoggedSet
@@ -56,7 +56,7 @@ func TestUpdateLoggedExerciseWithSets_addUpdateDelete(t *testing.T) {
 
 func TestRemoveLoggedExerciseForDay_notFound(t *testing.T) {
 	testutil.SetupTestDB(t)
-	err := workoutrepo.RemoveLoggedExerciseForDay(context.Background(), utils.ZerodTime(0), 1)
+	err := workoutrepo.RemoveLoggedExerciseForDay(context.Background(), utils.Today(context.Background(), 0), 1)
 	if err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected not found, got %v", err)
 	}
@@ -64,7 +64,7 @@ func TestRemoveLoggedExerciseForDay_notFound(t *testing.T) {
 
 func TestGetPreviousExerciseLog_noMatch(t *testing.T) {
 	testutil.SetupTestDB(t)
-	_, err := workoutrepo.GetPreviousExerciseLog(context.Background(), utils.ZerodTime(0), "Missing", 0)
+	_, err := workoutrepo.GetPreviousExerciseLog(context.Background(), utils.Today(context.Background(), 0), "Missing", 0)
 	if err != nil {
 		t.Fatalf("expected nil error with empty result, got %v", err)
 	}
@@ -72,13 +72,13 @@ func TestGetPreviousExerciseLog_noMatch(t *testing.T) {
 
 func TestGetMaxExerciseLog_usesHighestWeightThenReps(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	ex := models.Exercise{Name: "Bench"}
 	if err := db.Create(&ex).Error; err != nil {
 		t.Fatal(err)
 	}
-	older := models.WorkoutLog{Date: today.AddDate(0, 0, -3)}
-	recent := models.WorkoutLog{Date: today.AddDate(0, 0, -1)}
+	older := models.WorkoutLog{Date: today.AddDays(-3)}
+	recent := models.WorkoutLog{Date: today.AddDays(-1)}
 	current := models.WorkoutLog{Date: today}
 	if err := db.Create(&older).Error; err != nil {
 		t.Fatal(err)
@@ -121,7 +121,7 @@ func TestGetMaxExerciseLog_usesHighestWeightThenReps(t *testing.T) {
 	if len(got.Sets) != 1 || got.Sets[0].Weight != 100 || got.Sets[0].Reps != 8 {
 		t.Fatalf("sets %+v", got.Sets)
 	}
-	if got.LogDate.IsZero() || !got.LogDate.Equal(recent.Date) {
+	if got.LogDate != recent.Date {
 		t.Fatalf("log date %+v want %+v", got.LogDate, recent.Date)
 	}
 }
@@ -2,38 +2,38 @@ package workoutrepo
 
 import (
 	"context"
-	"time"
 
 	"be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
-func DatesWithLoggedSets(ctx context.Context, start, end time.Time) ([]time.Time, error) {
+func DatesWithLoggedSets(ctx context.Context, start, end database.Date) ([]database.Date, error) {
 	var rows []struct {
-		D time.Time `gorm:"column:d"`
+		D database.Date `gorm:"column:d"`
 	}
-	err := conn().WithContext(ctx).Raw(`
-		SELECT workout_logs.date AS d
-		FROM logged_sets
-		JOIN logged_exercises ON logged_exercises.id = logged_sets.logged_exercise_id
-		JOIN workout_logs ON workout_logs.id = logged_exercises.workout_log_id
-		WHERE workout_logs.date >= ? AND workout_logs.date <= ?
-		GROUP BY workout_logs.date
-		ORDER BY workout_logs.date ASC
-		`, start, end).Scan(&rows).Error
+	err := conn(ctx).Table("logged_sets").
+		Select("workout_logs.date AS d").
+		Joins("JOIN logged_exercises ON logged_exercises.id = logged_sets.logged_exercise_id").
+		Joins("JOIN workout_logs ON workout_logs.id = logged_exercises.workout_log_id").
+		Where("workout_logs.date >= ? AND workout_logs.date <= ?", start, end).
+		Scopes(database.OwnedBy(ctx, "workout_logs")).
+		Group("workout_logs.date").
+		Order("workout_logs.date ASC").
+		Scan(&rows).Error
 	if err != nil {
 		return nil, err
 	}
-	out := make([]time.Time, 0, len(rows))
+	out := make([]database.Date, 0, len(rows))
 	for _, row := range rows {
 		out = append(out, row.D)
 	}
 	return out, nil
 }
 
-func DeleteLoggedSet(setID uint) error {
-	return conn().Transaction(func(tx *gorm.DB) error {
+func DeleteLoggedSet(ctx context.Context, setID uint) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
 		var set models.LoggedSet
 		if err := tx.Where("id = ?", setID).First(&set).Error; err != nil {
 			return err
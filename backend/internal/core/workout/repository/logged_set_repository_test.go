@@ -5,6 +5,7 @@ import (
 	workoutrepo "be-simpletracker/internal/core/workout/repository"
 	"be-simpletracker/internal/core/workout/testutil"
 	"be-simpletracker/internal/utils"
+	"context"
 	"testing"
 
 	"gorm.io/gorm"
@@ -12,7 +13,7 @@ import (
 
 func TestDeleteLoggedSet_removesOrphanExercise(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	ex := models.Exercise{Name: "Fly"}
 	if err := db.Create(&ex).Error; err != nil {
 		t.Fatal(err)
@@ -29,7 +30,7 @@ func TestDeleteLoggedSet_removesOrphanExercise(t *testing.T) {
 	if err := db.Create(&set).Error; err != nil {
 		t.Fatal(err)
 	}
-	if err := workoutrepo.DeleteLoggedSet(set.ID); err != nil {
+	if err := workoutrepo.DeleteLoggedSet(context.Background(), set.ID); err != nil {
 		t.Fatal(err)
 	}
 	if err := db.First(&models.LoggedSet{}, set.ID).Error; err != gorm.ErrRecordNotFound {
@@ -42,7 +43,7 @@ func TestDeleteLoggedSet_removesOrphanExercise(t *testing.T) {
 
 func TestDeleteLoggedSet_notFound(t *testing.T) {
 	testutil.SetupTestDB(t)
-	err := workoutrepo.DeleteLoggedSet(9999)
+	err := workoutrepo.DeleteLoggedSet(context.Background(), 9999)
 	if err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected not found, got %v", err)
 	}
@@ -62,7 +63,7 @@ func TestDeleteLoggedSet_keepsExerciseWhenSetsRemain(t *testing.T) {
 	if err := db.Create(&set2).Error; err != nil {
 		t.Fatal(err)
 	}
-	if err := workoutrepo.DeleteLoggedSet(set1.ID); err != nil {
+	if err := workoutrepo.DeleteLoggedSet(context.Background(), set1.ID); err != nil {
 		t.Fatal(err)
 	}
 	if err := db.First(&models.LoggedExercise{}, le.ID).Error; err != nil {
@@ -2,17 +2,17 @@ package workoutrepo
 
 import (
 	"context"
-	"time"
 
 	"be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database"
 	dbrepo "be-simpletracker/internal/database/repository"
 
 	"gorm.io/gorm"
 )
 
-func LoadByDate(ctx context.Context, date time.Time) (models.WorkoutLog, error) {
+func LoadByDate(ctx context.Context, date database.Date) (models.WorkoutLog, error) {
 	var workoutDay models.WorkoutLog
-	err := conn().WithContext(ctx).
+	err := conn(ctx).
 		Preload("Cardio").
 		Preload("Exercises.Sets").
 		Preload("Exercises.Exercise").
@@ -23,7 +23,7 @@ func LoadByDate(ctx context.Context, date time.Time) (models.WorkoutLog, error)
 		return models.WorkoutLog{}, err
 	}
 	if workoutDay.WorkoutPlan != nil {
-		ex, err := LoadExercisesOrderedForPlan(workoutDay.WorkoutPlan.ID)
+		ex, err := LoadExercisesOrderedForPlan(ctx, workoutDay.WorkoutPlan.ID)
 		if err != nil {
 			return models.WorkoutLog{}, err
 		}
@@ -33,11 +33,11 @@ func LoadByDate(ctx context.Context, date time.Time) (models.WorkoutLog, error)
 }
 
 func CreateMinimal(ctx context.Context, log *models.WorkoutLog) error {
-	return conn().WithContext(ctx).Omit("WorkoutPlan", "Exercises", "Cardio").Create(log).Error
+	return conn(ctx).Omit("WorkoutPlan", "Exercises", "Cardio").Create(log).Error
 }
 
-func GetByDateRange(ctx context.Context, start, end time.Time) ([]models.WorkoutLog, error) {
-	repo := dbrepo.NewGormRepository[models.WorkoutLog](conn())
+func GetByDateRange(ctx context.Context, start, end database.Date) ([]models.WorkoutLog, error) {
+	repo := dbrepo.NewGormRepository[models.WorkoutLog](conn(ctx))
 	return repo.GetByDateRange(ctx, start, end, dbrepo.WithDefaultPreloads())
 }
 
@@ -48,25 +48,25 @@ func UpdateWorkoutPlanID(ctx context.Context, workoutLogID uint, planID *uint) e
 	} else {
 		wid = *planID
 	}
-	return conn().WithContext(ctx).Model(&models.WorkoutLog{}).Where("id = ?", workoutLogID).Updates(map[string]any{
+	return conn(ctx).Model(&models.WorkoutLog{}).Where("id = ?", workoutLogID).Updates(map[string]any{
 		"workout_plan_id": wid,
 	}).Error
 }
 
 func UpdatePreMobilityChecked(ctx context.Context, workoutLogID uint, checked []string) error {
 	var wl models.WorkoutLog
-	if err := conn().WithContext(ctx).First(&wl, workoutLogID).Error; err != nil {
+	if err := conn(ctx).First(&wl, workoutLogID).Error; err != nil {
 		return err
 	}
 	wl.PreMobilityChecked = checked
-	return conn().WithContext(ctx).Session(&gorm.Session{FullSaveAssociations: false}).Save(&wl).Error
+	return conn(ctx).Session(&gorm.Session{FullSaveAssociations: false}).Save(&wl).Error
 }
 
 func UpdatePostMobilityChecked(ctx context.Context, workoutLogID uint, checked []string) error {
 	var wl models.WorkoutLog
-	if err := conn().WithContext(ctx).First(&wl, workoutLogID).Error; err != nil {
+	if err := conn(ctx).First(&wl, workoutLogID).Error; err != nil {
 		return err
 	}
 	wl.PostMobilityChecked = checked
-	return conn().WithContext(ctx).Session(&gorm.Session{FullSaveAssociations: false}).Save(&wl).Error
+	return conn(ctx).Session(&gorm.Session{FullSaveAssociations: false}).Save(&wl).Error
 }
@@ -5,44 +5,45 @@ import (
 	"fmt"
 
 	"be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 )
 
-func LoadExercisesOrderedForPlan(planID uint) ([]models.Exercise, error) {
+func LoadExercisesOrderedForPlan(ctx context.Context, planID uint) ([]models.Exercise, error) {
 	var exercises []models.Exercise
-	err := conn().Model(&models.Exercise{}).
+	err := conn(ctx).Model(&models.Exercise{}).
 		Joins("INNER JOIN workout_plan_exercises AS wpe ON wpe.exercise_id = exercises.id AND wpe.workout_plan_id = ?", planID).
 		Order("wpe.display_order ASC").
 		Find(&exercises).Error
 	return exercises, err
 }
 
-func FindWorkoutPlanByID(planID uint) (models.WorkoutPlan, error) {
+func FindWorkoutPlanByID(ctx context.Context, planID uint) (models.WorkoutPlan, error) {
 	var plan models.WorkoutPlan
-	err := conn().First(&plan, planID).Error
+	err := conn(ctx).First(&plan, planID).Error
 	return plan, err
 }
 
-func LoadPlanWithOrderedExercises(planID uint) (*models.WorkoutPlan, error) {
-	plan, err := FindWorkoutPlanByID(planID)
+func LoadPlanWithOrderedExercises(ctx context.Context, planID uint) (*models.WorkoutPlan, error) {
+	plan, err := FindWorkoutPlanByID(ctx, planID)
 	if err != nil {
 		return nil, err
 	}
-	ex, err := LoadExercisesOrderedForPlan(planID)
+	ex, err := LoadExercisesOrderedForPlan(ctx, planID)
 	if err != nil {
 		return nil, err
 	}
 	plan.Exercises = ex
-	if err := loadAssignedDays(&plan); err != nil {
+	if err := loadAssignedDays(ctx, &plan); err != nil {
 		return nil, err
 	}
 	return &plan, nil
 }
 
-func loadAssignedDays(plan *models.WorkoutPlan) error {
+func loadAssignedDays(ctx context.Context, plan *models.WorkoutPlan) error {
 	var rows []models.WorkoutPlanDay
-	if err := conn().Where("workout_plan_id = ?", plan.ID).Order("day_of_week ASC").Find(&rows).Error; err != nil {
+	if err := conn(ctx).Where("workout_plan_id = ?", plan.ID).Order("day_of_week ASC").Find(&rows).Error; err != nil {
 		return err
 	}
 	plan.AssignedDays = make([]int, 0, len(rows))
@@ -58,14 +59,14 @@ func loadAssignedDays(plan *models.WorkoutPlan) error {
 	return nil
 }
 
-func FindAllWorkoutPlans() ([]models.WorkoutPlan, error) {
+func FindAllWorkoutPlans(ctx context.Context) ([]models.WorkoutPlan, error) {
 	var workoutPlans []models.WorkoutPlan
-	err := conn().Find(&workoutPlans).Error
+	err := conn(ctx).Find(&workoutPlans).Error
 	if err != nil {
 		return []models.WorkoutPlan{}, err
 	}
 	for i := range workoutPlans {
-		loaded, err := LoadPlanWithOrderedExercises(workoutPlans[i].ID)
+		loaded, err := LoadPlanWithOrderedExercises(ctx, workoutPlans[i].ID)
 		if err != nil {
 			return nil, err
 		}
@@ -74,13 +75,13 @@ func FindAllWorkoutPlans() ([]models.WorkoutPlan, error) {
 	return workoutPlans, nil
 }
 
-func FindAllWorkoutPrograms() ([]models.WorkoutProgram, error) {
+func FindAllWorkoutPrograms(ctx context.Context) ([]models.WorkoutProgram, error) {
 	var programs []models.WorkoutProgram
-	if err := conn().Order("id ASC").Find(&programs).Error; err != nil {
+	if err := conn(ctx).Order("id ASC").Find(&programs).Error; err != nil {
 		return nil, err
 	}
 	for i := range programs {
-		plans, err := FindWorkoutPlansByProgram(programs[i].ID)
+		plans, err := FindWorkoutPlansByProgram(ctx, programs[i].ID)
 		if err != nil {
 			return nil, err
 		}
@@ -89,13 +90,13 @@ func FindAllWorkoutPrograms() ([]models.WorkoutProgram, error) {
 	return programs, nil
 }
 
-func FindWorkoutPlansByProgram(programID uint) ([]models.WorkoutPlan, error) {
+func FindWorkoutPlansByProgram(ctx context.Context, programID uint) ([]models.WorkoutPlan, error) {
 	var plans []models.WorkoutPlan
-	if err := conn().Where("workout_program_id = ?", programID).Order("id ASC").Find(&plans).Error; err != nil {
+	if err := conn(ctx).Where("workout_program_id = ?", programID).Order("id ASC").Find(&plans).Error; err != nil {
 		return nil, err
 	}
 	for i := range plans {
-		loaded, err := LoadPlanWithOrderedExercises(plans[i].ID)
+		loaded, err := LoadPlanWithOrderedExercises(ctx, plans[i].ID)
 		if err != nil {
 			return nil, err
 		}
@@ -104,30 +105,35 @@ func FindWorkoutPlansByProgram(programID uint) ([]models.WorkoutPlan, error) {
 	return plans, nil
 }
 
-func CreateWorkoutProgram(program *models.WorkoutProgram) error {
-	return conn().Create(program).Error
+func CreateWorkoutProgram(ctx context.Context, program *models.WorkoutProgram) error {
+	return conn(ctx).Create(program).Error
 }
 
-func CreateWorkoutPlan(plan *models.WorkoutPlan) error {
-	return conn().Create(plan).Error
+func CreateWorkoutPlan(ctx context.Context, plan *models.WorkoutPlan) error {
+	return conn(ctx).Create(plan).Error
 }
 
-func FindWorkoutProgramByID(id uint) (models.WorkoutProgram, error) {
+func FindWorkoutProgramByID(ctx context.Context, id uint) (models.WorkoutProgram, error) {
 	var program models.WorkoutProgram
-	return program, conn().First(&program, id).Error
+	return program, conn(ctx).First(&program, id).Error
 }
 
-func FindActiveWorkoutProgram() (models.WorkoutProgram, error) {
+func FindActiveWorkoutProgram(ctx context.Context) (models.WorkoutProgram, error) {
 	var program models.WorkoutProgram
-	return program, conn().Where("is_active = ?", true).Order("id ASC").First(&program).Error
+	return program, conn(ctx).Where("is_active = ?", true).Order("id ASC").First(&program).Error
 }
 
-func UpdateWorkoutProgramName(id uint, name string) error {
-	return conn().Model(&models.WorkoutProgram{}).Where("id = ?", id).Update("name", name).Error
+func UpdateWorkoutProgramName(ctx context.Context, id uint, name string) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := database.ClaimVersion(tx, &models.WorkoutProgram{}, id); err != nil {
+			return err
+		}
+		return tx.Model(&models.WorkoutProgram{}).Where("id = ?", id).Update("name", name).Error
+	})
 }
 
-func ActivateWorkoutProgram(id uint) error {
-	return conn().Transaction(func(tx *gorm.DB) error {
+func ActivateWorkoutProgram(ctx context.Context, id uint) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Model(&models.WorkoutProgram{}).Where("id = ?", id).Update("is_active", true).Error; err != nil {
 			return err
 		}
@@ -137,33 +143,33 @@ func ActivateWorkoutProgram(id uint) error {
 
 func WorkoutPlanExists(ctx context.Context, planID uint) (bool, error) {
 	var n int64
-	err := conn().WithContext(ctx).Model(&models.WorkoutPlan{}).Where("id = ?", planID).Count(&n).Error
+	err := conn(ctx).Model(&models.WorkoutPlan{}).Where("id = ?", planID).Count(&n).Error
 	return n > 0, err
 }
 
-func FindWorkoutPlanByDayOfWeek(dayOfWeek int) (models.WorkoutPlan, error) {
+func FindWorkoutPlanByDayOfWeek(ctx context.Context, dayOfWeek int) (models.WorkoutPlan, error) {
 	var plan models.WorkoutPlan
-	err := conn().Joins("INNER JOIN workout_plan_days AS wpd ON wpd.workout_plan_id = workout_plans.id").
+	err := conn(ctx).Joins("INNER JOIN workout_plan_days AS wpd ON wpd.workout_plan_id = workout_plans.id").
 		Where("wpd.day_of_week = ?", dayOfWeek).First(&plan).Error
 	if err == gorm.ErrRecordNotFound {
-		err = conn().Where("day_of_week = ?", dayOfWeek).First(&plan).Error
+		err = conn(ctx).Where("day_of_week = ?", dayOfWeek).First(&plan).Error
 	}
 	return plan, err
 }
 
-func FindWorkoutPlanByProgramAndDay(programID uint, dayOfWeek int) (models.WorkoutPlan, error) {
+func FindWorkoutPlanByProgramAndDay(ctx context.Context, programID uint, dayOfWeek int) (models.WorkoutPlan, error) {
 	var plan models.WorkoutPlan
-	err := conn().Joins("INNER JOIN workout_plan_days AS wpd ON wpd.workout_plan_id = workout_plans.id").
+	err := conn(ctx).Joins("INNER JOIN workout_plan_days AS wpd ON wpd.workout_plan_id = workout_plans.id").
 		Where("workout_plans.workout_program_id = ? AND wpd.day_of_week = ?", programID, dayOfWeek).
 		First(&plan).Error
 	if err == gorm.ErrRecordNotFound {
-		err = conn().Where("workout_program_id = ? AND day_of_week = ?", programID, dayOfWeek).First(&plan).Error
+		err = conn(ctx).Where("workout_program_id = ? AND day_of_week = ?", programID, dayOfWeek).First(&plan).Error
 	}
 	return plan, err
 }
 
-func UpdatePlannedCardio(planID uint, cardioType string, minutes int) error {
-	return conn().Model(&models.WorkoutPlan{}).
+func UpdatePlannedCardio(ctx context.Context, planID uint, cardioType string, minutes int) error {
+	return conn(ctx).Model(&models.WorkoutPlan{}).
 		Where("id = ?", planID).
 		Updates(map[string]any{
 			"planned_cardio_type":    cardioType,
@@ -171,113 +177,113 @@ func UpdatePlannedCardio(planID uint, cardioType string, minutes int) error {
 		}).Error
 }
 
-func UpdateMobilityItems(planID uint, preItems, postItems []string) error {
+func UpdateMobilityItems(ctx context.Context, planID uint, preItems, postItems []string) error {
 	var plan models.WorkoutPlan
-	if err := conn().First(&plan, planID).Error; err != nil {
+	if err := conn(ctx).First(&plan, planID).Error; err != nil {
 		return err
 	}
 	plan.PreMobilityItems = preItems
 	plan.PostMobilityItems = postItems
-	return conn().Model(&plan).
+	return conn(ctx).Model(&plan).
 		Select("PreMobilityItems", "PostMobilityItems").
 		Updates(&plan).Error
 }
 
-func AssignWorkoutPlanToProgram(planID uint, programID uint) error {
-	return conn().Model(&models.WorkoutPlan{}).Where("id = ?", planID).Update("workout_program_id", programID).Error
+func AssignWorkoutPlanToProgram(ctx context.Context, planID uint, programID uint) error {
+	return conn(ctx).Model(&models.WorkoutPlan{}).Where("id = ?", planID).Update("workout_program_id", programID).Error
 }
 
-func UnassignOtherPlansFromDay(dayOfWeek int, planID uint) error {
-	return conn().Model(&models.WorkoutPlan{}).
+func UnassignOtherPlansFromDay(ctx context.Context, dayOfWeek int, planID uint) error {
+	return conn(ctx).Model(&models.WorkoutPlan{}).
 		Where("day_of_week = ? AND id != ?", dayOfWeek, planID).
 		Update("day_of_week", nil).Error
 }
 
-func UnassignOtherPlansFromProgramDay(programID uint, dayOfWeek int, planID uint) error {
+func UnassignOtherPlansFromProgramDay(ctx context.Context, programID uint, dayOfWeek int, planID uint) error {
 	var plans []models.WorkoutPlan
-	if err := conn().Where("workout_program_id = ? AND id != ?", programID, planID).Find(&plans).Error; err != nil {
+	if err := conn(ctx).Where("workout_program_id = ? AND id != ?", programID, planID).Find(&plans).Error; err != nil {
 		return err
 	}
 	for _, plan := range plans {
-		if err := conn().Where("workout_plan_id = ? AND day_of_week = ?", plan.ID, dayOfWeek).
+		if err := conn(ctx).Where("workout_plan_id = ? AND day_of_week = ?", plan.ID, dayOfWeek).
 			Delete(&models.WorkoutPlanDay{}).Error; err != nil {
 			return err
 		}
-		if err := refreshLegacyDay(plan.ID); err != nil {
+		if err := refreshLegacyDay(ctx, plan.ID); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func AssignWorkoutPlanToDay(planID uint, dayOfWeek int) error {
-	if err := conn().Where("workout_plan_id = ? AND day_of_week = ?", planID, dayOfWeek).
+func AssignWorkoutPlanToDay(ctx context.Context, planID uint, dayOfWeek int) error {
+	if err := conn(ctx).Where("workout_plan_id = ? AND day_of_week = ?", planID, dayOfWeek).
 		FirstOrCreate(&models.WorkoutPlanDay{WorkoutPlanID: planID, DayOfWeek: dayOfWeek}).Error; err != nil {
 		return err
 	}
-	return refreshLegacyDay(planID)
+	return refreshLegacyDay(ctx, planID)
 }
 
-func ClearWorkoutPlanDay(planID uint) error {
-	if err := conn().Where("workout_plan_id = ?", planID).Delete(&models.WorkoutPlanDay{}).Error; err != nil {
+func ClearWorkoutPlanDay(ctx context.Context, planID uint) error {
+	if err := conn(ctx).Where("workout_plan_id = ?", planID).Delete(&models.WorkoutPlanDay{}).Error; err != nil {
 		return err
 	}
-	return refreshLegacyDay(planID)
+	return refreshLegacyDay(ctx, planID)
 }
 
-func ClearWorkoutPlanDayOfWeek(planID uint, dayOfWeek int) error {
-	if err := conn().Where("workout_plan_id = ? AND day_of_week = ?", planID, dayOfWeek).
+func ClearWorkoutPlanDayOfWeek(ctx context.Context, planID uint, dayOfWeek int) error {
+	if err := conn(ctx).Where("workout_plan_id = ? AND day_of_week = ?", planID, dayOfWeek).
 		Delete(&models.WorkoutPlanDay{}).Error; err != nil {
 		return err
 	}
-	return refreshLegacyDay(planID)
+	return refreshLegacyDay(ctx, planID)
 }
 
-func refreshLegacyDay(planID uint) error {
+func refreshLegacyDay(ctx context.Context, planID uint) error {
 	var row models.WorkoutPlanDay
-	err := conn().Where("workout_plan_id = ?", planID).Order("day_of_week ASC").First(&row).Error
+	err := conn(ctx).Where("workout_plan_id = ?", planID).Order("day_of_week ASC").First(&row).Error
 	if err == gorm.ErrRecordNotFound {
-		return conn().Model(&models.WorkoutPlan{}).Where("id = ?", planID).Update("day_of_week", nil).Error
+		return conn(ctx).Model(&models.WorkoutPlan{}).Where("id = ?", planID).Update("day_of_week", nil).Error
 	}
 	if err != nil {
 		return err
 	}
-	return conn().Model(&models.WorkoutPlan{}).Where("id = ?", planID).Update("day_of_week", row.DayOfWeek).Error
+	return conn(ctx).Model(&models.WorkoutPlan{}).Where("id = ?", planID).Update("day_of_week", row.DayOfWeek).Error
 }
 
-func AddExerciseToPlan(planID uint, exerciseID uint) error {
-	if err := conn().First(&models.WorkoutPlan{}, planID).Error; err != nil {
+func AddExerciseToPlan(ctx context.Context, planID uint, exerciseID uint) error {
+	if err := conn(ctx).First(&models.WorkoutPlan{}, planID).Error; err != nil {
 		return err
 	}
-	if err := conn().First(&models.Exercise{}, exerciseID).Error; err != nil {
+	if err := conn(ctx).First(&models.Exercise{}, exerciseID).Error; err != nil {
 		return err
 	}
 	var n int64
-	if err := conn().Model(&models.WorkoutPlanExercise{}).Where("workout_plan_id = ? AND exercise_id = ?", planID, exerciseID).Count(&n).Error; err != nil {
+	if err := conn(ctx).Model(&models.WorkoutPlanExercise{}).Where("workout_plan_id = ? AND exercise_id = ?", planID, exerciseID).Count(&n).Error; err != nil {
 		return err
 	}
 	if n > 0 {
 		return fmt.Errorf("exercise already in plan")
 	}
 	var count int64
-	if err := conn().Model(&models.WorkoutPlanExercise{}).Where("workout_plan_id = ?", planID).Count(&count).Error; err != nil {
+	if err := conn(ctx).Model(&models.WorkoutPlanExercise{}).Where("workout_plan_id = ?", planID).Count(&count).Error; err != nil {
 		return err
 	}
-	return conn().Create(&models.WorkoutPlanExercise{
+	return conn(ctx).Create(&models.WorkoutPlanExercise{
 		WorkoutPlanID: planID,
 		ExerciseID:    exerciseID,
 		DisplayOrder:  int(count),
 	}).Error
 }
 
-func renumberPlanExerciseDisplayOrder(planID uint) error {
+func renumberPlanExerciseDisplayOrder(ctx context.Context, planID uint) error {
 	var rows []models.WorkoutPlanExercise
-	if err := conn().Where("workout_plan_id = ?", planID).Order("display_order ASC").Find(&rows).Error; err != nil {
+	if err := conn(ctx).Where("workout_plan_id = ?", planID).Order("display_order ASC").Find(&rows).Error; err != nil {
 		return err
 	}
 	for i := range rows {
 		if rows[i].DisplayOrder != i {
-			if err := conn().Model(&models.WorkoutPlanExercise{}).
+			if err := conn(ctx).Model(&models.WorkoutPlanExercise{}).
 				Where("workout_plan_id = ? AND exercise_id = ?", planID, rows[i].ExerciseID).
 				Update("display_order", i).Error; err != nil {
 				return err
@@ -287,20 +293,20 @@ func renumberPlanExerciseDisplayOrder(planID uint) error {
 	return nil
 }
 
-func RemoveExerciseFromPlan(planID uint, exerciseID uint) error {
-	res := conn().Where("workout_plan_id = ? AND exercise_id = ?", planID, exerciseID).Delete(&models.WorkoutPlanExercise{})
+func RemoveExerciseFromPlan(ctx context.Context, planID uint, exerciseID uint) error {
+	res := conn(ctx).Where("workout_plan_id = ? AND exercise_id = ?", planID, exerciseID).Delete(&models.WorkoutPlanExercise{})
 	if res.Error != nil {
 		return res.Error
 	}
 	if res.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
-	return renumberPlanExerciseDisplayOrder(planID)
+	return renumberPlanExerciseDisplayOrder(ctx, planID)
 }
 
-func ReorderPlanExercises(planID uint, exerciseIDs []uint) error {
+func ReorderPlanExercises(ctx context.Context, planID uint, exerciseIDs []uint) error {
 	var existing []models.WorkoutPlanExercise
-	if err := conn().Where("workout_plan_id = ?", planID).Find(&existing).Error; err != nil {
+	if err := conn(ctx).Where("workout_plan_id = ?", planID).Find(&existing).Error; err != nil {
 		return err
 	}
 	if len(exerciseIDs) != len(existing) {
@@ -319,7 +325,7 @@ func ReorderPlanExercises(planID uint, exerciseIDs []uint) error {
 	if len(existingSet) != 0 {
 		return fmt.Errorf("exercise list must include all plan exercises")
 	}
-	return conn().Transaction(func(tx *gorm.DB) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
 		for i, eid := range exerciseIDs {
 			if err := tx.Model(&models.WorkoutPlanExercise{}).
 				Where("workout_plan_id = ? AND exercise_id = ?", planID, eid).
@@ -4,6 +4,7 @@ import (
 	"be-simpletracker/internal/core/workout/models"
 	workoutrepo "be-simpletracker/internal/core/workout/repository"
 	"be-simpletracker/internal/core/workout/testutil"
+	"context"
 	"strings"
 	"testing"
 
@@ -33,11 +34,11 @@ func TestReorderPlanExercises_rejectsPartialList(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	err := workoutrepo.ReorderPlanExercises(plan.ID, []uint{ex1.ID})
+	err := workoutrepo.ReorderPlanExercises(context.Background(), plan.ID, []uint{ex1.ID})
 	if err == nil || !strings.Contains(err.Error(), "must include all plan exercises") {
 		t.Fatalf("expected validation error, got %v", err)
 	}
-	err = workoutrepo.ReorderPlanExercises(plan.ID, []uint{ex1.ID, 9999})
+	err = workoutrepo.ReorderPlanExercises(context.Background(), plan.ID, []uint{ex1.ID, 9999})
 	if err == nil || !strings.Contains(err.Error(), "invalid exercise id") {
 		t.Fatalf("expected invalid id error, got %v", err)
 	}
@@ -49,7 +50,7 @@ func TestRemoveExerciseFromPlan_notFound(t *testing.T) {
 	if err := db.Create(&plan).Error; err != nil {
 		t.Fatal(err)
 	}
-	err := workoutrepo.RemoveExerciseFromPlan(plan.ID, 1)
+	err := workoutrepo.RemoveExerciseFromPlan(context.Background(), plan.ID, 1)
 	if err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected not found, got %v", err)
 	}
@@ -57,7 +58,7 @@ func TestRemoveExerciseFromPlan_notFound(t *testing.T) {
 
 func TestAddExerciseToPlan_rejectsMissingPlan(t *testing.T) {
 	testutil.SetupTestDB(t)
-	err := workoutrepo.AddExerciseToPlan(9999, 1)
+	err := workoutrepo.AddExerciseToPlan(context.Background(), 9999, 1)
 	if err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected not found, got %v", err)
 	}
@@ -65,7 +66,7 @@ func TestAddExerciseToPlan_rejectsMissingPlan(t *testing.T) {
 
 func TestLoadPlanWithOrderedExercises_notFound(t *testing.T) {
 	testutil.SetupTestDB(t)
-	_, err := workoutrepo.LoadPlanWithOrderedExercises(9999)
+	_, err := workoutrepo.LoadPlanWithOrderedExercises(context.Background(), 9999)
 	if err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected not found, got %v", err)
 	}
@@ -94,10 +95,10 @@ func TestRenumberPlanExerciseDisplayOrder_onRemove(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if err := workoutrepo.RemoveExerciseFromPlan(plan.ID, exercises[0].ID); err != nil {
+	if err := workoutrepo.RemoveExerciseFromPlan(context.Background(), plan.ID, exercises[0].ID); err != nil {
 		t.Fatal(err)
 	}
-	ordered, err := workoutrepo.LoadExercisesOrderedForPlan(plan.ID)
+	ordered, err := workoutrepo.LoadExercisesOrderedForPlan(context.Background(), plan.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -12,7 +12,7 @@ import (
 
 func TestGetWorkoutActivity_year_includesDayWithSet(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	ex := models.Exercise{Name: "Bench"}
 	if err := db.Create(&ex).Error; err != nil {
 		t.Fatal(err)
@@ -32,7 +32,7 @@ func TestGetWorkoutActivity_year_includesDayWithSet(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	want := today.Format("2006-01-02")
+	want := today.String()
 	found := false
 	for _, d := range res.ActiveDates {
 		if d == want {
@@ -54,7 +54,7 @@ func TestGetWorkoutActivity_rolling_excludesOldDayOutsideWindow(t *testing.T) {
 	if err := db.Create(&ex).Error; err != nil {
 		t.Fatal(err)
 	}
-	old := utils.ZerodTime(400)
+	old := utils.Today(context.Background(), 400)
 	wlOld := models.WorkoutLog{Date: old}
 	if err := db.Create(&wlOld).Error; err != nil {
 		t.Fatal(err)
@@ -71,7 +71,7 @@ func TestGetWorkoutActivity_rolling_excludesOldDayOutsideWindow(t *testing.T) {
 		t.Fatal(err)
 	}
 	for _, d := range res.ActiveDates {
-		if d == old.Format("2006-01-02") {
+		if d == old.String() {
 			t.Fatalf("old day should be outside window: %+v", res.ActiveDates)
 		}
 	}
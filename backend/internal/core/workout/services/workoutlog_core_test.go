@@ -12,7 +12,7 @@ import (
 
 func TestGetOrCreateToday_returnsExistingLog(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	wl := models.WorkoutLog{Date: today}
 	if err := db.Create(&wl).Error; err != nil {
 		t.Fatal(err)
@@ -28,7 +28,7 @@ func TestGetOrCreateToday_returnsExistingLog(t *testing.T) {
 
 func TestGetOrCreateToday_createsLogWithPlanForDay(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	dow := int(today.Weekday())
 	plan := models.WorkoutPlan{Name: "Push", DayOfWeek: &dow}
 	if err := db.Create(&plan).Error; err != nil {
@@ -45,7 +45,7 @@ func TestGetOrCreateToday_createsLogWithPlanForDay(t *testing.T) {
 
 func TestSwitchPlan_updatesPlanAndReturnsView(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	dow := int(today.Weekday())
 	plan := models.WorkoutPlan{Name: "Legs", DayOfWeek: &dow, PlannedCardioType: "Run", PlannedCardioMinutes: 30}
 	if err := db.Create(&plan).Error; err != nil {
@@ -93,8 +93,8 @@ func TestSwitchPlan_rejectsMissingPlan(t *testing.T) {
 
 func TestGetPreviousWorkoutView_includesPreviousLog(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
-	yesterday := utils.ZerodTime(1)
+	today := utils.Today(context.Background(), 0)
+	yesterday := utils.Today(context.Background(), 1)
 	ex := models.Exercise{Name: "Deadlift"}
 	if err := db.Create(&ex).Error; err != nil {
 		t.Fatal(err)
@@ -153,7 +153,7 @@ func TestGetPreviousWorkoutView_includesPreviousLog(t *testing.T) {
 
 func TestGetMonthWorkoutLogs_returnsLogsInRange(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	if err := db.Create(&models.WorkoutLog{Date: today}).Error; err != nil {
 		t.Fatal(err)
 	}
@@ -171,7 +171,7 @@ func TestGetMonthWorkoutLogs_returnsLogsInRange(t *testing.T) {
 
 func TestGetPlanByDay_returnsNilWhenUnassigned(t *testing.T) {
 	testutil.SetupTestDB(t)
-	plan, err := services.GetPlanByDay(3)
+	plan, err := services.GetPlanByDay(context.Background(), 3)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -182,7 +182,7 @@ func TestGetPlanByDay_returnsNilWhenUnassigned(t *testing.T) {
 
 func TestGetPlanByDay_rejectsInvalidDay(t *testing.T) {
 	testutil.SetupTestDB(t)
-	_, err := services.GetPlanByDay(7)
+	_, err := services.GetPlanByDay(context.Background(), 7)
 	if err == nil || !strings.Contains(err.Error(), "day_of_week") {
 		t.Fatalf("expected day_of_week error, got %v", err)
 	}
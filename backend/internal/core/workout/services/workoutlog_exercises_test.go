@@ -13,18 +13,18 @@ import (
 
 func TestCreateExercise_andGetAllExercises(t *testing.T) {
 	testutil.SetupTestDB(t)
-	created, err := services.CreateExercise("Bench Press", 12, "squeeze")
+	created, err := services.CreateExercise(context.Background(), "Bench Press", 12, "squeeze")
 	if err != nil {
 		t.Fatal(err)
 	}
-	all, err := services.GetAllExercises(nil)
+	all, err := services.GetAllExercises(context.Background(), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(all) != 1 || all[0].ID != created.ID {
 		t.Fatalf("got %+v", all)
 	}
-	excluded, err := services.GetAllExercises([]uint{created.ID})
+	excluded, err := services.GetAllExercises(context.Background(), []uint{created.ID})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -38,11 +38,11 @@ func TestCreateExercise_andGetAllExercises(t *testing.T) {
 
 func TestUpdateExercise_andUpdateExerciseCues(t *testing.T) {
 	testutil.SetupTestDB(t)
-	created, err := services.CreateExercise("Row", 10, "old")
+	created, err := services.CreateExercise(context.Background(), "Row", 10, "old")
 	if err != nil {
 		t.Fatal(err)
 	}
-	updated, err := services.UpdateExercise(
+	updated, err := services.UpdateExercise(context.Background(),
 		created.ID,
 		"Barbell Row",
 		8,
@@ -57,7 +57,7 @@ func TestUpdateExercise_andUpdateExerciseCues(t *testing.T) {
 		updated.LoadType != models.ExerciseLoadTypePlateLoadedWithoutBar {
 		t.Fatalf("got %+v", updated)
 	}
-	updated, err = services.UpdateExercise(
+	updated, err = services.UpdateExercise(context.Background(),
 		created.ID,
 		"Barbell Row",
 		8,
@@ -70,7 +70,7 @@ func TestUpdateExercise_andUpdateExerciseCues(t *testing.T) {
 	if updated.LoadType != models.ExerciseLoadTypeFreeWeights {
 		t.Fatalf("expected free weights load type, got %q", updated.LoadType)
 	}
-	updated, err = services.UpdateExercise(
+	updated, err = services.UpdateExercise(context.Background(),
 		created.ID,
 		"Barbell Row",
 		8,
@@ -83,7 +83,7 @@ func TestUpdateExercise_andUpdateExerciseCues(t *testing.T) {
 	if updated.LoadType != models.ExerciseLoadTypePlateLoadedTotal {
 		t.Fatalf("expected total plate load type, got %q", updated.LoadType)
 	}
-	cued, err := services.UpdateExerciseCues(created.ID, "new cue")
+	cued, err := services.UpdateExerciseCues(context.Background(), created.ID, "new cue")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -95,11 +95,11 @@ func TestUpdateExercise_andUpdateExerciseCues(t *testing.T) {
 func TestListExercises_paginates(t *testing.T) {
 	testutil.SetupTestDB(t)
 	for _, name := range []string{"Alpha", "Beta", "Gamma"} {
-		if _, err := services.CreateExercise(name, 10, ""); err != nil {
+		if _, err := services.CreateExercise(context.Background(), name, 10, ""); err != nil {
 			t.Fatal(err)
 		}
 	}
-	res, err := services.ListExercises(1, 2, "")
+	res, err := services.ListExercises(context.Background(), 1, 2, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -110,8 +110,8 @@ func TestListExercises_paginates(t *testing.T) {
 
 func TestLogExercise_UpdateLoggedExercise_DeleteLoggedSet(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
-	ex, err := services.CreateExercise("Curl", 12, "")
+	today := utils.Today(context.Background(), 0)
+	ex, err := services.CreateExercise(context.Background(), "Curl", 12, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -120,7 +120,7 @@ func TestLogExercise_UpdateLoggedExercise_DeleteLoggedSet(t *testing.T) {
 		t.Fatal(err)
 	}
 	le := models.LoggedExercise{WorkoutLogID: wl.ID, ExerciseID: ex.ID, Sets: []models.LoggedSet{{Reps: 10, Weight: 20}}}
-	if err := services.LogExercise(&le); err != nil {
+	if err := services.LogExercise(context.Background(), &le); err != nil {
 		t.Fatal(err)
 	}
 	if len(le.Sets) != 1 || le.Sets[0].ID == 0 {
@@ -129,20 +129,20 @@ func TestLogExercise_UpdateLoggedExercise_DeleteLoggedSet(t *testing.T) {
 	setID := le.Sets[0].ID
 	le.Sets[0].Reps = 12
 	le.Sets[0].Weight = 22.5
-	if err := services.UpdateLoggedExercise(le); err != nil {
+	if err := services.UpdateLoggedExercise(context.Background(), le); err != nil {
 		t.Fatal(err)
 	}
-	loaded, err := services.LoadLoggedExercise(le.ID)
+	loaded, err := services.LoadLoggedExercise(context.Background(), le.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if loaded.Sets[0].Reps != 12 || loaded.Sets[0].Weight != 22.5 {
 		t.Fatalf("update failed: %+v", loaded.Sets)
 	}
-	if err := services.DeleteLoggedSet(setID); err != nil {
+	if err := services.DeleteLoggedSet(context.Background(), setID); err != nil {
 		t.Fatal(err)
 	}
-	_, err = services.LoadLoggedExercise(le.ID)
+	_, err = services.LoadLoggedExercise(context.Background(), le.ID)
 	if err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected logged exercise removed, got %v", err)
 	}
@@ -150,8 +150,8 @@ func TestLogExercise_UpdateLoggedExercise_DeleteLoggedSet(t *testing.T) {
 
 func TestRemoveLoggedExerciseForDay(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
-	ex, err := services.CreateExercise("Lat Raise", 15, "")
+	today := utils.Today(context.Background(), 0)
+	ex, err := services.CreateExercise(context.Background(), "Lat Raise", 15, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -160,13 +160,13 @@ func TestRemoveLoggedExerciseForDay(t *testing.T) {
 		t.Fatal(err)
 	}
 	le := models.LoggedExercise{WorkoutLogID: wl.ID, ExerciseID: ex.ID}
-	if err := services.LogExercise(&le); err != nil {
+	if err := services.LogExercise(context.Background(), &le); err != nil {
 		t.Fatal(err)
 	}
 	if err := services.RemoveLoggedExerciseForDay(context.Background(), 0, ex.ID); err != nil {
 		t.Fatal(err)
 	}
-	_, err = services.LoadLoggedExercise(le.ID)
+	_, err = services.LoadLoggedExercise(context.Background(), le.ID)
 	if err != gorm.ErrRecordNotFound {
 		t.Fatalf("expected removal, got %v", err)
 	}
@@ -174,7 +174,7 @@ func TestRemoveLoggedExerciseForDay(t *testing.T) {
 
 func TestGetExerciseProgression(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	ex, err := services.CreateExercise("OHP", 10, "")
+	ex, err := services.CreateExercise(context.Background(), "OHP", 10, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -185,7 +185,7 @@ func TestGetExerciseProgression(t *testing.T) {
 		{1, 50},
 		{0, 52.5},
 	} {
-		wl := models.WorkoutLog{Date: utils.ZerodTime(day.offset)}
+		wl := models.WorkoutLog{Date: utils.Today(context.Background(), day.offset)}
 		if err := db.Create(&wl).Error; err != nil {
 			t.Fatal(err)
 		}
@@ -197,7 +197,7 @@ func TestGetExerciseProgression(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	entries, err := services.GetExerciseProgression(ex.ID)
+	entries, err := services.GetExerciseProgression(context.Background(), ex.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
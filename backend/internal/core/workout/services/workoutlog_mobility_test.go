@@ -12,7 +12,7 @@ import (
 
 func TestUpsertMobilityPre_persistsChecked(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	plan := models.WorkoutPlan{
 		Name:             "MobilityTestPlan",
 		PreMobilityItems: []string{"A", "B", "C"},
@@ -45,7 +45,7 @@ func TestUpsertMobilityPre_persistsChecked(t *testing.T) {
 
 func TestUpsertMobilityPost_persistsChecked(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	plan := models.WorkoutPlan{
 		Name:              "MobilityTestPlan",
 		PostMobilityItems: []string{"X", "Y"},
@@ -5,6 +5,7 @@ import (
 	"be-simpletracker/internal/core/workout/services"
 	"be-simpletracker/internal/core/workout/testutil"
 	"be-simpletracker/internal/utils"
+	"context"
 	"slices"
 	"strings"
 	"testing"
@@ -12,11 +13,11 @@ import (
 
 func TestAddExerciseToPlan_RemoveExerciseFromPlan_Reorder(t *testing.T) {
 	db := testutil.SetupTestDB(t)
-	ex1, err := services.CreateExercise("A", 10, "")
+	ex1, err := services.CreateExercise(context.Background(), "A", 10, "")
 	if err != nil {
 		t.Fatal(err)
 	}
-	ex2, err := services.CreateExercise("B", 10, "")
+	ex2, err := services.CreateExercise(context.Background(), "B", 10, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -24,29 +25,29 @@ func TestAddExerciseToPlan_RemoveExerciseFromPlan_Reorder(t *testing.T) {
 	if err := db.Create(&plan).Error; err != nil {
 		t.Fatal(err)
 	}
-	if err := services.AddExerciseToPlan(plan.ID, ex1.ID); err != nil {
+	if err := services.AddExerciseToPlan(context.Background(), plan.ID, ex1.ID); err != nil {
 		t.Fatal(err)
 	}
-	if err := services.AddExerciseToPlan(plan.ID, ex2.ID); err != nil {
+	if err := services.AddExerciseToPlan(context.Background(), plan.ID, ex2.ID); err != nil {
 		t.Fatal(err)
 	}
-	if err := services.AddExerciseToPlan(plan.ID, ex1.ID); err == nil || !strings.Contains(err.Error(), "already in plan") {
+	if err := services.AddExerciseToPlan(context.Background(), plan.ID, ex1.ID); err == nil || !strings.Contains(err.Error(), "already in plan") {
 		t.Fatalf("expected duplicate error, got %v", err)
 	}
-	if err := services.ReorderPlanExercises(plan.ID, []uint{ex2.ID, ex1.ID}); err != nil {
+	if err := services.ReorderPlanExercises(context.Background(), plan.ID, []uint{ex2.ID, ex1.ID}); err != nil {
 		t.Fatal(err)
 	}
-	loaded, err := services.LoadPlanWithOrderedExercises(plan.ID)
+	loaded, err := services.LoadPlanWithOrderedExercises(context.Background(), plan.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(loaded.Exercises) != 2 || loaded.Exercises[0].Name != "B" || loaded.Exercises[1].Name != "A" {
 		t.Fatalf("order %+v", loaded.Exercises)
 	}
-	if err := services.RemoveExerciseFromPlan(plan.ID, ex2.ID); err != nil {
+	if err := services.RemoveExerciseFromPlan(context.Background(), plan.ID, ex2.ID); err != nil {
 		t.Fatal(err)
 	}
-	loaded, err = services.LoadPlanWithOrderedExercises(plan.ID)
+	loaded, err = services.LoadPlanWithOrderedExercises(context.Background(), plan.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -61,20 +62,20 @@ func TestAssignPlanToDay_andUnassign(t *testing.T) {
 	if err := db.Create(&plan).Error; err != nil {
 		t.Fatal(err)
 	}
-	today := utils.ZerodTime(0)
+	today := utils.Today(context.Background(), 0)
 	dow := int(today.Weekday())
-	assigned, err := services.AssignPlanToDay(plan.ID, dow)
+	assigned, err := services.AssignPlanToDay(context.Background(), plan.ID, dow)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if assigned.DayOfWeek == nil || *assigned.DayOfWeek != dow {
 		t.Fatalf("got %+v", assigned.DayOfWeek)
 	}
-	byDay, err := services.GetPlanByDay(dow)
+	byDay, err := services.GetPlanByDay(context.Background(), dow)
 	if err != nil || byDay == nil || byDay.ID != plan.ID {
 		t.Fatalf("GetPlanByDay got %+v err=%v", byDay, err)
 	}
-	unassigned, err := services.UnassignPlanFromDay(plan.ID)
+	unassigned, err := services.UnassignPlanFromDay(context.Background(), plan.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -85,7 +86,7 @@ func TestAssignPlanToDay_andUnassign(t *testing.T) {
 
 func TestAssignPlanToDay_rejectsInvalidDay(t *testing.T) {
 	testutil.SetupTestDB(t)
-	_, err := services.AssignPlanToDay(1, 9)
+	_, err := services.AssignPlanToDay(context.Background(), 1, 9)
 	if err == nil || !strings.Contains(err.Error(), "day_of_week") {
 		t.Fatalf("expected day_of_week error, got %v", err)
 	}
@@ -97,7 +98,7 @@ func TestSetPlannedCardio(t *testing.T) {
 	if err := db.Create(&plan).Error; err != nil {
 		t.Fatal(err)
 	}
-	updated, err := services.SetPlannedCardio(plan.ID, "  Row  ", 30)
+	updated, err := services.SetPlannedCardio(context.Background(), plan.ID, "  Row  ", 30)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -112,7 +113,7 @@ func TestSetPlannedMobility(t *testing.T) {
 	if err := db.Create(&plan).Error; err != nil {
 		t.Fatal(err)
 	}
-	updated, err := services.SetPlannedMobility(
+	updated, err := services.SetPlannedMobility(context.Background(),
 		plan.ID,
 		[]string{" Leg swings ", "", "leg swings", "Arm circles"},
 		[]string{"Hamstring stretch", " Quad stretch "},
@@ -126,7 +127,7 @@ func TestSetPlannedMobility(t *testing.T) {
 	if !slices.Equal(updated.PostMobilityItems, []string{"Hamstring stretch", "Quad stretch"}) {
 		t.Fatalf("static stretching got %#v", updated.PostMobilityItems)
 	}
-	cleared, err := services.SetPlannedMobility(plan.ID, nil, nil)
+	cleared, err := services.SetPlannedMobility(context.Background(), plan.ID, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -143,7 +144,7 @@ func TestGetAllWorkoutPlans(t *testing.T) {
 	if err := db.Create(&models.WorkoutPlan{Name: "Two"}).Error; err != nil {
 		t.Fatal(err)
 	}
-	plans, err := services.GetAllWorkoutPlans()
+	plans, err := services.GetAllWorkoutPlans(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3,6 +3,7 @@ package services
 import (
 	"be-simpletracker/internal/core/workout/models"
 	workoutrepo "be-simpletracker/internal/core/workout/repository"
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/utils"
 	"context"
 	"errors"
@@ -14,7 +15,7 @@ import (
 )
 
 func GetOrCreateToday(ctx context.Context, offset int) (models.WorkoutLog, error) {
-	day := utils.ZerodTime(offset)
+	day := utils.Today(ctx, offset)
 	workoutDay, err := workoutrepo.LoadByDate(ctx, day)
 	if err == nil {
 		return workoutDay, nil
@@ -22,7 +23,7 @@ func GetOrCreateToday(ctx context.Context, offset int) (models.WorkoutLog, error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return models.WorkoutLog{}, err
 	}
-	plan, err := GetPlanByDay(int(day.Weekday()))
+	plan, err := GetPlanByDay(ctx, int(day.Weekday()))
 	if err != nil {
 		return models.WorkoutLog{}, err
 	}
@@ -69,13 +70,13 @@ type ExerciseGroup struct {
 }
 
 type MonthRange struct {
-	Start time.Time `json:"start"`
-	End   time.Time `json:"end"`
+	Start database.Date `json:"start"`
+	End   database.Date `json:"end"`
 }
 
 type MonthWorkoutLogsResponse struct {
 	Days   []models.WorkoutLog `json:"days"`
-	Today  time.Time           `json:"today"`
+	Today  database.Date       `json:"today"`
 	Range  MonthRange          `json:"range"`
 	Month  time.Month          `json:"month"`
 	Offset int                 `json:"offset"`
@@ -183,12 +184,13 @@ func plannedCardioFromPlan(plan *models.WorkoutPlan) any {
 }
 
 func GetMonthWorkoutLogs(ctx context.Context, monthOffset int) (MonthWorkoutLogsResponse, error) {
-	today := time.Now()
-	target := today.AddDate(0, monthOffset, 0)
-	startOfMonth := time.Date(target.Year(), target.Month(), 1, 0, 0, 0, 0, target.Location())
-	endOfMonth := startOfMonth.AddDate(0, 1, -1)
-	start := startOfMonth.AddDate(0, 0, -int(startOfMonth.Weekday()))
-	end := endOfMonth.AddDate(0, 0, 7-int(endOfMonth.Weekday()))
+	cal := utils.CalendarFrom(ctx)
+	today := utils.Today(ctx, 0)
+	target := today.AddMonths(monthOffset)
+	startOfMonth := database.Date{Year: target.Year, Month: target.Month, Day: 1}
+	endOfMonth := startOfMonth.AddMonths(1).AddDays(-1)
+	start := cal.StartOfWeek(startOfMonth)
+	end := cal.StartOfWeek(endOfMonth).AddDays(7)
 	data, err := workoutrepo.GetByDateRange(ctx, start, end)
 	if err != nil {
 		return MonthWorkoutLogsResponse{}, err
@@ -197,7 +199,7 @@ func GetMonthWorkoutLogs(ctx context.Context, monthOffset int) (MonthWorkoutLogs
 		Days:   data,
 		Today:  today,
 		Range:  MonthRange{Start: start, End: end},
-		Month:  target.Month(),
+		Month:  target.Month,
 		Offset: monthOffset,
 	}, nil
 }
@@ -219,16 +221,13 @@ func GetWorkoutActivity(ctx context.Context, mode string, weeks int) (WorkoutAct
 	if mode != "year" && mode != "rolling" {
 		return WorkoutActivityResponse{}, ErrInvalidActivityMode
 	}
-	now := time.Now()
-	loc := now.Location()
-	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
-	var start time.Time
+	end := utils.Today(ctx, 0)
+	var start database.Date
 
 	switch mode {
 	case "year":
-		y := now.Year()
-		start = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
-		end = time.Date(y, 12, 31, 0, 0, 0, 0, loc)
+		start = database.Date{Year: end.Year, Month: time.January, Day: 1}
+		end = database.Date{Year: end.Year, Month: time.December, Day: 31}
 	case "rolling":
 		w := weeks
 		if w < 1 {
@@ -237,7 +236,7 @@ func GetWorkoutActivity(ctx context.Context, mode string, weeks int) (WorkoutAct
 		if w > maxActivityWeeks {
 			w = maxActivityWeeks
 		}
-		start = end.AddDate(0, 0, -(w*7 - 1))
+		start = end.AddDays(-(w*7 - 1))
 	}
 
 	dates, err := workoutrepo.DatesWithLoggedSets(ctx, start, end)
@@ -246,8 +245,7 @@ func GetWorkoutActivity(ctx context.Context, mode string, weeks int) (WorkoutAct
 	}
 	active := make([]string, 0, len(dates))
 	for _, d := range dates {
-		d = d.In(loc)
-		active = append(active, time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc).Format("2006-01-02"))
+		active = append(active, d.String())
 	}
 	return WorkoutActivityResponse{
 		ActiveDates: active,
@@ -374,7 +372,7 @@ func UpsertMobilityPre(ctx context.Context, offset int, checked []string) (*Mobi
 	if err := workoutrepo.UpdatePreMobilityChecked(ctx, t.ID, filtered); err != nil {
 		return nil, err
 	}
-	reloaded, err := workoutrepo.LoadByDate(ctx, utils.ZerodTime(offset))
+	reloaded, err := workoutrepo.LoadByDate(ctx, utils.Today(ctx, offset))
 	if err != nil {
 		return nil, err
 	}
@@ -397,98 +395,102 @@ func UpsertMobilityPost(ctx context.Context, offset int, checked []string) (*Mob
 	if err := workoutrepo.UpdatePostMobilityChecked(ctx, t.ID, filtered); err != nil {
 		return nil, err
 	}
-	reloaded, err := workoutrepo.LoadByDate(ctx, utils.ZerodTime(offset))
+	reloaded, err := workoutrepo.LoadByDate(ctx, utils.Today(ctx, offset))
 	if err != nil {
 		return nil, err
 	}
 	return loggedPostMobilityView(reloaded.WorkoutPlan, &reloaded), nil
 }
 
-func LogExercise(exercise *models.LoggedExercise) error {
-	return workoutrepo.CreateLoggedExercise(exercise)
+func LogExercise(ctx context.Context, exercise *models.LoggedExercise) error {
+	return workoutrepo.CreateLoggedExercise(ctx, exercise)
 }
 
-func UpdateLoggedExercise(exercise models.LoggedExercise) error {
-	return workoutrepo.UpdateLoggedExerciseWithSets(exercise)
+func UpdateLoggedExercise(ctx context.Context, exercise models.LoggedExercise) error {
+	return workoutrepo.UpdateLoggedExerciseWithSets(ctx, exercise)
 }
 
 func RemoveLoggedExerciseForDay(ctx context.Context, offset int, exerciseID uint) error {
-	return workoutrepo.RemoveLoggedExerciseForDay(ctx, utils.ZerodTime(offset), exerciseID)
+	return workoutrepo.RemoveLoggedExerciseForDay(ctx, utils.Today(ctx, offset), exerciseID)
 }
 
-func DeleteLoggedSet(setID uint) error {
-	return workoutrepo.DeleteLoggedSet(setID)
+func DeleteLoggedSet(ctx context.Context, setID uint) error {
+	return workoutrepo.DeleteLoggedSet(ctx, setID)
 }
 
-func GetAllExercises(excludeIDs []uint) ([]models.Exercise, error) {
-	return workoutrepo.FindAllExercises(excludeIDs)
+func GetAllExercises(ctx context.Context, excludeIDs []uint) ([]models.Exercise, error) {
+	return workoutrepo.FindAllExercises(ctx, excludeIDs)
 }
 
 type ExerciseProgressionEntry = workoutrepo.ExerciseProgressionEntry
 
-func GetExerciseProgression(exerciseID uint) ([]ExerciseProgressionEntry, error) {
-	return workoutrepo.GetExerciseProgression(exerciseID)
+func GetExerciseProgression(ctx context.Context, exerciseID uint) ([]ExerciseProgressionEntry, error) {
+	return workoutrepo.GetExerciseProgression(ctx, exerciseID)
 }
 
-func LoadPlanWithOrderedExercises(planID uint) (*models.WorkoutPlan, error) {
-	return workoutrepo.LoadPlanWithOrderedExercises(planID)
+func LoadPlanWithOrderedExercises(ctx context.Context, planID uint) (*models.WorkoutPlan, error) {
+	return workoutrepo.LoadPlanWithOrderedExercises(ctx, planID)
 }
 
-func GetAllWorkoutPlans() ([]models.WorkoutPlan, error) {
-	return workoutrepo.FindAllWorkoutPlans()
+func GetAllWorkoutPlans(ctx context.Context) ([]models.WorkoutPlan, error) {
+	return workoutrepo.FindAllWorkoutPlans(ctx)
 }
 
-func GetAllWorkoutPrograms() ([]models.WorkoutProgram, error) {
-	return workoutrepo.FindAllWorkoutPrograms()
+func GetAllWorkoutPrograms(ctx context.Context) ([]models.WorkoutProgram, error) {
+	return workoutrepo.FindAllWorkoutPrograms(ctx)
 }
 
-func CreateWorkoutProgram(name string) (*models.WorkoutProgram, error) {
+func CreateWorkoutProgram(ctx context.Context, name string) (*models.WorkoutProgram, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return nil, fmt.Errorf("program name is required")
 	}
 	program := &models.WorkoutProgram{Name: name}
-	if _, err := workoutrepo.FindActiveWorkoutProgram(); err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+	if _, err := workoutrepo.FindActiveWorkoutProgram(ctx); err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
 		program.IsActive = true
 	}
-	if err := workoutrepo.CreateWorkoutProgram(program); err != nil {
+	if err := workoutrepo.CreateWorkoutProgram(ctx, program); err != nil {
 		return nil, err
 	}
 	return program, nil
 }
 
-func CreateWorkoutPlan(programID uint, name string, dayOfWeek *int) (*models.WorkoutPlan, error) {
+func CreateWorkoutPlan(ctx context.Context, programID uint, name string, dayOfWeek *int) (*models.WorkoutPlan, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return nil, fmt.Errorf("plan name is required")
 	}
-	if _, err := workoutrepo.FindWorkoutProgramByID(programID); err != nil {
+	if _, err := workoutrepo.FindWorkoutProgramByID(ctx, programID); err != nil {
 		return nil, fmt.Errorf("program not found: %w", err)
 	}
 	if dayOfWeek != nil && (*dayOfWeek < 0 || *dayOfWeek > 6) {
 		return nil, fmt.Errorf("day_of_week must be between 0 and 6")
 	}
 	plan := &models.WorkoutPlan{Name: name, WorkoutProgramID: &programID}
-	if err := workoutrepo.CreateWorkoutPlan(plan); err != nil {
+	if err := workoutrepo.CreateWorkoutPlan(ctx, plan); err != nil {
 		return nil, err
 	}
 	if dayOfWeek != nil {
-		if _, err := AssignPlanToDay(plan.ID, *dayOfWeek); err != nil {
+		if _, err := AssignPlanToDay(ctx, plan.ID, *dayOfWeek); err != nil {
 			return nil, err
 		}
 	}
-	return workoutrepo.LoadPlanWithOrderedExercises(plan.ID)
+	return workoutrepo.LoadPlanWithOrderedExercises(ctx, plan.ID)
 }
 
-func RenameWorkoutProgram(id uint, name string) (*models.WorkoutProgram, error) {
+func GetWorkoutProgram(ctx context.Context, id uint) (models.WorkoutProgram, error) {
+	return workoutrepo.FindWorkoutProgramByID(ctx, id)
+}
+
+func RenameWorkoutProgram(ctx context.Context, id uint, name string) (*models.WorkoutProgram, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return nil, fmt.Errorf("program name is required")
 	}
-	if err := workoutrepo.UpdateWorkoutProgramName(id, name); err != nil {
+	if err := workoutrepo.UpdateWorkoutProgramName(ctx, id, name); err != nil {
 		return nil, err
 	}
-	program, err := workoutrepo.FindWorkoutProgramByID(id)
+	program, err := workoutrepo.FindWorkoutProgramByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -496,35 +498,35 @@ func RenameWorkoutProgram(id uint, name string) (*models.WorkoutProgram, error)
 }
 
 func ActivateWorkoutProgram(ctx context.Context, id uint) (*models.WorkoutProgram, error) {
-	if _, err := workoutrepo.FindWorkoutProgramByID(id); err != nil {
+	if _, err := workoutrepo.FindWorkoutProgramByID(ctx, id); err != nil {
 		return nil, fmt.Errorf("program not found: %w", err)
 	}
 	if _, err := GetOrCreateToday(ctx, 0); err != nil {
 		return nil, err
 	}
-	if err := workoutrepo.ActivateWorkoutProgram(id); err != nil {
+	if err := workoutrepo.ActivateWorkoutProgram(ctx, id); err != nil {
 		return nil, err
 	}
-	program, err := workoutrepo.FindWorkoutProgramByID(id)
+	program, err := workoutrepo.FindWorkoutProgramByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return &program, nil
 }
 
-func AddExerciseToPlan(planID uint, exerciseID uint) error {
-	return workoutrepo.AddExerciseToPlan(planID, exerciseID)
+func AddExerciseToPlan(ctx context.Context, planID uint, exerciseID uint) error {
+	return workoutrepo.AddExerciseToPlan(ctx, planID, exerciseID)
 }
 
-func RemoveExerciseFromPlan(planID uint, exerciseID uint) error {
-	return workoutrepo.RemoveExerciseFromPlan(planID, exerciseID)
+func RemoveExerciseFromPlan(ctx context.Context, planID uint, exerciseID uint) error {
+	return workoutrepo.RemoveExerciseFromPlan(ctx, planID, exerciseID)
 }
 
-func ReorderPlanExercises(planID uint, exerciseIDs []uint) error {
-	return workoutrepo.ReorderPlanExercises(planID, exerciseIDs)
+func ReorderPlanExercises(ctx context.Context, planID uint, exerciseIDs []uint) error {
+	return workoutrepo.ReorderPlanExercises(ctx, planID, exerciseIDs)
 }
 
-func CreateExercise(name string, repRollover uint, cues string, loadTypes ...models.ExerciseLoadType) (*models.Exercise, error) {
+func CreateExercise(ctx context.Context, name string, repRollover uint, cues string, loadTypes ...models.ExerciseLoadType) (*models.Exercise, error) {
 	loadType := models.ExerciseLoadType("")
 	if len(loadTypes) > 0 {
 		loadType = loadTypes[0]
@@ -535,93 +537,97 @@ func CreateExercise(name string, repRollover uint, cues string, loadTypes ...mod
 		Cues:        cues,
 		LoadType:    models.NormalizeExerciseLoadType(loadType),
 	}
-	if err := workoutrepo.CreateExercise(&exercise); err != nil {
+	if err := workoutrepo.CreateExercise(ctx, &exercise); err != nil {
 		return nil, err
 	}
 	return &exercise, nil
 }
 
-func UpdateExercise(id uint, name string, repRollover uint, cues string, loadTypes ...models.ExerciseLoadType) (*models.Exercise, error) {
-	return workoutrepo.UpdateExercise(id, name, repRollover, cues, loadTypes...)
+func GetExercise(ctx context.Context, id uint) (models.Exercise, error) {
+	return workoutrepo.FindExerciseByID(ctx, id)
+}
+
+func UpdateExercise(ctx context.Context, id uint, name string, repRollover uint, cues string, loadTypes ...models.ExerciseLoadType) (*models.Exercise, error) {
+	return workoutrepo.UpdateExercise(ctx, id, name, repRollover, cues, loadTypes...)
 }
 
-func AssignPlanToDay(planID uint, dayOfWeek int) (*models.WorkoutPlan, error) {
+func AssignPlanToDay(ctx context.Context, planID uint, dayOfWeek int) (*models.WorkoutPlan, error) {
 	if dayOfWeek < 0 || dayOfWeek > 6 {
 		return nil, fmt.Errorf("day_of_week must be between 0 (Sunday) and 6 (Saturday)")
 	}
-	plan, err := workoutrepo.FindWorkoutPlanByID(planID)
+	plan, err := workoutrepo.FindWorkoutPlanByID(ctx, planID)
 	if err != nil {
 		return nil, fmt.Errorf("plan not found: %w", err)
 	}
 	if plan.WorkoutProgramID == nil {
-		program, err := workoutrepo.FindActiveWorkoutProgram()
+		program, err := workoutrepo.FindActiveWorkoutProgram(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("active program not found: %w", err)
 		}
-		if err := workoutrepo.AssignWorkoutPlanToProgram(planID, program.ID); err != nil {
+		if err := workoutrepo.AssignWorkoutPlanToProgram(ctx, planID, program.ID); err != nil {
 			return nil, err
 		}
 		plan.WorkoutProgramID = &program.ID
 	}
-	if err := workoutrepo.UnassignOtherPlansFromProgramDay(*plan.WorkoutProgramID, dayOfWeek, planID); err != nil {
+	if err := workoutrepo.UnassignOtherPlansFromProgramDay(ctx, *plan.WorkoutProgramID, dayOfWeek, planID); err != nil {
 		return nil, fmt.Errorf("failed to unassign existing plan: %w", err)
 	}
-	if err := workoutrepo.AssignWorkoutPlanToDay(planID, dayOfWeek); err != nil {
+	if err := workoutrepo.AssignWorkoutPlanToDay(ctx, planID, dayOfWeek); err != nil {
 		return nil, fmt.Errorf("failed to assign plan to day: %w", err)
 	}
-	reloaded, err := workoutrepo.LoadPlanWithOrderedExercises(planID)
+	reloaded, err := workoutrepo.LoadPlanWithOrderedExercises(ctx, planID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reload plan: %w", err)
 	}
 	return reloaded, nil
 }
 
-func UnassignPlanFromDay(planID uint) (*models.WorkoutPlan, error) {
-	return unassignPlanFromDay(planID, nil)
+func UnassignPlanFromDay(ctx context.Context, planID uint) (*models.WorkoutPlan, error) {
+	return unassignPlanFromDay(ctx, planID, nil)
 }
 
-func UnassignPlanFromSpecificDay(planID uint, dayOfWeek int) (*models.WorkoutPlan, error) {
+func UnassignPlanFromSpecificDay(ctx context.Context, planID uint, dayOfWeek int) (*models.WorkoutPlan, error) {
 	if dayOfWeek < 0 || dayOfWeek > 6 {
 		return nil, fmt.Errorf("day_of_week must be between 0 (Sunday) and 6 (Saturday)")
 	}
-	return unassignPlanFromDay(planID, &dayOfWeek)
+	return unassignPlanFromDay(ctx, planID, &dayOfWeek)
 }
 
-func unassignPlanFromDay(planID uint, dayOfWeek *int) (*models.WorkoutPlan, error) {
-	if _, err := workoutrepo.FindWorkoutPlanByID(planID); err != nil {
+func unassignPlanFromDay(ctx context.Context, planID uint, dayOfWeek *int) (*models.WorkoutPlan, error) {
+	if _, err := workoutrepo.FindWorkoutPlanByID(ctx, planID); err != nil {
 		return nil, fmt.Errorf("plan not found: %w", err)
 	}
 	var err error
 	if dayOfWeek == nil {
-		err = workoutrepo.ClearWorkoutPlanDay(planID)
+		err = workoutrepo.ClearWorkoutPlanDay(ctx, planID)
 	} else {
-		err = workoutrepo.ClearWorkoutPlanDayOfWeek(planID, *dayOfWeek)
+		err = workoutrepo.ClearWorkoutPlanDayOfWeek(ctx, planID, *dayOfWeek)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to unassign plan from day: %w", err)
 	}
-	reloaded, err := workoutrepo.LoadPlanWithOrderedExercises(planID)
+	reloaded, err := workoutrepo.LoadPlanWithOrderedExercises(ctx, planID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reload plan: %w", err)
 	}
 	return reloaded, nil
 }
 
-func GetPlanByDay(dayOfWeek int) (*models.WorkoutPlan, error) {
+func GetPlanByDay(ctx context.Context, dayOfWeek int) (*models.WorkoutPlan, error) {
 	if dayOfWeek < 0 || dayOfWeek > 6 {
 		return nil, fmt.Errorf("day_of_week must be between 0 (Sunday) and 6 (Saturday)")
 	}
-	program, err := workoutrepo.FindActiveWorkoutProgram()
+	program, err := workoutrepo.FindActiveWorkoutProgram(ctx)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
 		return nil, err
 	}
-	plan, err := workoutrepo.FindWorkoutPlanByProgramAndDay(program.ID, dayOfWeek)
+	plan, err := workoutrepo.FindWorkoutPlanByProgramAndDay(ctx, program.ID, dayOfWeek)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			legacy, legacyErr := workoutrepo.FindWorkoutPlanByDayOfWeek(dayOfWeek)
+			legacy, legacyErr := workoutrepo.FindWorkoutPlanByDayOfWeek(ctx, dayOfWeek)
 			if legacyErr == gorm.ErrRecordNotFound {
 				return nil, nil
 			}
@@ -629,7 +635,7 @@ func GetPlanByDay(dayOfWeek int) (*models.WorkoutPlan, error) {
 				return nil, legacyErr
 			}
 			if legacy.WorkoutProgramID == nil {
-				if err := workoutrepo.AssignWorkoutPlanToProgram(legacy.ID, program.ID); err != nil {
+				if err := workoutrepo.AssignWorkoutPlanToProgram(ctx, legacy.ID, program.ID); err != nil {
 					return nil, err
 				}
 			}
@@ -638,24 +644,24 @@ func GetPlanByDay(dayOfWeek int) (*models.WorkoutPlan, error) {
 			return nil, err
 		}
 	}
-	return workoutrepo.LoadPlanWithOrderedExercises(plan.ID)
+	return workoutrepo.LoadPlanWithOrderedExercises(ctx, plan.ID)
 }
 
-func UpdateExerciseCues(exerciseID uint, cues string) (*models.Exercise, error) {
-	return workoutrepo.UpdateExerciseCues(exerciseID, cues)
+func UpdateExerciseCues(ctx context.Context, exerciseID uint, cues string) (*models.Exercise, error) {
+	return workoutrepo.UpdateExerciseCues(ctx, exerciseID, cues)
 }
 
 type ExerciseListResult = workoutrepo.ExerciseListResult
 
-func ListExercises(page, pageSize int, search string) (ExerciseListResult, error) {
-	return workoutrepo.ListExercises(page, pageSize, search)
+func ListExercises(ctx context.Context, page, pageSize int, search string) (ExerciseListResult, error) {
+	return workoutrepo.ListExercises(ctx, page, pageSize, search)
 }
 
-func LoadLoggedExercise(id uint) (models.LoggedExercise, error) {
-	return workoutrepo.LoadLoggedExercise(id)
+func LoadLoggedExercise(ctx context.Context, id uint) (models.LoggedExercise, error) {
+	return workoutrepo.LoadLoggedExercise(ctx, id)
 }
 
-func SetPlannedCardio(planID uint, cardioType string, minutes int) (*models.WorkoutPlan, error) {
+func SetPlannedCardio(ctx context.Context, planID uint, cardioType string, minutes int) (*models.WorkoutPlan, error) {
 	if minutes < 0 {
 		return nil, errors.New("planned cardio minutes cannot be negative")
 	}
@@ -663,13 +669,13 @@ func SetPlannedCardio(planID uint, cardioType string, minutes int) (*models.Work
 	if cardioType == "" {
 		minutes = 0
 	}
-	if err := workoutrepo.UpdatePlannedCardio(planID, cardioType, minutes); err != nil {
+	if err := workoutrepo.UpdatePlannedCardio(ctx, planID, cardioType, minutes); err != nil {
 		return nil, err
 	}
-	return workoutrepo.LoadPlanWithOrderedExercises(planID)
+	return workoutrepo.LoadPlanWithOrderedExercises(ctx, planID)
 }
 
-func SetPlannedMobility(planID uint, preItems, postItems []string) (*models.WorkoutPlan, error) {
+func SetPlannedMobility(ctx context.Context, planID uint, preItems, postItems []string) (*models.WorkoutPlan, error) {
 	normalize := func(items []string) []string {
 		result := make([]string, 0, len(items))
 		seen := make(map[string]struct{}, len(items))
@@ -689,8 +695,8 @@ func SetPlannedMobility(planID uint, preItems, postItems []string) (*models.Work
 	}
 	preItems = normalize(preItems)
 	postItems = normalize(postItems)
-	if err := workoutrepo.UpdateMobilityItems(planID, preItems, postItems); err != nil {
+	if err := workoutrepo.UpdateMobilityItems(ctx, planID, preItems, postItems); err != nil {
 		return nil, err
 	}
-	return workoutrepo.LoadPlanWithOrderedExercises(planID)
+	return workoutrepo.LoadPlanWithOrderedExercises(ctx, planID)
 }
@@ -28,7 +28,7 @@ func TestActiveWorkoutProgramResolvesItsOwnWeek(t *testing.T) {
 	if err := db.Create(&otherPlan).Error; err != nil {
 		t.Fatal(err)
 	}
-	plan, err := services.GetPlanByDay(day)
+	plan, err := services.GetPlanByDay(context.Background(), day)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -81,13 +81,13 @@ func TestWorkoutPlanCanBeAssignedToMultipleWeekdays(t *testing.T) {
 	if err := db.Create(&plan).Error; err != nil {
 		t.Fatal(err)
 	}
-	if _, err := services.AssignPlanToDay(plan.ID, 1); err != nil {
+	if _, err := services.AssignPlanToDay(context.Background(), plan.ID, 1); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := services.AssignPlanToDay(plan.ID, 4); err != nil {
+	if _, err := services.AssignPlanToDay(context.Background(), plan.ID, 4); err != nil {
 		t.Fatal(err)
 	}
-	loaded, err := services.LoadPlanWithOrderedExercises(plan.ID)
+	loaded, err := services.LoadPlanWithOrderedExercises(context.Background(), plan.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -106,13 +106,13 @@ func TestUnassignWorkoutPlanFromSpecificWeekdayKeepsOtherAssignments(t *testing.
 	if err := db.Create(&plan).Error; err != nil {
 		t.Fatal(err)
 	}
-	if _, err := services.AssignPlanToDay(plan.ID, 1); err != nil {
+	if _, err := services.AssignPlanToDay(context.Background(), plan.ID, 1); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := services.AssignPlanToDay(plan.ID, 4); err != nil {
+	if _, err := services.AssignPlanToDay(context.Background(), plan.ID, 4); err != nil {
 		t.Fatal(err)
 	}
-	loaded, err := services.UnassignPlanFromSpecificDay(plan.ID, 1)
+	loaded, err := services.UnassignPlanFromSpecificDay(context.Background(), plan.ID, 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -123,11 +123,11 @@ func TestUnassignWorkoutPlanFromSpecificWeekdayKeepsOtherAssignments(t *testing.
 
 func TestCreateWorkoutPlanWithoutDayCreatesUnassignedRoutine(t *testing.T) {
 	testutil.SetupTestDB(t)
-	program, err := services.CreateWorkoutProgram("New Split")
+	program, err := services.CreateWorkoutProgram(context.Background(), "New Split")
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan, err := services.CreateWorkoutPlan(program.ID, "Push", nil)
+	plan, err := services.CreateWorkoutPlan(context.Background(), program.ID, "Push", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3,18 +3,15 @@ package testutil
 import (
 	"be-simpletracker/internal/core/workout/models"
 	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/dbtest"
 	"testing"
 
-	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
 func SetupTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatal(err)
-	}
+	db := dbtest.Open(t)
 	if err := db.AutoMigrate(
 		&models.Exercise{},
 		&models.WorkoutProgram{},
@@ -2,13 +2,16 @@ package workout
 
 import (
 	"be-simpletracker/internal/core/workout/controller"
+	"be-simpletracker/internal/idempotency"
 	"be-simpletracker/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+func RegisterRoutes(router *gin.Engine, db *gorm.DB, authMiddleware, ifMatch gin.HandlerFunc) {
 	dayOffsetMiddleware := utils.DayOffsetMiddleware()
+	idempotent := idempotency.Middleware(db)
 
 	group := router.Group("/workout", authMiddleware)
 	{
@@ -16,7 +19,7 @@ func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
 		{
 			programs.GET("", controller.GetAllWorkoutPrograms)
 			programs.POST("", controller.CreateWorkoutProgram)
-			programs.PATCH("/:id", controller.RenameWorkoutProgram)
+			programs.PATCH("/:id", ifMatch, controller.RenameWorkoutProgram)
 			programs.POST("/:id/activate", controller.ActivateWorkoutProgram)
 			programs.POST("/:id/plans", controller.CreateWorkoutPlan)
 		}
@@ -35,10 +38,12 @@ func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
 		{
 			exercises.GET("/all", controller.GetAllExercises)
 			exercises.POST("", controller.CreateExercise)
-			exercises.PUT("/:id", controller.UpdateExercise)
-			exercises.PUT("/:id/cues", controller.UpdateExerciseCues)
-			exercises.POST("/log", controller.LogExercise)
-			exercises.POST("/add", dayOffsetMiddleware, controller.AddExerciseToWorkout)
+			exercises.PUT("/:id", ifMatch, controller.UpdateExercise)
+			exercises.PUT("/:id/cues", ifMatch, controller.UpdateExerciseCues)
+			// Logging from a previous workout creates the set, which has no ETag to match yet,
+			// so If-Match is only checked here when the client sends one.
+			exercises.POST("/log", utils.IfMatchMiddleware(false), idempotent, controller.LogExercise)
+			exercises.POST("/add", dayOffsetMiddleware, idempotent, controller.AddExerciseToWorkout)
 			exercises.DELETE("/remove", dayOffsetMiddleware, controller.RemoveExerciseFromWorkout)
 			exercises.DELETE("/sets/:id", controller.DeleteLoggedSet)
 			exercises.GET("/progression/:id", controller.GetExerciseProgression)
@@ -49,9 +54,9 @@ func RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
 			logs.GET("/month", controller.GetWorkoutMonth)
 			logs.GET("/previous", controller.GetPreviousWorkout)
 			logs.GET("/activity", controller.GetWorkoutActivity)
-			logs.POST("/cardio", controller.UpsertCardio)
-			logs.POST("/mobility/pre", controller.UpsertMobilityPre)
-			logs.POST("/mobility/post", controller.UpsertMobilityPost)
+			logs.POST("/cardio", idempotent, controller.UpsertCardio)
+			logs.POST("/mobility/pre", idempotent, controller.UpsertMobilityPre)
+			logs.POST("/mobility/post", idempotent, controller.UpsertMobilityPost)
 			logs.PATCH("/switch-plan", controller.SwitchPlan)
 		}
 	}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDateJSON(t *testing.T) {
+	var v struct {
+		Date Date `json:"date"`
+	}
+	if err := json.Unmarshal([]byte(`{"date":"2026-03-29"}`), &v); err != nil || v.Date != (Date{2026, time.March, 29}) {
+		t.Fatalf("date = %v, %v", v.Date, err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil || string(out) != `{"date":"2026-03-29"}` {
+		t.Fatalf("marshal = %s, %v", out, err)
+	}
+	// A timestamp keeps the day it was written on, whatever its offset.
+	if err := json.Unmarshal([]byte(`{"date":"2026-03-29T00:00:00+02:00"}`), &v); err != nil || v.Date.Day != 29 {
+		t.Fatalf("timestamp = %v, %v", v.Date, err)
+	}
+	if err := json.Unmarshal([]byte(`{"date":"29/03/2026"}`), &v); err == nil {
+		t.Fatal("accepted a date that is not YYYY-MM-DD")
+	}
+	if out, _ := json.Marshal(Date{}); string(out) != "null" {
+		t.Fatalf("zero date = %s", out)
+	}
+}
+
+func TestDateScan(t *testing.T) {
+	want := Date{2026, time.October, 25}
+	berlin := time.FixedZone("CEST", 2*60*60)
+	for _, src := range []any{
+		"2026-10-25",
+		[]byte("2026-10-25"),
+		"2026-10-25 00:00:00+02:00",
+		time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 25, 0, 0, 0, 0, berlin),
+	} {
+		var d Date
+		if err := d.Scan(src); err != nil || d != want {
+			t.Errorf("Scan(%v) = %v, %v", src, d, err)
+		}
+	}
+	var d Date
+	if err := d.Scan(nil); err != nil || !d.IsZero() {
+		t.Errorf("Scan(nil) = %v, %v", d, err)
+	}
+}
+
+func TestDateArithmetic(t *testing.T) {
+	d := Date{2026, time.December, 31}
+	if got := d.AddDays(1); got != (Date{2027, time.January, 1}) {
+		t.Errorf("AddDays = %v", got)
+	}
+	if got := (Date{2026, time.January, 31}).AddMonths(1); got != (Date{2026, time.March, 3}) {
+		t.Errorf("AddMonths = %v", got)
+	}
+	if !d.After(Date{2026, time.December, 30}) || d.Before(d) {
+		t.Error("comparison")
+	}
+}
@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// lockKey is the Postgres advisory lock id shared by every instance migrating this schema.
+const lockKey int64 = 0x73696d706c6574 // "simplet"
+
+type dialect struct {
+	name         string
+	createTable  string
+	systemTables []string
+	placeholder  func(n int) string
+	lock         func(ctx context.Context, conn *sql.Conn) error
+	unlock       func(ctx context.Context, conn *sql.Conn) error
+}
+
+func dialectFor(name string) (dialect, error) {
+	switch name {
+	case "postgres":
+		return dialect{
+			name: "postgres",
+			createTable: `CREATE TABLE IF NOT EXISTS ` + table + ` (
+				module TEXT NOT NULL DEFAULT '',
+				version BIGINT NOT NULL,
+				name TEXT NOT NULL,
+				checksum TEXT NOT NULL,
+				applied_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY (module, version)
+			)`,
+			placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+			lock: func(ctx context.Context, conn *sql.Conn) error {
+				_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey)
+				return err
+			},
+			unlock: func(ctx context.Context, conn *sql.Conn) error {
+				_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+				return err
+			},
+		}, nil
+	case "sqlite":
+		// SQLite serialises writers itself; a file database has one migrating process at a time.
+		noop := func(context.Context, *sql.Conn) error { return nil }
+		return dialect{
+			name: "sqlite",
+			createTable: `CREATE TABLE IF NOT EXISTS ` + table + ` (
+				module TEXT NOT NULL DEFAULT '',
+				version INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				checksum TEXT NOT NULL,
+				applied_at DATETIME NOT NULL,
+				PRIMARY KEY (module, version)
+			)`,
+			systemTables: []string{"sqlite_sequence"},
+			placeholder:  func(int) string { return "?" },
+			lock:         noop,
+			unlock:       noop,
+		}, nil
+	default:
+		return dialect{}, fmt.Errorf("migrations are not supported for driver %q", name)
+	}
+}
@@ -0,0 +1,548 @@
+// Package migrate applies the versioned SQL files in internal/database/migrations and those
+// each module keeps for its own tables.
+//
+// Files are named NNN_name.up.sql and NNN_name.down.sql. The core schema and every module's
+// set share one version sequence, so a version can appear in several sets when one change
+// touched tables of each. Every applied migration is recorded in the migrations table
+// by set and version with a checksum of its up file, each file runs in its own transaction,
+// and on Postgres the whole run holds an advisory lock so concurrent instances apply
+// migrations one at a time.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const table = "migrations"
+
+var (
+	ErrNeedsBaseline    = errors.New("database has tables but no migration history; run `migration baseline` once")
+	ErrChecksumMismatch = errors.New("applied migration file has changed")
+	ErrMissingFile      = errors.New("applied migration has no file")
+	ErrNoDown           = errors.New("migration has no down file")
+	ErrOutOfOrder       = errors.New("pending migration is older than the latest applied one")
+	ErrHasHistory       = errors.New("database already has migration history")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Set is the migrations of one owner of tables: the core schema, or a module.
+type Set struct {
+	// Module is empty for the core schema.
+	Module string
+	FS     fs.FS
+	// Disabled sets are never applied. The runner still reads them, to check the history
+	// they already have.
+	Disabled bool
+}
+
+// Migration is one version read from disk.
+type Migration struct {
+	// Module is the set the migration belongs to, empty for the core schema.
+	Module   string
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+func (m Migration) String() string {
+	return label(m.Module, m.Version, m.Name)
+}
+
+func label(module string, version int, name string) string {
+	if module == "" {
+		return fmt.Sprintf("%03d_%s", version, name)
+	}
+	return fmt.Sprintf("%s/%03d_%s", module, version, name)
+}
+
+// Status describes a migration file, the history row for it, or both.
+type Status struct {
+	Module    string
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Modified is set when the file no longer matches the checksum recorded when it ran.
+	Modified bool
+	// Missing is set when the history has a version that no file provides.
+	Missing bool
+}
+
+func (s Status) String() string {
+	return label(s.Module, s.Version, s.Name)
+}
+
+type applied struct {
+	module    string
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Load reads the migrations in fsys ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected file %q in migrations", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %03d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Runner applies one driver's migrations to a database.
+type Runner struct {
+	db      *gorm.DB
+	dialect dialect
+	// migrations are those of the enabled sets in the order Up applies them: by version, and
+	// the core schema before the modules within one.
+	migrations []Migration
+	// known adds the disabled sets' migrations.
+	known    []Migration
+	disabled map[string]bool
+}
+
+// New loads the migrations for db's driver from the matching subdirectory of fsys, which
+// holds the core schema, and of each module set.
+func New(db *gorm.DB, fsys fs.FS, modules ...Set) (*Runner, error) {
+	d, err := dialectFor(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	r := &Runner{db: db, dialect: d, disabled: map[string]bool{}}
+	for _, set := range append([]Set{{FS: fsys}}, modules...) {
+		sub, err := fs.Sub(set.FS, d.name)
+		if err != nil {
+			return nil, err
+		}
+		loaded, err := Load(sub)
+		if err != nil {
+			return nil, fmt.Errorf("%s migrations: %w", cmp.Or(set.Module, "core"), err)
+		}
+		for i := range loaded {
+			loaded[i].Module = set.Module
+		}
+		r.known = append(r.known, loaded...)
+		if set.Disabled {
+			r.disabled[set.Module] = true
+		} else {
+			r.migrations = append(r.migrations, loaded...)
+		}
+	}
+	byVersion := func(a, b Migration) int { return a.Version - b.Version }
+	slices.SortStableFunc(r.migrations, byVersion)
+	slices.SortStableFunc(r.known, byVersion)
+	return r, nil
+}
+
+// Migrations returns the enabled sets' migrations in the order Up applies them.
+func (r *Runner) Migrations() []Migration {
+	return r.migrations
+}
+
+// Up applies pending migrations up to and including target, or all of them when target is 0.
+func (r *Runner) Up(ctx context.Context, target int) ([]Migration, error) {
+	var ran []Migration
+	err := r.locked(ctx, func(conn *sql.Conn) error {
+		history, err := r.history(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := r.verify(history); err != nil {
+			return err
+		}
+		if len(history) == 0 {
+			if err := r.requireEmpty(); err != nil {
+				return err
+			}
+		}
+		// A module enabled later starts from its first migration, so order only matters
+		// within a set.
+		latest := map[string]int{}
+		done := map[key]bool{}
+		for _, h := range history {
+			done[key{h.module, h.version}] = true
+			latest[h.module] = max(latest[h.module], h.version)
+		}
+		for _, m := range r.migrations {
+			if done[key{m.Module, m.Version}] || (target > 0 && m.Version > target) {
+				continue
+			}
+			if m.Version < latest[m.Module] {
+				return fmt.Errorf("%w: %s", ErrOutOfOrder, m)
+			}
+			if err := r.apply(ctx, conn, m.Up, func(tx *sql.Tx) error {
+				return r.record(ctx, tx, m)
+			}); err != nil {
+				return fmt.Errorf("%s: %w", m, err)
+			}
+			ran = append(ran, m)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Down rolls back the most recent steps migrations applied from enabled sets, newest first.
+func (r *Runner) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var ran []Migration
+	err := r.locked(ctx, func(conn *sql.Conn) error {
+		history, err := r.history(ctx, conn)
+		if err != nil {
+			return err
+		}
+		history = slices.DeleteFunc(history, func(h applied) bool { return r.disabled[h.module] })
+		slices.Reverse(history)
+		for _, h := range history[:min(steps, len(history))] {
+			m, ok := r.find(h.module, h.version)
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrMissingFile, label(h.module, h.version, h.name))
+			}
+			if m.Down == "" {
+				return fmt.Errorf("%w: %s", ErrNoDown, m)
+			}
+			if err := r.apply(ctx, conn, m.Down, func(tx *sql.Tx) error {
+				p := r.dialect.placeholder
+				_, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE module = "+p(1)+" AND version = "+p(2), m.Module, m.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("%s: %w", m, err)
+			}
+			ran = append(ran, m)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Baseline records the enabled sets' migrations up to version (all of them when 0) as applied
+// without running them, for a database whose schema was already created another way.
+func (r *Runner) Baseline(ctx context.Context, version int) ([]Migration, error) {
+	var recorded []Migration
+	err := r.locked(ctx, func(conn *sql.Conn) error {
+		history, err := r.history(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(history) > 0 {
+			return ErrHasHistory
+		}
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		for _, m := range r.migrations {
+			if version > 0 && m.Version > version {
+				break
+			}
+			if err := r.record(ctx, tx, m); err != nil {
+				return err
+			}
+			recorded = append(recorded, m)
+		}
+		return tx.Commit()
+	})
+	return recorded, err
+}
+
+// CreateMissing brings a database whose tables were made before the runner, by the AutoMigrate
+// setup it replaced, up to the schema of each enabled set's first migration: it adds the
+// tables, columns and indexes those migrations create and the database lacks, and leaves
+// everything else as it is. Call it before Baseline records them as applied.
+func (r *Runner) CreateMissing(ctx context.Context) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seen := map[string]bool{}
+		for _, m := range r.migrations {
+			if seen[m.Module] {
+				continue
+			}
+			seen[m.Module] = true
+			if err := createMissing(tx, m.Up); err != nil {
+				return fmt.Errorf("%s: %w", m, err)
+			}
+		}
+		return nil
+	})
+}
+
+func createMissing(tx *gorm.DB, script string) error {
+	for _, stmt := range statements(script) {
+		match := createTable.FindStringSubmatch(stmt)
+		if match == nil || !tx.Migrator().HasTable(match[1]) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		name := match[1]
+		for _, line := range strings.Split(match[2], "\n") {
+			line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+			column := columnDef.FindStringSubmatch(line)
+			if column == nil || tx.Migrator().HasColumn(name, column[1]) {
+				continue
+			}
+			if err := tx.Exec(`ALTER TABLE "` + name + `" ADD COLUMN ` + line).Error; err != nil {
+				return fmt.Errorf("%s.%s: %w", name, column[1], err)
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	createTable = regexp.MustCompile(`(?s)^CREATE TABLE "(\w+)" \((.*)\)$`)
+	columnDef   = regexp.MustCompile(`^"(\w+)" `)
+)
+
+// statements splits a migration file into its statements, which end with a semicolon at the
+// end of a line, dropping comment lines.
+func statements(script string) []string {
+	var out []string
+	for _, stmt := range strings.Split(script, ";\n") {
+		var lines []string
+		for _, line := range strings.Split(stmt, "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+				lines = append(lines, line)
+			}
+		}
+		if stmt := strings.TrimSuffix(strings.TrimSpace(strings.Join(lines, "\n")), ";"); stmt != "" {
+			out = append(out, stmt)
+		}
+	}
+	return out
+}
+
+// Status lists every migration of the enabled sets, applied or not, and any applied one this
+// build has no file for, in order.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := r.locked(ctx, func(conn *sql.Conn) error {
+		history, err := r.history(ctx, conn)
+		if err != nil {
+			return err
+		}
+		byKey := map[key]applied{}
+		for _, h := range history {
+			byKey[key{h.module, h.version}] = h
+		}
+		for _, m := range r.migrations {
+			status := Status{Module: m.Module, Version: m.Version, Name: m.Name}
+			if h, ok := byKey[key{m.Module, m.Version}]; ok {
+				status.AppliedAt = &h.appliedAt
+				status.Modified = h.checksum != m.Checksum
+			}
+			statuses = append(statuses, status)
+		}
+		for _, h := range history {
+			if _, ok := r.find(h.module, h.version); !ok {
+				statuses = append(statuses, Status{Module: h.module, Version: h.version, Name: h.name, AppliedAt: &h.appliedAt, Missing: true})
+			}
+		}
+		slices.SortStableFunc(statuses, func(a, b Status) int { return a.Version - b.Version })
+		return nil
+	})
+	return statuses, err
+}
+
+// Pending reports how many migrations Up would apply.
+func (r *Runner) Pending(ctx context.Context) (int, error) {
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Version returns the highest version up to which every migration of the enabled sets is
+// applied, without taking the migration lock, so it is cheap enough for a readiness probe and
+// does not wait on a migration in progress.
+func (r *Runner) Version(ctx context.Context) (int, error) {
+	var rows []struct {
+		Module  string
+		Version int
+	}
+	if err := r.db.WithContext(ctx).Raw("SELECT module, version FROM " + table).Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+	done := map[key]bool{}
+	for _, row := range rows {
+		done[key{row.Module, row.Version}] = true
+	}
+	version := 0
+	for _, m := range r.migrations {
+		if !done[key{m.Module, m.Version}] {
+			return min(version, m.Version-1), nil
+		}
+		version = m.Version
+	}
+	return version, nil
+}
+
+// Latest returns the highest version among the enabled sets' files, 0 when there are none.
+func (r *Runner) Latest() int {
+	if len(r.migrations) == 0 {
+		return 0
+	}
+	return r.migrations[len(r.migrations)-1].Version
+}
+
+type key struct {
+	module  string
+	version int
+}
+
+func (r *Runner) find(module string, version int) (Migration, bool) {
+	for _, m := range r.known {
+		if m.Module == module && m.Version == version {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+// locked runs fn on one connection that holds the migration lock and has the history table.
+func (r *Runner) locked(ctx context.Context, fn func(*sql.Conn) error) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := r.dialect.lock(ctx, conn); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer r.dialect.unlock(context.Background(), conn)
+	if _, err := conn.ExecContext(ctx, r.dialect.createTable); err != nil {
+		return fmt.Errorf("create %s table: %w", table, err)
+	}
+	return fn(conn)
+}
+
+func (r *Runner) history(ctx context.Context, conn *sql.Conn) ([]applied, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT module, version, name, checksum, applied_at FROM "+table+" ORDER BY version, module")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var history []applied
+	for rows.Next() {
+		var h applied
+		if err := rows.Scan(&h.module, &h.version, &h.name, &h.checksum, &h.appliedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+func (r *Runner) verify(history []applied) error {
+	for _, h := range history {
+		m, ok := r.find(h.module, h.version)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrMissingFile, label(h.module, h.version, h.name))
+		}
+		if m.Checksum != h.checksum {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, m)
+		}
+	}
+	return nil
+}
+
+// requireEmpty refuses to run the first migration over tables created before the runner.
+func (r *Runner) requireEmpty() error {
+	tables, err := r.db.Migrator().GetTables()
+	if err != nil {
+		return err
+	}
+	for _, name := range tables {
+		if name != table && !slices.Contains(r.dialect.systemTables, name) {
+			return ErrNeedsBaseline
+		}
+	}
+	return nil
+}
+
+func (r *Runner) apply(ctx context.Context, conn *sql.Conn, script string, bookkeeping func(*sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if err := bookkeeping(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Runner) record(ctx context.Context, tx *sql.Tx, m Migration) error {
+	p := r.dialect.placeholder
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO "+table+" (module, version, name, checksum, applied_at) VALUES ("+p(1)+", "+p(2)+", "+p(3)+", "+p(4)+", "+p(5)+")",
+		m.Module, m.Version, m.Name, m.Checksum, time.Now().UTC())
+	return err
+}
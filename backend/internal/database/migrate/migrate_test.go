@@ -0,0 +1,196 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"sqlite/001_notes.up.sql":        {Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);")},
+		"sqlite/001_notes.down.sql":      {Data: []byte("DROP TABLE notes;")},
+		"sqlite/002_note_title.up.sql":   {Data: []byte("ALTER TABLE notes ADD COLUMN title TEXT;\nCREATE INDEX idx_notes_title ON notes (title);")},
+		"sqlite/002_note_title.down.sql": {Data: []byte("DROP INDEX idx_notes_title;\nALTER TABLE notes DROP COLUMN title;")},
+	}
+}
+
+func TestRunnerUpDownAndStatus(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	runner, err := New(db, testFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ran, err := runner.Up(ctx, 1)
+	if err != nil || len(ran) != 1 {
+		t.Fatalf("up to 1: ran %d, err %v", len(ran), err)
+	}
+	if pending, err := runner.Pending(ctx); err != nil || pending != 1 {
+		t.Fatalf("pending: %d, err %v", pending, err)
+	}
+	if version, err := runner.Version(ctx); err != nil || version != 1 || runner.Latest() != 2 {
+		t.Fatalf("version %d of %d, err %v", version, runner.Latest(), err)
+	}
+	if ran, err := runner.Up(ctx, 0); err != nil || len(ran) != 1 || ran[0].Version != 2 {
+		t.Fatalf("up: %+v, err %v", ran, err)
+	}
+	if !db.Migrator().HasColumn("notes", "title") {
+		t.Fatal("002 was not applied")
+	}
+	if ran, err := runner.Up(ctx, 0); err != nil || len(ran) != 0 {
+		t.Fatalf("second up: ran %d, err %v", len(ran), err)
+	}
+
+	if ran, err := runner.Down(ctx, 1); err != nil || len(ran) != 1 || ran[0].Version != 2 {
+		t.Fatalf("down: %+v, err %v", ran, err)
+	}
+	if db.Migrator().HasColumn("notes", "title") {
+		t.Fatal("002 was not rolled back")
+	}
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Fatalf("status: %+v", statuses)
+	}
+}
+
+func TestRunnerRollsBackFailedMigration(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	fsys := testFS()
+	fsys["sqlite/002_note_title.up.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE notes ADD COLUMN title TEXT;\nNOT VALID SQL;")}
+	runner, err := New(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(ctx, 0); err == nil {
+		t.Fatal("expected the broken migration to fail")
+	}
+	if db.Migrator().HasColumn("notes", "title") {
+		t.Fatal("failed migration left a partial change")
+	}
+	if pending, err := runner.Pending(ctx); err != nil || pending != 1 {
+		t.Fatalf("pending after failure: %d, err %v", pending, err)
+	}
+}
+
+func TestRunnerRejectsEditedMigration(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	runner, err := New(db, testFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	edited := testFS()
+	edited["sqlite/001_notes.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY);")}
+	runner, err = New(db, edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(ctx, 0); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v want %v", err, ErrChecksumMismatch)
+	}
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[0].Modified {
+		t.Fatalf("status did not flag the edit: %+v", statuses[0])
+	}
+}
+
+func TestRunnerRequiresBaselineForExistingSchema(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	if err := db.Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	runner, err := New(db, testFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(ctx, 0); !errors.Is(err, ErrNeedsBaseline) {
+		t.Fatalf("got %v want %v", err, ErrNeedsBaseline)
+	}
+	if recorded, err := runner.Baseline(ctx, 1); err != nil || len(recorded) != 1 {
+		t.Fatalf("baseline: %d recorded, err %v", len(recorded), err)
+	}
+	if _, err := runner.Baseline(ctx, 1); !errors.Is(err, ErrHasHistory) {
+		t.Fatalf("second baseline: got %v want %v", err, ErrHasHistory)
+	}
+	if ran, err := runner.Up(ctx, 0); err != nil || len(ran) != 1 || ran[0].Version != 2 {
+		t.Fatalf("up after baseline: %+v, err %v", ran, err)
+	}
+}
+
+func tagsFS() fstest.MapFS {
+	return fstest.MapFS{
+		"sqlite/001_tags.up.sql":        {Data: []byte("CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT);")},
+		"sqlite/001_tags.down.sql":      {Data: []byte("DROP TABLE tags;")},
+		"sqlite/003_tag_color.up.sql":   {Data: []byte("ALTER TABLE tags ADD COLUMN color TEXT;")},
+		"sqlite/003_tag_color.down.sql": {Data: []byte("ALTER TABLE tags DROP COLUMN color;")},
+	}
+}
+
+func TestRunnerAppliesModuleSetsOnceEnabled(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	runner, err := New(db, testFS(), Set{Module: "tags", FS: tagsFS(), Disabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran, err := runner.Up(ctx, 0); err != nil || len(ran) != 2 {
+		t.Fatalf("up with tags disabled: %+v, err %v", ran, err)
+	}
+	if db.Migrator().HasTable("tags") {
+		t.Fatal("disabled set was applied")
+	}
+	if version, err := runner.Version(ctx); err != nil || version != 2 || runner.Latest() != 2 {
+		t.Fatalf("version %d of %d, err %v", version, runner.Latest(), err)
+	}
+
+	runner, err = New(db, testFS(), Set{Module: "tags", FS: tagsFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tags/001 is not applied yet, so the schema is only complete up to version 0.
+	if version, err := runner.Version(ctx); err != nil || version != 0 {
+		t.Fatalf("version before enabling: %d, err %v", version, err)
+	}
+	ran, err := runner.Up(ctx, 0)
+	if err != nil || len(ran) != 2 || ran[0].String() != "tags/001_tags" || ran[1].String() != "tags/003_tag_color" {
+		t.Fatalf("up with tags enabled: %+v, err %v", ran, err)
+	}
+	if version, err := runner.Version(ctx); err != nil || version != 3 {
+		t.Fatalf("version after enabling: %d, err %v", version, err)
+	}
+	if ran, err := runner.Down(ctx, 1); err != nil || len(ran) != 1 || ran[0].String() != "tags/003_tag_color" {
+		t.Fatalf("down: %+v, err %v", ran, err)
+	}
+}
@@ -0,0 +1,9 @@
+// Package migrations embeds the core schema, one directory per database driver; each module
+// embeds its own tables' migrations in the same version sequence.
+// Add files with `go run ./cmd/migration create <name>`.
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
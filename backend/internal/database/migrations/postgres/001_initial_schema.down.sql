@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "invites";
+DROP TABLE IF EXISTS "login_attempts";
+DROP TABLE IF EXISTS "login_spray_blocks";
+DROP TABLE IF EXISTS "login_spray_usernames";
+DROP TABLE IF EXISTS "login_rate_windows";
+DROP TABLE IF EXISTS "password_reset_tokens";
+DROP TABLE IF EXISTS "login_challenges";
+DROP TABLE IF EXISTS "recovery_codes";
+DROP TABLE IF EXISTS "access_tokens";
+DROP TABLE IF EXISTS "sessions";
+DROP TABLE IF EXISTS "users";
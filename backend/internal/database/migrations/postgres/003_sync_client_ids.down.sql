@@ -0,0 +1,3 @@
+-- sync_client_ids (postgres, down)
+
+DROP TABLE "sync_client_ids";
@@ -0,0 +1,12 @@
+-- sync_client_ids (postgres, up)
+
+-- Rows created offline arrive under an ID the client made up; this maps it to the row it
+-- became so a retried push creates nothing twice and later mutations can name the row.
+CREATE TABLE "sync_client_ids" (
+    "user_id" bigint NOT NULL,
+    "client_id" text NOT NULL,
+    "entity" text NOT NULL,
+    "row_id" bigint NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("user_id","client_id")
+);
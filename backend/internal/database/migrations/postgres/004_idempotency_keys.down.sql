@@ -0,0 +1,3 @@
+-- idempotency_keys (postgres, down)
+
+DROP TABLE "idempotency_keys";
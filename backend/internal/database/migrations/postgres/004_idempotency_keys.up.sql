@@ -0,0 +1,14 @@
+-- idempotency_keys (postgres, up)
+
+-- Responses to logging requests sent with an Idempotency-Key, replayed when the client retries.
+CREATE TABLE "idempotency_keys" (
+    "user_id" bigint NOT NULL,
+    "idempotency_key" text NOT NULL,
+    "request_hash" text NOT NULL,
+    "status" bigint NOT NULL DEFAULT 0,
+    "content_type" text,
+    "body" bytea,
+    "created_at" timestamptz NOT NULL,
+    PRIMARY KEY ("user_id","idempotency_key")
+);
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_created_at" ON "idempotency_keys" ("created_at");
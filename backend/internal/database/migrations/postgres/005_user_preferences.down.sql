@@ -0,0 +1,3 @@
+-- user_preferences (postgres, down)
+
+DROP TABLE "user_preferences";
@@ -0,0 +1,15 @@
+-- user_preferences (postgres, up)
+
+CREATE TABLE "user_preferences" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "timezone" text NOT NULL DEFAULT '',
+    "week_start" bigint NOT NULL DEFAULT 0,
+    "day_start_hour" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_preferences_user_id" ON "user_preferences" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_user_preferences_deleted_at" ON "user_preferences" ("deleted_at");
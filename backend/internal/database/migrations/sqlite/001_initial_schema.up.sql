@@ -0,0 +1,145 @@
+-- Initial schema: the core tables as created by the AutoMigrate setup this runner replaces.
+
+CREATE TABLE "users" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "username" text NOT NULL,
+    "password" text NOT NULL,
+    "email" text,
+    "birth_year" integer,
+    "totp_secret" text,
+    "totp_enabled" numeric NOT NULL DEFAULT false,
+    "totp_last_step" integer NOT NULL DEFAULT 0,
+    "disabled_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username");
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE "sessions" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer NOT NULL,
+    "token_id" text NOT NULL,
+    "user_agent" text,
+    "ip" text,
+    "last_seen_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_sessions_token_id" ON "sessions" ("token_id");
+CREATE INDEX IF NOT EXISTS "idx_sessions_user_id" ON "sessions" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_sessions_deleted_at" ON "sessions" ("deleted_at");
+
+CREATE TABLE "access_tokens" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer NOT NULL,
+    "name" text NOT NULL,
+    "prefix" text NOT NULL,
+    "token_hash" text NOT NULL,
+    "scopes" text NOT NULL,
+    "expires_at" datetime,
+    "last_used_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_access_tokens_token_hash" ON "access_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_access_tokens_user_id" ON "access_tokens" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_access_tokens_deleted_at" ON "access_tokens" ("deleted_at");
+
+CREATE TABLE "recovery_codes" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer NOT NULL,
+    "code_hash" text NOT NULL,
+    "used_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_deleted_at" ON "recovery_codes" ("deleted_at");
+
+CREATE TABLE "login_challenges" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer NOT NULL,
+    "token_hash" text NOT NULL,
+    "expires_at" datetime NOT NULL,
+    "attempts" integer NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_login_challenges_token_hash" ON "login_challenges" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_login_challenges_user_id" ON "login_challenges" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_login_challenges_deleted_at" ON "login_challenges" ("deleted_at");
+
+CREATE TABLE "password_reset_tokens" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer NOT NULL,
+    "token_hash" text NOT NULL,
+    "expires_at" datetime NOT NULL,
+    "used_at" datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_password_reset_tokens_token_hash" ON "password_reset_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_password_reset_tokens_user_id" ON "password_reset_tokens" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_password_reset_tokens_deleted_at" ON "password_reset_tokens" ("deleted_at");
+
+CREATE TABLE "login_rate_windows" (
+    "rate_key" text,
+    "count" integer NOT NULL,
+    "reset_at" datetime NOT NULL,
+    PRIMARY KEY ("rate_key")
+);
+CREATE INDEX IF NOT EXISTS "idx_login_rate_windows_reset_at" ON "login_rate_windows" ("reset_at");
+
+CREATE TABLE "login_spray_usernames" (
+    "ip_key" text,
+    "username" text,
+    "expires_at" datetime NOT NULL,
+    PRIMARY KEY ("ip_key","username")
+);
+CREATE INDEX IF NOT EXISTS "idx_login_spray_usernames_expires_at" ON "login_spray_usernames" ("expires_at");
+
+CREATE TABLE "login_spray_blocks" (
+    "ip_key" text,
+    "blocked_until" datetime NOT NULL,
+    PRIMARY KEY ("ip_key")
+);
+CREATE INDEX IF NOT EXISTS "idx_login_spray_blocks_blocked_until" ON "login_spray_blocks" ("blocked_until");
+
+CREATE TABLE "login_attempts" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime NOT NULL,
+    "outcome" text NOT NULL,
+    "username" text,
+    "client_ip" text,
+    "user_agent" text,
+    "new_ip" numeric NOT NULL DEFAULT false,
+    "new_user_agent" numeric NOT NULL DEFAULT false,
+    "acknowledged_at" datetime
+);
+CREATE INDEX IF NOT EXISTS "idx_login_attempts_username" ON "login_attempts" ("username");
+CREATE INDEX IF NOT EXISTS "idx_login_attempts_outcome" ON "login_attempts" ("outcome");
+CREATE INDEX IF NOT EXISTS "idx_login_attempts_created_at" ON "login_attempts" ("created_at");
+
+CREATE TABLE "invites" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "created_by_id" integer NOT NULL,
+    "code_hash" text NOT NULL,
+    "prefix" text NOT NULL,
+    "expires_at" datetime NOT NULL,
+    "used_at" datetime,
+    "used_by_id" integer
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invites_code_hash" ON "invites" ("code_hash");
+CREATE INDEX IF NOT EXISTS "idx_invites_created_by_id" ON "invites" ("created_by_id");
+CREATE INDEX IF NOT EXISTS "idx_invites_deleted_at" ON "invites" ("deleted_at");
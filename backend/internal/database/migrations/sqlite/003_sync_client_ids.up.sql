@@ -0,0 +1,12 @@
+-- sync_client_ids (sqlite, up)
+
+-- Rows created offline arrive under an ID the client made up; this maps it to the row it
+-- became so a retried push creates nothing twice and later mutations can name the row.
+CREATE TABLE "sync_client_ids" (
+    "user_id" integer NOT NULL,
+    "client_id" text NOT NULL,
+    "entity" text NOT NULL,
+    "row_id" integer NOT NULL,
+    "created_at" datetime,
+    PRIMARY KEY ("user_id","client_id")
+);
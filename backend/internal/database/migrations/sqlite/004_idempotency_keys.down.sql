@@ -0,0 +1,3 @@
+-- idempotency_keys (sqlite, down)
+
+DROP TABLE "idempotency_keys";
@@ -0,0 +1,3 @@
+-- user_preferences (sqlite, down)
+
+DROP TABLE "user_preferences";
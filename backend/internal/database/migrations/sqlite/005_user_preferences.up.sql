@@ -0,0 +1,14 @@
+-- user_preferences (sqlite, up)
+
+CREATE TABLE "user_preferences" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "user_id" integer NOT NULL,
+    "timezone" text NOT NULL DEFAULT '',
+    "week_start" integer NOT NULL DEFAULT 0,
+    "day_start_hour" integer NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_preferences_user_id" ON "user_preferences" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_user_preferences_deleted_at" ON "user_preferences" ("deleted_at");
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ownerField       = "UserID"
+	ownerColumn      = "user_id"
+	ownerScopeMarker = "owner_scope_enabled"
+	usersTable       = "users"
+)
+
+type userIDKey struct{}
+
+// WithUserID returns a context whose queries against owned models are limited to userID.
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFrom returns the user attached by WithUserID, if any.
+func UserIDFrom(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	id, ok := ctx.Value(userIDKey{}).(uint)
+	return id, ok && id != 0
+}
+
+// OwnedBy scopes hand-written joins and Table() queries, which carry no model schema,
+// to the user in ctx. table is the table name or alias holding the user_id column.
+func OwnedBy(ctx context.Context, table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		id, ok := UserIDFrom(ctx)
+		if !ok {
+			return db
+		}
+		return db.Where(clause.Eq{Column: clause.Column{Table: table, Name: ownerColumn}, Value: id})
+	}
+}
+
+// OwnerScope is a gorm plugin: any model with a UserID field is filtered to the user in the
+// statement context on query, update and delete, and stamped with that user on create.
+// Statements without a user in context are left untouched (migrations, CLI tools, tests).
+type OwnerScope struct{}
+
+func (OwnerScope) Name() string { return "owner_scope" }
+
+func (OwnerScope) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("owner_scope:create", assignOwner); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("owner_scope:query", scopeToOwner); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("owner_scope:row", scopeToOwner); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("owner_scope:update", scopeToOwner); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("owner_scope:delete", scopeToOwner)
+}
+
+func scopeToOwner(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.SQL.Len() > 0 {
+		return
+	}
+	field := stmt.Schema.LookUpField(ownerField)
+	if field == nil {
+		return
+	}
+	id, ok := UserIDFrom(stmt.Context)
+	if !ok {
+		return
+	}
+	if _, done := stmt.Clauses[ownerScopeMarker]; done {
+		return
+	}
+	// A lone OR condition would otherwise bind looser than the owner filter.
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) >= 1 {
+			for _, expr := range where.Exprs {
+				if or, ok := expr.(clause.OrConditions); ok && len(or.Exprs) == 1 {
+					where.Exprs = []clause.Expression{clause.And(where.Exprs...)}
+					c.Expression = where
+					stmt.Clauses["WHERE"] = c
+					break
+				}
+			}
+		}
+	}
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: id},
+	}})
+	stmt.Clauses[ownerScopeMarker] = clause.Clause{}
+}
+
+func assignOwner(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	field := stmt.Schema.LookUpField(ownerField)
+	if field == nil {
+		return
+	}
+	id, ok := UserIDFrom(stmt.Context)
+	if !ok {
+		return
+	}
+	setOwner := func(rv reflect.Value) {
+		rv = reflect.Indirect(rv)
+		if rv.Kind() != reflect.Struct {
+			return
+		}
+		if _, zero := field.ValueOf(stmt.Context, rv); zero {
+			db.AddError(field.Set(stmt.Context, rv, id))
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			setOwner(stmt.ReflectValue.Index(i))
+		}
+	case reflect.Struct:
+		setOwner(stmt.ReflectValue)
+	}
+}
+
+// RequireTables reports the first of models whose table does not exist.
+func RequireTables(db *gorm.DB, models ...any) error {
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			return err
+		}
+		if !db.Migrator().HasTable(stmt.Table) {
+			return fmt.Errorf("table %s is missing", stmt.Table)
+		}
+	}
+	return nil
+}
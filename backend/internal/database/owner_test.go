@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type ownedNote struct {
+	gorm.Model
+	UserID uint
+	Body   string
+}
+
+type ownedNoteChild struct {
+	gorm.Model
+	UserID      uint
+	OwnedNoteID uint
+}
+
+func setupOwnerDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(OwnerScope{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&ownedNote{}, &ownedNoteChild{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestOwnerScope_createStampsUserFromContext(t *testing.T) {
+	db := setupOwnerDB(t)
+	ctx := WithUserID(context.Background(), 7)
+	notes := []ownedNote{{Body: "a"}, {Body: "b"}}
+	if err := db.WithContext(ctx).Create(&notes).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range notes {
+		if n.UserID != 7 {
+			t.Fatalf("user_id: got %d want 7", n.UserID)
+		}
+	}
+}
+
+func TestOwnerScope_readsAndWritesStayWithinUser(t *testing.T) {
+	db := setupOwnerDB(t)
+	alice := db.WithContext(WithUserID(context.Background(), 1))
+	bob := db.WithContext(WithUserID(context.Background(), 2))
+	note := ownedNote{Body: "alice"}
+	if err := alice.Create(&note).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var got []ownedNote
+	if err := bob.Where("body = ? OR body = ?", "alice", "x").Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("bob sees %d notes", len(got))
+	}
+	if err := bob.First(&ownedNote{}, note.ID).Error; err != gorm.ErrRecordNotFound {
+		t.Fatalf("bob First: got %v want record not found", err)
+	}
+	if res := bob.Model(&ownedNote{}).Where("id = ?", note.ID).Update("body", "bob"); res.Error != nil || res.RowsAffected != 0 {
+		t.Fatalf("bob update: err=%v rows=%d", res.Error, res.RowsAffected)
+	}
+	if res := bob.Unscoped().Delete(&ownedNote{}, note.ID); res.Error != nil || res.RowsAffected != 0 {
+		t.Fatalf("bob delete: err=%v rows=%d", res.Error, res.RowsAffected)
+	}
+	var count int64
+	if err := alice.Model(&ownedNote{}).Count(&count).Error; err != nil || count != 1 {
+		t.Fatalf("alice count: err=%v count=%d", err, count)
+	}
+}
+
+func TestOwnerScope_softDeleteIsScoped(t *testing.T) {
+	db := setupOwnerDB(t)
+	alice := db.WithContext(WithUserID(context.Background(), 1))
+	bob := db.WithContext(WithUserID(context.Background(), 2))
+	note := ownedNote{Body: "alice"}
+	if err := alice.Create(&note).Error; err != nil {
+		t.Fatal(err)
+	}
+	if res := bob.Delete(&ownedNote{}, note.ID); res.Error != nil || res.RowsAffected != 0 {
+		t.Fatalf("bob soft delete: err=%v rows=%d", res.Error, res.RowsAffected)
+	}
+}
+
+func TestOwnerScope_noUserInContextIsUnscoped(t *testing.T) {
+	db := setupOwnerDB(t)
+	for _, id := range []uint{1, 2} {
+		if err := db.WithContext(WithUserID(context.Background(), id)).Create(&ownedNote{Body: "n"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	var count int64
+	if err := db.Model(&ownedNote{}).Count(&count).Error; err != nil || count != 2 {
+		t.Fatalf("count: err=%v count=%d", err, count)
+	}
+}
+
+func TestOwnedBy_scopesTableQueries(t *testing.T) {
+	db := setupOwnerDB(t)
+	for _, id := range []uint{1, 2} {
+		ctx := WithUserID(context.Background(), id)
+		if err := db.WithContext(ctx).Create(&ownedNoteChild{OwnedNoteID: 1}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := WithUserID(context.Background(), 2)
+	var ids []uint
+	if err := db.Table("owned_note_children").Select("user_id").Scopes(OwnedBy(ctx, "owned_note_children")).Scan(&ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != 2 {
+		t.Fatalf("ids: %v", ids)
+	}
+}
@@ -1,8 +1,6 @@
 package repository
 
-import (
-	"time"
-)
+import "be-simpletracker/internal/database"
 
 // Entity is the base interface all database models must implement
 type Entity interface {
@@ -17,7 +15,7 @@ type Preloadable interface {
 
 // Dateable defines models that have a date field for range queries
 type Dateable interface {
-	GetDate() time.Time
+	GetDate() database.Date
 }
 
 // SoftDeletable marks entities that use soft deletes
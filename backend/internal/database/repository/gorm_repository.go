@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
+	"reflect"
+
+	"be-simpletracker/internal/database"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -214,9 +216,39 @@ func (r *GormRepository[T]) Create(ctx context.Context, entity *T) error {
 	return r.db.WithContext(ctx).Create(entity).Error
 }
 
-// Update updates an existing entity
+// Update overwrites an existing entity with the caller's values. The row is loaded through
+// the owner scope first, so an id belonging to another user is ErrNotFound rather than an
+// upsert, and user_id is never written: entities bound from a request carry none. Entities
+// with a Version field are checked and bumped through database.ClaimVersion, so a write based
+// on a stale read fails with database.ErrStaleVersion instead of overwriting.
 func (r *GormRepository[T]) Update(ctx context.Context, entity *T) error {
-	return r.db.WithContext(ctx).Save(entity).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return update(tx, entity)
+	})
+}
+
+func update[T Entity](tx *gorm.DB, entity *T) error {
+	id := (*entity).GetID()
+	if err := tx.Select("id").First(new(T), id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(entity); err != nil {
+		return err
+	}
+	if versionField := stmt.Schema.LookUpField("Version"); versionField != nil {
+		version, err := database.ClaimVersion(tx, new(T), id)
+		if err != nil {
+			return err
+		}
+		if err := versionField.Set(tx.Statement.Context, reflect.ValueOf(entity).Elem(), version); err != nil {
+			return err
+		}
+	}
+	return tx.Model(entity).Select("*").Omit("id", "user_id", "created_at", clause.Associations).Updates(entity).Error
 }
 
 // Delete removes an entity by ID (soft delete if the entity has DeletedAt)
@@ -270,7 +302,7 @@ func (r *GormRepository[T]) Transaction(ctx context.Context, fn func(repo Reposi
 // ========== DateableRepository methods ==========
 
 // GetByDate retrieves a single entity for a specific date
-func (r *GormRepository[T]) GetByDate(ctx context.Context, date time.Time, opts ...QueryOption) (T, error) {
+func (r *GormRepository[T]) GetByDate(ctx context.Context, date database.Date, opts ...QueryOption) (T, error) {
 	var entity T
 	options := ApplyOptions(opts...)
 
@@ -288,14 +320,14 @@ func (r *GormRepository[T]) GetByDate(ctx context.Context, date time.Time, opts
 }
 
 // GetByDateRange retrieves entities within a date range
-func (r *GormRepository[T]) GetByDateRange(ctx context.Context, start, end time.Time, opts ...QueryOption) ([]T, error) {
+func (r *GormRepository[T]) GetByDateRange(ctx context.Context, start, end database.Date, opts ...QueryOption) ([]T, error) {
 	// Add date range to options
 	opts = append(opts, WithDateRange(start, end), WithOrderByAsc(r.dateField))
 	return r.GetAll(ctx, opts...)
 }
 
 // GetByDateRangePaginated retrieves entities within a date range with pagination
-func (r *GormRepository[T]) GetByDateRangePaginated(ctx context.Context, start, end time.Time, page, pageSize int, opts ...QueryOption) (*PaginatedResult[T], error) {
+func (r *GormRepository[T]) GetByDateRangePaginated(ctx context.Context, start, end database.Date, page, pageSize int, opts ...QueryOption) (*PaginatedResult[T], error) {
 	opts = append(opts, WithDateRange(start, end), WithOrderByAsc(r.dateField))
 	return r.GetAllPaginated(ctx, page, pageSize, opts...)
 }
@@ -313,8 +345,8 @@ func (r *GormRepository[T]) CreateBatch(ctx context.Context, entities []T) error
 // UpdateBatch updates multiple entities
 func (r *GormRepository[T]) UpdateBatch(ctx context.Context, entities []T) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		for _, entity := range entities {
-			if err := tx.Save(entity).Error; err != nil {
+		for i := range entities {
+			if err := update(tx, &entities[i]); err != nil {
 				return err
 			}
 		}
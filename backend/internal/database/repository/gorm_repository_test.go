@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/dbtest"
+
+	"gorm.io/gorm"
+)
+
+type note struct {
+	gorm.Model
+	UserID uint `json:"-"`
+	Body   string
+}
+
+func (n note) GetID() uint     { return n.ID }
+func (note) TableName() string { return "notes" }
+
+func TestUpdateKeepsOwnerAndStaysWithinUser(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.AutoMigrate(&note{}); err != nil {
+		t.Fatal(err)
+	}
+	alice := database.WithUserID(context.Background(), 1)
+	bob := database.WithUserID(context.Background(), 2)
+	repo := NewGormRepository[note](db)
+	owned := note{Body: "alice"}
+	if err := repo.Create(alice, &owned); err != nil {
+		t.Fatal(err)
+	}
+
+	// As bound from a request body: the id from the URL, no owner.
+	edit := note{Model: gorm.Model{ID: owned.ID}, Body: "edited"}
+	if err := repo.Update(alice, &edit); err != nil {
+		t.Fatal(err)
+	}
+	var got note
+	if err := db.First(&got, owned.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.UserID != 1 || got.Body != "edited" {
+		t.Fatalf("after update: user_id %d, body %q", got.UserID, got.Body)
+	}
+
+	for _, id := range []uint{owned.ID, owned.ID + 1} {
+		err := repo.Update(bob, &note{Model: gorm.Model{ID: id}, Body: "bob"})
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("bob updating id %d: err = %v, want ErrNotFound", id, err)
+		}
+	}
+	var count int64
+	db.Model(&note{}).Count(&count)
+	if err := db.First(&got, owned.ID).Error; err != nil || got.Body != "edited" || count != 1 {
+		t.Fatalf("bob's updates leaked: body %q, %d rows", got.Body, count)
+	}
+}
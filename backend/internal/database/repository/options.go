@@ -1,6 +1,6 @@
 package repository
 
-import "time"
+import "be-simpletracker/internal/database"
 
 // QueryOptions configures how a repository query is executed
 type QueryOptions struct {
@@ -17,8 +17,8 @@ type QueryOptions struct {
 	OrderDesc bool
 
 	// Date range filtering (for Dateable entities)
-	DateStart *time.Time
-	DateEnd   *time.Time
+	DateStart *database.Date
+	DateEnd   *database.Date
 
 	// Generic filters (field -> value)
 	Filters map[string]interface{}
@@ -125,7 +125,7 @@ func WithOrderByDesc(field string) QueryOption {
 }
 
 // WithDateRange filters by date range (for Dateable entities)
-func WithDateRange(start, end time.Time) QueryOption {
+func WithDateRange(start, end database.Date) QueryOption {
 	return func(o *QueryOptions) {
 		o.DateStart = &start
 		o.DateEnd = &end
@@ -133,14 +133,14 @@ func WithDateRange(start, end time.Time) QueryOption {
 }
 
 // WithDateFrom filters from a start date
-func WithDateFrom(start time.Time) QueryOption {
+func WithDateFrom(start database.Date) QueryOption {
 	return func(o *QueryOptions) {
 		o.DateStart = &start
 	}
 }
 
 // WithDateUntil filters until an end date
-func WithDateUntil(end time.Time) QueryOption {
+func WithDateUntil(end database.Date) QueryOption {
 	return func(o *QueryOptions) {
 		o.DateEnd = &end
 	}
@@ -2,7 +2,8 @@ package repository
 
 import (
 	"context"
-	"time"
+
+	"be-simpletracker/internal/database"
 )
 
 // Repository defines the generic repository interface for CRUD operations
@@ -47,13 +48,13 @@ type DateableRepository[T Entity] interface {
 	Repository[T]
 
 	// GetByDate retrieves entities for a specific date
-	GetByDate(ctx context.Context, date time.Time, opts ...QueryOption) (T, error)
+	GetByDate(ctx context.Context, date database.Date, opts ...QueryOption) (T, error)
 
 	// GetByDateRange retrieves entities within a date range
-	GetByDateRange(ctx context.Context, start, end time.Time, opts ...QueryOption) ([]T, error)
+	GetByDateRange(ctx context.Context, start, end database.Date, opts ...QueryOption) ([]T, error)
 
 	// GetByDateRangePaginated retrieves entities within a date range with pagination
-	GetByDateRangePaginated(ctx context.Context, start, end time.Time, page, pageSize int, opts ...QueryOption) (*PaginatedResult[T], error)
+	GetByDateRangePaginated(ctx context.Context, start, end database.Date, page, pageSize int, opts ...QueryOption) (*PaginatedResult[T], error)
 }
 
 // BatchRepository adds batch operation support
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"be-simpletracker/internal/config"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ConnectToSQLite opens the file database at cfg.Path, for installs that run the tracker as
+// a single binary without a Postgres server.
+func ConnectToSQLite(cfg config.Database) (*gorm.DB, error) {
+	path := cfg.Path
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("sqlite: %w", err)
+		}
+	}
+	db, err := gorm.Open(sqlite.Open(sqliteDSN(path, cfg.BusyTimeoutMS)), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Use(OwnerScope{}); err != nil {
+		return nil, err
+	}
+	db_conn = db
+	log.Printf("sqlite: opened %s", path)
+	return db, nil
+}
+
+// sqliteDSN turns on WAL so reads carry on during a write, and makes writers wait up to
+// busyTimeoutMS for the lock. Transactions take the write lock when they begin: a deferred
+// one that reads first and then writes fails with SQLITE_BUSY without waiting at all.
+func sqliteDSN(path string, busyTimeoutMS int) string {
+	q := url.Values{}
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeoutMS))
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Set("_txlock", "immediate")
+	return path + "?" + q.Encode()
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"be-simpletracker/internal/config"
+)
+
+func TestConnectToSQLiteUsesWALAndBusyTimeout(t *testing.T) {
+	t.Cleanup(func() { SetDB(nil) })
+	db, err := ConnectToSQLite(config.Database{Path: filepath.Join(t.TempDir(), "data", "tracker.db"), BusyTimeoutMS: 2500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	var journalMode string
+	var busyTimeout, foreignKeys int
+	db.Raw("PRAGMA journal_mode").Scan(&journalMode)
+	db.Raw("PRAGMA busy_timeout").Scan(&busyTimeout)
+	db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys)
+	if journalMode != "wal" || busyTimeout != 2500 || foreignKeys != 1 {
+		t.Fatalf("journal_mode=%s busy_timeout=%d foreign_keys=%d", journalMode, busyTimeout, foreignKeys)
+	}
+}
+
+func TestConnectRejectsUnknownDriver(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Driver = "mysql"
+	_, err := Connect(cfg)
+	if err == nil || !strings.Contains(err.Error(), "DATABASE_DRIVER") {
+		t.Fatalf("expected unknown driver error, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// StringList is a []string column stored as jsonb on Postgres and JSON text on SQLite.
+// Tag the field with serializer:json.
+type StringList []string
+
+func (StringList) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "text"
+}
+
+// Money is an amount in a currency with cents. Postgres keeps it as numeric(14,2); SQLite
+// has no fixed-point type, so values are rounded to the cent on the way in instead.
+type Money float64
+
+func (Money) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "numeric(14,2)"
+	}
+	return "numeric"
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return math.Round(float64(m)*100) / 100, nil
+}
+
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case float64:
+		*m = Money(v)
+	case int64:
+		*m = Money(v)
+	case []byte:
+		return m.Scan(string(v))
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("money: %w", err)
+		}
+		*m = Money(f)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const versionColumn = "version"
+
+// ErrStaleVersion means the row changed since the version the caller based its write on.
+var ErrStaleVersion = errors.New("modified since it was read")
+
+type expectedVersionKey struct{}
+
+// WithExpectedVersion returns a context whose ClaimVersion calls only succeed on a row still
+// at version.
+func WithExpectedVersion(ctx context.Context, version uint) context.Context {
+	return context.WithValue(ctx, expectedVersionKey{}, version)
+}
+
+// ExpectedVersion returns the version attached by WithExpectedVersion, if any.
+func ExpectedVersion(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	v, ok := ctx.Value(expectedVersionKey{}).(uint)
+	return v, ok
+}
+
+// ClaimVersion moves the version of model's row id on by one and returns the new version. When
+// the statement context expects a version the row must still be at it, or the result is
+// ErrStaleVersion. Call it first in the transaction making the change: the update holds the row
+// until commit, so a concurrent writer that read the same version fails instead of
+// overwriting.
+func ClaimVersion(tx *gorm.DB, model any, id uint) (uint, error) {
+	q := tx.Model(model).Where("id = ?", id)
+	expected, check := ExpectedVersion(tx.Statement.Context)
+	if check {
+		q = q.Where(versionColumn+" = ?", expected)
+	}
+	result := q.UpdateColumn(versionColumn, gorm.Expr(versionColumn+" + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	var current []uint
+	if err := tx.Model(model).Where("id = ?", id).Pluck(versionColumn, &current).Error; err != nil {
+		return 0, err
+	}
+	if len(current) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	if result.RowsAffected == 0 {
+		return current[0], ErrStaleVersion
+	}
+	return current[0], nil
+}
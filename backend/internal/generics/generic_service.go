@@ -1,9 +1,9 @@
 package generics
 
 import (
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/database/repository"
 	"context"
-	"time"
 
 	"gorm.io/gorm"
 )
@@ -107,7 +107,7 @@ func GetAllPaginated[T repository.Entity](ctx context.Context, db *gorm.DB, page
 
 // GetByDateRange retrieves entities within a date range (for Dateable entities)
 // Usage: entities, err := generics.GetByDateRange[models.WorkoutLog](ctx, db, start, end, repository.WithDefaultPreloads())
-func GetByDateRange[T repository.Entity](ctx context.Context, db *gorm.DB, start, end time.Time, opts ...repository.QueryOption) ([]T, error) {
+func GetByDateRange[T repository.Entity](ctx context.Context, db *gorm.DB, start, end database.Date, opts ...repository.QueryOption) ([]T, error) {
 	repo := repository.NewGormRepository[T](db)
 	return repo.GetByDateRange(ctx, start, end, opts...)
 }
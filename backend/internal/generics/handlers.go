@@ -1,8 +1,11 @@
 package generics
 
 import (
+	"be-simpletracker/internal/database"
 	"be-simpletracker/internal/database/repository"
+	"be-simpletracker/internal/utils"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -25,6 +28,9 @@ type CRUDConfig[T repository.Entity] struct {
 	EnableUpdate  bool // Default: true - enables PUT /base/:id
 	EnableDelete  bool // Default: true - enables DELETE /base/:id
 
+	// IfMatch guards PUT /base/:id. Default: If-Match required
+	IfMatch gin.HandlerFunc
+
 	// Default query options
 	DefaultPageSize  int    // Default: 20
 	DefaultOrderBy   string // Default: "id"
@@ -64,6 +70,7 @@ func DefaultCRUDConfig[T repository.Entity](basePath, resourceName string) CRUDC
 		EnableCreate:       true,
 		EnableUpdate:       true,
 		EnableDelete:       true,
+		IfMatch:            utils.IfMatchMiddleware(true),
 		DefaultPageSize:    20,
 		DefaultOrderBy:     "id",
 		DefaultOrderDesc:   true,
@@ -97,7 +104,7 @@ func RegisterBasicCRUD[T repository.Entity](
 			base.POST("", handler.Create) // POST /base
 		}
 		if config.EnableUpdate {
-			base.PUT("/:id", handler.Update) // PUT /base/:id
+			base.PUT("/:id", config.IfMatch, handler.Update) // PUT /base/:id
 		}
 		if config.EnableDelete {
 			base.DELETE("/:id", handler.Delete) // DELETE /base/:id
@@ -315,7 +322,17 @@ func (h *GenericCRUDHandler[T]) Update(c *gin.Context) {
 
 	// Use generic Update
 	if err := Update(ctx, h.db, &entity); err != nil {
-		if err == repository.ErrNotFound {
+		if errors.Is(err, database.ErrStaleVersion) {
+			current, err := GetByID[T](ctx, h.db, id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			version := reflect.ValueOf(current).FieldByName("Version")
+			utils.PreconditionFailed(c, h.config.ResourceName, current, uint(version.Uint()))
+			return
+		}
+		if err == repository.ErrNotFound || errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s not found", h.config.ResourceName)})
 			return
 		}
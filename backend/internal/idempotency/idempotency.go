@@ -0,0 +1,206 @@
+// Package idempotency lets a client retry a POST without the write happening twice. A request
+// sent with an Idempotency-Key header is run once per user and key; the response is kept for
+// a day, and a retry carrying the same key and body gets that response back instead of
+// running the handler again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"be-simpletracker/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	TTL            = 24 * time.Hour
+	purgeInterval  = time.Hour
+	maxKeyLength   = 255
+	maxBodyBytes   = 1 << 20
+	pendingStatus  = 0
+	requestTimeout = 10 * time.Second
+)
+
+// record is a key's claim and, once the handler has answered, the response it gave.
+type record struct {
+	UserID         uint   `gorm:"primaryKey;autoIncrement:false"`
+	IdempotencyKey string `gorm:"primaryKey"`
+	RequestHash    string
+	Status         int
+	ContentType    string
+	Body           []byte
+	CreatedAt      time.Time
+}
+
+func (record) TableName() string { return "idempotency_keys" }
+
+// Middleware replays the stored response to a request that repeats an earlier key, and
+// answers 422 when the key comes back with a different request. A retry that arrives while
+// the first attempt is still running gets 409. Server errors are not kept, so the client can
+// retry those with the same key. Requests without the header, or without a signed-in user,
+// pass straight through.
+func Middleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(Header))
+		userID, signedIn := database.UserIDFrom(c.Request.Context())
+		if key == "" || !signedIn {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+		// The body is held in memory to be hashed, so it is bounded first.
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body is too large"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "could not read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The response is stored even when the client has given up on the request, since
+		// that is exactly when it will retry.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), requestTimeout)
+		defer cancel()
+		conn := db.WithContext(ctx)
+		claim := record{UserID: userID, IdempotencyKey: key, RequestHash: requestHash(c.Request, body), Status: pendingStatus}
+		claimed, err := claimKey(conn, &claim)
+		if err != nil {
+			log.Printf("idempotency: claim %q: %v", key, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		if !claimed {
+			replay(c, conn, claim)
+			return
+		}
+
+		keyRow := func() *gorm.DB { return conn.Where("idempotency_key = ?", key) }
+		defer func() {
+			if p := recover(); p != nil {
+				keyRow().Delete(&record{})
+				panic(p)
+			}
+		}()
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			err = keyRow().Delete(&record{}).Error
+		} else {
+			err = keyRow().Model(&record{}).Updates(map[string]any{
+				"status":       status,
+				"content_type": recorder.Header().Get("Content-Type"),
+				"body":         recorder.body.Bytes(),
+			}).Error
+		}
+		if err != nil {
+			log.Printf("idempotency: store response for %q: %v", key, err)
+		}
+	}
+}
+
+// claimKey inserts claim unless the user already holds the key. A key older than TTL is
+// taken over as if it were new.
+func claimKey(conn *gorm.DB, claim *record) (bool, error) {
+	err := conn.Where("idempotency_key = ? AND created_at < ?", claim.IdempotencyKey, time.Now().Add(-TTL)).
+		Delete(&record{}).Error
+	if err != nil {
+		return false, err
+	}
+	result := conn.Clauses(clause.OnConflict{DoNothing: true}).Create(claim)
+	return result.RowsAffected == 1, result.Error
+}
+
+func replay(c *gin.Context, conn *gorm.DB, claim record) {
+	var stored record
+	if err := conn.Where("idempotency_key = ?", claim.IdempotencyKey).Take(&stored).Error; err != nil {
+		log.Printf("idempotency: load %q: %v", claim.IdempotencyKey, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	switch {
+	case stored.RequestHash != claim.RequestHash:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	case stored.Status == pendingStatus:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still in progress"})
+	default:
+		c.Header(ReplayedHeader, "true")
+		c.Data(stored.Status, stored.ContentType, stored.Body)
+		c.Abort()
+	}
+}
+
+// requestHash identifies a request by method, path with query, and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body as it is written.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// Purge removes keys older than TTL and returns how many went.
+func Purge(ctx context.Context, db *gorm.DB) (int64, error) {
+	result := db.WithContext(ctx).Where("created_at < ?", time.Now().Add(-TTL)).Delete(&record{})
+	return result.RowsAffected, result.Error
+}
+
+// Job is Run as a background job of the module registry. Keys are not tied to a module, so
+// it purges them whichever modules are enabled.
+func Job(db *gorm.DB) func(ctx context.Context, enabled func(table string) bool) {
+	return func(ctx context.Context, _ func(string) bool) { Run(ctx, db) }
+}
+
+// Run purges expired keys every hour until ctx is done.
+func Run(ctx context.Context, db *gorm.DB) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := Purge(ctx, db); err != nil {
+			log.Printf("idempotency: purge failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"be-simpletracker/internal/database"
+	"be-simpletracker/internal/database/dbtest"
+	"be-simpletracker/internal/database/migrate"
+	"be-simpletracker/internal/database/migrations"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupRouter(t *testing.T, calls *int) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	db := dbtest.Open(t)
+	runner, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	signIn := func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.WithUserID(c.Request.Context(), 1))
+	}
+	router.POST("/log", signIn, Middleware(db), func(c *gin.Context) {
+		*calls++
+		if c.Query("fail") != "" {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": *calls})
+	})
+	return router, db
+}
+
+func post(router *gin.Engine, target, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRetryReplaysFirstResponse(t *testing.T) {
+	var calls int
+	router, _ := setupRouter(t, &calls)
+
+	first := post(router, "/log", "abc", `{"steps":9000}`)
+	retry := post(router, "/log", "abc", `{"steps":9000}`)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get(ReplayedHeader) != "true" {
+		t.Fatalf("retry = %d %q %v", retry.Code, retry.Body.String(), retry.Header())
+	}
+	if w := post(router, "/log", "abc", `{"steps":100}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with another body = %d", w.Code)
+	}
+	post(router, "/log", "", `{"steps":9000}`)
+	post(router, "/log", "", `{"steps":9000}`)
+	if calls != 3 {
+		t.Fatalf("requests without a key ran the handler %d times in all, want 3", calls)
+	}
+}
+
+func TestServerErrorsAreNotKept(t *testing.T) {
+	var calls int
+	router, db := setupRouter(t, &calls)
+
+	post(router, "/log?fail=1", "k", `{}`)
+	if w := post(router, "/log?fail=1", "k", `{}`); w.Code != http.StatusInternalServerError || calls != 2 {
+		t.Fatalf("retry after a server error = %d after %d calls", w.Code, calls)
+	}
+	var kept int64
+	if err := db.Model(&record{}).Count(&kept).Error; err != nil || kept != 0 {
+		t.Fatalf("%d keys kept, %v", kept, err)
+	}
+}
+
+func TestOversizedBodyIsRefused(t *testing.T) {
+	var calls int
+	router, _ := setupRouter(t, &calls)
+
+	if w := post(router, "/log", "big", strings.Repeat("x", maxBodyBytes+1)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized body = %d", w.Code)
+	}
+	if calls != 0 {
+		t.Fatalf("handler ran %d times", calls)
+	}
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"be-simpletracker/internal/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers plain-text mail.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromConfig picks the mailer cfg.Mail.Driver names: "smtp" for real delivery, or "outbox"
+// (the default) to write messages to MAIL_OUTBOX_DIR, or to the log when that is unset.
+// Logging mail is refused in production because messages carry reset links.
+func FromConfig(cfg *config.Config) (Mailer, error) {
+	switch driver := strings.ToLower(cfg.Mail.Driver); driver {
+	case "smtp":
+		return SMTPFromConfig(cfg.Mail)
+	case "outbox":
+		if cfg.Mail.OutboxDir == "" && cfg.IsProduction() {
+			return nil, fmt.Errorf("MAIL_DRIVER=outbox needs MAIL_OUTBOX_DIR in production")
+		}
+		return NewOutbox(cfg.Mail.OutboxDir, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q (expected smtp or outbox)", driver)
+	}
+}
+
+func formatMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	if from != "" {
+		fmt.Fprintf(&b, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// validHeader rejects CR/LF so user-controlled values cannot inject headers.
+func validHeader(values ...string) error {
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("mail header contains a line break")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Outbox stands in for SMTP in development and tests. With a directory it writes each
+// message to its own .eml file; without one it logs the message.
+type Outbox struct {
+	dir    string
+	logger *slog.Logger
+	seq    atomic.Int64
+}
+
+func NewOutbox(dir string, logger *slog.Logger) *Outbox {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Outbox{dir: dir, logger: logger}
+}
+
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+	if o.dir == "" {
+		o.logger.InfoContext(ctx, "outbox mail",
+			slog.String("to", msg.To),
+			slog.String("subject", msg.Subject),
+			slog.String("body", msg.Body),
+		)
+		return nil
+	}
+	if err := os.MkdirAll(o.dir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%03d.eml", time.Now().UTC().Format("20060102T150405.000000000"), o.seq.Add(1))
+	return os.WriteFile(filepath.Join(o.dir, name), formatMessage("", msg), 0o600)
+}
@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"be-simpletracker/internal/config"
+)
+
+func TestOutboxWritesMessageFile(t *testing.T) {
+	dir := t.TempDir()
+	outbox := NewOutbox(dir, nil)
+	if err := outbox.Send(context.Background(), Message{To: "a@example.com", Subject: "Hi", Body: "line1\nline2"}); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("files: %v %v", files, err)
+	}
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "To: a@example.com\r\n") || !strings.Contains(string(raw), "line1\r\nline2") {
+		t.Fatalf("message: %q", raw)
+	}
+}
+
+func TestOutboxLogsWithoutDirectory(t *testing.T) {
+	var logs bytes.Buffer
+	outbox := NewOutbox("", slog.New(slog.NewJSONHandler(&logs, nil)))
+	if err := outbox.Send(context.Background(), Message{To: "a@example.com", Subject: "Hi", Body: "body"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logs.String(), `"to":"a@example.com"`) {
+		t.Fatalf("log: %s", logs.String())
+	}
+}
+
+func TestSendRejectsHeaderInjection(t *testing.T) {
+	outbox := NewOutbox(t.TempDir(), nil)
+	err := outbox.Send(context.Background(), Message{To: "a@example.com\r\nBcc: x@example.com", Subject: "Hi"})
+	if err == nil {
+		t.Fatal("expected error for CRLF in recipient")
+	}
+}
+
+func TestFromConfigRejectsUnknownDriver(t *testing.T) {
+	cfg := config.Default()
+	cfg.Mail.Driver = "carrier-pigeon"
+	if _, err := FromConfig(cfg); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestFromConfigRefusesLogOutboxInProduction(t *testing.T) {
+	cfg := config.Default()
+	cfg.Environment = "production"
+	if _, err := FromConfig(cfg); err == nil {
+		t.Fatal("expected error")
+	}
+}
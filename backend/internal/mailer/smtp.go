@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+
+	"be-simpletracker/internal/config"
+)
+
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPFromConfig needs SMTP_HOST and MAIL_FROM.
+func SMTPFromConfig(cfg config.Mail) (*SMTPMailer, error) {
+	if cfg.SMTPHost == "" || cfg.From == "" {
+		return nil, fmt.Errorf("MAIL_DRIVER=smtp needs SMTP_HOST and MAIL_FROM")
+	}
+	return &SMTPMailer{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.From,
+	}, nil
+}
+
+// Send uses net/smtp, which upgrades to STARTTLS when the server offers it and refuses
+// PLAIN auth over an unencrypted remote connection.
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	if err := validHeader(msg.To, msg.Subject, m.From); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	return smtp.SendMail(addr, auth, m.From, []string{msg.To}, formatMessage(m.From, msg))
+}
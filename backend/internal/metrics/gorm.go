@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+var (
+	queryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time spent in database statements run through GORM, by operation and table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	entriesLogged = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "entries_logged_total",
+		Help:      "Entries users have logged, by kind: set, meal, steps, weight or water.",
+	}, []string{"kind"})
+)
+
+// loggedKinds names the tables whose new rows are entries a user logged, whether through the
+// API, a sync push or an import.
+var loggedKinds = map[string]string{
+	"logged_sets":      "set",
+	"day_logs":         "meal",
+	"step_logs":        "steps",
+	"body_weight_logs": "weight",
+	"water_logs":       "water",
+}
+
+const startedKey = "metrics:started"
+
+// GORMPlugin times every statement GORM runs and counts logged entries as they are created.
+type GORMPlugin struct{}
+
+func (GORMPlugin) Name() string { return "metrics" }
+
+func (GORMPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", startTimer),
+		cb.Create().After("gorm:create").Register("metrics:after_create", observe("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", startTimer),
+		cb.Query().After("gorm:query").Register("metrics:after_query", observe("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", startTimer),
+		cb.Update().After("gorm:update").Register("metrics:after_update", observe("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", startTimer),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", observe("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", startTimer),
+		cb.Row().After("gorm:row").Register("metrics:after_row", observe("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", startTimer),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", observe("raw")),
+	)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startedKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		started, ok := db.InstanceGet(startedKey)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "none"
+		}
+		queryDuration.WithLabelValues(operation, table).Observe(time.Since(started.(time.Time)).Seconds())
+		if kind, ok := loggedKinds[table]; ok && operation == "create" && db.Error == nil && db.RowsAffected > 0 {
+			entriesLogged.WithLabelValues(kind).Add(float64(db.RowsAffected))
+		}
+	}
+}
+
+// WatchPool exports the connection pool statistics of db.
+func WatchPool(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return Registry.Register(collectors.NewDBStatsCollector(sqlDB, db.Dialector.Name()))
+}
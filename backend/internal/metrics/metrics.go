@@ -0,0 +1,108 @@
+// Package metrics collects the server's Prometheus metrics: HTTP traffic by route, database
+// queries and connection pool, login protection, and what users log. Handler serves them in
+// the exposition format.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "simpletrack"
+
+// Registry holds every metric the server exports, plus the Go runtime and process ones.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to answer HTTP requests, by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	loginAttempts = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_attempts_total",
+		Help:      "Sign-in and registration attempts by outcome.",
+	}, []string{"outcome"})
+
+	loginProtection = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_protection_decisions_total",
+		Help:      "Login protection decisions: check is attempt or failure, result is allowed or the reason for refusing.",
+	}, []string{"check", "result"})
+)
+
+// unmatchedRoute labels requests no route matched, so stray paths do not each get a series.
+const unmatchedRoute = "unmatched"
+
+// Middleware counts and times every request under its route template, such as
+// /diet/meals/:id, rather than the path it was called with.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// LoginAttempt counts a sign-in attempt with the outcome it was logged with.
+func LoginAttempt(outcome string) {
+	loginAttempts.WithLabelValues(outcome).Inc()
+}
+
+// LoginProtectionDecision counts one login protection check. An empty reason means the
+// attempt was allowed.
+func LoginProtectionDecision(check, reason string) {
+	if reason == "" {
+		reason = "allowed"
+	}
+	loginProtection.WithLabelValues(check, reason).Inc()
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RequireToken answers 401 unless the request carries "Authorization: Bearer <token>", for
+// serving /metrics on the public port.
+func RequireToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "metrics token required"})
+			return
+		}
+		c.Next()
+	}
+}
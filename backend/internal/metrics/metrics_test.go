@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"be-simpletracker/internal/database/dbtest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testToken = "0123456789abcdef"
+
+func TestRequestsAreLabelledByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/items/:id", "204"))
+	strays := testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404"))
+	for _, path := range []string{"/items/1", "/items/2", "/nowhere"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/items/:id", "204")) - before; got != 2 {
+		t.Fatalf("/items/:id counted %v requests, want 2", got)
+	}
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404")) - strays; got != 1 {
+		t.Fatalf("unmatched counted %v requests, want 1", got)
+	}
+}
+
+type loggedSet struct {
+	ID   uint
+	Reps int
+}
+
+func (loggedSet) TableName() string { return "logged_sets" }
+
+func TestGORMPluginCountsLoggedEntries(t *testing.T) {
+	db := dbtest.Open(t)
+	if err := db.Use(GORMPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&loggedSet{}); err != nil {
+		t.Fatal(err)
+	}
+	before := testutil.ToFloat64(entriesLogged.WithLabelValues("set"))
+	if err := db.Create(&[]loggedSet{{Reps: 5}, {Reps: 8}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var sets []loggedSet
+	if err := db.Find(&sets).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(entriesLogged.WithLabelValues("set")) - before; got != 2 {
+		t.Fatalf("sets logged = %v, want 2", got)
+	}
+	if n := testutil.CollectAndCount(queryDuration, "simpletrack_db_query_duration_seconds"); n < 2 {
+		t.Fatalf("%d query duration series, want create and query", n)
+	}
+}
+
+func TestMetricsNeedTheToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/metrics", RequireToken(testToken), gin.WrapH(Handler()))
+	LoginAttempt("success")
+
+	for _, header := range []string{"", "Bearer wrong", "Basic " + testToken} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Authorization %q: %d", header, w.Code)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `simpletrack_login_attempts_total{outcome="success"}`) {
+		t.Fatalf("%d\n%s", w.Code, w.Body.String())
+	}
+}
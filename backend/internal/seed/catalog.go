@@ -0,0 +1,199 @@
+package seed
+
+import workoutmodels "be-simpletracker/internal/core/workout/models"
+
+type food struct {
+	name                            string
+	servingType                     string
+	servingAmount                   float32
+	calories, protein, fiber, carbs float32
+	fat                             float32
+	// variantOf names an earlier food this one is a variant of.
+	variantOf string
+}
+
+var foods = []food{
+	{name: "Rolled oats", servingType: "g", servingAmount: 40, calories: 150, protein: 5, fiber: 4, carbs: 27, fat: 3},
+	{name: "Banana", servingType: "medium", servingAmount: 1, calories: 105, protein: 1.3, fiber: 3.1, carbs: 27, fat: 0.4},
+	{name: "Blueberries", servingType: "g", servingAmount: 100, calories: 57, protein: 0.7, fiber: 2.4, carbs: 14, fat: 0.3},
+	{name: "Greek yogurt, plain", servingType: "g", servingAmount: 170, calories: 100, protein: 17, carbs: 6, fat: 0.7},
+	{name: "Greek yogurt, vanilla", servingType: "g", servingAmount: 170, calories: 140, protein: 13, carbs: 17, fat: 2.5, variantOf: "Greek yogurt, plain"},
+	{name: "Egg", servingType: "large", servingAmount: 1, calories: 72, protein: 6.3, carbs: 0.4, fat: 4.8},
+	{name: "Egg whites", servingType: "g", servingAmount: 100, calories: 52, protein: 11, carbs: 0.7, fat: 0.2},
+	{name: "Whey protein, chocolate", servingType: "scoop", servingAmount: 1, calories: 120, protein: 24, fiber: 1, carbs: 3, fat: 1.5},
+	{name: "Whey protein, vanilla", servingType: "scoop", servingAmount: 1, calories: 120, protein: 24, carbs: 3, fat: 1.5, variantOf: "Whey protein, chocolate"},
+	{name: "Whole wheat bread", servingType: "slice", servingAmount: 1, calories: 80, protein: 4, fiber: 2, carbs: 14, fat: 1},
+	{name: "Peanut butter", servingType: "tbsp", servingAmount: 2, calories: 190, protein: 7, fiber: 2, carbs: 7, fat: 16},
+	{name: "Chicken breast", servingType: "g", servingAmount: 100, calories: 165, protein: 31, fat: 3.6},
+	{name: "Ground beef 90/10", servingType: "g", servingAmount: 100, calories: 184, protein: 20, fat: 11},
+	{name: "Salmon", servingType: "g", servingAmount: 100, calories: 208, protein: 20, fat: 13},
+	{name: "White rice, cooked", servingType: "g", servingAmount: 100, calories: 130, protein: 2.7, fiber: 0.4, carbs: 28, fat: 0.3},
+	{name: "Sweet potato", servingType: "g", servingAmount: 100, calories: 86, protein: 1.6, fiber: 3, carbs: 20, fat: 0.1},
+	{name: "Broccoli", servingType: "g", servingAmount: 100, calories: 34, protein: 2.8, fiber: 2.6, carbs: 7, fat: 0.4},
+	{name: "Mixed greens", servingType: "g", servingAmount: 85, calories: 20, protein: 1.5, fiber: 1.5, carbs: 3.5, fat: 0.2},
+	{name: "Olive oil", servingType: "tbsp", servingAmount: 1, calories: 119, fat: 13.5},
+	{name: "Flour tortilla", servingType: "tortilla", servingAmount: 1, calories: 140, protein: 4, fiber: 2, carbs: 24, fat: 3.5},
+	{name: "Black beans", servingType: "g", servingAmount: 130, calories: 114, protein: 7.6, fiber: 7.5, carbs: 20, fat: 0.5},
+	{name: "Cheddar", servingType: "g", servingAmount: 28, calories: 113, protein: 7, carbs: 0.4, fat: 9.3},
+	{name: "Apple", servingType: "medium", servingAmount: 1, calories: 95, protein: 0.5, fiber: 4.4, carbs: 25, fat: 0.3},
+	{name: "Almonds", servingType: "g", servingAmount: 28, calories: 164, protein: 6, fiber: 3.5, carbs: 6, fat: 14},
+	{name: "Pasta, cooked", servingType: "g", servingAmount: 100, calories: 158, protein: 5.8, fiber: 1.8, carbs: 31, fat: 0.9},
+	{name: "Marinara", servingType: "g", servingAmount: 125, calories: 70, protein: 2, fiber: 3, carbs: 12, fat: 2},
+	{name: "Protein bar", servingType: "bar", servingAmount: 1, calories: 200, protein: 20, fiber: 8, carbs: 22, fat: 7},
+	{name: "Dark chocolate", servingType: "g", servingAmount: 20, calories: 120, protein: 1.5, fiber: 2, carbs: 9, fat: 8.5},
+	{name: "Milk, 2%", servingType: "cup", servingAmount: 1, calories: 122, protein: 8, carbs: 12, fat: 4.8},
+}
+
+type portion struct {
+	food   string
+	amount float64
+}
+
+type composite struct {
+	name  string
+	items []portion
+}
+
+var composites = []composite{
+	{name: "Overnight oats", items: []portion{
+		{"Rolled oats", 1}, {"Greek yogurt, plain", 0.5}, {"Milk, 2%", 0.5}, {"Blueberries", 0.5}, {"Peanut butter", 0.5},
+	}},
+	{name: "Burrito bowl", items: []portion{
+		{"White rice, cooked", 1.5}, {"Black beans", 0.75}, {"Ground beef 90/10", 1.25}, {"Cheddar", 0.5}, {"Mixed greens", 0.5},
+	}},
+}
+
+type mealSlot int
+
+const (
+	breakfast mealSlot = iota
+	lunch
+	dinner
+	snack
+)
+
+// template is a meal the account eats regularly. Saved templates also exist as saved meals
+// that planned meals point back to.
+type template struct {
+	name      string
+	slot      mealSlot
+	saved     bool
+	items     []portion
+	composite string
+}
+
+var templates = []template{
+	{name: "Oats & berries", slot: breakfast, saved: true, items: []portion{
+		{"Rolled oats", 1.5}, {"Blueberries", 1}, {"Whey protein, vanilla", 1}, {"Milk, 2%", 0.5},
+	}},
+	{name: "Eggs & toast", slot: breakfast, items: []portion{
+		{"Egg", 3}, {"Egg whites", 1}, {"Whole wheat bread", 2}, {"Banana", 1},
+	}},
+	{name: "Overnight oats", slot: breakfast, composite: "Overnight oats", items: []portion{{"Banana", 1}}},
+	{name: "Yogurt bowl", slot: breakfast, items: []portion{
+		{"Greek yogurt, plain", 1.5}, {"Blueberries", 0.75}, {"Almonds", 1},
+	}},
+
+	{name: "Chicken rice bowl", slot: lunch, saved: true, items: []portion{
+		{"Chicken breast", 1.75}, {"White rice, cooked", 1.5}, {"Broccoli", 1}, {"Olive oil", 0.5},
+	}},
+	{name: "Burrito bowl", slot: lunch, composite: "Burrito bowl"},
+	{name: "Salmon salad", slot: lunch, items: []portion{
+		{"Salmon", 1.5}, {"Mixed greens", 1.5}, {"Sweet potato", 1.5}, {"Olive oil", 1},
+	}},
+	{name: "Chicken sandwich", slot: lunch, items: []portion{
+		{"Whole wheat bread", 2}, {"Chicken breast", 1.25}, {"Cheddar", 1}, {"Apple", 1},
+	}},
+
+	{name: "Pasta bolognese", slot: dinner, saved: true, items: []portion{
+		{"Pasta, cooked", 2}, {"Marinara", 1}, {"Ground beef 90/10", 1.25},
+	}},
+	{name: "Salmon & sweet potato", slot: dinner, items: []portion{
+		{"Salmon", 1.75}, {"Sweet potato", 2}, {"Broccoli", 1.5},
+	}},
+	{name: "Chicken & rice", slot: dinner, items: []portion{
+		{"Chicken breast", 2}, {"White rice, cooked", 2}, {"Broccoli", 1}, {"Olive oil", 1},
+	}},
+	{name: "Beef tacos", slot: dinner, items: []portion{
+		{"Flour tortilla", 3}, {"Ground beef 90/10", 1.5}, {"Cheddar", 1}, {"Mixed greens", 0.5},
+	}},
+
+	{name: "Protein shake", slot: snack, saved: true, items: []portion{{"Whey protein, chocolate", 1.5}, {"Milk, 2%", 1}}},
+	{name: "Apple & peanut butter", slot: snack, items: []portion{{"Apple", 1}, {"Peanut butter", 1}}},
+	{name: "Protein bar", slot: snack, items: []portion{{"Protein bar", 1}}},
+	{name: "Vanilla yogurt", slot: snack, items: []portion{{"Greek yogurt, vanilla", 1}}},
+	{name: "Chocolate & almonds", slot: snack, items: []portion{{"Dark chocolate", 1}, {"Almonds", 0.5}}},
+}
+
+// lift is an exercise and how it progresses: reps climb from minReps to the exercise's rep
+// rollover, then the weight goes up by step and reps start over.
+type lift struct {
+	name     string
+	loadType workoutmodels.ExerciseLoadType
+	cues     string
+	weight   float64
+	step     float64
+	minReps  int
+	rollover uint
+	sets     int
+}
+
+var lifts = []lift{
+	{name: "Back Squat", loadType: workoutmodels.ExerciseLoadTypePlateLoadedWithBar, cues: "Brace, knees out, hit depth", weight: 185, step: 10, minReps: 5, rollover: 8, sets: 3},
+	{name: "Bench Press", loadType: workoutmodels.ExerciseLoadTypePlateLoadedWithBar, cues: "Shoulder blades back, touch low chest", weight: 155, step: 5, minReps: 5, rollover: 8, sets: 3},
+	{name: "Barbell Row", loadType: workoutmodels.ExerciseLoadTypePlateLoadedWithBar, weight: 135, step: 5, minReps: 6, rollover: 10, sets: 3},
+	{name: "Overhead Press", loadType: workoutmodels.ExerciseLoadTypePlateLoadedWithBar, cues: "Squeeze glutes, head through", weight: 95, step: 5, minReps: 5, rollover: 8, sets: 3},
+	{name: "Romanian Deadlift", loadType: workoutmodels.ExerciseLoadTypePlateLoadedWithBar, cues: "Hips back, soft knees", weight: 185, step: 10, minReps: 6, rollover: 10, sets: 3},
+	{name: "Incline Dumbbell Press", loadType: workoutmodels.ExerciseLoadTypeFreeWeights, weight: 50, step: 5, minReps: 8, rollover: 12, sets: 3},
+	{name: "Lat Pulldown", loadType: workoutmodels.ExerciseLoadTypeWeightStack, weight: 120, step: 10, minReps: 8, rollover: 12, sets: 3},
+	{name: "Seated Cable Row", loadType: workoutmodels.ExerciseLoadTypeWeightStack, weight: 110, step: 10, minReps: 8, rollover: 12, sets: 3},
+	{name: "Leg Press", loadType: workoutmodels.ExerciseLoadTypePlateLoadedWithoutBar, weight: 270, step: 20, minReps: 10, rollover: 15, sets: 3},
+	{name: "Leg Curl", loadType: workoutmodels.ExerciseLoadTypeWeightStack, weight: 90, step: 10, minReps: 10, rollover: 15, sets: 3},
+	{name: "Lateral Raise", loadType: workoutmodels.ExerciseLoadTypeFreeWeights, weight: 20, step: 2.5, minReps: 12, rollover: 20, sets: 3},
+	{name: "Triceps Pushdown", loadType: workoutmodels.ExerciseLoadTypeWeightStack, weight: 50, step: 5, minReps: 10, rollover: 15, sets: 3},
+	{name: "Barbell Curl", loadType: workoutmodels.ExerciseLoadTypePlateLoadedTotal, weight: 60, step: 5, minReps: 8, rollover: 12, sets: 3},
+	{name: "Standing Calf Raise", loadType: workoutmodels.ExerciseLoadTypeWeightStack, weight: 150, step: 10, minReps: 12, rollover: 20, sets: 3},
+}
+
+type planSpec struct {
+	name        string
+	days        []int // time.Weekday values
+	lifts       []string
+	cardioType  string
+	cardioMins  int
+	preMobility []string
+}
+
+type programSpec struct {
+	name  string
+	plans []planSpec
+}
+
+// programs run one after the other; the last one is the active program.
+var programs = []programSpec{
+	{name: "Full Body 3x", plans: []planSpec{
+		{name: "Full Body A", days: []int{1, 5}, lifts: []string{"Back Squat", "Bench Press", "Barbell Row", "Lateral Raise"},
+			preMobility: []string{"Hip openers", "Band pull-aparts"}},
+		{name: "Full Body B", days: []int{3}, lifts: []string{"Romanian Deadlift", "Overhead Press", "Lat Pulldown", "Barbell Curl"},
+			cardioType: "Bike", cardioMins: 15, preMobility: []string{"Hip openers", "Thoracic rotations"}},
+	}},
+	{name: "Upper / Lower", plans: []planSpec{
+		{name: "Upper A", days: []int{1}, lifts: []string{"Bench Press", "Barbell Row", "Overhead Press", "Lat Pulldown", "Triceps Pushdown"},
+			preMobility: []string{"Band pull-aparts", "Wall slides"}},
+		{name: "Lower A", days: []int{2}, lifts: []string{"Back Squat", "Romanian Deadlift", "Leg Curl", "Standing Calf Raise"},
+			cardioType: "Bike", cardioMins: 15, preMobility: []string{"Hip openers", "Ankle rocks"}},
+		{name: "Upper B", days: []int{4}, lifts: []string{"Incline Dumbbell Press", "Seated Cable Row", "Lateral Raise", "Barbell Curl", "Triceps Pushdown"},
+			preMobility: []string{"Band pull-aparts", "Thoracic rotations"}},
+		{name: "Lower B", days: []int{5}, lifts: []string{"Leg Press", "Romanian Deadlift", "Leg Curl", "Standing Calf Raise"},
+			cardioType: "Incline walk", cardioMins: 20, preMobility: []string{"Hip openers", "Ankle rocks"}},
+	}},
+}
+
+var postMobility = []string{"Couch stretch", "Doorway pec stretch", "Child's pose"}
+
+var groceries = []string{
+	"Chicken breast", "Ground beef", "Salmon fillets", "Eggs", "Greek yogurt", "Milk", "Rolled oats",
+	"Blueberries", "Bananas", "Apples", "Broccoli", "Mixed greens", "Sweet potatoes", "Rice",
+	"Pasta", "Marinara", "Tortillas", "Black beans", "Cheddar", "Almonds", "Peanut butter",
+	"Protein bars", "Coffee", "Olive oil", "Dark chocolate", "Whole wheat bread",
+}
@@ -0,0 +1,203 @@
+package seed
+
+import (
+	"fmt"
+	"time"
+
+	dietmodels "be-simpletracker/internal/core/diet/models"
+	"be-simpletracker/internal/database"
+)
+
+// plannedDaysAhead get planned but unlogged meals after the end date.
+const plannedDaysAhead = 3
+
+// phase is a stretch of the history eating to one diet plan.
+type phase struct {
+	plan dietmodels.Plan
+	from time.Time
+	// weightRate is the trend change in body weight, in pounds per day.
+	weightRate float64
+}
+
+// phases splits the history into maintenance, a cut, and maintenance again.
+func (g *generator) phases() []phase {
+	total := int(g.end.Sub(g.start).Hours()/24) + 1
+	return []phase{
+		{from: g.start, weightRate: 0.005, plan: dietmodels.Plan{Name: "Maintenance", Calories: 2600, Protein: 170, Fiber: 35, Carbs: 300, Fat: 80}},
+		{from: g.start.AddDate(0, 0, total*3/10), weightRate: -0.1, plan: dietmodels.Plan{Name: "Cut", Calories: 2150, Protein: 190, Fiber: 35, Carbs: 215, Fat: 60}},
+		{from: g.start.AddDate(0, 0, total*3/4), weightRate: 0.01, plan: dietmodels.Plan{Name: "Maintenance (post-cut)", Calories: 2500, Protein: 180, Fiber: 35, Carbs: 285, Fat: 75}},
+	}
+}
+
+func phaseOn(phases []phase, day time.Time) phase {
+	current := phases[0]
+	for _, p := range phases {
+		if !day.Before(p.from) {
+			current = p
+		}
+	}
+	return current
+}
+
+type dietCatalog struct {
+	foods      map[string]dietmodels.Food
+	composites map[string]dietmodels.CompositeFood
+	saved      map[string]uint
+	bySlot     map[mealSlot][]template
+}
+
+func (g *generator) diet() error {
+	catalog, err := g.dietCatalog()
+	if err != nil {
+		return err
+	}
+	phases := g.phases()
+	for i := range phases {
+		from := database.DateOf(phases[i].from)
+		phases[i].plan.EffectiveFrom = &from
+		if err := g.create(&phases[i].plan); err != nil {
+			return err
+		}
+	}
+	return g.days(g.start, g.end.AddDate(0, 0, plannedDaysAhead), func(date time.Time, _ int) error {
+		p := phaseOn(phases, date)
+		day := dietmodels.DietDay{Date: database.DateOf(date), PlanID: p.plan.ID}
+		if err := g.create(&day); err != nil {
+			return err
+		}
+		past := !date.After(g.end)
+		// Portions follow the plan's calories, a little over or under on any given day.
+		scale := float64(p.plan.Calories) / 2600
+		slots := []mealSlot{breakfast, lunch, dinner}
+		if g.chance(0.6) {
+			slots = append(slots, snack)
+		}
+		for order, slot := range slots {
+			t := g.pick(catalog.bySlot[slot])
+			meal, err := g.meal(catalog, t, scale*g.between(0.9, 1.1))
+			if err != nil {
+				return err
+			}
+			logged := past && g.chance(0.92)
+			planned := dietmodels.PlannedMeal{DayID: day.ID, MealID: meal.ID, Logged: logged, DisplayOrder: order}
+			if id, ok := catalog.saved[t.name]; ok {
+				planned.SavedMealID = &id
+			}
+			if err := g.create(&planned); err != nil {
+				return err
+			}
+			if logged {
+				if err := g.create(&dietmodels.DayLog{DayID: day.ID, MealID: meal.ID}); err != nil {
+					return err
+				}
+			}
+		}
+		// Now and then something off-plan gets logged too.
+		if past && g.chance(0.25) {
+			meal, err := g.meal(catalog, g.pick(catalog.bySlot[snack]), 1)
+			if err != nil {
+				return err
+			}
+			if err := g.create(&dietmodels.DayLog{DayID: day.ID, MealID: meal.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (g *generator) dietCatalog() (dietCatalog, error) {
+	catalog := dietCatalog{
+		foods:      map[string]dietmodels.Food{},
+		composites: map[string]dietmodels.CompositeFood{},
+		saved:      map[string]uint{},
+		bySlot:     map[mealSlot][]template{},
+	}
+	var nextGroup uint
+	if err := g.tx.Model(&dietmodels.Food{}).Select("COALESCE(MAX(variant_group_id), 0)").Scan(&nextGroup).Error; err != nil {
+		return catalog, err
+	}
+	for _, f := range foods {
+		row := dietmodels.Food{
+			Name: f.name, ServingType: f.servingType, ServingAmount: f.servingAmount,
+			Calories: f.calories, Protein: f.protein, Fiber: f.fiber, Carbs: f.carbs, Fat: f.fat,
+		}
+		if err := g.create(&row); err != nil {
+			return catalog, err
+		}
+		if f.variantOf != "" {
+			base := catalog.foods[f.variantOf]
+			if base.VariantGroupID == nil {
+				nextGroup++
+				group := nextGroup
+				base.VariantGroupID = &group
+				if err := g.tx.Model(&base).Update("variant_group_id", group).Error; err != nil {
+					return catalog, err
+				}
+				catalog.foods[base.Name] = base
+			}
+			if err := g.tx.Model(&row).Update("variant_group_id", *base.VariantGroupID).Error; err != nil {
+				return catalog, err
+			}
+		}
+		catalog.foods[f.name] = row
+	}
+
+	for _, c := range composites {
+		row := dietmodels.CompositeFood{Name: c.name}
+		for _, p := range c.items {
+			row.Items = append(row.Items, dietmodels.CompositeFoodItem{FoodID: catalog.foods[p.food].ID, Amount: float32(p.amount)})
+		}
+		if err := g.create(&row); err != nil {
+			return catalog, err
+		}
+		catalog.composites[c.name] = row
+	}
+
+	for _, t := range templates {
+		catalog.bySlot[t.slot] = append(catalog.bySlot[t.slot], t)
+		if !t.saved {
+			continue
+		}
+		saved := dietmodels.SavedMeal{Name: t.name}
+		for _, p := range t.items {
+			saved.Items = append(saved.Items, dietmodels.SavedMealItem{FoodID: catalog.foods[p.food].ID, Amount: p.amount})
+		}
+		if err := g.create(&saved); err != nil {
+			return catalog, err
+		}
+		catalog.saved[t.name] = saved.ID
+	}
+	return catalog, nil
+}
+
+// meal writes one eaten instance of t with every amount multiplied by scale.
+func (g *generator) meal(catalog dietCatalog, t template, scale float64) (dietmodels.Meal, error) {
+	meal := dietmodels.Meal{Name: t.name}
+	amount := func(base float64) float32 {
+		return float32(max(0.25, round(base*scale, 0.25)))
+	}
+	if t.composite != "" {
+		c := catalog.composites[t.composite]
+		group := g.groupID()
+		for _, item := range c.Items {
+			id := c.ID
+			meal.Items = append(meal.Items, dietmodels.MealItem{
+				FoodID: item.FoodID, Amount: amount(float64(item.Amount)),
+				GroupID: group, GroupLabel: c.Name, CompositeFoodID: &id,
+			})
+		}
+	}
+	for _, p := range t.items {
+		f, ok := catalog.foods[p.food]
+		if !ok {
+			return meal, fmt.Errorf("template %q uses unknown food %q", t.name, p.food)
+		}
+		meal.Items = append(meal.Items, dietmodels.MealItem{FoodID: f.ID, Amount: amount(p.amount)})
+	}
+	return meal, g.create(&meal)
+}
+
+func (g *generator) pick(options []template) template {
+	return options[g.rng.IntN(len(options))]
+}
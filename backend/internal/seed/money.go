@@ -0,0 +1,97 @@
+package seed
+
+import (
+	"time"
+
+	moneymodels "be-simpletracker/internal/core/money/models"
+	"be-simpletracker/internal/database"
+)
+
+type accountSpec struct {
+	name    string
+	kind    string
+	opening float64
+	// deposit returns the amount deposited on date, or 0.
+	deposit func(g *generator, date time.Time) float64
+}
+
+// annualLimits are contribution limits by account type and year; later years reuse the last.
+var annualLimits = map[string][]struct {
+	year  int
+	limit float64
+}{
+	"401(k)":   {{2024, 23000}, {2025, 23500}, {2026, 24500}},
+	"Roth IRA": {{2024, 7000}, {2025, 7000}, {2026, 7500}},
+}
+
+var accounts = []accountSpec{
+	{name: "Work 401(k)", kind: "401(k)", opening: 42000, deposit: func(g *generator, date time.Time) float64 {
+		// Payroll every other Friday.
+		if date.Weekday() != time.Friday || (date.YearDay()/7)%2 != 0 {
+			return 0
+		}
+		return round(g.between(820, 900), 0.01)
+	}},
+	{name: "Roth IRA", kind: "Roth IRA", opening: 18500, deposit: func(g *generator, date time.Time) float64 {
+		if date.Day() != 1 {
+			return 0
+		}
+		return 583.33
+	}},
+	{name: "Brokerage", kind: "Taxable", opening: 9000, deposit: func(g *generator, date time.Time) float64 {
+		if date.Day() != 15 || !g.chance(0.3) {
+			return 0
+		}
+		return round(g.between(250, 2000), 50)
+	}},
+}
+
+func (g *generator) money() error {
+	types := map[string]moneymodels.InvestmentAccountType{}
+	for _, kind := range []string{"401(k)", "Roth IRA", "Taxable"} {
+		row := moneymodels.InvestmentAccountType{Name: kind}
+		if err := g.create(&row); err != nil {
+			return err
+		}
+		limits := annualLimits[kind]
+		for year := g.start.Year(); len(limits) > 0 && year <= g.end.Year(); year++ {
+			limit := limits[0].limit
+			for _, l := range limits {
+				if l.year <= year {
+					limit = l.limit
+				}
+			}
+			rule := moneymodels.ContributionRule{InvestmentAccountTypeID: row.ID, Year: year, AnnualLimit: database.Money(limit)}
+			if err := g.create(&rule); err != nil {
+				return err
+			}
+		}
+		types[kind] = row
+	}
+
+	for _, spec := range accounts {
+		typeID := types[spec.kind].ID
+		account := moneymodels.InvestmentAccount{Name: spec.name, InvestmentAccountTypeID: &typeID}
+		if err := g.tx.Omit("InvestmentAccountType").Create(&account).Error; err != nil {
+			return err
+		}
+		balance := spec.opening
+		err := g.days(g.start, g.end, func(date time.Time, _ int) error {
+			// About 7% a year, with some day-to-day market noise.
+			balance *= 1 + 0.07/365 + g.noise(0.004)
+			amount := spec.deposit(g, date)
+			if amount == 0 {
+				return nil
+			}
+			balance += amount
+			return g.create(&moneymodels.InvestmentDeposit{AccountID: account.ID, Amount: database.Money(amount), Date: database.DateOf(date)})
+		})
+		if err != nil {
+			return err
+		}
+		if err := g.tx.Model(&account).Update("current_balance", database.Money(round(balance, 0.01))).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
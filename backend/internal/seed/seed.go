@@ -0,0 +1,147 @@
+// Package seed fills an account with months of plausible tracker data for development and
+// load testing. Output depends only on the options: the same seed, end date and length
+// produce the same rows.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"be-simpletracker/internal/database"
+
+	"gorm.io/gorm"
+)
+
+type Options struct {
+	// Months of history ending on End.
+	Months int
+	// Seed drives every random choice.
+	Seed uint64
+	// End is the last day with logged data; a few days after it get planned meals only.
+	End time.Time
+}
+
+// Summary counts the rows written per table.
+type Summary struct {
+	From time.Time
+	To   time.Time
+	Rows map[string]int64
+}
+
+var ErrAccountHasData = errors.New("account already has tracker data")
+
+// tables are the ones Generate writes, in the order the summary lists them.
+var tables = []string{
+	"plans", "foods", "composite_foods", "composite_food_items", "saved_meals", "saved_meal_items",
+	"days", "meals", "meal_items", "planned_meals", "day_logs",
+	"exercises", "workout_programs", "workout_plans", "workout_plan_days", "workout_plan_exercises",
+	"workout_logs", "logged_exercises", "logged_sets", "cardios",
+	"body_weight_logs", "step_logs", "drink_size_presets", "water_logs", "grocery_items", "user_profiles",
+	"investment_account_types", "investment_contribution_rules", "investment_accounts", "investment_deposits",
+}
+
+// Generate writes the data for userID in one transaction. It refuses an account that already
+// has rows in any of the tables it writes, so nothing it creates collides with real data.
+func Generate(ctx context.Context, db *gorm.DB, userID uint, opts Options) (Summary, error) {
+	if opts.Months < 1 {
+		return Summary{}, fmt.Errorf("months must be at least 1")
+	}
+	if opts.End.IsZero() {
+		return Summary{}, fmt.Errorf("end date is required")
+	}
+	end := midnight(opts.End)
+	start := end.AddDate(0, -opts.Months, 1)
+	summary := Summary{From: start, To: end, Rows: map[string]int64{}}
+
+	err := db.WithContext(database.WithUserID(ctx, userID)).Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			var count int64
+			if err := tx.Table(table).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return fmt.Errorf("%w: %s has %d rows", ErrAccountHasData, table, count)
+			}
+		}
+		g := &generator{
+			tx:    tx,
+			rng:   rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x5eed)),
+			start: start,
+			end:   end,
+		}
+		steps := []func() error{g.diet, g.workouts, g.bodyWeight, g.steps, g.water, g.groceries, g.profile, g.money}
+		for _, step := range steps {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+		for _, table := range tables {
+			var count int64
+			if err := tx.Table(table).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+				return err
+			}
+			summary.Rows[table] = count
+		}
+		return nil
+	})
+	return summary, err
+}
+
+// Tables lists the tables Generate writes, for printing a Summary in order.
+func Tables() []string {
+	return append([]string(nil), tables...)
+}
+
+type generator struct {
+	tx         *gorm.DB
+	rng        *rand.Rand
+	start, end time.Time
+}
+
+// days calls fn for every calendar day from start to end, inclusive.
+func (g *generator) days(start, end time.Time, fn func(day time.Time, index int) error) error {
+	for i, day := 0, start; !day.After(end); i, day = i+1, day.AddDate(0, 0, 1) {
+		if err := fn(day, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) create(value any) error {
+	return g.tx.Create(value).Error
+}
+
+// chance reports true with probability p.
+func (g *generator) chance(p float64) bool {
+	return g.rng.Float64() < p
+}
+
+// between returns a uniform value in [lo, hi).
+func (g *generator) between(lo, hi float64) float64 {
+	return lo + g.rng.Float64()*(hi-lo)
+}
+
+// noise returns a normally distributed value with mean 0.
+func (g *generator) noise(stddev float64) float64 {
+	return g.rng.NormFloat64() * stddev
+}
+
+func (g *generator) groupID() string {
+	return fmt.Sprintf("%08x-%04x-4%03x-%04x-%012x",
+		g.rng.Uint32(), g.rng.Uint32()&0xffff, g.rng.Uint32()&0xfff, g.rng.Uint32()&0x3fff|0x8000, g.rng.Uint64()&0xffffffffffff)
+}
+
+// midnight is the start of t's calendar day in local time, which is how the tracker stores dates.
+func midnight(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func round(v float64, step float64) float64 {
+	return math.Round(v/step) * step
+}
@@ -0,0 +1,151 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"be-simpletracker/internal/core/archive"
+	dietmodels "be-simpletracker/internal/core/diet/models"
+	"be-simpletracker/internal/core/module"
+	"be-simpletracker/internal/core/tracking/weight"
+	"be-simpletracker/internal/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const demoUser uint = 1
+
+var end = time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local)
+
+func openMigratedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "seed.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.Use(database.OwnerScope{}); err != nil {
+		t.Fatal(err)
+	}
+	modules, err := module.NewRegistry(module.All(db), module.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := modules.Runner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func generate(t *testing.T, db *gorm.DB, opts Options) Summary {
+	t.Helper()
+	summary, err := Generate(context.Background(), db, demoUser, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return summary
+}
+
+func weights(t *testing.T, db *gorm.DB) []float64 {
+	t.Helper()
+	var out []float64
+	if err := db.Model(&weight.BodyWeightLog{}).Order("date").Pluck("weight_lbs", &out).Error; err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	opts := Options{Months: 2, Seed: 7, End: end}
+	first, second := openMigratedDB(t), openMigratedDB(t)
+	a, b := generate(t, first, opts), generate(t, second, opts)
+	if !reflect.DeepEqual(a.Rows, b.Rows) {
+		t.Fatalf("row counts differ:\n%v\n%v", a.Rows, b.Rows)
+	}
+	if !reflect.DeepEqual(weights(t, first), weights(t, second)) {
+		t.Fatal("same seed gave different weigh-ins")
+	}
+
+	other := openMigratedDB(t)
+	generate(t, other, Options{Months: 2, Seed: 8, End: end})
+	if reflect.DeepEqual(weights(t, first), weights(t, other)) {
+		t.Fatal("different seeds gave the same weigh-ins")
+	}
+}
+
+func TestGenerateWritesConsistentHistory(t *testing.T) {
+	db := openMigratedDB(t)
+	summary := generate(t, db, Options{Months: 4, Seed: 1, End: end})
+	for _, table := range tables {
+		if summary.Rows[table] == 0 {
+			t.Errorf("%s is empty", table)
+		}
+	}
+	if found, err := archive.DanglingReferences(context.Background(), db); err != nil || len(found) > 0 {
+		t.Fatalf("dangling references %+v, %v", found, err)
+	}
+
+	// Every day eats to the plan in effect that day. Plans start at a local midnight, which
+	// SQLite keeps as text beginning with that day.
+	var mismatched int64
+	err := db.Table("days").Joins("JOIN plans ON plans.id = days.plan_id").
+		Where("substr(plans.effective_from, 1, 10) > days.date").Count(&mismatched).Error
+	if err != nil || mismatched > 0 {
+		t.Fatalf("%d days use a plan that had not started, %v", mismatched, err)
+	}
+	// Logged planned meals show up in the day's log; days after the end are only planned.
+	var unlogged int64
+	err = db.Model(&dietmodels.PlannedMeal{}).Where("logged = ?", true).
+		Where("NOT EXISTS (SELECT 1 FROM day_logs dl WHERE dl.day_id = planned_meals.day_id AND dl.meal_id = planned_meals.meal_id)").
+		Count(&unlogged).Error
+	if err != nil || unlogged > 0 {
+		t.Fatalf("%d logged planned meals have no day log, %v", unlogged, err)
+	}
+	var future int64
+	if err := db.Model(&dietmodels.DayLog{}).Joins("JOIN days ON days.id = day_logs.day_id").Where("days.date > ?", end).Count(&future).Error; err != nil || future > 0 {
+		t.Fatalf("%d meals logged after the end date, %v", future, err)
+	}
+
+	// The main lifts get stronger over the months.
+	var bench []float64
+	err = db.Table("logged_sets").Select("MAX(logged_sets.weight)").
+		Joins("JOIN logged_exercises le ON le.id = logged_sets.logged_exercise_id").
+		Joins("JOIN exercises e ON e.id = le.exercise_id").
+		Joins("JOIN workout_logs wl ON wl.id = le.workout_log_id").
+		Where("e.name = ?", "Bench Press").Group("wl.date").Order("wl.date").Pluck("MAX(logged_sets.weight)", &bench).Error
+	if err != nil || len(bench) < 10 {
+		t.Fatalf("bench sessions %v, %v", bench, err)
+	}
+	if bench[len(bench)-1] <= bench[0] {
+		t.Fatalf("bench went from %v to %v", bench[0], bench[len(bench)-1])
+	}
+
+	// The cut shows in the weight trend.
+	w := weights(t, db)
+	if w[len(w)-1] >= w[0]-3 {
+		t.Fatalf("weight went from %v to %v", w[0], w[len(w)-1])
+	}
+}
+
+func TestGenerateRefusesAccountWithData(t *testing.T) {
+	db := openMigratedDB(t)
+	opts := Options{Months: 1, Seed: 1, End: end}
+	generate(t, db, opts)
+	if _, err := Generate(context.Background(), db, demoUser, opts); !errors.Is(err, ErrAccountHasData) {
+		t.Fatalf("got %v, want ErrAccountHasData", err)
+	}
+}
@@ -0,0 +1,105 @@
+package seed
+
+import (
+	"math"
+	"time"
+
+	"be-simpletracker/internal/core/tracking/grocery"
+	"be-simpletracker/internal/core/tracking/profile"
+	"be-simpletracker/internal/core/tracking/steps"
+	"be-simpletracker/internal/core/tracking/water"
+	"be-simpletracker/internal/core/tracking/weight"
+	"be-simpletracker/internal/database"
+)
+
+// bodyWeight follows the diet phases' trend, with day-to-day water weight on top and a
+// bump after weekends.
+func (g *generator) bodyWeight() error {
+	phases := g.phases()
+	trend := 186.0
+	return g.days(g.start, g.end, func(date time.Time, _ int) error {
+		trend += phaseOn(phases, date).weightRate
+		if !g.chance(0.85) {
+			return nil
+		}
+		reading := trend + g.noise(0.9)
+		switch date.Weekday() {
+		case time.Sunday:
+			reading += 0.8
+		case time.Monday:
+			reading += 1.2
+		}
+		return g.create(&weight.BodyWeightLog{Date: database.DateOf(date), WeightLbs: round(reading, 0.1)})
+	})
+}
+
+func (g *generator) steps() error {
+	return g.days(g.start, g.end, func(date time.Time, _ int) error {
+		mean, spread := 9500.0, 2500.0
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			mean, spread = 7000, 4000
+		}
+		// Step counts drift up over the summer months.
+		mean += 1500 * math.Sin(float64(date.YearDay()-80)/365*2*math.Pi)
+		count := int(math.Max(800, mean+g.noise(spread)))
+		if !g.chance(0.95) {
+			return nil
+		}
+		return g.create(&steps.StepLog{Date: database.DateOf(date), Steps: count})
+	})
+}
+
+func (g *generator) water() error {
+	presets := []water.DrinkSizePreset{
+		{Name: "Glass", AmountOz: 8},
+		{Name: "Bottle", AmountOz: 16.9},
+		{Name: "Big bottle", AmountOz: 32},
+	}
+	for i := range presets {
+		if err := g.create(&presets[i]); err != nil {
+			return err
+		}
+	}
+	return g.days(g.start, g.end, func(date time.Time, _ int) error {
+		drinks := 3 + g.rng.IntN(5)
+		for range drinks {
+			log := water.WaterLog{Date: database.DateOf(date)}
+			if g.chance(0.85) {
+				preset := presets[g.rng.IntN(len(presets))]
+				log.PresetID, log.AmountOz = &preset.ID, preset.AmountOz
+			} else {
+				log.AmountOz = round(g.between(4, 24), 1)
+			}
+			if err := g.create(&log); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// groceries writes a weekly shopping list. Past lists are checked off; the current week's
+// list is still open.
+func (g *generator) groceries() error {
+	return g.days(g.start, g.end, func(date time.Time, _ int) error {
+		if date.Weekday() != time.Sunday && !date.Equal(g.end) {
+			return nil
+		}
+		current := date.After(g.end.AddDate(0, 0, -7))
+		for _, i := range g.rng.Perm(len(groceries))[:6+g.rng.IntN(6)] {
+			item := grocery.GroceryItem{Name: groceries[i]}
+			if !current || g.chance(0.3) {
+				done := date.Add(time.Duration(9+g.rng.IntN(10)) * time.Hour)
+				item.CompletedAt = &done
+			}
+			if err := g.create(&item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (g *generator) profile() error {
+	return g.create(&profile.UserProfile{HeightIn: 70, Age: 32, Sex: "male", ActivityLevel: "moderately_active"})
+}
@@ -0,0 +1,147 @@
+package seed
+
+import (
+	"time"
+
+	workoutmodels "be-simpletracker/internal/core/workout/models"
+	"be-simpletracker/internal/database"
+)
+
+// progress is where one exercise's progression stands.
+type progress struct {
+	lift     lift
+	id       uint
+	weight   float64
+	reps     int
+	sessions int
+}
+
+// session performs one workout of the lift and moves its progression along: a good session
+// adds a rep, and reaching the rollover adds weight. Every sixth session is a lighter deload.
+func (g *generator) session(p *progress, readiness float64) []workoutmodels.LoggedSet {
+	p.sessions++
+	weight, reps := p.weight, p.reps
+	deload := p.sessions%6 == 0
+	if deload {
+		weight = round(weight*0.85, p.lift.step)
+	}
+	sets := make([]workoutmodels.LoggedSet, p.lift.sets)
+	for i := range sets {
+		done := reps
+		// Fatigue takes a rep or two off the later sets on a bad day.
+		if !deload && i > 0 && !g.chance(readiness) {
+			done -= 1 + g.rng.IntN(2)
+		}
+		sets[i] = workoutmodels.LoggedSet{Reps: uint(max(1, done)), Weight: float32(weight)}
+	}
+	if deload || !g.chance(readiness) {
+		return sets
+	}
+	p.reps++
+	if p.reps > int(p.lift.rollover) {
+		p.weight += p.lift.step
+		p.reps = p.lift.minReps
+	}
+	return sets
+}
+
+func (g *generator) workouts() error {
+	progression := map[string]*progress{}
+	for _, l := range lifts {
+		exercise := workoutmodels.Exercise{Name: l.name, RepRollover: l.rollover, Cues: l.cues, LoadType: l.loadType}
+		if err := g.create(&exercise); err != nil {
+			return err
+		}
+		progression[l.name] = &progress{lift: l, id: exercise.ID, weight: l.weight, reps: l.minReps}
+	}
+
+	type scheduled struct {
+		plan workoutmodels.WorkoutPlan
+		spec planSpec
+	}
+	// The first program runs for the first third of the history, the last one since then.
+	total := int(g.end.Sub(g.start).Hours()/24) + 1
+	switchAt := g.start.AddDate(0, 0, total/3)
+	byProgram := make([]map[int]scheduled, len(programs))
+	for i, spec := range programs {
+		program := workoutmodels.WorkoutProgram{Name: spec.name, IsActive: i == len(programs)-1}
+		if err := g.create(&program); err != nil {
+			return err
+		}
+		byProgram[i] = map[int]scheduled{}
+		for _, ps := range spec.plans {
+			day := ps.days[0]
+			plan := workoutmodels.WorkoutPlan{
+				Name: ps.name, WorkoutProgramID: &program.ID, DayOfWeek: &day,
+				PlannedCardioType: ps.cardioType, PlannedCardioMinutes: ps.cardioMins,
+				PreMobilityItems: database.StringList(ps.preMobility), PostMobilityItems: database.StringList(postMobility),
+			}
+			if err := g.tx.Omit("Exercises").Create(&plan).Error; err != nil {
+				return err
+			}
+			for order, name := range ps.lifts {
+				row := workoutmodels.WorkoutPlanExercise{WorkoutPlanID: plan.ID, ExerciseID: progression[name].id, DisplayOrder: order}
+				if err := g.create(&row); err != nil {
+					return err
+				}
+			}
+			for _, weekday := range ps.days {
+				if err := g.create(&workoutmodels.WorkoutPlanDay{WorkoutPlanID: plan.ID, DayOfWeek: weekday, CreatedAt: program.CreatedAt}); err != nil {
+					return err
+				}
+				byProgram[i][weekday] = scheduled{plan: plan, spec: ps}
+			}
+		}
+	}
+
+	// One week off somewhere in the middle, as happens.
+	vacation := g.start.AddDate(0, 0, total/2+g.rng.IntN(max(1, total/4)))
+	phases := g.phases()
+	return g.days(g.start, g.end, func(date time.Time, _ int) error {
+		program := byProgram[len(byProgram)-1]
+		if date.Before(switchAt) {
+			program = byProgram[0]
+		}
+		s, ok := program[int(date.Weekday())]
+		if !ok || !g.chance(0.9) || (!date.Before(vacation) && date.Before(vacation.AddDate(0, 0, 7))) {
+			return nil
+		}
+		// Progress comes slower in a calorie deficit.
+		readiness := 0.8
+		if phaseOn(phases, date).weightRate < 0 {
+			readiness = 0.65
+		}
+		planID := s.plan.ID
+		log := workoutmodels.WorkoutLog{Date: database.DateOf(date), WorkoutPlanID: &planID}
+		for _, item := range s.spec.preMobility {
+			if g.chance(0.8) {
+				log.PreMobilityChecked = append(log.PreMobilityChecked, item)
+			}
+		}
+		for _, item := range postMobility {
+			if g.chance(0.5) {
+				log.PostMobilityChecked = append(log.PostMobilityChecked, item)
+			}
+		}
+		if err := g.tx.Omit("WorkoutPlan", "Exercises", "Cardio").Create(&log).Error; err != nil {
+			return err
+		}
+		for _, name := range s.spec.lifts {
+			p := progression[name]
+			logged := workoutmodels.LoggedExercise{WorkoutLogID: log.ID, ExerciseID: p.id, Sets: g.session(p, readiness)}
+			if err := g.tx.Omit("Exercise").Create(&logged).Error; err != nil {
+				return err
+			}
+		}
+		if s.spec.cardioType != "" && g.chance(0.75) {
+			cardio := workoutmodels.Cardio{
+				WorkoutLogID: log.ID, Type: s.spec.cardioType,
+				Minutes: max(5, s.spec.cardioMins+int(round(g.noise(3), 1))),
+			}
+			if err := g.create(&cardio); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"be-simpletracker/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag is the entity tag of a resource at version.
+func ETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// SetETag sends the entity tag of the resource in the response.
+func SetETag(c *gin.Context, version uint) {
+	c.Header("ETag", ETag(version))
+}
+
+// IfMatchMiddleware guards routes that update a versioned resource. An If-Match header naming
+// one version is passed to database.ClaimVersion through the request context; "*" writes
+// regardless. When required (IF_MATCH_REQUIRED=true) a request without the header is refused
+// with 428, so a client cannot overwrite a change it never saw; otherwise it writes whatever
+// version is stored, for clients that send none.
+func IfMatchMiddleware(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := strings.TrimSpace(c.GetHeader("If-Match"))
+		if header == "" {
+			if required {
+				c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header required"})
+				return
+			}
+			c.Next()
+			return
+		}
+		if header == "*" {
+			c.Next()
+			return
+		}
+		version, ok := parseETag(header)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "If-Match must be a single ETag from this API"})
+			return
+		}
+		c.Request = c.Request.WithContext(database.WithExpectedVersion(c.Request.Context(), version))
+		c.Next()
+	}
+}
+
+// parseETag reads a strong tag written by ETag. Weak tags never match under If-Match.
+func parseETag(tag string) (uint, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(v), true
+}
+
+// PreconditionFailed answers a write based on a stale version with 412 and the resource as it
+// is now, under key, so the client can merge its change and retry.
+func PreconditionFailed(c *gin.Context, key string, current any, version uint) {
+	SetETag(c, version)
+	c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{
+		"error": "modified since it was read",
+		key:     current,
+	})
+}
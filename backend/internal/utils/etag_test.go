@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"be-simpletracker/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveIfMatch(t *testing.T, required bool, header string) (int, uint, bool) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	var version uint
+	var expected bool
+	r := gin.New()
+	r.PUT("/", IfMatchMiddleware(required), func(c *gin.Context) {
+		version, expected = database.ExpectedVersion(c.Request.Context())
+	})
+	req := httptest.NewRequest(http.MethodPut, "/", nil)
+	if header != "" {
+		req.Header.Set("If-Match", header)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, version, expected
+}
+
+func TestIfMatchMiddleware_passesVersionToContext(t *testing.T) {
+	code, version, expected := serveIfMatch(t, true, ETag(7))
+	if code != http.StatusOK || !expected || version != 7 {
+		t.Fatalf("got %d, version %d (%v)", code, version, expected)
+	}
+	if code, _, expected := serveIfMatch(t, true, "*"); code != http.StatusOK || expected {
+		t.Fatalf("If-Match *: got %d, expected %v", code, expected)
+	}
+}
+
+func TestIfMatchMiddleware_rejectsTagsItDidNotIssue(t *testing.T) {
+	for _, header := range []string{`W/"7"`, `"7", "8"`, "7", `"abc"`} {
+		if code, _, _ := serveIfMatch(t, true, header); code != http.StatusBadRequest {
+			t.Errorf("If-Match %s: got %d", header, code)
+		}
+	}
+}
+
+func TestIfMatchMiddleware_requiredUnlessOptedOut(t *testing.T) {
+	if code, _, _ := serveIfMatch(t, true, ""); code != http.StatusPreconditionRequired {
+		t.Fatalf("required: got %d", code)
+	}
+	if code, _, expected := serveIfMatch(t, false, ""); code != http.StatusOK || expected {
+		t.Fatalf("opted out: got %d, expected %v", code, expected)
+	}
+}
@@ -8,8 +8,9 @@ import (
 
 const ginContextKeyDayOffset = "dayOffset"
 
-// DayOffsetMiddleware parses the "offset" query param (days from today: 0 = today)
-// and stores it on the gin context for GetDayOffset.
+// DayOffsetMiddleware parses the "offset" query param (days before today: 0 = today)
+// and stores it on the gin context for GetDayOffset. Today is the user's, so Today turns
+// the offset into a date on the calendar of the request context.
 func DayOffsetMiddleware() gin.HandlerFunc {
 	spec := QueryIntVar{
 		Key:        "offset",
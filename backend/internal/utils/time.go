@@ -1,8 +1,54 @@
 package utils
 
-import "time"
+import (
+	"context"
+	"time"
 
-func ZerodTime(offset int) time.Time {
-	now := time.Now() // local time
-	return time.Date(now.Year(), now.Month(), now.Day()-offset, 0, 0, 0, 0, now.Location())
+	"be-simpletracker/internal/database"
+)
+
+// Calendar is how a user counts days: the zone they live in, how many hours past midnight
+// the previous day still runs, and the day their week starts on.
+type Calendar struct {
+	Location     *time.Location
+	DayStartHour int
+	WeekStart    time.Weekday
+}
+
+// DefaultCalendar counts days in the server's zone from midnight, with weeks from Sunday.
+func DefaultCalendar() Calendar {
+	return Calendar{Location: time.Local, WeekStart: time.Sunday}
+}
+
+type calendarKey struct{}
+
+// WithCalendar returns a context whose dates are counted on cal.
+func WithCalendar(ctx context.Context, cal Calendar) context.Context {
+	return context.WithValue(ctx, calendarKey{}, cal)
+}
+
+// CalendarFrom returns the calendar attached by WithCalendar, or DefaultCalendar.
+func CalendarFrom(ctx context.Context) Calendar {
+	if ctx != nil {
+		if cal, ok := ctx.Value(calendarKey{}).(Calendar); ok && cal.Location != nil {
+			return cal
+		}
+	}
+	return DefaultCalendar()
+}
+
+// Today returns the user's current day, offset days back, on the calendar in ctx.
+func Today(ctx context.Context, offset int) database.Date {
+	return CalendarFrom(ctx).Day(time.Now()).AddDays(-offset)
+}
+
+// Day returns the day instant t falls on. Until DayStartHour it is still the day before.
+func (c Calendar) Day(t time.Time) database.Date {
+	return database.DateOf(t.In(c.Location).Add(-time.Duration(c.DayStartHour) * time.Hour))
+}
+
+// StartOfWeek returns the first day of the week holding day.
+func (c Calendar) StartOfWeek(day database.Date) database.Date {
+	back := (int(day.Weekday()) - int(c.WeekStart) + 7) % 7
+	return day.AddDays(-back)
 }
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"be-simpletracker/internal/database"
+)
+
+func TestCalendarDayRollsOverAtDayStartHour(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+	cal := Calendar{Location: tokyo, DayStartHour: 4}
+	cases := []struct {
+		at   time.Time
+		want database.Date
+	}{
+		{time.Date(2026, 3, 10, 2, 30, 0, 0, tokyo), database.Date{Year: 2026, Month: 3, Day: 9}},
+		{time.Date(2026, 3, 10, 4, 0, 0, 0, tokyo), database.Date{Year: 2026, Month: 3, Day: 10}},
+		// 20:00 UTC is already the next morning in Tokyo.
+		{time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC), database.Date{Year: 2026, Month: 3, Day: 11}},
+	}
+	for _, tc := range cases {
+		if got := cal.Day(tc.at); got != tc.want {
+			t.Errorf("Day(%v) = %v, want %v", tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestCalendarStartOfWeek(t *testing.T) {
+	thursday := database.Date{Year: 2026, Month: 10, Day: 15}
+	if got := DefaultCalendar().StartOfWeek(thursday); got.Day != 11 || got.Weekday() != time.Sunday {
+		t.Errorf("Sunday week = %v", got)
+	}
+	if got := (Calendar{WeekStart: time.Monday}).StartOfWeek(thursday); got.Day != 12 {
+		t.Errorf("Monday week = %v", got)
+	}
+	if got := (Calendar{WeekStart: time.Thursday}).StartOfWeek(thursday); got != thursday {
+		t.Errorf("Thursday week = %v", got)
+	}
+}